@@ -0,0 +1,213 @@
+package handoff
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+type MessageType string
+
+const (
+	// MessageSnapshot carries the whole dataset, it is always the first
+	// message of a subscription
+	MessageSnapshot MessageType = "snapshot"
+	// MessageDelta carries what changed since the Base version
+	MessageDelta MessageType = "delta"
+	// MessageHeartbeat is sent while nothing changes so that each side can
+	// tell the other is still connected
+	MessageHeartbeat MessageType = "heartbeat"
+)
+
+// Message is one line of the stream, Version is the version of the dataset
+// once the message has been applied
+type Message struct {
+	Type    MessageType      `json:"type"`
+	Version uint64           `json:"version"`
+	Base    uint64           `json:"base,omitempty"`
+	Dataset *transit.Dataset `json:"dataset,omitempty"`
+	Changes *Changes         `json:"changes,omitempty"`
+}
+
+// Changes is what changed between two versions of a dataset. Vehicles, alerts
+// and line statuses are added or replaced whole and removed by key, the
+// departures of a stop are replaced together
+type Changes struct {
+	Vehicles            []transit.Vehicle              `json:"vehicles,omitempty"`
+	RemovedVehicles     []string                       `json:"removed_vehicles,omitempty"`
+	Departures          map[string][]transit.Departure `json:"departures,omitempty"`
+	Alerts              []transit.Alert                `json:"alerts,omitempty"`
+	RemovedAlerts       []string                       `json:"removed_alerts,omitempty"`
+	LineStatuses        []transit.LineStatus           `json:"line_statuses,omitempty"`
+	RemovedLineStatuses []string                       `json:"removed_line_statuses,omitempty"`
+}
+
+func (c Changes) empty() bool {
+	return len(c.Vehicles) == 0 && len(c.RemovedVehicles) == 0 &&
+		len(c.Departures) == 0 &&
+		len(c.Alerts) == 0 && len(c.RemovedAlerts) == 0 &&
+		len(c.LineStatuses) == 0 && len(c.RemovedLineStatuses) == 0
+}
+
+// vehicleKey is unique across sources, as two sources may use the same id
+func vehicleKey(v transit.Vehicle) string {
+	return v.Source + "/" + v.ID
+}
+
+func lineStatusKey(s transit.LineStatus) string {
+	return s.Operator + "/" + s.RouteID
+}
+
+// state is a dataset indexed by key so that two versions can be compared
+type state struct {
+	vehicles     map[string]transit.Vehicle
+	departures   map[string][]transit.Departure
+	alerts       map[string]transit.Alert
+	lineStatuses map[string]transit.LineStatus
+}
+
+func newState(d transit.Dataset) state {
+	s := state{
+		vehicles:     make(map[string]transit.Vehicle, len(d.Vehicles)),
+		departures:   map[string][]transit.Departure{},
+		alerts:       make(map[string]transit.Alert, len(d.Alerts)),
+		lineStatuses: make(map[string]transit.LineStatus, len(d.LineStatuses)),
+	}
+	for _, v := range d.Vehicles {
+		s.vehicles[vehicleKey(v)] = v
+	}
+	for _, dep := range d.Departures {
+		s.departures[dep.StopID] = append(s.departures[dep.StopID], dep)
+	}
+	for _, a := range d.Alerts {
+		s.alerts[a.ID] = a
+	}
+	for _, l := range d.LineStatuses {
+		s.lineStatuses[lineStatusKey(l)] = l
+	}
+
+	return s
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// dataset returns the state ordered by key, departures keep their order
+// within each stop
+func (s state) dataset() transit.Dataset {
+	d := transit.Dataset{
+		Vehicles:     make([]transit.Vehicle, 0, len(s.vehicles)),
+		Departures:   []transit.Departure{},
+		Alerts:       make([]transit.Alert, 0, len(s.alerts)),
+		LineStatuses: make([]transit.LineStatus, 0, len(s.lineStatuses)),
+	}
+	for _, k := range sortedKeys(s.vehicles) {
+		d.Vehicles = append(d.Vehicles, s.vehicles[k])
+	}
+	for _, k := range sortedKeys(s.departures) {
+		d.Departures = append(d.Departures, s.departures[k]...)
+	}
+	for _, k := range sortedKeys(s.alerts) {
+		d.Alerts = append(d.Alerts, s.alerts[k])
+	}
+	for _, k := range sortedKeys(s.lineStatuses) {
+		d.LineStatuses = append(d.LineStatuses, s.lineStatuses[k])
+	}
+
+	return d
+}
+
+// diff returns what changed from s to next
+func (s state) diff(next state) Changes {
+	c := Changes{Departures: map[string][]transit.Departure{}}
+
+	for _, k := range sortedKeys(next.vehicles) {
+		if v, ok := s.vehicles[k]; !ok || !reflect.DeepEqual(v, next.vehicles[k]) {
+			c.Vehicles = append(c.Vehicles, next.vehicles[k])
+		}
+	}
+	for _, k := range sortedKeys(s.vehicles) {
+		if _, ok := next.vehicles[k]; !ok {
+			c.RemovedVehicles = append(c.RemovedVehicles, k)
+		}
+	}
+
+	for stop, departures := range next.departures {
+		if !reflect.DeepEqual(s.departures[stop], departures) {
+			c.Departures[stop] = departures
+		}
+	}
+	for stop := range s.departures {
+		if _, ok := next.departures[stop]; !ok {
+			c.Departures[stop] = []transit.Departure{}
+		}
+	}
+	if len(c.Departures) == 0 {
+		c.Departures = nil
+	}
+
+	for _, k := range sortedKeys(next.alerts) {
+		if a, ok := s.alerts[k]; !ok || !reflect.DeepEqual(a, next.alerts[k]) {
+			c.Alerts = append(c.Alerts, next.alerts[k])
+		}
+	}
+	for _, k := range sortedKeys(s.alerts) {
+		if _, ok := next.alerts[k]; !ok {
+			c.RemovedAlerts = append(c.RemovedAlerts, k)
+		}
+	}
+
+	for _, k := range sortedKeys(next.lineStatuses) {
+		if l, ok := s.lineStatuses[k]; !ok || !reflect.DeepEqual(l, next.lineStatuses[k]) {
+			c.LineStatuses = append(c.LineStatuses, next.lineStatuses[k])
+		}
+	}
+	for _, k := range sortedKeys(s.lineStatuses) {
+		if _, ok := next.lineStatuses[k]; !ok {
+			c.RemovedLineStatuses = append(c.RemovedLineStatuses, k)
+		}
+	}
+
+	return c
+}
+
+// apply changes s in place
+func (s state) apply(c Changes) {
+	for _, v := range c.Vehicles {
+		s.vehicles[vehicleKey(v)] = v
+	}
+	for _, k := range c.RemovedVehicles {
+		delete(s.vehicles, k)
+	}
+
+	for stop, departures := range c.Departures {
+		if len(departures) == 0 {
+			delete(s.departures, stop)
+
+			continue
+		}
+		s.departures[stop] = departures
+	}
+
+	for _, a := range c.Alerts {
+		s.alerts[a.ID] = a
+	}
+	for _, k := range c.RemovedAlerts {
+		delete(s.alerts, k)
+	}
+
+	for _, l := range c.LineStatuses {
+		s.lineStatuses[lineStatusKey(l)] = l
+	}
+	for _, k := range c.RemovedLineStatuses {
+		delete(s.lineStatuses, k)
+	}
+}
@@ -0,0 +1,88 @@
+package handoff
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateDiff(t *testing.T) {
+	before := transit.Dataset{
+		Vehicles: []transit.Vehicle{
+			{ID: "V1", Source: "gtfsr", Latitude: 53.1},
+			{ID: "V2", Source: "gtfsr"},
+			{ID: "V1", Source: "irishrail"},
+		},
+		Departures: []transit.Departure{
+			{StopID: "RAN", TripID: "T1"},
+			{StopID: "RAN", TripID: "T2"},
+			{StopID: "STS", TripID: "T3"},
+		},
+		Alerts:       []transit.Alert{{ID: "A1"}, {ID: "A2"}},
+		LineStatuses: []transit.LineStatus{{Operator: "luas", RouteID: "red"}},
+	}
+	after := transit.Dataset{
+		Vehicles: []transit.Vehicle{
+			{ID: "V1", Source: "gtfsr", Latitude: 53.2},
+			{ID: "V1", Source: "irishrail"},
+			{ID: "V3", Source: "gtfsr"},
+		},
+		Departures: []transit.Departure{
+			{StopID: "RAN", TripID: "T2"},
+			{StopID: "HAR", TripID: "T4"},
+		},
+		Alerts: []transit.Alert{{ID: "A1"}},
+		LineStatuses: []transit.LineStatus{
+			{Operator: "luas", RouteID: "red"},
+			{Operator: "luas", RouteID: "green"},
+		},
+	}
+
+	t.Run("has only what changed", func(t *testing.T) {
+		assert.Equal(t, Changes{
+			Vehicles:        []transit.Vehicle{{ID: "V1", Source: "gtfsr", Latitude: 53.2}, {ID: "V3", Source: "gtfsr"}},
+			RemovedVehicles: []string{"gtfsr/V2"},
+			Departures: map[string][]transit.Departure{
+				"RAN": {{StopID: "RAN", TripID: "T2"}},
+				"HAR": {{StopID: "HAR", TripID: "T4"}},
+				"STS": {},
+			},
+			RemovedAlerts: []string{"A2"},
+			LineStatuses:  []transit.LineStatus{{Operator: "luas", RouteID: "green"}},
+		}, newState(before).diff(newState(after)))
+	})
+
+	t.Run("is empty when nothing changed", func(t *testing.T) {
+		assert.True(t, newState(before).diff(newState(before)).empty())
+	})
+
+	t.Run("applies to the same dataset through JSON", func(t *testing.T) {
+		changes := newState(before).diff(newState(after))
+		b, err := json.Marshal(changes)
+		assert.NoError(t, err)
+
+		var got Changes
+		assert.NoError(t, json.Unmarshal(b, &got))
+		s := newState(before)
+		s.apply(got)
+		assert.Equal(t, newState(after).dataset(), s.dataset())
+	})
+}
+
+func TestStateDataset(t *testing.T) {
+	t.Run("is ordered by key", func(t *testing.T) {
+		s := newState(transit.Dataset{
+			Vehicles:   []transit.Vehicle{{ID: "V2", Source: "gtfsr"}, {ID: "V1", Source: "irishrail"}, {ID: "V1", Source: "gtfsr"}},
+			Departures: []transit.Departure{{StopID: "STS", TripID: "T2"}, {StopID: "RAN", TripID: "T3"}, {StopID: "STS", TripID: "T1"}},
+		})
+
+		assert.Equal(t, transit.Dataset{
+			Vehicles:     []transit.Vehicle{{ID: "V1", Source: "gtfsr"}, {ID: "V2", Source: "gtfsr"}, {ID: "V1", Source: "irishrail"}},
+			Departures:   []transit.Departure{{StopID: "RAN", TripID: "T3"}, {StopID: "STS", TripID: "T2"}, {StopID: "STS", TripID: "T1"}},
+			Alerts:       []transit.Alert{},
+			LineStatuses: []transit.LineStatus{},
+		}, s.dataset())
+	})
+}
@@ -0,0 +1,172 @@
+package handoff
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultHeartbeat = 10 * time.Second
+	DefaultBuffer    = 16
+	// DefaultWriteWait is how long a subscriber has to take a message before
+	// it is disconnected as dead
+	DefaultWriteWait = 10 * time.Second
+)
+
+var errFellBehind = errors.New("subscriber fell behind")
+
+// Publisher streams a dataset to its subscribers as newline delimited JSON
+// messages. Each subscriber is sent a snapshot when it connects and a delta
+// every time the dataset changes, a subscriber which falls more than Buffer
+// messages behind, or does not take a message within WriteWait, is
+// disconnected and has to start again from a snapshot
+type Publisher struct {
+	Heartbeat time.Duration
+	Buffer    int
+	WriteWait time.Duration
+
+	mu          sync.Mutex
+	version     uint64
+	state       state
+	subscribers map[*subscription]struct{}
+}
+
+type subscription struct {
+	messages chan Message
+	dropped  chan struct{}
+}
+
+func NewPublisher() *Publisher {
+	return &Publisher{
+		Heartbeat:   DefaultHeartbeat,
+		Buffer:      DefaultBuffer,
+		WriteWait:   DefaultWriteWait,
+		state:       newState(transit.Dataset{}),
+		subscribers: map[*subscription]struct{}{},
+	}
+}
+
+// Publish replaces the dataset and sends what changed to every subscriber,
+// the version is only incremented when something changed
+func (p *Publisher) Publish(d transit.Dataset) {
+	next := newState(d)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changes := p.state.diff(next)
+	if changes.empty() {
+		return
+	}
+	p.state = next
+	p.version++
+
+	m := Message{Type: MessageDelta, Version: p.version, Base: p.version - 1, Changes: &changes}
+	for sub := range p.subscribers {
+		select {
+		case sub.messages <- m:
+		default:
+			close(sub.dropped)
+			delete(p.subscribers, sub)
+		}
+	}
+}
+
+func (p *Publisher) Version() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.version
+}
+
+// Subscribers returns how many subscribers are connected
+func (p *Publisher) Subscribers() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.subscribers)
+}
+
+func (p *Publisher) subscribe() (*subscription, Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sub := &subscription{
+		messages: make(chan Message, p.Buffer),
+		dropped:  make(chan struct{}),
+	}
+	p.subscribers[sub] = struct{}{}
+	d := p.state.dataset()
+
+	return sub, Message{Type: MessageSnapshot, Version: p.version, Dataset: &d}
+}
+
+func (p *Publisher) unsubscribe(sub *subscription) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.subscribers, sub)
+}
+
+// ServeHTTP streams the dataset until the subscriber disconnects, falls
+// behind, stops taking messages or the request is cancelled. Every message
+// has a write deadline, as a subscriber whose connection died without closing
+// would otherwise block its write forever
+func (p *Publisher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, ok := w.(http.Flusher); !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+
+		return
+	}
+	rc := http.NewResponseController(w)
+
+	sub, snapshot := p.subscribe()
+	defer p.unsubscribe(sub)
+	log.Info().Str("remote_addr", r.RemoteAddr).Uint64("version", snapshot.Version).Msg("subscriber connected")
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	enc := json.NewEncoder(w)
+	version := snapshot.Version
+	send := func(m Message) error {
+		// A writer which cannot set deadlines, such as one wrapped by a
+		// middleware, still streams without them
+		if err := rc.SetWriteDeadline(time.Now().Add(p.WriteWait)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return err
+		}
+		if err := enc.Encode(m); err != nil {
+			return err
+		}
+		if err := rc.Flush(); err != nil {
+			return err
+		}
+		version = m.Version
+
+		return nil
+	}
+
+	heartbeat := time.NewTicker(p.Heartbeat)
+	defer heartbeat.Stop()
+
+	err := send(snapshot)
+	for err == nil {
+		select {
+		case <-r.Context().Done():
+			err = r.Context().Err()
+		case <-sub.dropped:
+			err = errFellBehind
+		case m := <-sub.messages:
+			err = send(m)
+		case <-heartbeat.C:
+			err = send(Message{Type: MessageHeartbeat, Version: version})
+		}
+	}
+
+	log.Warn().Err(err).Str("remote_addr", r.RemoteAddr).Uint64("version", version).Msg("subscriber disconnected")
+}
@@ -0,0 +1,146 @@
+package handoff
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func connect(t *testing.T, url string) (func() Message, context.CancelFunc) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+	scanner := bufio.NewScanner(resp.Body)
+
+	return func() Message {
+		t.Helper()
+		require.True(t, scanner.Scan(), "expected a message")
+		var m Message
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &m))
+
+		return m
+	}, cancel
+}
+
+func TestPublisher(t *testing.T) {
+	t.Run("sends a snapshot then deltas", func(t *testing.T) {
+		p := NewPublisher()
+		p.Publish(transit.Dataset{Vehicles: []transit.Vehicle{{ID: "V1", Source: "gtfsr"}}})
+		srv := httptest.NewServer(p)
+		t.Cleanup(srv.Close)
+
+		next, _ := connect(t, srv.URL)
+		snapshot := next()
+		assert.Equal(t, MessageSnapshot, snapshot.Type)
+		assert.Equal(t, uint64(1), snapshot.Version)
+		assert.Equal(t, []transit.Vehicle{{ID: "V1", Source: "gtfsr"}}, snapshot.Dataset.Vehicles)
+
+		p.Publish(transit.Dataset{Vehicles: []transit.Vehicle{{ID: "V2", Source: "gtfsr"}}})
+		delta := next()
+		assert.Equal(t, Message{
+			Type:    MessageDelta,
+			Version: 2,
+			Base:    1,
+			Changes: &Changes{
+				Vehicles:        []transit.Vehicle{{ID: "V2", Source: "gtfsr"}},
+				RemovedVehicles: []string{"gtfsr/V1"},
+			},
+		}, delta)
+	})
+
+	t.Run("does not publish a dataset that has not changed", func(t *testing.T) {
+		p := NewPublisher()
+		d := transit.Dataset{Alerts: []transit.Alert{{ID: "A1"}}}
+		p.Publish(d)
+		p.Publish(d)
+		assert.Equal(t, uint64(1), p.Version())
+	})
+
+	t.Run("sends heartbeats", func(t *testing.T) {
+		p := NewPublisher()
+		p.Heartbeat = 10 * time.Millisecond
+		srv := httptest.NewServer(p)
+		t.Cleanup(srv.Close)
+
+		next, _ := connect(t, srv.URL)
+		assert.Equal(t, MessageSnapshot, next().Type)
+		assert.Equal(t, Message{Type: MessageHeartbeat, Version: 0}, next())
+	})
+
+	t.Run("disconnects a subscriber which falls behind", func(t *testing.T) {
+		p := NewPublisher()
+		p.Buffer = 1
+		sub, _ := p.subscribe()
+		p.Publish(transit.Dataset{Alerts: []transit.Alert{{ID: "A1"}}})
+		p.Publish(transit.Dataset{Alerts: []transit.Alert{{ID: "A2"}}})
+
+		assert.Equal(t, 0, p.Subscribers())
+		select {
+		case <-sub.dropped:
+		default:
+			assert.Fail(t, "expected the subscriber to be dropped")
+		}
+	})
+
+	t.Run("disconnects a subscriber which stops taking messages", func(t *testing.T) {
+		p := NewPublisher()
+		p.WriteWait = 50 * time.Millisecond
+		vehicles := make([]transit.Vehicle, 100000)
+		for i := range vehicles {
+			vehicles[i] = transit.Vehicle{ID: strconv.Itoa(i), Source: "gtfsr", Headsign: "Dún Laoghaire via Stillorgan and Blackrock"}
+		}
+		p.Publish(transit.Dataset{Vehicles: vehicles})
+
+		done := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p.ServeHTTP(w, r)
+			close(done)
+		}))
+		t.Cleanup(srv.Close)
+
+		// The subscriber never reads, so the snapshot fills the connection
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		_, err = conn.Write([]byte("GET / HTTP/1.1\r\nHost: handoff\r\n\r\n"))
+		require.NoError(t, err)
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "expected the subscriber to be disconnected")
+		}
+		assert.Equal(t, 0, p.Subscribers())
+	})
+
+	t.Run("forgets a subscriber which disconnects", func(t *testing.T) {
+		p := NewPublisher()
+		srv := httptest.NewServer(p)
+		t.Cleanup(srv.Close)
+
+		next, cancel := connect(t, srv.URL)
+		next()
+		assert.Equal(t, 1, p.Subscribers())
+		cancel()
+		assert.Eventually(t, func() bool { return p.Subscribers() == 0 }, time.Second, 5*time.Millisecond)
+	})
+}
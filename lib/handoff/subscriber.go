@@ -0,0 +1,162 @@
+package handoff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultTimeout allows for a few missed heartbeats before the publisher
+	// is considered disconnected
+	DefaultTimeout       = 3 * DefaultHeartbeat
+	DefaultRetryInterval = 5 * time.Second
+)
+
+// Subscriber follows the stream of a Publisher and calls Update with the whole
+// dataset every time it changes. It reconnects whenever the stream ends, the
+// publisher sends nothing for Timeout, or a delta is missed
+type Subscriber struct {
+	URL           string
+	Client        *http.Client
+	Timeout       time.Duration
+	RetryInterval time.Duration
+	Update        func(d transit.Dataset)
+
+	mu        sync.RWMutex
+	connected bool
+	version   uint64
+}
+
+func NewSubscriber(url string, update func(d transit.Dataset)) *Subscriber {
+	return &Subscriber{
+		URL:           url,
+		Client:        &http.Client{},
+		Timeout:       DefaultTimeout,
+		RetryInterval: DefaultRetryInterval,
+		Update:        update,
+	}
+}
+
+// Connected reports whether a snapshot has been received and the publisher
+// has not been heard to disconnect since
+func (s *Subscriber) Connected() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.connected
+}
+
+// Version returns the version of the last dataset passed to Update
+func (s *Subscriber) Version() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.version
+}
+
+func (s *Subscriber) setConnected(connected bool, version uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connected, s.version = connected, version
+}
+
+// Run follows the publisher until the context is cancelled
+func (s *Subscriber) Run(ctx context.Context) {
+	for {
+		err := s.subscribe(ctx)
+		s.mu.Lock()
+		s.connected = false
+		s.mu.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Error().Err(err).Str("url", s.URL).Dur("retry_in", s.RetryInterval).Msg("disconnected from publisher")
+
+		timer := time.NewTimer(s.RetryInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func (s *Subscriber) subscribe(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+
+	// Nothing arriving in time cancels the request, which unblocks the decoder
+	var timedOut atomic.Bool
+	timer := time.AfterFunc(s.Timeout, func() {
+		timedOut.Store(true)
+		cancel()
+	})
+	defer timer.Stop()
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not connect to publisher: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected publisher response status %d", resp.StatusCode)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	var st *state
+	var version uint64
+	for {
+		var m Message
+		if err := dec.Decode(&m); err != nil {
+			if timedOut.Load() {
+				return fmt.Errorf("nothing received from publisher in %s", s.Timeout)
+			}
+
+			return fmt.Errorf("could not read from publisher: %w", err)
+		}
+		timer.Reset(s.Timeout)
+
+		switch m.Type {
+		case MessageSnapshot:
+			if m.Dataset == nil {
+				return errors.New("snapshot without a dataset")
+			}
+			next := newState(*m.Dataset)
+			st = &next
+			if !s.Connected() {
+				log.Info().Str("url", s.URL).Uint64("version", m.Version).Msg("connected to publisher")
+			}
+		case MessageDelta:
+			if st == nil || m.Base != version {
+				return fmt.Errorf("missed the changes from version %d to %d", version, m.Base)
+			}
+			if m.Changes != nil {
+				st.apply(*m.Changes)
+			}
+		default:
+			continue
+		}
+
+		version = m.Version
+		s.Update(st.dataset())
+		s.setConnected(true, version)
+	}
+}
@@ -0,0 +1,133 @@
+package handoff
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+type updates struct {
+	mu       sync.Mutex
+	datasets []transit.Dataset
+}
+
+func (u *updates) update(d transit.Dataset) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.datasets = append(u.datasets, d)
+}
+
+func (u *updates) last() (transit.Dataset, int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.datasets) == 0 {
+		return transit.Dataset{}, 0
+	}
+
+	return u.datasets[len(u.datasets)-1], len(u.datasets)
+}
+
+func run(t *testing.T, s *Subscriber) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func TestSubscriber(t *testing.T) {
+	vehicles := func(ids ...string) transit.Dataset {
+		d := transit.Dataset{}
+		for _, id := range ids {
+			d.Vehicles = append(d.Vehicles, transit.Vehicle{ID: id, Source: "gtfsr"})
+		}
+
+		return d
+	}
+
+	t.Run("follows the publisher", func(t *testing.T) {
+		p := NewPublisher()
+		p.Publish(vehicles("V1"))
+		srv := httptest.NewServer(p)
+		t.Cleanup(srv.Close)
+
+		u := &updates{}
+		s := NewSubscriber(srv.URL, u.update)
+		run(t, s)
+
+		assert.Eventually(t, s.Connected, time.Second, 5*time.Millisecond)
+		d, _ := u.last()
+		assert.Equal(t, vehicles("V1").Vehicles, d.Vehicles)
+
+		p.Publish(vehicles("V1", "V2"))
+		assert.Eventually(t, func() bool { return s.Version() == 2 }, time.Second, 5*time.Millisecond)
+		d, _ = u.last()
+		assert.Equal(t, vehicles("V1", "V2").Vehicles, d.Vehicles)
+	})
+
+	t.Run("reconnects when the publisher goes away", func(t *testing.T) {
+		p := NewPublisher()
+		p.Publish(vehicles("V1"))
+		srv := httptest.NewServer(p)
+		t.Cleanup(srv.Close)
+
+		u := &updates{}
+		s := NewSubscriber(srv.URL, u.update)
+		s.RetryInterval = 10 * time.Millisecond
+		run(t, s)
+		assert.Eventually(t, s.Connected, time.Second, 5*time.Millisecond)
+
+		srv.CloseClientConnections()
+		assert.Eventually(t, func() bool {
+			_, n := u.last()
+
+			return n >= 2 && s.Connected()
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("disconnects when nothing is received in time", func(t *testing.T) {
+		p := NewPublisher()
+		p.Heartbeat = time.Hour
+		srv := httptest.NewServer(p)
+		t.Cleanup(srv.Close)
+
+		s := NewSubscriber(srv.URL, func(transit.Dataset) {})
+		s.Timeout = 50 * time.Millisecond
+		err := s.subscribe(context.Background())
+		assert.EqualError(t, err, "nothing received from publisher in 50ms")
+	})
+
+	t.Run("disconnects when a delta is missed", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"type":"snapshot","version":1,"dataset":{}}` + "\n"))
+			_, _ = w.Write([]byte(`{"type":"delta","version":3,"base":2,"changes":{}}` + "\n"))
+		}))
+		t.Cleanup(srv.Close)
+
+		s := NewSubscriber(srv.URL, func(transit.Dataset) {})
+		err := s.subscribe(context.Background())
+		assert.EqualError(t, err, "missed the changes from version 1 to 2")
+	})
+
+	t.Run("fails on an unexpected status", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		t.Cleanup(srv.Close)
+
+		s := NewSubscriber(srv.URL, func(transit.Dataset) {})
+		err := s.subscribe(context.Background())
+		assert.EqualError(t, err, "unexpected publisher response status 404")
+		assert.False(t, s.Connected())
+	})
+}
@@ -0,0 +1,67 @@
+package schedule
+
+import (
+	"sort"
+	"strings"
+)
+
+// indexCodes indexes the stops by code and the trips by short name, which is
+// how sources outside of GTFS such as Irish Rail and Luas refer to them. A
+// station is preferred over its platforms when they share a code
+func (s *Schedule) indexCodes() {
+	s.stopCodes = make(map[string]*Stop, len(s.Stops))
+	for _, stop := range s.Stops {
+		if stop.Code == "" {
+			continue
+		}
+
+		code := strings.ToUpper(stop.Code)
+		if other, ok := s.stopCodes[code]; ok && (other.ParentStation == "" && stop.ParentStation != "" ||
+			other.ParentStation == stop.ParentStation && other.ID < stop.ID) {
+			continue
+		}
+		s.stopCodes[code] = stop
+	}
+
+	s.tripNames = map[string][]*Trip{}
+	for _, t := range s.Trips {
+		if t.ShortName != "" {
+			s.tripNames[t.ShortName] = append(s.tripNames[t.ShortName], t)
+		}
+	}
+	for _, trips := range s.tripNames {
+		sort.Slice(trips, func(i, j int) bool { return trips[i].ID < trips[j].ID })
+	}
+}
+
+// StopByCode returns the stop with a code, ignoring case
+func (s *Schedule) StopByCode(code string) (*Stop, bool) {
+	stop, ok := s.stopCodes[strings.ToUpper(code)]
+
+	return stop, ok
+}
+
+// TripsByShortName returns every trip with a short name ordered by trip id,
+// such as the trips of a train code on each of the days it runs
+func (s *Schedule) TripsByShortName(name string) []*Trip {
+	return s.tripNames[name]
+}
+
+// AgencyByName returns the agency whose name contains name, comparing them
+// without case, accents or spaces so that "irishrail" finds "Iarnród Éireann /
+// Irish Rail". The agency with the lowest id wins when several match
+func (s *Schedule) AgencyByName(name string) (*Agency, bool) {
+	name = strings.ReplaceAll(normalise(name), " ", "")
+	if name == "" {
+		return nil, false
+	}
+
+	var found *Agency
+	for _, a := range s.Agencies {
+		if strings.Contains(strings.ReplaceAll(normalise(a.Name), " ", ""), name) && (found == nil || a.ID < found.ID) {
+			found = a
+		}
+	}
+
+	return found, found != nil
+}
@@ -0,0 +1,57 @@
+package schedule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleCodes(t *testing.T) {
+	s := New()
+	s.Agencies["7778017"] = &Agency{ID: "7778017", Name: "Iarnród Éireann / Irish Rail"}
+	s.Agencies["7778021"] = &Agency{ID: "7778021", Name: "Luas"}
+	s.Stops["8220IR0132"] = &Stop{ID: "8220IR0132", Code: "HSTON", LocationType: 1}
+	s.Stops["8220IR0133"] = &Stop{ID: "8220IR0133", Code: "HSTON", ParentStation: "8220IR0132"}
+	s.Stops["8220DB000334"] = &Stop{ID: "8220DB000334", Code: "334"}
+	s.Trips["IR_2"] = &Trip{ID: "IR_2", ShortName: "E109"}
+	s.Trips["IR_1"] = &Trip{ID: "IR_1", ShortName: "E109"}
+	s.Trips["3249_10466"] = &Trip{ID: "3249_10466"}
+	s.Index()
+
+	t.Run("finds a stop by code ignoring case", func(t *testing.T) {
+		stop, ok := s.StopByCode("hston")
+		assert.True(t, ok, "expected a stop")
+		assert.Equal(t, "8220IR0132", stop.ID, "expected the station over its platform")
+
+		stop, ok = s.StopByCode("334")
+		assert.True(t, ok, "expected a stop")
+		assert.Equal(t, "8220DB000334", stop.ID)
+
+		_, ok = s.StopByCode("")
+		assert.False(t, ok, "expected no stop without a code")
+	})
+
+	t.Run("finds trips by short name", func(t *testing.T) {
+		trips := s.TripsByShortName("E109")
+		if assert.Len(t, trips, 2) {
+			assert.Equal(t, "IR_1", trips[0].ID, "expected trips to be ordered by id")
+		}
+		assert.Empty(t, s.TripsByShortName("E110"))
+		assert.Empty(t, s.TripsByShortName(""))
+	})
+
+	t.Run("finds an agency by name", func(t *testing.T) {
+		a, ok := s.AgencyByName("irishrail")
+		assert.True(t, ok, "expected an agency")
+		assert.Equal(t, "7778017", a.ID)
+
+		a, ok = s.AgencyByName("LUAS")
+		assert.True(t, ok, "expected an agency")
+		assert.Equal(t, "7778021", a.ID)
+
+		_, ok = s.AgencyByName("buseireann")
+		assert.False(t, ok, "expected no agency")
+		_, ok = s.AgencyByName("")
+		assert.False(t, ok, "expected no agency for an empty name")
+	})
+}
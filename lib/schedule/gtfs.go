@@ -0,0 +1,459 @@
+package schedule
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// LoadGTFS loads a GTFS static feed from either a zip file or a directory
+func LoadGTFS(path string) (*Schedule, error) {
+	fsys, closer, err := openFeed(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open GTFS feed: %w", err)
+	}
+	defer closer.Close()
+
+	return ReadGTFS(fsys)
+}
+
+// openFeed opens the files of a static timetable in either a zip file or a
+// directory
+func openFeed(path string) (fs.FS, io.Closer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if info.IsDir() {
+		return os.DirFS(path), io.NopCloser(nil), nil
+	}
+
+	z, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return z, z, nil
+}
+
+// ReadGTFS reads the agency, stops, routes, trips, stop_times, calendar,
+// calendar_dates, shapes and translations files of a GTFS static feed
+func ReadGTFS(fsys fs.FS) (*Schedule, error) {
+	s := New()
+	ids := interner{}
+	names := stopNames{}
+
+	files := []struct {
+		name     string
+		required bool
+		read     func(r row) error
+	}{
+		{"agency.txt", true, func(r row) error { return readAgency(s, r) }},
+		{"stops.txt", true, func(r row) error { return readStop(s, ids, r) }},
+		{"routes.txt", true, func(r row) error { return readRoute(s, ids, r) }},
+		{"trips.txt", true, func(r row) error { return readTrip(s, ids, r) }},
+		{"stop_times.txt", true, func(r row) error { return readStopTime(s, ids, r) }},
+		{"calendar.txt", false, func(r row) error { return readCalendar(s, ids, r) }},
+		{"calendar_dates.txt", false, func(r row) error { return readCalendarDate(s, ids, r) }},
+		{"shapes.txt", false, func(r row) error { return readShapePoint(s, ids, r) }},
+		{"translations.txt", false, func(r row) error { return readTranslation(s, names, r) }},
+	}
+
+	for _, f := range files {
+		if err := readCSV(fsys, f.name, f.required, f.read); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(s.calendars) == 0 && len(s.calendarDates) == 0 {
+		return nil, errors.New("GTFS feed has neither calendar.txt nor calendar_dates.txt")
+	}
+
+	s.Index()
+
+	return s, nil
+}
+
+// interner deduplicates ids which are repeated on many rows, such as the trip
+// and stop ids of stop times, so that only one copy of each is kept in memory
+type interner map[string]string
+
+func (i interner) get(s string) string {
+	if v, ok := i[s]; ok {
+		return v
+	}
+	s = strings.Clone(s)
+	i[s] = s
+
+	return s
+}
+
+type row struct {
+	cols   map[string]int
+	record []string
+}
+
+func (r row) get(col string) string {
+	i, ok := r.cols[col]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+
+	return strings.TrimSpace(r.record[i])
+}
+
+func (r row) text(col string) string {
+	return strings.Clone(r.get(col))
+}
+
+func (r row) required(col string) (string, error) {
+	v := r.get(col)
+	if v == "" {
+		return "", fmt.Errorf("missing %s", col)
+	}
+
+	return v, nil
+}
+
+func (r row) int(col string) (int, error) {
+	v := r.get(col)
+	if v == "" {
+		return 0, nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", col, v)
+	}
+
+	return i, nil
+}
+
+func (r row) float(col string) (float64, error) {
+	v := r.get(col)
+	if v == "" {
+		return 0, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q", col, v)
+	}
+
+	return f, nil
+}
+
+func readCSV(fsys fs.FS, name string, required bool, fn func(r row) error) error {
+	f, err := fsys.Open(name)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.ReuseRecord = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return fmt.Errorf("could not read %s header: %w", name, err)
+	}
+
+	r := row{cols: make(map[string]int, len(header))}
+	for i, col := range header {
+		r.cols[strings.TrimSpace(strings.TrimPrefix(col, "\ufeff"))] = i
+	}
+
+	for line := 2; ; line++ {
+		r.record, err = reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not read %s: %w", name, err)
+		}
+
+		if err := fn(r); err != nil {
+			return fmt.Errorf("%s line %d: %w", name, line, err)
+		}
+	}
+}
+
+func readAgency(s *Schedule, r row) error {
+	a := &Agency{
+		ID:       r.text("agency_id"),
+		Name:     r.text("agency_name"),
+		URL:      r.text("agency_url"),
+		Timezone: r.text("agency_timezone"),
+		Lang:     r.text("agency_lang"),
+		Phone:    r.text("agency_phone"),
+	}
+	s.Agencies[a.ID] = a
+
+	return nil
+}
+
+func readStop(s *Schedule, ids interner, r row) error {
+	id, err := r.required("stop_id")
+	if err != nil {
+		return err
+	}
+
+	stop := &Stop{
+		ID:            ids.get(id),
+		Code:          r.text("stop_code"),
+		Name:          r.text("stop_name"),
+		Description:   r.text("stop_desc"),
+		ParentStation: ids.get(r.get("parent_station")),
+		PlatformCode:  r.text("platform_code"),
+	}
+	if stop.Latitude, err = r.float("stop_lat"); err != nil {
+		return err
+	}
+	if stop.Longitude, err = r.float("stop_lon"); err != nil {
+		return err
+	}
+	if stop.LocationType, err = r.int("location_type"); err != nil {
+		return err
+	}
+	s.Stops[stop.ID] = stop
+
+	return nil
+}
+
+func readRoute(s *Schedule, ids interner, r row) error {
+	id, err := r.required("route_id")
+	if err != nil {
+		return err
+	}
+
+	route := &Route{
+		ID:        ids.get(id),
+		AgencyID:  ids.get(r.get("agency_id")),
+		ShortName: r.text("route_short_name"),
+		LongName:  r.text("route_long_name"),
+		Color:     r.text("route_color"),
+		TextColor: r.text("route_text_color"),
+	}
+	if route.Type, err = r.int("route_type"); err != nil {
+		return err
+	}
+
+	// agency_id may be left out when a feed only has one agency
+	if route.AgencyID == "" && len(s.Agencies) == 1 {
+		for id := range s.Agencies {
+			route.AgencyID = id
+		}
+	}
+	s.Routes[route.ID] = route
+
+	return nil
+}
+
+func readTrip(s *Schedule, ids interner, r row) error {
+	id, err := r.required("trip_id")
+	if err != nil {
+		return err
+	}
+
+	routeID, err := r.required("route_id")
+	if err != nil {
+		return err
+	}
+
+	trip := &Trip{
+		ID:        ids.get(id),
+		RouteID:   ids.get(routeID),
+		ServiceID: ids.get(r.get("service_id")),
+		Headsign:  ids.get(r.get("trip_headsign")),
+		ShortName: r.text("trip_short_name"),
+		BlockID:   ids.get(r.get("block_id")),
+		ShapeID:   ids.get(r.get("shape_id")),
+	}
+	if trip.DirectionID, err = r.int("direction_id"); err != nil {
+		return err
+	}
+	s.Trips[trip.ID] = trip
+
+	return nil
+}
+
+func readStopTime(s *Schedule, ids interner, r row) error {
+	tripID, err := r.required("trip_id")
+	if err != nil {
+		return err
+	}
+
+	stopID, err := r.required("stop_id")
+	if err != nil {
+		return err
+	}
+
+	st := StopTime{
+		TripID:   ids.get(tripID),
+		StopID:   ids.get(stopID),
+		Headsign: ids.get(r.get("stop_headsign")),
+	}
+
+	arrival, departure := r.get("arrival_time"), r.get("departure_time")
+	if arrival == "" {
+		arrival = departure
+	}
+	if departure == "" {
+		departure = arrival
+	}
+	// Times are only required at timepoints, the others are interpolated once
+	// every stop time has been read
+	st.Arrival, st.Departure = noTime, noTime
+	if arrival != "" {
+		if st.Arrival, err = ParseServiceTime(arrival); err != nil {
+			return err
+		}
+		if st.Departure, err = ParseServiceTime(departure); err != nil {
+			return err
+		}
+	}
+
+	if st.StopSequence, err = r.int("stop_sequence"); err != nil {
+		return err
+	}
+	if st.PickupType, err = r.int("pickup_type"); err != nil {
+		return err
+	}
+	if st.DropOffType, err = r.int("drop_off_type"); err != nil {
+		return err
+	}
+	if st.ShapeDistTraveled, err = r.float("shape_dist_traveled"); err != nil {
+		return err
+	}
+	s.AddStopTime(st)
+
+	return nil
+}
+
+func readCalendar(s *Schedule, ids interner, r row) error {
+	id, err := r.required("service_id")
+	if err != nil {
+		return err
+	}
+
+	c := Calendar{
+		ServiceID: ids.get(id),
+		StartDate: r.text("start_date"),
+		EndDate:   r.text("end_date"),
+	}
+
+	days := []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+	for i, day := range days {
+		c.Days[i] = r.get(day) == "1"
+	}
+	s.AddCalendar(c)
+
+	return nil
+}
+
+func readCalendarDate(s *Schedule, ids interner, r row) error {
+	id, err := r.required("service_id")
+	if err != nil {
+		return err
+	}
+
+	exceptionType, err := r.int("exception_type")
+	if err != nil {
+		return err
+	}
+
+	s.AddCalendarDate(CalendarDate{
+		ServiceID:     ids.get(id),
+		Date:          r.text("date"),
+		ExceptionType: ExceptionType(exceptionType),
+	})
+
+	return nil
+}
+
+func readShapePoint(s *Schedule, ids interner, r row) error {
+	id, err := r.required("shape_id")
+	if err != nil {
+		return err
+	}
+
+	p := ShapePoint{}
+	if p.Latitude, err = r.float("shape_pt_lat"); err != nil {
+		return err
+	}
+	if p.Longitude, err = r.float("shape_pt_lon"); err != nil {
+		return err
+	}
+	if p.Sequence, err = r.int("shape_pt_sequence"); err != nil {
+		return err
+	}
+	if p.DistTraveled, err = r.float("shape_dist_traveled"); err != nil {
+		return err
+	}
+
+	id = ids.get(id)
+	s.Shapes[id] = append(s.Shapes[id], p)
+
+	return nil
+}
+
+// stopNames finds the stops by name, for translations which give the name
+// they translate rather than the id of the stop. It is filled on first use
+type stopNames map[string][]*Stop
+
+func (n stopNames) get(s *Schedule, name string) []*Stop {
+	if len(n) == 0 {
+		for _, stop := range s.Stops {
+			n[stop.Name] = append(n[stop.Name], stop)
+		}
+	}
+
+	return n[name]
+}
+
+// readTranslation reads the translations of stop names, which are the only
+// ones used. A translation applies to the stop of record_id or otherwise to
+// every stop named field_value
+func readTranslation(s *Schedule, names stopNames, r row) error {
+	if r.get("table_name") != "stops" || r.get("field_name") != "stop_name" {
+		return nil
+	}
+
+	language, err := r.required("language")
+	if err != nil {
+		return err
+	}
+	translation, err := r.required("translation")
+	if err != nil {
+		return err
+	}
+
+	var stops []*Stop
+	if id := r.get("record_id"); id != "" {
+		if stop, ok := s.Stops[id]; ok {
+			stops = append(stops, stop)
+		}
+	} else {
+		stops = names.get(s, r.get("field_value"))
+	}
+
+	for _, stop := range stops {
+		if stop.Translations == nil {
+			stop.Translations = map[string]string{}
+		}
+		stop.Translations[strings.Clone(language)] = strings.Clone(translation)
+	}
+
+	return nil
+}
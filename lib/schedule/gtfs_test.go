@@ -0,0 +1,245 @@
+package schedule
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func zipFeed(t *testing.T, dir string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "gtfs.zip")
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	w := zip.NewWriter(f)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, e := range entries {
+		src, err := os.Open(filepath.Join(dir, e.Name()))
+		assert.NoError(t, err)
+		dst, err := w.Create(e.Name())
+		assert.NoError(t, err)
+		_, err = io.Copy(dst, src)
+		assert.NoError(t, err)
+		src.Close()
+	}
+	assert.NoError(t, w.Close())
+
+	return path
+}
+
+// minimalFeed returns a valid feed with a single stop time which tests can
+// override files of
+func minimalFeed(files map[string]string) fstest.MapFS {
+	feed := fstest.MapFS{}
+	for name, data := range map[string]string{
+		"agency.txt":     "agency_id,agency_name,agency_url,agency_timezone\nA,Agency,https://example.com,Europe/Dublin\n",
+		"stops.txt":      "stop_id,stop_name,stop_lat,stop_lon\nS,Stop,53.35,-6.26\n",
+		"routes.txt":     "route_id,route_short_name,route_type\nR,1,3\n",
+		"trips.txt":      "route_id,service_id,trip_id\nR,1,T\n",
+		"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\nT,10:00:00,10:00:00,S,1\n",
+		"calendar.txt":   "service_id,monday,tuesday,wednesday,thursday,friday,saturday,sunday,start_date,end_date\n1,1,1,1,1,1,1,1,20250101,20251231\n",
+	} {
+		feed[name] = &fstest.MapFile{Data: []byte(data)}
+	}
+	for name, data := range files {
+		if data == "" {
+			delete(feed, name)
+
+			continue
+		}
+		feed[name] = &fstest.MapFile{Data: []byte(data)}
+	}
+
+	return feed
+}
+
+func TestReadGTFS(t *testing.T) {
+	t.Run("reads every file of the feed", func(t *testing.T) {
+		s, err := ReadGTFS(os.DirFS("testdata/gtfs"))
+		assert.NoError(t, err, "expected feed to load")
+
+		assert.Len(t, s.Agencies, 2)
+		assert.Equal(t, &Agency{
+			ID:       "7778019",
+			Name:     "Dublin Bus",
+			URL:      "https://www.dublinbus.ie",
+			Timezone: "Europe/Dublin",
+			Lang:     "EN",
+		}, s.Agencies["7778019"], "expected byte order mark to be stripped from the header")
+
+		assert.Len(t, s.Stops, 6)
+		assert.Equal(t, &Stop{
+			ID:            "8220IR0133",
+			Name:          "Heuston Platform 1",
+			Latitude:      53.3465,
+			Longitude:     -6.2930,
+			ParentStation: "8220IR0132",
+			PlatformCode:  "1",
+		}, s.Stops["8220IR0133"])
+		assert.Equal(t, "Parnell Square West, stop 2", s.Stops["8220DB000334"].Name)
+		assert.Equal(t, 1, s.Stops["8220IR0132"].LocationType)
+		assert.Equal(t, map[string]string{"ga": "Stáisiún Heuston"}, s.Stops["8220IR0132"].Translations, "expected translation of record id")
+		assert.Equal(t, map[string]string{"ga": "Sráid Uí Chonaill Uachtarach"}, s.Stops["8220DB000335"].Translations, "expected translation of field value")
+
+		assert.Len(t, s.Routes, 3)
+		assert.Equal(t, &Route{
+			ID:        "3249_46342",
+			AgencyID:  "7778019",
+			ShortName: "46A",
+			LongName:  "Phoenix Park - Dún Laoghaire",
+			Type:      3,
+		}, s.Routes["3249_46342"])
+
+		assert.Len(t, s.Trips, 4)
+		assert.Equal(t, &Trip{
+			ID:          "3249_10511",
+			RouteID:     "3249_46350",
+			ServiceID:   "1",
+			Headsign:    "UCD Belfield",
+			DirectionID: 1,
+		}, s.Trips["3249_10511"])
+		assert.Len(t, s.TripsForRoute("3249_46342"), 2)
+
+		assert.Equal(t, 9, s.StopTimeCount())
+		assert.Equal(t, []ShapePoint{
+			{Latitude: 53.3531, Longitude: -6.2645, Sequence: 1, DistTraveled: 1000},
+			{Latitude: 53.3511, Longitude: -6.2608, Sequence: 2, DistTraveled: 1200.5},
+			{Latitude: 53.2949, Longitude: -6.1341, Sequence: 3, DistTraveled: 12000},
+		}, s.Shapes["3249_46342_1"], "expected shape points to be ordered by sequence")
+	})
+
+	t.Run("orders stop times and interpolates the ones without times", func(t *testing.T) {
+		s, err := ReadGTFS(os.DirFS("testdata/gtfs"))
+		assert.NoError(t, err)
+
+		stopTimes := s.StopTimesForTrip("3249_10466")
+		var stops []string
+		for _, st := range stopTimes {
+			stops = append(stops, st.StopID)
+		}
+		assert.Equal(t, []string{"8220DB000334", "8220DB000335", "8220DB000336", "8250DB002002"}, stops)
+		assert.Equal(t, "10:35:00", stopTimes[0].Arrival.String())
+		assert.Equal(t, "10:35:30", stopTimes[0].Departure.String())
+		assert.Equal(t, "10:58:30", stopTimes[2].Arrival.String(), "expected time to be interpolated")
+		assert.Equal(t, 1, stopTimes[3].DropOffType)
+		assert.Equal(t, 12000.0, stopTimes[3].ShapeDistTraveled)
+
+		assert.Equal(t, "24:05:00", s.StopTimesForTrip("3249_10467")[1].Arrival.String())
+		assert.Len(t, s.StopTimesForStop("8250DB002002"), 3)
+	})
+
+	t.Run("reads the calendar and its exceptions", func(t *testing.T) {
+		s, err := ReadGTFS(os.DirFS("testdata/gtfs"))
+		assert.NoError(t, err)
+
+		monday := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+		stPatricksDay := time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC)
+		assert.True(t, s.ServiceRunsOn("1", monday))
+		assert.False(t, s.ServiceRunsOn("2", monday))
+		assert.False(t, s.ServiceRunsOn("1", stPatricksDay), "expected weekday service to be removed")
+		assert.True(t, s.ServiceRunsOn("2", stPatricksDay), "expected weekend service to be added")
+	})
+
+	t.Run("falls back to the only agency for routes without one", func(t *testing.T) {
+		s, err := ReadGTFS(minimalFeed(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, "A", s.Routes["R"].AgencyID)
+	})
+
+	t.Run("accepts feeds with only calendar dates", func(t *testing.T) {
+		s, err := ReadGTFS(minimalFeed(map[string]string{
+			"calendar.txt":       "",
+			"calendar_dates.txt": "service_id,date,exception_type\n1,20250317,1\n",
+		}))
+		assert.NoError(t, err)
+		assert.True(t, s.ServiceRunsOn("1", time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC)))
+	})
+
+	t.Run("errors without a calendar", func(t *testing.T) {
+		_, err := ReadGTFS(minimalFeed(map[string]string{"calendar.txt": ""}))
+		assert.EqualError(t, err, "GTFS feed has neither calendar.txt nor calendar_dates.txt")
+	})
+
+	t.Run("errors when a required file is missing", func(t *testing.T) {
+		_, err := ReadGTFS(minimalFeed(map[string]string{"stop_times.txt": ""}))
+		assert.ErrorContains(t, err, "could not open stop_times.txt")
+	})
+
+	t.Run("errors on an empty file", func(t *testing.T) {
+		feed := minimalFeed(nil)
+		feed["routes.txt"] = &fstest.MapFile{}
+		_, err := ReadGTFS(feed)
+		assert.ErrorContains(t, err, "could not read routes.txt header")
+	})
+
+	t.Run("errors with the line of an invalid row", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			files map[string]string
+			err   string
+		}{
+			{
+				"invalid time",
+				map[string]string{"stop_times.txt": "trip_id,arrival_time,departure_time,stop_id,stop_sequence\nT,10:00:00,10:00:00,S,1\nT,10:65:00,10:65:00,S,2\n"},
+				`stop_times.txt line 3: invalid service time "10:65:00"`,
+			},
+			{
+				"invalid coordinate",
+				map[string]string{"stops.txt": "stop_id,stop_name,stop_lat,stop_lon\nS,Stop,north,-6.26\n"},
+				`stops.txt line 2: invalid stop_lat "north"`,
+			},
+			{
+				"missing id",
+				map[string]string{"trips.txt": "route_id,service_id,trip_id\nR,1,\n"},
+				"trips.txt line 2: missing trip_id",
+			},
+			{
+				"invalid sequence",
+				map[string]string{"shapes.txt": "shape_id,shape_pt_lat,shape_pt_lon,shape_pt_sequence\nS,53.35,-6.26,first\n"},
+				`shapes.txt line 2: invalid shape_pt_sequence "first"`,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := ReadGTFS(minimalFeed(tt.files))
+				assert.EqualError(t, err, tt.err)
+			})
+		}
+	})
+}
+
+func TestLoadGTFS(t *testing.T) {
+	t.Run("loads a directory", func(t *testing.T) {
+		s, err := LoadGTFS("testdata/gtfs")
+		assert.NoError(t, err)
+		assert.Len(t, s.Stops, 6)
+	})
+
+	t.Run("loads a zip file", func(t *testing.T) {
+		s, err := LoadGTFS(zipFeed(t, "testdata/gtfs"))
+		assert.NoError(t, err)
+		assert.Len(t, s.Stops, 6)
+		assert.Equal(t, 9, s.StopTimeCount())
+	})
+
+	t.Run("errors when the path does not exist", func(t *testing.T) {
+		_, err := LoadGTFS("testdata/missing.zip")
+		assert.ErrorContains(t, err, "could not open GTFS feed")
+	})
+
+	t.Run("errors when the file is not a zip", func(t *testing.T) {
+		_, err := LoadGTFS("testdata/gtfs/stops.txt")
+		assert.ErrorContains(t, err, "could not open GTFS feed")
+	})
+}
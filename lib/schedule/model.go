@@ -0,0 +1,126 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServiceTime is the number of seconds since noon minus 12h of the service
+// day, it can go past 24:00:00 for trips which run after midnight
+type ServiceTime int32
+
+func ParseServiceTime(s string) (ServiceTime, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid service time %q", s)
+	}
+
+	var hms [3]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 || (i > 0 && v > 59) {
+			return 0, fmt.Errorf("invalid service time %q", s)
+		}
+		hms[i] = v
+	}
+
+	return ServiceTime(hms[0]*3600 + hms[1]*60 + hms[2]), nil
+}
+
+func (t ServiceTime) String() string {
+	return fmt.Sprintf("%02d:%02d:%02d", t/3600, t/60%60, t%60)
+}
+
+// On returns the wall clock time of t on the service day of date
+func (t ServiceTime) On(date time.Time) time.Time {
+	noon := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, date.Location())
+
+	return noon.Add(-12 * time.Hour).Add(time.Duration(t) * time.Second)
+}
+
+type Agency struct {
+	ID       string
+	Name     string
+	URL      string
+	Timezone string
+	Lang     string
+	Phone    string
+}
+
+type Stop struct {
+	ID            string
+	Code          string
+	Name          string
+	Description   string
+	Latitude      float64
+	Longitude     float64
+	LocationType  int
+	ParentStation string
+	PlatformCode  string
+	// Translations of the name keyed by language, such as the Irish name
+	Translations map[string]string
+}
+
+type Route struct {
+	ID        string
+	AgencyID  string
+	ShortName string
+	LongName  string
+	Type      int
+	Color     string
+	TextColor string
+}
+
+type Trip struct {
+	ID          string
+	RouteID     string
+	ServiceID   string
+	Headsign    string
+	ShortName   string
+	DirectionID int
+	BlockID     string
+	ShapeID     string
+}
+
+type StopTime struct {
+	TripID            string
+	StopID            string
+	StopSequence      int
+	Arrival           ServiceTime
+	Departure         ServiceTime
+	Headsign          string
+	PickupType        int
+	DropOffType       int
+	ShapeDistTraveled float64
+}
+
+// Calendar is the weekly pattern of a service, Days is indexed by
+// time.Weekday. Dates are in the YYYYMMDD form
+type Calendar struct {
+	ServiceID string
+	Days      [7]bool
+	StartDate string
+	EndDate   string
+}
+
+type ExceptionType int
+
+const (
+	ServiceAdded   ExceptionType = 1
+	ServiceRemoved ExceptionType = 2
+)
+
+type CalendarDate struct {
+	ServiceID     string
+	Date          string
+	ExceptionType ExceptionType
+}
+
+type ShapePoint struct {
+	Latitude     float64
+	Longitude    float64
+	Sequence     int
+	DistTraveled float64
+}
@@ -0,0 +1,59 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseServiceTime(t *testing.T) {
+	t.Run("parses times within the service day", func(t *testing.T) {
+		st, err := ParseServiceTime("10:35:30")
+		assert.NoError(t, err)
+		assert.Equal(t, ServiceTime(10*3600+35*60+30), st)
+	})
+
+	t.Run("parses times past midnight", func(t *testing.T) {
+		st, err := ParseServiceTime("24:05:00")
+		assert.NoError(t, err)
+		assert.Equal(t, ServiceTime(24*3600+5*60), st)
+	})
+
+	t.Run("parses single digit hours", func(t *testing.T) {
+		st, err := ParseServiceTime(" 5:00:00")
+		assert.NoError(t, err)
+		assert.Equal(t, ServiceTime(5*3600), st)
+	})
+
+	t.Run("errors on invalid times", func(t *testing.T) {
+		for _, s := range []string{"", "10:35", "10:60:00", "10:00:61", "-1:00:00", "aa:bb:cc"} {
+			_, err := ParseServiceTime(s)
+			assert.ErrorContains(t, err, "invalid service time", "expected %q to be invalid", s)
+		}
+	})
+}
+
+func TestServiceTimeString(t *testing.T) {
+	assert.Equal(t, "10:35:30", ServiceTime(10*3600+35*60+30).String())
+	assert.Equal(t, "24:05:00", ServiceTime(24*3600+5*60).String())
+}
+
+func TestServiceTimeOn(t *testing.T) {
+	dublin, err := time.LoadLocation("Europe/Dublin")
+	assert.NoError(t, err)
+
+	t.Run("returns the wall clock time on the service day", func(t *testing.T) {
+		date := time.Date(2025, 3, 17, 0, 0, 0, 0, dublin)
+		assert.Equal(t, time.Date(2025, 3, 17, 10, 35, 0, 0, dublin), ServiceTime(10*3600+35*60).On(date))
+		assert.Equal(t, time.Date(2025, 3, 18, 0, 5, 0, 0, dublin), ServiceTime(24*3600+5*60).On(date))
+	})
+
+	t.Run("counts from noon minus 12 hours on daylight saving days", func(t *testing.T) {
+		// clocks go forward at 01:00 on the 30th of March 2025, so the service
+		// day starts at 23:00 the night before
+		date := time.Date(2025, 3, 30, 0, 0, 0, 0, dublin)
+		assert.Equal(t, time.Date(2025, 3, 30, 10, 0, 0, 0, dublin), ServiceTime(10*3600).On(date))
+		assert.Equal(t, time.Date(2025, 3, 29, 23, 30, 0, 0, dublin), ServiceTime(30*60).On(date))
+	})
+}
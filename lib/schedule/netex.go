@@ -0,0 +1,888 @@
+package schedule
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const netexDateLayout = "2006-01-02"
+
+// netexRouteTypes maps the transport modes of NeTEx to GTFS route types, a
+// line of any other mode is taken as a bus
+var netexRouteTypes = map[string]int{
+	"tram":       0,
+	"metro":      1,
+	"rail":       2,
+	"bus":        3,
+	"coach":      3,
+	"water":      4,
+	"ferry":      4,
+	"cableway":   6,
+	"funicular":  7,
+	"trolleyBus": 11,
+}
+
+// netexDays maps the DaysOfWeek of a day type to the days it names, indexed by
+// time.Weekday
+var netexDays = map[string][]time.Weekday{
+	"Sunday":    {time.Sunday},
+	"Monday":    {time.Monday},
+	"Tuesday":   {time.Tuesday},
+	"Wednesday": {time.Wednesday},
+	"Thursday":  {time.Thursday},
+	"Friday":    {time.Friday},
+	"Saturday":  {time.Saturday},
+	"Weekdays":  {time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday},
+	"Weekend":   {time.Saturday, time.Sunday},
+	"Everyday":  {time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+}
+
+type netexRef struct {
+	Ref string `xml:"ref,attr"`
+}
+
+type netexLocale struct {
+	TimeZone        string `xml:"TimeZone"`
+	DefaultLanguage string `xml:"DefaultLanguage"`
+}
+
+type netexOperator struct {
+	ID    string `xml:"id,attr"`
+	Name  string `xml:"Name"`
+	URL   string `xml:"ContactDetails>Url"`
+	Phone string `xml:"ContactDetails>Phone"`
+}
+
+type netexLine struct {
+	ID            string   `xml:"id,attr"`
+	Name          string   `xml:"Name"`
+	ShortName     string   `xml:"ShortName"`
+	PublicCode    string   `xml:"PublicCode"`
+	TransportMode string   `xml:"TransportMode"`
+	OperatorRef   netexRef `xml:"OperatorRef"`
+	Colour        string   `xml:"Presentation>Colour"`
+	TextColour    string   `xml:"Presentation>TextColour"`
+}
+
+type netexText struct {
+	Lang  string `xml:"lang,attr"`
+	Value string `xml:",chardata"`
+}
+
+type netexAlternativeText struct {
+	AttributeName string    `xml:"attributeName,attr"`
+	Text          netexText `xml:"Text"`
+}
+
+type netexStopPoint struct {
+	ID               string                 `xml:"id,attr"`
+	Name             string                 `xml:"Name"`
+	PublicCode       string                 `xml:"PublicCode"`
+	Description      string                 `xml:"Description"`
+	Latitude         float64                `xml:"Location>Latitude"`
+	Longitude        float64                `xml:"Location>Longitude"`
+	AlternativeTexts []netexAlternativeText `xml:"alternativeTexts>AlternativeText"`
+}
+
+// netexPlace is a stop place or one of its quays, where a scheduled stop point
+// without a location of its own is
+type netexPlace struct {
+	ID        string       `xml:"id,attr"`
+	Latitude  float64      `xml:"Centroid>Location>Latitude"`
+	Longitude float64      `xml:"Centroid>Location>Longitude"`
+	Quays     []netexPlace `xml:"quays>Quay"`
+}
+
+// netexStopAssignment assigns a scheduled stop point to the stop place, or the
+// quay of it, where passengers board
+type netexStopAssignment struct {
+	ScheduledStopPointRef netexRef `xml:"ScheduledStopPointRef"`
+	StopPlaceRef          netexRef `xml:"StopPlaceRef"`
+	QuayRef               netexRef `xml:"QuayRef"`
+}
+
+// netexRoute is the path a line takes in one direction, not a GTFS route
+type netexRoute struct {
+	ID            string   `xml:"id,attr"`
+	LineRef       netexRef `xml:"LineRef"`
+	DirectionType string   `xml:"DirectionType"`
+}
+
+type netexDestinationDisplay struct {
+	ID        string `xml:"id,attr"`
+	FrontText string `xml:"FrontText"`
+}
+
+type netexPointInPattern struct {
+	ID                    string   `xml:"id,attr"`
+	Order                 int      `xml:"order,attr"`
+	ScheduledStopPointRef netexRef `xml:"ScheduledStopPointRef"`
+	DestinationDisplayRef netexRef `xml:"DestinationDisplayRef"`
+	ForAlighting          string   `xml:"ForAlighting"`
+	ForBoarding           string   `xml:"ForBoarding"`
+}
+
+type netexJourneyPattern struct {
+	ID                    string                `xml:"id,attr"`
+	RouteRef              netexRef              `xml:"RouteRef"`
+	DestinationDisplayRef netexRef              `xml:"DestinationDisplayRef"`
+	Points                []netexPointInPattern `xml:"pointsInSequence>StopPointInJourneyPattern"`
+}
+
+type netexPassingTime struct {
+	StopPointInJourneyPatternRef netexRef `xml:"StopPointInJourneyPatternRef"`
+	ArrivalTime                  string   `xml:"ArrivalTime"`
+	ArrivalDayOffset             int      `xml:"ArrivalDayOffset"`
+	DepartureTime                string   `xml:"DepartureTime"`
+	DepartureDayOffset           int      `xml:"DepartureDayOffset"`
+}
+
+type netexServiceJourney struct {
+	ID                       string             `xml:"id,attr"`
+	PublicCode               string             `xml:"PublicCode"`
+	PrivateCode              string             `xml:"PrivateCode"`
+	DayTypeRefs              []netexRef         `xml:"dayTypes>DayTypeRef"`
+	JourneyPatternRef        netexRef           `xml:"JourneyPatternRef"`
+	ServiceJourneyPatternRef netexRef           `xml:"ServiceJourneyPatternRef"`
+	LineRef                  netexRef           `xml:"LineRef"`
+	OperatorRef              netexRef           `xml:"OperatorRef"`
+	PassingTimes             []netexPassingTime `xml:"passingTimes>TimetabledPassingTime"`
+}
+
+type netexDayType struct {
+	ID         string   `xml:"id,attr"`
+	DaysOfWeek []string `xml:"properties>PropertyOfDay>DaysOfWeek"`
+}
+
+type netexOperatingPeriod struct {
+	ID       string `xml:"id,attr"`
+	FromDate string `xml:"FromDate"`
+	ToDate   string `xml:"ToDate"`
+}
+
+type netexOperatingDay struct {
+	ID           string `xml:"id,attr"`
+	CalendarDate string `xml:"CalendarDate"`
+}
+
+// netexDayTypeAssignment assigns a day type to an operating period, or to a
+// single day given by its date or an operating day
+type netexDayTypeAssignment struct {
+	DayTypeRef         netexRef `xml:"DayTypeRef"`
+	OperatingPeriodRef netexRef `xml:"OperatingPeriodRef"`
+	OperatingDayRef    netexRef `xml:"OperatingDayRef"`
+	Date               string   `xml:"Date"`
+	IsAvailable        string   `xml:"isAvailable"`
+}
+
+// netex holds everything read from the files of a NeTEx delivery, which
+// reference each other across files, until it is built into a schedule
+type netex struct {
+	locale        netexLocale
+	operators     []netexOperator
+	lines         []netexLine
+	stopPoints    []netexStopPoint
+	places        map[string]netexPlace
+	assignedStops map[string]netexStopAssignment
+	routes        map[string]netexRoute
+	displays      map[string]string
+	patterns      map[string]netexJourneyPattern
+	journeys      []netexServiceJourney
+	dayTypes      map[string]netexDayType
+	periods       map[string]netexOperatingPeriod
+	operatingDays map[string]netexOperatingDay
+	assignments   map[string][]netexDayTypeAssignment
+	patternPoints map[string]map[string]netexPointInPattern
+}
+
+// element decodes the element which has just started into v
+func element[T any](d *xml.Decoder, start xml.StartElement) (T, error) {
+	var v T
+	err := d.DecodeElement(&v, &start)
+
+	return v, err
+}
+
+// read decodes the elements of a NeTEx file which make up a schedule wherever
+// they are in its frames, everything else is skipped
+func (n *netex) read(r io.Reader) error {
+	d := xml.NewDecoder(r)
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "DefaultLocale":
+			n.locale, err = element[netexLocale](d, start)
+		case "Operator":
+			var o netexOperator
+			if o, err = element[netexOperator](d, start); err == nil {
+				n.operators = append(n.operators, o)
+			}
+		case "Line", "FlexibleLine":
+			var l netexLine
+			if l, err = element[netexLine](d, start); err == nil {
+				n.lines = append(n.lines, l)
+			}
+		case "ScheduledStopPoint":
+			var p netexStopPoint
+			if p, err = element[netexStopPoint](d, start); err == nil {
+				n.stopPoints = append(n.stopPoints, p)
+			}
+		case "StopPlace", "Quay":
+			var place netexPlace
+			if place, err = element[netexPlace](d, start); err == nil {
+				n.places[place.ID] = place
+				for _, q := range place.Quays {
+					n.places[q.ID] = q
+				}
+			}
+		case "PassengerStopAssignment":
+			var a netexStopAssignment
+			if a, err = element[netexStopAssignment](d, start); err == nil {
+				n.assignedStops[a.ScheduledStopPointRef.Ref] = a
+			}
+		case "Route":
+			var route netexRoute
+			if route, err = element[netexRoute](d, start); err == nil {
+				n.routes[route.ID] = route
+			}
+		case "DestinationDisplay":
+			var dd netexDestinationDisplay
+			if dd, err = element[netexDestinationDisplay](d, start); err == nil {
+				n.displays[dd.ID] = strings.TrimSpace(dd.FrontText)
+			}
+		case "JourneyPattern", "ServiceJourneyPattern":
+			var p netexJourneyPattern
+			if p, err = element[netexJourneyPattern](d, start); err == nil {
+				n.patterns[p.ID] = p
+			}
+		case "ServiceJourney":
+			var j netexServiceJourney
+			if j, err = element[netexServiceJourney](d, start); err == nil {
+				n.journeys = append(n.journeys, j)
+			}
+		case "DayType":
+			var dt netexDayType
+			if dt, err = element[netexDayType](d, start); err == nil {
+				n.dayTypes[dt.ID] = dt
+			}
+		case "OperatingPeriod":
+			var p netexOperatingPeriod
+			if p, err = element[netexOperatingPeriod](d, start); err == nil {
+				n.periods[p.ID] = p
+			}
+		case "OperatingDay":
+			var day netexOperatingDay
+			if day, err = element[netexOperatingDay](d, start); err == nil {
+				n.operatingDays[day.ID] = day
+			}
+		case "DayTypeAssignment":
+			var a netexDayTypeAssignment
+			if a, err = element[netexDayTypeAssignment](d, start); err == nil {
+				n.assignments[a.DayTypeRef.Ref] = append(n.assignments[a.DayTypeRef.Ref], a)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", start.Name.Local, err)
+		}
+	}
+}
+
+// LoadNeTEx loads NeTEx from a single XML file, or from every XML file in a
+// zip file or a directory
+func LoadNeTEx(p string) (*Schedule, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("could not open NeTEx: %w", err)
+	}
+
+	if !info.IsDir() && strings.EqualFold(filepath.Ext(p), ".xml") {
+		return readNeTEx(os.DirFS(filepath.Dir(p)), []string{filepath.Base(p)})
+	}
+
+	fsys, closer, err := openFeed(p)
+	if err != nil {
+		return nil, fmt.Errorf("could not open NeTEx: %w", err)
+	}
+	defer closer.Close()
+
+	return ReadNeTEx(fsys)
+}
+
+// Load loads a static timetable which is either a GTFS feed or NeTEx, a
+// timetable without an agency.txt but with XML files is taken as NeTEx
+func Load(p string) (*Schedule, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, fmt.Errorf("could not open timetable: %w", err)
+	}
+	if !info.IsDir() && strings.EqualFold(filepath.Ext(p), ".xml") {
+		return LoadNeTEx(p)
+	}
+
+	fsys, closer, err := openFeed(p)
+	if err != nil {
+		return nil, fmt.Errorf("could not open timetable: %w", err)
+	}
+	defer closer.Close()
+
+	if _, err := fs.Stat(fsys, "agency.txt"); err == nil {
+		return ReadGTFS(fsys)
+	}
+	if names, _ := netexFiles(fsys); len(names) > 0 {
+		return readNeTEx(fsys, names)
+	}
+
+	return ReadGTFS(fsys)
+}
+
+// ReadNeTEx reads the operators, lines, scheduled stop points, journey
+// patterns, service journeys and day types of every XML file in fsys, such
+// as the shared data and line files of a NeTEx delivery
+func ReadNeTEx(fsys fs.FS) (*Schedule, error) {
+	names, err := netexFiles(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("could not list NeTEx files: %w", err)
+	}
+	if len(names) == 0 {
+		return nil, errors.New("NeTEx has no XML files")
+	}
+
+	return readNeTEx(fsys, names)
+}
+
+// netexFiles returns the names of the XML files in fsys and its directories
+func netexFiles(fsys fs.FS) ([]string, error) {
+	var names []string
+	err := fs.WalkDir(fsys, ".", func(name string, e fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !e.IsDir() && strings.EqualFold(path.Ext(name), ".xml") {
+			names = append(names, name)
+		}
+
+		return nil
+	})
+
+	return names, err
+}
+
+func readNeTEx(fsys fs.FS, names []string) (*Schedule, error) {
+	n := &netex{
+		routes:        map[string]netexRoute{},
+		displays:      map[string]string{},
+		patterns:      map[string]netexJourneyPattern{},
+		dayTypes:      map[string]netexDayType{},
+		places:        map[string]netexPlace{},
+		assignedStops: map[string]netexStopAssignment{},
+		periods:       map[string]netexOperatingPeriod{},
+		operatingDays: map[string]netexOperatingDay{},
+		assignments:   map[string][]netexDayTypeAssignment{},
+		patternPoints: map[string]map[string]netexPointInPattern{},
+	}
+
+	for _, name := range names {
+		if err := readNeTExFile(fsys, name, n); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(n.journeys) == 0 {
+		return nil, errors.New("NeTEx has no service journeys")
+	}
+
+	s, err := n.build()
+	if err != nil {
+		return nil, err
+	}
+	s.Index()
+
+	return s, nil
+}
+
+func readNeTExFile(fsys fs.FS, name string, n *netex) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", name, err)
+	}
+	defer f.Close()
+
+	if err := n.read(f); err != nil {
+		return fmt.Errorf("could not read %s: %w", name, err)
+	}
+
+	return nil
+}
+
+// build maps what was read to a schedule, lines become routes, scheduled stop
+// points become stops, service journeys become trips and day types become
+// services
+func (n *netex) build() (*Schedule, error) {
+	s := New()
+	ids := interner{}
+
+	for _, o := range n.operators {
+		s.Agencies[o.ID] = &Agency{
+			ID:       o.ID,
+			Name:     strings.TrimSpace(o.Name),
+			URL:      strings.TrimSpace(o.URL),
+			Timezone: strings.TrimSpace(n.locale.TimeZone),
+			Lang:     strings.TrimSpace(n.locale.DefaultLanguage),
+			Phone:    strings.TrimSpace(o.Phone),
+		}
+	}
+
+	for _, p := range n.stopPoints {
+		s.Stops[p.ID] = n.stop(ids, p)
+	}
+
+	lineAgencies := map[string]string{}
+	for _, j := range n.journeys {
+		if j.OperatorRef.Ref != "" {
+			lineAgencies[n.lineRef(j)] = j.OperatorRef.Ref
+		}
+	}
+	for _, l := range n.lines {
+		route := n.route(ids, l)
+		if route.AgencyID == "" {
+			route.AgencyID = ids.get(lineAgencies[l.ID])
+		}
+		if route.AgencyID == "" && len(s.Agencies) == 1 {
+			for id := range s.Agencies {
+				route.AgencyID = id
+			}
+		}
+		s.Routes[route.ID] = route
+	}
+
+	services := map[string]bool{}
+	for _, j := range n.journeys {
+		trip, stopTimes, err := n.trip(ids, j)
+		if err != nil {
+			return nil, fmt.Errorf("service journey %s: %w", j.ID, err)
+		}
+		s.Trips[trip.ID] = trip
+		for _, st := range stopTimes {
+			s.AddStopTime(st)
+		}
+
+		if !services[trip.ServiceID] {
+			services[trip.ServiceID] = true
+			if err := n.service(s, j.DayTypeRefs, trip.ServiceID); err != nil {
+				return nil, fmt.Errorf("service journey %s: %w", j.ID, err)
+			}
+		}
+	}
+
+	return s, nil
+}
+
+// stop maps a scheduled stop point, one without a location is where the quay
+// or stop place it is assigned to is
+func (n *netex) stop(ids interner, p netexStopPoint) *Stop {
+	if p.Latitude == 0 && p.Longitude == 0 {
+		p.Latitude, p.Longitude = n.location(p.ID)
+	}
+
+	stop := &Stop{
+		ID:          ids.get(p.ID),
+		Code:        strings.TrimSpace(p.PublicCode),
+		Name:        strings.TrimSpace(p.Name),
+		Description: strings.TrimSpace(p.Description),
+		Latitude:    p.Latitude,
+		Longitude:   p.Longitude,
+	}
+	for _, t := range p.AlternativeTexts {
+		if t.AttributeName != "Name" || t.Text.Lang == "" {
+			continue
+		}
+		if stop.Translations == nil {
+			stop.Translations = map[string]string{}
+		}
+		stop.Translations[t.Text.Lang] = strings.TrimSpace(t.Text.Value)
+	}
+
+	return stop
+}
+
+// location returns where the quay a stop point is assigned to is, or else its
+// stop place
+func (n *netex) location(stopPointID string) (float64, float64) {
+	a, ok := n.assignedStops[stopPointID]
+	if !ok {
+		return 0, 0
+	}
+
+	for _, ref := range []string{a.QuayRef.Ref, a.StopPlaceRef.Ref} {
+		if place, ok := n.places[ref]; ok && (place.Latitude != 0 || place.Longitude != 0) {
+			return place.Latitude, place.Longitude
+		}
+	}
+
+	return 0, 0
+}
+
+func (n *netex) route(ids interner, l netexLine) *Route {
+	route := &Route{
+		ID:        ids.get(l.ID),
+		AgencyID:  ids.get(l.OperatorRef.Ref),
+		ShortName: strings.TrimSpace(l.PublicCode),
+		LongName:  strings.TrimSpace(l.Name),
+		Type:      3,
+		Color:     strings.TrimSpace(l.Colour),
+		TextColor: strings.TrimSpace(l.TextColour),
+	}
+	if route.ShortName == "" {
+		route.ShortName = strings.TrimSpace(l.ShortName)
+	}
+	if t, ok := netexRouteTypes[strings.TrimSpace(l.TransportMode)]; ok {
+		route.Type = t
+	}
+
+	return route
+}
+
+func (n *netex) pattern(j netexServiceJourney) (netexJourneyPattern, bool) {
+	ref := j.JourneyPatternRef.Ref
+	if ref == "" {
+		ref = j.ServiceJourneyPatternRef.Ref
+	}
+	p, ok := n.patterns[ref]
+
+	return p, ok
+}
+
+// lineRef returns the line of a journey, which is otherwise that of the route
+// of its journey pattern
+func (n *netex) lineRef(j netexServiceJourney) string {
+	if j.LineRef.Ref != "" {
+		return j.LineRef.Ref
+	}
+	p, _ := n.pattern(j)
+
+	return n.routes[p.RouteRef.Ref].LineRef.Ref
+}
+
+// points returns the stop points of a journey pattern by id
+func (n *netex) points(p netexJourneyPattern) map[string]netexPointInPattern {
+	points, ok := n.patternPoints[p.ID]
+	if !ok {
+		points = make(map[string]netexPointInPattern, len(p.Points))
+		for _, point := range p.Points {
+			points[point.ID] = point
+		}
+		n.patternPoints[p.ID] = points
+	}
+
+	return points
+}
+
+// passingTime parses a time of day which is offset by a number of days, such
+// as a time past midnight of a journey which started the day before
+func passingTime(clock string, offset int) (ServiceTime, error) {
+	clock = strings.TrimSpace(clock)
+	if clock == "" {
+		return noTime, nil
+	}
+
+	t, err := ParseServiceTime(clock)
+	if err != nil {
+		return 0, err
+	}
+
+	return t + ServiceTime(offset*24*3600), nil
+}
+
+// serviceID names the service of a journey after its day types, a journey
+// which runs on several has a service of its own
+func serviceID(refs []netexRef) string {
+	ids := make([]string, 0, len(refs))
+	for _, r := range refs {
+		ids = append(ids, r.Ref)
+	}
+
+	return strings.Join(ids, "+")
+}
+
+// trip maps a journey and its passing times, the stop of each passing time is
+// that of the point of the journey pattern it passes
+func (n *netex) trip(ids interner, j netexServiceJourney) (*Trip, []StopTime, error) {
+	line := n.lineRef(j)
+	if line == "" {
+		return nil, nil, errors.New("no line")
+	}
+	if len(j.DayTypeRefs) == 0 {
+		return nil, nil, errors.New("no day types")
+	}
+
+	p, ok := n.pattern(j)
+	if !ok {
+		return nil, nil, errors.New("unknown journey pattern")
+	}
+
+	trip := &Trip{
+		ID:        ids.get(j.ID),
+		RouteID:   ids.get(line),
+		ServiceID: ids.get(serviceID(j.DayTypeRefs)),
+		Headsign:  n.displays[p.DestinationDisplayRef.Ref],
+		ShortName: strings.TrimSpace(j.PublicCode),
+	}
+	if trip.ShortName == "" {
+		trip.ShortName = strings.TrimSpace(j.PrivateCode)
+	}
+	if n.routes[p.RouteRef.Ref].DirectionType == "inbound" {
+		trip.DirectionID = 1
+	}
+
+	points := n.points(p)
+	stopTimes := make([]StopTime, 0, len(j.PassingTimes))
+	for i, pt := range j.PassingTimes {
+		point, ok := points[pt.StopPointInJourneyPatternRef.Ref]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown stop point in journey pattern %s", pt.StopPointInJourneyPatternRef.Ref)
+		}
+		if trip.Headsign == "" {
+			trip.Headsign = n.displays[point.DestinationDisplayRef.Ref]
+		}
+
+		st := StopTime{
+			TripID:       trip.ID,
+			StopID:       ids.get(point.ScheduledStopPointRef.Ref),
+			StopSequence: point.Order,
+		}
+		if st.StopSequence == 0 {
+			st.StopSequence = i + 1
+		}
+		if strings.TrimSpace(point.ForBoarding) == "false" {
+			st.PickupType = 1
+		}
+		if strings.TrimSpace(point.ForAlighting) == "false" {
+			st.DropOffType = 1
+		}
+
+		var err error
+		if st.Arrival, err = passingTime(pt.ArrivalTime, pt.ArrivalDayOffset); err != nil {
+			return nil, nil, err
+		}
+		if st.Departure, err = passingTime(pt.DepartureTime, pt.DepartureDayOffset); err != nil {
+			return nil, nil, err
+		}
+		if st.Arrival == noTime {
+			st.Arrival = st.Departure
+		}
+		if st.Departure == noTime {
+			st.Departure = st.Arrival
+		}
+		stopTimes = append(stopTimes, st)
+	}
+	trip.Headsign = ids.get(trip.Headsign)
+
+	return trip, stopTimes, nil
+}
+
+func parseNeTExDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if len(s) > len(netexDateLayout) {
+		s = s[:len(netexDateLayout)]
+	}
+
+	d, err := time.Parse(netexDateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", s)
+	}
+
+	return d, nil
+}
+
+// date returns the day a day type is assigned to when it is not assigned to an
+// operating period, which is either given by its date or an operating day
+func (n *netex) date(dayTypeID string, a netexDayTypeAssignment) (time.Time, error) {
+	if strings.TrimSpace(a.Date) != "" {
+		return parseNeTExDate(a.Date)
+	}
+	if a.OperatingDayRef.Ref != "" {
+		day, ok := n.operatingDays[a.OperatingDayRef.Ref]
+		if !ok {
+			return time.Time{}, fmt.Errorf("unknown operating day %s", a.OperatingDayRef.Ref)
+		}
+
+		return parseNeTExDate(day.CalendarDate)
+	}
+
+	return time.Time{}, fmt.Errorf("day type %s is assigned without an operating period, operating day or date", dayTypeID)
+}
+
+// days returns the days of the week a day type runs on, every day when it
+// does not name any
+func (dt netexDayType) days() [7]bool {
+	var days [7]bool
+	named := false
+	for _, list := range dt.DaysOfWeek {
+		for _, name := range strings.Fields(list) {
+			for _, day := range netexDays[name] {
+				days[day] = true
+				named = true
+			}
+		}
+	}
+	if !named {
+		return [7]bool{true, true, true, true, true, true, true}
+	}
+
+	return days
+}
+
+// dates returns the dates a day type runs on in the YYYYMMDD form, those of its
+// operating periods on its days of the week along with the dates it is
+// assigned to, less those it is taken off
+func (n *netex) dates(id string) (map[string]bool, error) {
+	dt, ok := n.dayTypes[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown day type %s", id)
+	}
+
+	days := dt.days()
+	dates := map[string]bool{}
+	for _, a := range n.assignments[id] {
+		if a.OperatingPeriodRef.Ref == "" {
+			continue
+		}
+		period, ok := n.periods[a.OperatingPeriodRef.Ref]
+		if !ok {
+			return nil, fmt.Errorf("unknown operating period %s", a.OperatingPeriodRef.Ref)
+		}
+		from, err := parseNeTExDate(period.FromDate)
+		if err != nil {
+			return nil, err
+		}
+		to, err := parseNeTExDate(period.ToDate)
+		if err != nil {
+			return nil, err
+		}
+		for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+			if days[d.Weekday()] {
+				dates[d.Format(dateLayout)] = true
+			}
+		}
+	}
+
+	for _, a := range n.assignments[id] {
+		if a.OperatingPeriodRef.Ref != "" {
+			continue
+		}
+		d, err := n.date(id, a)
+		if err != nil {
+			return nil, err
+		}
+		dates[d.Format(dateLayout)] = strings.TrimSpace(a.IsAvailable) != "false"
+	}
+
+	return dates, nil
+}
+
+// service adds the calendar of a service. A service of one day type in a
+// single operating period is a weekly calendar with its dates as exceptions,
+// any other is the list of dates it runs on
+func (n *netex) service(s *Schedule, refs []netexRef, id string) error {
+	if len(refs) == 1 {
+		if ok, err := n.calendar(s, refs[0].Ref); ok || err != nil {
+			return err
+		}
+	}
+
+	running := map[string]bool{}
+	for _, r := range refs {
+		dates, err := n.dates(r.Ref)
+		if err != nil {
+			return err
+		}
+		for date, runs := range dates {
+			running[date] = running[date] || runs
+		}
+	}
+
+	days := make([]string, 0, len(running))
+	for date, runs := range running {
+		if runs {
+			days = append(days, date)
+		}
+	}
+	sort.Strings(days)
+	for _, date := range days {
+		s.AddCalendarDate(CalendarDate{ServiceID: id, Date: date, ExceptionType: ServiceAdded})
+	}
+
+	return nil
+}
+
+// calendar adds the weekly calendar of a day type assigned to a single
+// operating period, it returns false for any other day type
+func (n *netex) calendar(s *Schedule, id string) (bool, error) {
+	dt, ok := n.dayTypes[id]
+	if !ok {
+		return false, fmt.Errorf("unknown day type %s", id)
+	}
+
+	var periods []netexOperatingPeriod
+	var exceptions []netexDayTypeAssignment
+	for _, a := range n.assignments[id] {
+		if a.OperatingPeriodRef.Ref == "" {
+			exceptions = append(exceptions, a)
+
+			continue
+		}
+		period, ok := n.periods[a.OperatingPeriodRef.Ref]
+		if !ok {
+			return false, fmt.Errorf("unknown operating period %s", a.OperatingPeriodRef.Ref)
+		}
+		periods = append(periods, period)
+	}
+	if len(periods) != 1 {
+		return false, nil
+	}
+
+	from, err := parseNeTExDate(periods[0].FromDate)
+	if err != nil {
+		return false, err
+	}
+	to, err := parseNeTExDate(periods[0].ToDate)
+	if err != nil {
+		return false, err
+	}
+	s.AddCalendar(Calendar{ServiceID: id, Days: dt.days(), StartDate: from.Format(dateLayout), EndDate: to.Format(dateLayout)})
+
+	for _, a := range exceptions {
+		d, err := n.date(id, a)
+		if err != nil {
+			return false, err
+		}
+		exception := ServiceAdded
+		if strings.TrimSpace(a.IsAvailable) == "false" {
+			exception = ServiceRemoved
+		}
+		s.AddCalendarDate(CalendarDate{ServiceID: id, Date: d.Format(dateLayout), ExceptionType: exception})
+	}
+
+	return true, nil
+}
@@ -0,0 +1,305 @@
+package schedule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// minimalNeTEx returns a valid delivery with a single service journey whose
+// elements tests can override
+func minimalNeTEx(journey string) fstest.MapFS {
+	if journey == "" {
+		journey = `<ServiceJourney id="SJ"><dayTypes><DayTypeRef ref="DT"/></dayTypes><JourneyPatternRef ref="JP"/>` +
+			`<passingTimes><TimetabledPassingTime><StopPointInJourneyPatternRef ref="P1"/><DepartureTime>10:00:00</DepartureTime></TimetabledPassingTime></passingTimes>` +
+			`</ServiceJourney>`
+	}
+
+	return fstest.MapFS{"netex.xml": &fstest.MapFile{Data: []byte(`<PublicationDelivery xmlns="http://www.netex.org.uk/netex">` +
+		`<Line id="L"><Name>Line</Name></Line>` +
+		`<Route id="R"><LineRef ref="L"/></Route>` +
+		`<JourneyPattern id="JP"><RouteRef ref="R"/><pointsInSequence><StopPointInJourneyPattern id="P1" order="1"><ScheduledStopPointRef ref="S"/></StopPointInJourneyPattern></pointsInSequence></JourneyPattern>` +
+		`<DayType id="DT"/><OperatingPeriod id="OP"><FromDate>2025-01-01</FromDate><ToDate>2025-12-31</ToDate></OperatingPeriod>` +
+		`<DayTypeAssignment><OperatingPeriodRef ref="OP"/><DayTypeRef ref="DT"/></DayTypeAssignment>` +
+		journey + `</PublicationDelivery>`)}}
+}
+
+func TestReadNeTEx(t *testing.T) {
+	t.Run("reads the elements of every file", func(t *testing.T) {
+		s, err := ReadNeTEx(os.DirFS("testdata/netex"))
+		assert.NoError(t, err, "expected delivery to load")
+
+		assert.Equal(t, map[string]*Agency{"IE:GAI:Operator:GAI": {
+			ID:       "IE:GAI:Operator:GAI",
+			Name:     "Go-Ahead Ireland",
+			URL:      "https://www.goaheadireland.ie",
+			Timezone: "Europe/Dublin",
+			Lang:     "en",
+			Phone:    "+353 1 447 3700",
+		}}, s.Agencies)
+
+		assert.Len(t, s.Stops, 3)
+		assert.Equal(t, &Stop{
+			ID:           "IE:NTA:ScheduledStopPoint:4728",
+			Code:         "4728",
+			Name:         "Citywest Road",
+			Latitude:     53.2887,
+			Longitude:    -6.3915,
+			Translations: map[string]string{"ga": "Bóthar Citywest"},
+		}, s.Stops["IE:NTA:ScheduledStopPoint:4728"])
+		assert.Equal(t, "Stillorgan Road", s.Stops["IE:NTA:ScheduledStopPoint:768"].Description)
+
+		assert.Equal(t, map[string]*Route{"IE:GAI:Line:175": {
+			ID:        "IE:GAI:Line:175",
+			AgencyID:  "IE:GAI:Operator:GAI",
+			ShortName: "175",
+			LongName:  "Citywest - UCD Belfield",
+			Type:      3,
+			Color:     "0096D6",
+			TextColor: "FFFFFF",
+		}}, s.Routes)
+
+		assert.Equal(t, &Trip{
+			ID:        "IE:GAI:ServiceJourney:175:0700",
+			RouteID:   "IE:GAI:Line:175",
+			ServiceID: "IE:NTA:DayType:Weekdays",
+			Headsign:  "UCD Belfield",
+			ShortName: "0700",
+		}, s.Trips["IE:GAI:ServiceJourney:175:0700"], "expected line to be found through the route of the pattern")
+		assert.Equal(t, &Trip{
+			ID:          "IE:GAI:ServiceJourney:175:2350",
+			RouteID:     "IE:GAI:Line:175",
+			ServiceID:   "IE:NTA:DayType:Saturday+IE:NTA:DayType:BankHoliday",
+			Headsign:    "Citywest",
+			ShortName:   "175-2350",
+			DirectionID: 1,
+		}, s.Trips["IE:GAI:ServiceJourney:175:2350"], "expected headsign of the first stop point")
+	})
+
+	t.Run("maps passing times to stop times", func(t *testing.T) {
+		s, err := ReadNeTEx(os.DirFS("testdata/netex"))
+		assert.NoError(t, err)
+		assert.Equal(t, 5, s.StopTimeCount())
+
+		stopTimes := s.StopTimesForTrip("IE:GAI:ServiceJourney:175:0700")
+		assert.Len(t, stopTimes, 3)
+		assert.Equal(t, "IE:NTA:ScheduledStopPoint:2893", stopTimes[1].StopID)
+		assert.Equal(t, 2, stopTimes[1].StopSequence)
+		assert.Equal(t, "07:00:00", stopTimes[0].Arrival.String(), "expected arrival to be the departure")
+		assert.Equal(t, "07:20:00", stopTimes[1].Departure.String(), "expected time to be interpolated")
+		assert.Equal(t, "07:40:00", stopTimes[2].Departure.String())
+		assert.Equal(t, 1, stopTimes[0].DropOffType)
+		assert.Equal(t, 1, stopTimes[2].PickupType)
+
+		stopTimes = s.StopTimesForTrip("IE:GAI:ServiceJourney:175:2350")
+		assert.Equal(t, "23:52:00", stopTimes[0].Departure.String())
+		assert.Equal(t, "24:20:00", stopTimes[1].Arrival.String(), "expected day offset to be added")
+	})
+
+	t.Run("maps day types to services", func(t *testing.T) {
+		s, err := ReadNeTEx(os.DirFS("testdata/netex"))
+		assert.NoError(t, err)
+
+		date := func(month time.Month, day int) time.Time { return time.Date(2025, month, day, 0, 0, 0, 0, time.UTC) }
+		weekdays, nightly := "IE:NTA:DayType:Weekdays", "IE:NTA:DayType:Saturday+IE:NTA:DayType:BankHoliday"
+		assert.True(t, s.ServiceRunsOn(weekdays, date(time.March, 10)))
+		assert.False(t, s.ServiceRunsOn(weekdays, date(time.March, 15)), "expected weekday service not to run on a Saturday")
+		assert.False(t, s.ServiceRunsOn(weekdays, date(time.March, 17)), "expected weekday service to be taken off")
+		assert.True(t, s.ServiceRunsOn(nightly, date(time.March, 15)))
+		assert.True(t, s.ServiceRunsOn(nightly, date(time.September, 6)), "expected every operating period")
+		assert.False(t, s.ServiceRunsOn(nightly, date(time.June, 7)))
+		assert.True(t, s.ServiceRunsOn(nightly, date(time.March, 17)), "expected every day type")
+		assert.False(t, s.ServiceRunsOn(nightly, date(time.March, 18)))
+	})
+
+	t.Run("maps transport modes to route types", func(t *testing.T) {
+		for mode, want := range map[string]int{"tram": 0, "rail": 2, "water": 4, "bus": 3, "air": 3} {
+			feed := minimalNeTEx("")
+			feed["line.xml"] = &fstest.MapFile{Data: []byte(`<Line id="L2"><TransportMode>` + mode + `</TransportMode></Line>`)}
+			s, err := ReadNeTEx(feed)
+			assert.NoError(t, err)
+			assert.Equal(t, want, s.Routes["L2"].Type, mode)
+		}
+	})
+
+	t.Run("falls back to the only operator for lines without one", func(t *testing.T) {
+		feed := minimalNeTEx("")
+		feed["operator.xml"] = &fstest.MapFile{Data: []byte(`<Operator id="O"><Name>Operator</Name></Operator>`)}
+		s, err := ReadNeTEx(feed)
+		assert.NoError(t, err)
+		assert.Equal(t, "O", s.Routes["L"].AgencyID)
+		assert.True(t, s.ServiceRunsOn("DT", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)), "expected day type without days to run every day")
+	})
+
+	t.Run("places stop points without a location at their quay or stop place", func(t *testing.T) {
+		feed := minimalNeTEx("")
+		feed["stops.xml"] = &fstest.MapFile{Data: []byte(`<PublicationDelivery xmlns="http://www.netex.org.uk/netex">` +
+			`<ScheduledStopPoint id="S"><Name>Heuston</Name></ScheduledStopPoint>` +
+			`<ScheduledStopPoint id="S2"><Name>Heuston Luas</Name></ScheduledStopPoint>` +
+			`<StopPlace id="SP"><Centroid><Location><Longitude>-6.2925</Longitude><Latitude>53.3464</Latitude></Location></Centroid>` +
+			`<quays><Quay id="Q"><Centroid><Location><Longitude>-6.2931</Longitude><Latitude>53.3461</Latitude></Location></Centroid></Quay></quays></StopPlace>` +
+			`<PassengerStopAssignment id="A1"><ScheduledStopPointRef ref="S"/><StopPlaceRef ref="SP"/><QuayRef ref="Q"/></PassengerStopAssignment>` +
+			`<PassengerStopAssignment id="A2"><ScheduledStopPointRef ref="S2"/><StopPlaceRef ref="SP"/></PassengerStopAssignment>` +
+			`</PublicationDelivery>`)}
+		s, err := ReadNeTEx(feed)
+		assert.NoError(t, err)
+		assert.Equal(t, 53.3461, s.Stops["S"].Latitude, "expected the location of the quay")
+		assert.Equal(t, -6.2931, s.Stops["S"].Longitude)
+		assert.Equal(t, 53.3464, s.Stops["S2"].Latitude, "expected the location of the stop place")
+		assert.Equal(t, -6.2925, s.Stops["S2"].Longitude)
+	})
+
+	t.Run("assigns day types to operating days", func(t *testing.T) {
+		feed := minimalNeTEx(`<ServiceJourney id="SJ"><dayTypes><DayTypeRef ref="DT"/></dayTypes><JourneyPatternRef ref="JP"/></ServiceJourney>` +
+			`<ServiceJourney id="SJ2"><dayTypes><DayTypeRef ref="DT2"/></dayTypes><JourneyPatternRef ref="JP"/></ServiceJourney>`)
+		feed["calendar.xml"] = &fstest.MapFile{Data: []byte(`<PublicationDelivery xmlns="http://www.netex.org.uk/netex">` +
+			`<DayType id="DT2"/>` +
+			`<OperatingDay id="OD1"><CalendarDate>2025-03-17</CalendarDate></OperatingDay>` +
+			`<OperatingDay id="OD2"><CalendarDate>2025-03-18</CalendarDate></OperatingDay>` +
+			`<DayTypeAssignment><OperatingDayRef ref="OD1"/><DayTypeRef ref="DT"/><isAvailable>false</isAvailable></DayTypeAssignment>` +
+			`<DayTypeAssignment><OperatingDayRef ref="OD2"/><DayTypeRef ref="DT2"/></DayTypeAssignment>` +
+			`</PublicationDelivery>`)}
+		s, err := ReadNeTEx(feed)
+		assert.NoError(t, err)
+
+		date := func(day int) time.Time { return time.Date(2025, time.March, day, 0, 0, 0, 0, time.UTC) }
+		assert.True(t, s.ServiceRunsOn("DT", date(16)))
+		assert.False(t, s.ServiceRunsOn("DT", date(17)), "expected the operating day to be taken off the period")
+		assert.True(t, s.ServiceRunsOn("DT2", date(18)))
+		assert.False(t, s.ServiceRunsOn("DT2", date(17)), "expected only the operating day")
+	})
+
+	t.Run("errors on a day type assignment which cannot be dated", func(t *testing.T) {
+		for assignment, want := range map[string]string{
+			`<DayTypeAssignment><OperatingDayRef ref="OD"/><DayTypeRef ref="DT"/></DayTypeAssignment>`: "service journey SJ: unknown operating day OD",
+			`<DayTypeAssignment><DayTypeRef ref="DT"/></DayTypeAssignment>`:                            "service journey SJ: day type DT is assigned without an operating period, operating day or date",
+		} {
+			feed := minimalNeTEx("")
+			feed["calendar.xml"] = &fstest.MapFile{Data: []byte(`<PublicationDelivery xmlns="http://www.netex.org.uk/netex">` + assignment + `</PublicationDelivery>`)}
+			_, err := ReadNeTEx(feed)
+			assert.EqualError(t, err, want)
+		}
+	})
+
+	t.Run("errors without service journeys", func(t *testing.T) {
+		_, err := ReadNeTEx(fstest.MapFS{"netex.xml": &fstest.MapFile{Data: []byte(`<PublicationDelivery/>`)}})
+		assert.EqualError(t, err, "NeTEx has no service journeys")
+	})
+
+	t.Run("errors without XML files", func(t *testing.T) {
+		_, err := ReadNeTEx(os.DirFS("testdata/gtfs"))
+		assert.EqualError(t, err, "NeTEx has no XML files")
+	})
+
+	t.Run("errors on invalid XML", func(t *testing.T) {
+		_, err := ReadNeTEx(fstest.MapFS{"netex.xml": &fstest.MapFile{Data: []byte(`<PublicationDelivery><Line id="L"></PublicationDelivery>`)}})
+		assert.ErrorContains(t, err, "could not read netex.xml: invalid Line")
+	})
+
+	t.Run("errors with the service journey which is invalid", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			journey string
+			err     string
+		}{
+			{
+				"invalid time",
+				`<ServiceJourney id="SJ"><dayTypes><DayTypeRef ref="DT"/></dayTypes><JourneyPatternRef ref="JP"/><passingTimes><TimetabledPassingTime>` +
+					`<StopPointInJourneyPatternRef ref="P1"/><DepartureTime>10:65:00</DepartureTime></TimetabledPassingTime></passingTimes></ServiceJourney>`,
+				`service journey SJ: invalid service time "10:65:00"`,
+			},
+			{
+				"unknown stop point",
+				`<ServiceJourney id="SJ"><dayTypes><DayTypeRef ref="DT"/></dayTypes><JourneyPatternRef ref="JP"/><passingTimes><TimetabledPassingTime>` +
+					`<StopPointInJourneyPatternRef ref="P2"/></TimetabledPassingTime></passingTimes></ServiceJourney>`,
+				"service journey SJ: unknown stop point in journey pattern P2",
+			},
+			{
+				"unknown journey pattern",
+				`<ServiceJourney id="SJ"><dayTypes><DayTypeRef ref="DT"/></dayTypes><LineRef ref="L"/><JourneyPatternRef ref="JP2"/></ServiceJourney>`,
+				"service journey SJ: unknown journey pattern",
+			},
+			{
+				"no day types",
+				`<ServiceJourney id="SJ"><JourneyPatternRef ref="JP"/></ServiceJourney>`,
+				"service journey SJ: no day types",
+			},
+			{
+				"unknown day type",
+				`<ServiceJourney id="SJ"><dayTypes><DayTypeRef ref="DT2"/></dayTypes><JourneyPatternRef ref="JP"/></ServiceJourney>`,
+				"service journey SJ: unknown day type DT2",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := ReadNeTEx(minimalNeTEx(tt.journey))
+				assert.EqualError(t, err, tt.err)
+			})
+		}
+	})
+}
+
+func TestLoadNeTEx(t *testing.T) {
+	t.Run("loads a directory", func(t *testing.T) {
+		s, err := LoadNeTEx("testdata/netex")
+		assert.NoError(t, err)
+		assert.Len(t, s.Trips, 2)
+	})
+
+	t.Run("loads a zip file", func(t *testing.T) {
+		s, err := LoadNeTEx(zipFeed(t, "testdata/netex"))
+		assert.NoError(t, err)
+		assert.Equal(t, 5, s.StopTimeCount())
+	})
+
+	t.Run("loads a single file", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "netex.xml"), minimalNeTEx("")["netex.xml"].Data, 0o600))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "other.xml"), []byte(`<Line id="L2"/>`), 0o600))
+
+		s, err := LoadNeTEx(filepath.Join(dir, "netex.xml"))
+		assert.NoError(t, err)
+		assert.Len(t, s.Trips, 1)
+		assert.Len(t, s.Routes, 1, "expected the other files not to be read")
+	})
+
+	t.Run("errors when the path does not exist", func(t *testing.T) {
+		_, err := LoadNeTEx("testdata/missing.zip")
+		assert.ErrorContains(t, err, "could not open NeTEx")
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("loads GTFS", func(t *testing.T) {
+		s, err := Load(zipFeed(t, "testdata/gtfs"))
+		assert.NoError(t, err)
+		assert.Len(t, s.Agencies, 2)
+	})
+
+	t.Run("loads NeTEx", func(t *testing.T) {
+		s, err := Load("testdata/netex")
+		assert.NoError(t, err)
+		assert.Len(t, s.Agencies, 1)
+
+		path := filepath.Join(t.TempDir(), "netex.xml")
+		assert.NoError(t, os.WriteFile(path, minimalNeTEx("")["netex.xml"].Data, 0o600))
+		s, err = Load(path)
+		assert.NoError(t, err)
+		assert.Len(t, s.Trips, 1)
+	})
+
+	t.Run("errors as GTFS when it is neither", func(t *testing.T) {
+		_, err := Load(t.TempDir())
+		assert.ErrorContains(t, err, "could not open agency.txt")
+	})
+
+	t.Run("errors when the path does not exist", func(t *testing.T) {
+		_, err := Load("testdata/missing.zip")
+		assert.ErrorContains(t, err, "could not open timetable")
+	})
+}
@@ -0,0 +1,193 @@
+package schedule
+
+import (
+	"sort"
+	"time"
+)
+
+const dateLayout = "20060102"
+
+// noTime marks the arrival and departure of stop times which are not
+// timepoints until they are interpolated
+const noTime ServiceTime = -1
+
+// Schedule is an in-memory model of a static timetable, indexed so that the
+// stop times of a trip or of a stop can be looked up without a scan
+type Schedule struct {
+	Agencies map[string]*Agency
+	Stops    map[string]*Stop
+	Routes   map[string]*Route
+	Trips    map[string]*Trip
+	Shapes   map[string][]ShapePoint
+
+	calendars     map[string]Calendar
+	calendarDates map[string][]CalendarDate
+	stopTimes     []StopTime
+	tripStopTimes map[string][2]int
+	stopStopTimes map[string][]int32
+	routeTrips    map[string][]*Trip
+	childStops    map[string][]*Stop
+	stopCells     map[cell][]*Stop
+	stopCodes     map[string]*Stop
+	tripNames     map[string][]*Trip
+	search        searchIndex
+}
+
+func New() *Schedule {
+	return &Schedule{
+		Agencies:      map[string]*Agency{},
+		Stops:         map[string]*Stop{},
+		Routes:        map[string]*Route{},
+		Trips:         map[string]*Trip{},
+		Shapes:        map[string][]ShapePoint{},
+		calendars:     map[string]Calendar{},
+		calendarDates: map[string][]CalendarDate{},
+		tripStopTimes: map[string][2]int{},
+		stopStopTimes: map[string][]int32{},
+		routeTrips:    map[string][]*Trip{},
+		childStops:    map[string][]*Stop{},
+		stopCells:     map[cell][]*Stop{},
+		stopCodes:     map[string]*Stop{},
+		tripNames:     map[string][]*Trip{},
+	}
+}
+
+func (s *Schedule) AddCalendar(c Calendar) {
+	s.calendars[c.ServiceID] = c
+}
+
+func (s *Schedule) AddCalendarDate(d CalendarDate) {
+	s.calendarDates[d.ServiceID] = append(s.calendarDates[d.ServiceID], d)
+}
+
+func (s *Schedule) AddStopTime(st StopTime) {
+	s.stopTimes = append(s.stopTimes, st)
+}
+
+// Index sorts the stop times and shapes and builds the lookup indexes, it must
+// be called once everything has been added
+func (s *Schedule) Index() {
+	sort.SliceStable(s.stopTimes, func(i, j int) bool {
+		a, b := s.stopTimes[i], s.stopTimes[j]
+		if a.TripID != b.TripID {
+			return a.TripID < b.TripID
+		}
+
+		return a.StopSequence < b.StopSequence
+	})
+
+	s.tripStopTimes = make(map[string][2]int, len(s.Trips))
+	s.stopStopTimes = make(map[string][]int32, len(s.Stops))
+	for i := 0; i < len(s.stopTimes); {
+		start := i
+		for i < len(s.stopTimes) && s.stopTimes[i].TripID == s.stopTimes[start].TripID {
+			stopID := s.stopTimes[i].StopID
+			s.stopStopTimes[stopID] = append(s.stopStopTimes[stopID], int32(i))
+			i++
+		}
+		s.tripStopTimes[s.stopTimes[start].TripID] = [2]int{start, i}
+		interpolate(s.stopTimes[start:i])
+	}
+
+	s.routeTrips = make(map[string][]*Trip, len(s.Routes))
+	for _, t := range s.Trips {
+		s.routeTrips[t.RouteID] = append(s.routeTrips[t.RouteID], t)
+	}
+	for _, trips := range s.routeTrips {
+		sort.Slice(trips, func(i, j int) bool { return trips[i].ID < trips[j].ID })
+	}
+
+	s.childStops = map[string][]*Stop{}
+	for _, stop := range s.Stops {
+		if stop.ParentStation != "" {
+			s.childStops[stop.ParentStation] = append(s.childStops[stop.ParentStation], stop)
+		}
+	}
+	for _, children := range s.childStops {
+		sort.Slice(children, func(i, j int) bool { return children[i].ID < children[j].ID })
+	}
+
+	for _, points := range s.Shapes {
+		sort.Slice(points, func(i, j int) bool { return points[i].Sequence < points[j].Sequence })
+	}
+
+	s.indexStops()
+	s.indexCodes()
+	s.indexSearch()
+}
+
+// interpolate fills in the times of stops between two timepoints of a trip
+// evenly, stops before the first or after the last timepoint are left as is
+func interpolate(stopTimes []StopTime) {
+	last := -1
+	for i, st := range stopTimes {
+		if st.Arrival == noTime {
+			continue
+		}
+
+		if last >= 0 && i-last > 1 {
+			from, to := stopTimes[last].Departure, st.Arrival
+			step := float64(to-from) / float64(i-last)
+			for j := last + 1; j < i; j++ {
+				t := from + ServiceTime(step*float64(j-last))
+				stopTimes[j].Arrival, stopTimes[j].Departure = t, t
+			}
+		}
+		last = i
+	}
+}
+
+// StopTimesForTrip returns the stop times of a trip ordered by stop sequence,
+// the returned slice must not be modified
+func (s *Schedule) StopTimesForTrip(tripID string) []StopTime {
+	span, ok := s.tripStopTimes[tripID]
+	if !ok {
+		return nil
+	}
+
+	return s.stopTimes[span[0]:span[1]:span[1]]
+}
+
+// StopTimesForStop returns every stop time at a stop, ordered by trip id
+func (s *Schedule) StopTimesForStop(stopID string) []StopTime {
+	indexes := s.stopStopTimes[stopID]
+	stopTimes := make([]StopTime, 0, len(indexes))
+	for _, i := range indexes {
+		stopTimes = append(stopTimes, s.stopTimes[i])
+	}
+
+	return stopTimes
+}
+
+// TripsForRoute returns every trip of a route ordered by trip id
+func (s *Schedule) TripsForRoute(routeID string) []*Trip {
+	return s.routeTrips[routeID]
+}
+
+// ChildStops returns the stops whose parent station is a stop, such as the
+// platforms of a station, ordered by stop id
+func (s *Schedule) ChildStops(stopID string) []*Stop {
+	return s.childStops[stopID]
+}
+
+// ServiceRunsOn reports whether a service runs on the service day of date,
+// calendar date exceptions take priority over the weekly calendar
+func (s *Schedule) ServiceRunsOn(serviceID string, date time.Time) bool {
+	day := date.Format(dateLayout)
+	for _, d := range s.calendarDates[serviceID] {
+		if d.Date == day {
+			return d.ExceptionType == ServiceAdded
+		}
+	}
+
+	c, ok := s.calendars[serviceID]
+	if !ok {
+		return false
+	}
+
+	return c.Days[date.Weekday()] && c.StartDate <= day && day <= c.EndDate
+}
+
+func (s *Schedule) StopTimeCount() int {
+	return len(s.stopTimes)
+}
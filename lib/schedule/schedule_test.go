@@ -0,0 +1,105 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleIndex(t *testing.T) {
+	t.Run("orders stop times by trip and stop sequence", func(t *testing.T) {
+		s := New()
+		s.AddStopTime(StopTime{TripID: "B", StopID: "1", StopSequence: 1})
+		s.AddStopTime(StopTime{TripID: "A", StopID: "2", StopSequence: 2})
+		s.AddStopTime(StopTime{TripID: "A", StopID: "1", StopSequence: 1})
+		s.Index()
+
+		assert.Equal(t, []StopTime{
+			{TripID: "A", StopID: "1", StopSequence: 1},
+			{TripID: "A", StopID: "2", StopSequence: 2},
+		}, s.StopTimesForTrip("A"))
+		assert.Equal(t, []StopTime{
+			{TripID: "A", StopID: "1", StopSequence: 1},
+			{TripID: "B", StopID: "1", StopSequence: 1},
+		}, s.StopTimesForStop("1"))
+		assert.Nil(t, s.StopTimesForTrip("C"), "expected no stop times for an unknown trip")
+		assert.Empty(t, s.StopTimesForStop("3"), "expected no stop times for an unknown stop")
+		assert.Equal(t, 3, s.StopTimeCount())
+	})
+
+	t.Run("interpolates the times of stops between timepoints", func(t *testing.T) {
+		s := New()
+		s.AddStopTime(StopTime{TripID: "A", StopSequence: 1, Arrival: noTime, Departure: noTime})
+		s.AddStopTime(StopTime{TripID: "A", StopSequence: 2, Arrival: 600, Departure: 660})
+		s.AddStopTime(StopTime{TripID: "A", StopSequence: 3, Arrival: noTime, Departure: noTime})
+		s.AddStopTime(StopTime{TripID: "A", StopSequence: 4, Arrival: noTime, Departure: noTime})
+		s.AddStopTime(StopTime{TripID: "A", StopSequence: 5, Arrival: 960, Departure: 960})
+		s.Index()
+
+		var times []ServiceTime
+		for _, st := range s.StopTimesForTrip("A") {
+			times = append(times, st.Arrival)
+		}
+		assert.Equal(t, []ServiceTime{noTime, 600, 760, 860, 960}, times)
+	})
+
+	t.Run("groups trips by route", func(t *testing.T) {
+		s := New()
+		s.Trips["2"] = &Trip{ID: "2", RouteID: "R"}
+		s.Trips["1"] = &Trip{ID: "1", RouteID: "R"}
+		s.Index()
+
+		trips := s.TripsForRoute("R")
+		assert.Len(t, trips, 2)
+		assert.Equal(t, "1", trips[0].ID, "expected trips to be ordered by id")
+		assert.Empty(t, s.TripsForRoute("S"))
+	})
+
+	t.Run("groups stops by parent station", func(t *testing.T) {
+		s := New()
+		s.Stops["8220IR0132"] = &Stop{ID: "8220IR0132", LocationType: 1}
+		s.Stops["8220IR0134"] = &Stop{ID: "8220IR0134", ParentStation: "8220IR0132"}
+		s.Stops["8220IR0133"] = &Stop{ID: "8220IR0133", ParentStation: "8220IR0132"}
+		s.Index()
+
+		children := s.ChildStops("8220IR0132")
+		assert.Len(t, children, 2)
+		assert.Equal(t, "8220IR0133", children[0].ID, "expected stops to be ordered by id")
+		assert.Empty(t, s.ChildStops("8220IR0133"))
+	})
+}
+
+func TestScheduleServiceRunsOn(t *testing.T) {
+	s := New()
+	s.AddCalendar(Calendar{
+		ServiceID: "weekdays",
+		Days:      [7]bool{time.Monday: true, time.Tuesday: true, time.Wednesday: true, time.Thursday: true, time.Friday: true},
+		StartDate: "20250101",
+		EndDate:   "20251231",
+	})
+	s.AddCalendarDate(CalendarDate{ServiceID: "weekdays", Date: "20250317", ExceptionType: ServiceRemoved})
+	s.AddCalendarDate(CalendarDate{ServiceID: "special", Date: "20250317", ExceptionType: ServiceAdded})
+
+	tests := []struct {
+		name      string
+		serviceID string
+		date      time.Time
+		runs      bool
+	}{
+		{"runs on a weekday", "weekdays", time.Date(2025, 3, 18, 0, 0, 0, 0, time.UTC), true},
+		{"does not run at the weekend", "weekdays", time.Date(2025, 3, 22, 0, 0, 0, 0, time.UTC), false},
+		{"does not run before the start date", "weekdays", time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{"does not run after the end date", "weekdays", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"does not run when removed", "weekdays", time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), false},
+		{"runs when added", "special", time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), true},
+		{"only runs on added dates", "special", time.Date(2025, 3, 18, 0, 0, 0, 0, time.UTC), false},
+		{"does not run an unknown service", "unknown", time.Date(2025, 3, 18, 0, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.runs, s.ServiceRunsOn(tt.serviceID, tt.date))
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package schedule
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Scores of a query term matching a term of a name
+const (
+	scoreExact  = 4
+	scorePrefix = 3
+	scoreFuzzy  = 1
+	// scoreWhole is added when the whole query is the whole of a name, or
+	// the start of it
+	scoreWhole       = 4
+	scoreWholePrefix = 2
+)
+
+// SearchMatch is a stop or a route matching a search, only one of them is set
+type SearchMatch struct {
+	Stop  *Stop
+	Route *Route
+	Score int
+}
+
+func (m SearchMatch) id() string {
+	if m.Stop != nil {
+		return m.Stop.ID
+	}
+
+	return m.Route.ID
+}
+
+type searchDoc struct {
+	stop  *Stop
+	route *Route
+	// names are the normalised names, codes and translations searched
+	names []string
+}
+
+// searchIndex maps every term of the names of stops and routes to the
+// documents they appear in, terms is sorted so that prefixes can be found by
+// binary search
+type searchIndex struct {
+	docs     []searchDoc
+	terms    []string
+	postings map[string][]int
+}
+
+var folds = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u",
+	"à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u",
+	"â", "a", "ê", "e", "î", "i", "ô", "o", "û", "u",
+	"ä", "a", "ë", "e", "ï", "i", "ö", "o", "ü", "u",
+	"ç", "c", "ñ", "n", "'", "", "’", "",
+)
+
+// normalise lowercases a name, drops its accents, including the fadas of Irish,
+// and apostrophes and turns everything which is not a letter or digit into a
+// single space
+func normalise(s string) string {
+	s = folds.Replace(strings.ToLower(s))
+
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// indexSearch indexes the names, codes and translations of stops and the short
+// names of routes
+func (s *Schedule) indexSearch() {
+	idx := searchIndex{postings: map[string][]int{}}
+	add := func(doc searchDoc, names ...string) {
+		seen := map[string]bool{}
+		for _, name := range names {
+			if n := normalise(name); n != "" && !seen[n] {
+				seen[n] = true
+				doc.names = append(doc.names, n)
+			}
+		}
+		if len(doc.names) == 0 {
+			return
+		}
+
+		i := len(idx.docs)
+		idx.docs = append(idx.docs, doc)
+		terms := map[string]bool{}
+		for _, name := range doc.names {
+			for _, term := range strings.Fields(name) {
+				if !terms[term] {
+					terms[term] = true
+					idx.postings[term] = append(idx.postings[term], i)
+				}
+			}
+		}
+	}
+
+	for _, stop := range s.Stops {
+		names := []string{stop.Name, stop.Code}
+		for _, t := range stop.Translations {
+			names = append(names, t)
+		}
+		add(searchDoc{stop: stop}, names...)
+	}
+	for _, route := range s.Routes {
+		add(searchDoc{route: route}, route.ShortName)
+	}
+
+	for term := range idx.postings {
+		idx.terms = append(idx.terms, term)
+	}
+	sort.Strings(idx.terms)
+	s.search = idx
+}
+
+// maxEdits is how many typos a query term of its length may have
+func maxEdits(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// withinEdits reports whether a and b are at most limit edits apart, an edit
+// being an insertion, deletion, substitution or swap of adjacent letters. It
+// gives up as soon as a row of the distances exceeds limit
+func withinEdits(a, b string, limit int) bool {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > limit || -d > limit {
+		return false
+	}
+
+	before := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		best := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], before[j-2]+1)
+			}
+			best = min(best, curr[j])
+		}
+		if best > limit {
+			return false
+		}
+		before, prev, curr = prev, curr, before
+	}
+
+	return prev[len(rb)] <= limit
+}
+
+// termScores returns the best score of a query term for every document with a
+// term it matches exactly, as a prefix or within its typo allowance
+func (idx searchIndex) termScores(query string) map[int]int {
+	scores := map[int]int{}
+	match := func(term string, score int) {
+		for _, i := range idx.postings[term] {
+			if score > scores[i] {
+				scores[i] = score
+			}
+		}
+	}
+
+	from := sort.SearchStrings(idx.terms, query)
+	for _, term := range idx.terms[from:] {
+		if !strings.HasPrefix(term, query) {
+			break
+		}
+		if term == query {
+			match(term, scoreExact)
+		} else {
+			match(term, scorePrefix)
+		}
+	}
+
+	if edits := maxEdits(query); edits > 0 {
+		for _, term := range idx.terms {
+			if !strings.HasPrefix(term, query) && withinEdits(query, term, edits) {
+				match(term, scoreFuzzy)
+			}
+		}
+	}
+
+	return scores
+}
+
+// Search returns the stops and routes whose name matches every term of the
+// query, each query term matching a term of the name exactly, as a prefix or
+// with a typo or two. They are ranked by how well they match, the ones whose
+// whole name is the query first
+func (s *Schedule) Search(query string, limit int) []SearchMatch {
+	query = normalise(query)
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var scores map[int]int
+	for _, term := range terms {
+		termScores := s.search.termScores(term)
+		if scores == nil {
+			scores = termScores
+
+			continue
+		}
+		for i, score := range scores {
+			if termScore, ok := termScores[i]; ok {
+				scores[i] = score + termScore
+			} else {
+				delete(scores, i)
+			}
+		}
+	}
+
+	type ranked struct {
+		SearchMatch
+		length int
+	}
+	ranking := make([]ranked, 0, len(scores))
+	for i, score := range scores {
+		doc := s.search.docs[i]
+		r := ranked{SearchMatch{Stop: doc.stop, Route: doc.route, Score: score}, len(doc.names[0])}
+		bonus := 0
+		for _, name := range doc.names {
+			switch {
+			case name == query:
+				bonus = max(bonus, scoreWhole)
+			case strings.HasPrefix(name, query):
+				bonus = max(bonus, scoreWholePrefix)
+			}
+		}
+		r.Score += bonus
+		ranking = append(ranking, r)
+	}
+
+	// Equally good matches are ranked by the shortest name, which has the
+	// least left unmatched, and then by id so that the order is stable
+	sort.Slice(ranking, func(a, b int) bool {
+		ra, rb := ranking[a], ranking[b]
+		if ra.Score != rb.Score {
+			return ra.Score > rb.Score
+		}
+		if ra.length != rb.length {
+			return ra.length < rb.length
+		}
+
+		return ra.id() < rb.id()
+	})
+
+	matches := make([]SearchMatch, 0, len(ranking))
+	for _, r := range ranking {
+		matches = append(matches, r.SearchMatch)
+	}
+
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches
+}
@@ -0,0 +1,72 @@
+package schedule
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func matchIDs(matches []SearchMatch) []string {
+	ids := []string{}
+	for _, m := range matches {
+		ids = append(ids, m.id())
+	}
+
+	return ids
+}
+
+func TestNormalise(t *testing.T) {
+	assert.Equal(t, "staisiun heuston", normalise("  Stáisiún  Heuston "))
+	assert.Equal(t, "oconnell street upper", normalise("O'Connell Street Upper"))
+	assert.Equal(t, "parnell square west stop 2", normalise("Parnell Square West, stop 2"))
+}
+
+func TestWithinEdits(t *testing.T) {
+	assert.True(t, withinEdits("heuston", "heuston", 0))
+	assert.True(t, withinEdits("hueston", "heuston", 1), "expected a swap of adjacent letters to be one edit")
+	assert.False(t, withinEdits("hueston", "heuston", 0))
+	assert.True(t, withinEdits("heustn", "heuston", 1))
+	assert.False(t, withinEdits("parnell", "heuston", 2))
+}
+
+func TestScheduleSearch(t *testing.T) {
+	s, err := LoadGTFS("testdata/gtfs")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{"matches a stop name", "heuston", []string{"8220IR0132", "8220IR0133"}},
+		{"matches an Irish stop name", "Stáisiún Heuston", []string{"8220IR0132"}},
+		{"matches an Irish stop name without fadas", "sraid ui chonaill", []string{"8220DB000335"}},
+		{"matches a route short name", "46a", []string{"3249_46342"}},
+		{"matches a stop code", "334", []string{"8220DB000334"}},
+		{"matches a prefix", "parn", []string{"8220DB000334"}},
+		{"matches every term of the query", "heuston platform", []string{"8220IR0133"}},
+		{"tolerates a typo", "heustn", []string{"8220IR0132", "8220IR0133"}},
+		{"tolerates swapped letters", "hueston", []string{"8220IR0132", "8220IR0133"}},
+		{"tolerates two typos in a long term", "westmorelnad", []string{"8220DB000336"}},
+		{"ignores case and punctuation", "O'CONNELL", []string{"8220DB000335"}},
+		{"finds nothing for an unknown name", "belfast", []string{}},
+		{"finds nothing for an empty query", " , ", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matchIDs(s.Search(tt.query, 0)))
+		})
+	}
+
+	t.Run("ranks exact matches over prefixes and typos", func(t *testing.T) {
+		matches := s.Search("heuston", 0)
+		if assert.Len(t, matches, 2) {
+			assert.Greater(t, matches[0].Score, matches[1].Score)
+		}
+	})
+
+	t.Run("limits the number of matches", func(t *testing.T) {
+		assert.Equal(t, []string{"8220IR0132"}, matchIDs(s.Search("heuston", 1)))
+	})
+}
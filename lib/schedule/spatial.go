@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	earthRadius = 6371008.8 // metres
+	// cellSize is the side of a cell of the stop grid in degrees, about 1.1km
+	// north to south and 670m east to west in Ireland
+	cellSize = 0.01
+)
+
+type cell struct {
+	lat, lon int32
+}
+
+func cellOf(lat, lon float64) cell {
+	return cell{int32(math.Floor(lat / cellSize)), int32(math.Floor(lon / cellSize))}
+}
+
+// NearbyStop is a stop and its great-circle distance in metres from a point
+type NearbyStop struct {
+	Stop     *Stop
+	Distance float64
+}
+
+// Distance returns the great-circle distance in metres between two points
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dPhi, dLambda := phi2-phi1, (lon2-lon1)*math.Pi/180
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(math.Min(1, a)))
+}
+
+// indexStops places every stop in the cell of a grid it is in
+func (s *Schedule) indexStops() {
+	s.stopCells = make(map[cell][]*Stop, len(s.Stops))
+	for _, stop := range s.Stops {
+		c := cellOf(stop.Latitude, stop.Longitude)
+		s.stopCells[c] = append(s.stopCells[c], stop)
+	}
+}
+
+// StopsNear returns the stops within radius metres of a point ordered by
+// distance, only the cells of the grid the radius reaches are searched
+func (s *Schedule) StopsNear(lat, lon, radius float64) []NearbyStop {
+	dLat := radius / earthRadius * 180 / math.Pi
+	dLon := dLat / math.Max(math.Cos(lat*math.Pi/180), 0.01)
+	from, to := cellOf(lat-dLat, lon-dLon), cellOf(lat+dLat, lon+dLon)
+
+	var stops []NearbyStop
+	for c := (cell{lat: from.lat}); c.lat <= to.lat; c.lat++ {
+		for c.lon = from.lon; c.lon <= to.lon; c.lon++ {
+			for _, stop := range s.stopCells[c] {
+				if d := Distance(lat, lon, stop.Latitude, stop.Longitude); d <= radius {
+					stops = append(stops, NearbyStop{Stop: stop, Distance: d})
+				}
+			}
+		}
+	}
+	sort.Slice(stops, func(i, j int) bool {
+		if stops[i].Distance != stops[j].Distance {
+			return stops[i].Distance < stops[j].Distance
+		}
+
+		return stops[i].Stop.ID < stops[j].Stop.ID
+	})
+
+	return stops
+}
@@ -0,0 +1,69 @@
+package schedule
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nearbyIDs(stops []NearbyStop) []string {
+	ids := []string{}
+	for _, s := range stops {
+		ids = append(ids, s.Stop.ID)
+	}
+
+	return ids
+}
+
+func TestDistance(t *testing.T) {
+	t.Run("is zero between a point and itself", func(t *testing.T) {
+		assert.Zero(t, Distance(53.3498, -6.2603, 53.3498, -6.2603))
+	})
+
+	t.Run("measures the great-circle distance", func(t *testing.T) {
+		// O'Connell Street to Dún Laoghaire is about 10.5km as the crow flies
+		assert.InDelta(t, 10480, Distance(53.3511, -6.2608, 53.2949, -6.1341), 10)
+	})
+}
+
+func TestScheduleStopsNear(t *testing.T) {
+	s, err := LoadGTFS("testdata/gtfs")
+	assert.NoError(t, err)
+
+	t.Run("returns the stops within the radius ordered by distance", func(t *testing.T) {
+		stops := s.StopsNear(53.3511, -6.2608, 700)
+		assert.Equal(t, []string{"8220DB000335", "8220DB000334", "8220DB000336"}, nearbyIDs(stops))
+		assert.Zero(t, stops[0].Distance)
+		assert.InDelta(t, 331, stops[1].Distance, 1)
+	})
+
+	t.Run("is empty when nothing is within the radius", func(t *testing.T) {
+		assert.Empty(t, s.StopsNear(53.2707, -9.0568, 1000))
+	})
+
+	t.Run("finds the same stops as a scan across cells", func(t *testing.T) {
+		s := New()
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 2000; i++ {
+			id := fmt.Sprint(i)
+			s.Stops[id] = &Stop{ID: id, Latitude: 53.3 + r.Float64()*0.1, Longitude: -6.3 + r.Float64()*0.1}
+		}
+		s.Index()
+
+		for _, radius := range []float64{50, 400, 2500} {
+			expected := []string{}
+			for id, stop := range s.Stops {
+				if Distance(53.35, -6.25, stop.Latitude, stop.Longitude) <= radius {
+					expected = append(expected, id)
+				}
+			}
+			got := nearbyIDs(s.StopsNear(53.35, -6.25, radius))
+			sort.Strings(expected)
+			sort.Strings(got)
+			assert.Equal(t, expected, got, fmt.Sprintf("expected the stops within %.0fm", radius))
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+// Operator returns an agency mapped to the canonical model
+func (s *Schedule) Operator(id string) (transit.Operator, bool) {
+	a, ok := s.Agencies[id]
+	if !ok {
+		return transit.Operator{}, false
+	}
+
+	return transit.Operator{
+		ID:       a.ID,
+		Name:     a.Name,
+		URL:      a.URL,
+		Phone:    a.Phone,
+		Timezone: a.Timezone,
+	}, true
+}
+
+// Stop returns a stop mapped to the canonical model, its translations are
+// ordered by language
+func (s *Schedule) Stop(id string) (transit.Stop, bool) {
+	stop, ok := s.Stops[id]
+	if !ok {
+		return transit.Stop{}, false
+	}
+
+	mapped := transit.Stop{
+		ID:            stop.ID,
+		Code:          stop.Code,
+		Name:          stop.Name,
+		Description:   stop.Description,
+		Latitude:      stop.Latitude,
+		Longitude:     stop.Longitude,
+		ParentStation: stop.ParentStation,
+		Platform:      stop.PlatformCode,
+	}
+	for language, text := range stop.Translations {
+		mapped.Translations = append(mapped.Translations, transit.Translation{Text: text, Language: language})
+	}
+	sort.Slice(mapped.Translations, func(i, j int) bool {
+		return mapped.Translations[i].Language < mapped.Translations[j].Language
+	})
+
+	return mapped, true
+}
+
+// Route returns a route mapped to the canonical model, its mode is taken from
+// the route type
+func (s *Schedule) Route(id string) (transit.Route, bool) {
+	r, ok := s.Routes[id]
+	if !ok {
+		return transit.Route{}, false
+	}
+
+	return transit.Route{
+		ID:        r.ID,
+		Operator:  r.AgencyID,
+		ShortName: r.ShortName,
+		LongName:  r.LongName,
+		Mode:      transit.RouteTypeMode(r.Type),
+		Color:     r.Color,
+		TextColor: r.TextColor,
+	}, true
+}
+
+// Trip returns a trip mapped to the canonical model, its operator is the
+// agency of its route
+func (s *Schedule) Trip(id string) (transit.Trip, bool) {
+	t, ok := s.Trips[id]
+	if !ok {
+		return transit.Trip{}, false
+	}
+
+	trip := transit.Trip{
+		ID:          t.ID,
+		RouteID:     t.RouteID,
+		Headsign:    t.Headsign,
+		ShortName:   t.ShortName,
+		DirectionID: t.DirectionID,
+		ShapeID:     t.ShapeID,
+	}
+	if r, ok := s.Routes[t.RouteID]; ok {
+		trip.Operator = r.AgencyID
+	}
+
+	return trip, true
+}
+
+// Shape returns the points of a shape as a line
+func (s *Schedule) Shape(id string) (transit.Shape, bool) {
+	points, ok := s.Shapes[id]
+	if !ok {
+		return transit.Shape{}, false
+	}
+
+	shape := transit.Shape{ID: id, Coordinates: make([][]float64, 0, len(points))}
+	for _, p := range points {
+		shape.Coordinates = append(shape.Coordinates, []float64{p.Longitude, p.Latitude})
+	}
+
+	return shape, true
+}
+
+// StopPatterns returns the distinct orders of stops the trips of a route call
+// at, ordered by direction and then by how many trips follow them
+func (s *Schedule) StopPatterns(routeID string) []transit.StopPattern {
+	type key struct {
+		direction int
+		stops     string
+	}
+
+	var patterns []transit.StopPattern
+	seen := map[key]int{}
+	for _, t := range s.TripsForRoute(routeID) {
+		stopTimes := s.StopTimesForTrip(t.ID)
+		stopIDs := make([]string, 0, len(stopTimes))
+		for _, st := range stopTimes {
+			stopIDs = append(stopIDs, st.StopID)
+		}
+
+		k := key{t.DirectionID, strings.Join(stopIDs, "\x00")}
+		if i, ok := seen[k]; ok {
+			patterns[i].Trips++
+
+			continue
+		}
+		seen[k] = len(patterns)
+		patterns = append(patterns, transit.StopPattern{
+			DirectionID: t.DirectionID,
+			Headsign:    t.Headsign,
+			ShapeID:     t.ShapeID,
+			StopIDs:     stopIDs,
+			Trips:       1,
+		})
+	}
+
+	sort.SliceStable(patterns, func(i, j int) bool {
+		if patterns[i].DirectionID != patterns[j].DirectionID {
+			return patterns[i].DirectionID < patterns[j].DirectionID
+		}
+
+		return patterns[i].Trips > patterns[j].Trips
+	})
+
+	n := map[int]int{}
+	for i := range patterns {
+		n[patterns[i].DirectionID]++
+		patterns[i].ID = fmt.Sprintf("%s:%d:%d", routeID, patterns[i].DirectionID, n[patterns[i].DirectionID])
+	}
+
+	return patterns
+}
@@ -0,0 +1,102 @@
+package schedule
+
+import (
+	"testing"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleTransit(t *testing.T) {
+	s, err := LoadGTFS("testdata/gtfs")
+	assert.NoError(t, err)
+
+	t.Run("maps an agency to an operator", func(t *testing.T) {
+		o, ok := s.Operator("7778019")
+		assert.True(t, ok, "expected operator to be known")
+		assert.Equal(t, transit.Operator{
+			ID:       "7778019",
+			Name:     "Dublin Bus",
+			URL:      "https://www.dublinbus.ie",
+			Timezone: "Europe/Dublin",
+		}, o)
+	})
+
+	t.Run("maps a stop", func(t *testing.T) {
+		stop, ok := s.Stop("8220DB000335")
+		assert.True(t, ok, "expected stop to be known")
+		assert.Equal(t, transit.Stop{
+			ID:        "8220DB000335",
+			Code:      "335",
+			Name:      "O'Connell Street Upper",
+			Latitude:  53.3511,
+			Longitude: -6.2608,
+			Translations: []transit.Translation{
+				{Text: "Sráid Uí Chonaill Uachtarach", Language: "ga"},
+			},
+		}, stop)
+	})
+
+	t.Run("maps a route with the mode of its type", func(t *testing.T) {
+		r, ok := s.Route("3249_46342")
+		assert.True(t, ok, "expected route to be known")
+		assert.Equal(t, transit.Route{
+			ID:        "3249_46342",
+			Operator:  "7778019",
+			ShortName: "46A",
+			LongName:  "Phoenix Park - Dún Laoghaire",
+			Mode:      transit.ModeBus,
+		}, r)
+	})
+
+	t.Run("maps a trip with the operator of its route", func(t *testing.T) {
+		trip, ok := s.Trip("3249_10511")
+		assert.True(t, ok, "expected trip to be known")
+		assert.Equal(t, transit.Trip{
+			ID:          "3249_10511",
+			Operator:    "7778019",
+			RouteID:     "3249_46350",
+			Headsign:    "UCD Belfield",
+			DirectionID: 1,
+		}, trip)
+	})
+
+	t.Run("maps a shape to longitude and latitude pairs in order", func(t *testing.T) {
+		shape, ok := s.Shape("3249_46342_1")
+		assert.True(t, ok, "expected shape to be known")
+		assert.Equal(t, transit.Shape{
+			ID:          "3249_46342_1",
+			Coordinates: [][]float64{{-6.2645, 53.3531}, {-6.2608, 53.3511}, {-6.1341, 53.2949}},
+		}, shape)
+	})
+
+	t.Run("groups the trips of a route by their stops", func(t *testing.T) {
+		assert.Equal(t, []transit.StopPattern{{
+			ID:       "3249_46342:0:1",
+			Headsign: "Dún Laoghaire",
+			ShapeID:  "3249_46342_1",
+			StopIDs:  []string{"8220DB000334", "8220DB000335", "8220DB000336", "8250DB002002"},
+			Trips:    1,
+		}, {
+			ID:       "3249_46342:0:2",
+			Headsign: "Dún Laoghaire",
+			ShapeID:  "3249_46342_1",
+			StopIDs:  []string{"8220DB000334", "8250DB002002"},
+			Trips:    1,
+		}}, s.StopPatterns("3249_46342"))
+		assert.Empty(t, s.StopPatterns("1"), "expected an unknown route to have no patterns")
+	})
+
+	t.Run("reports unknown ids", func(t *testing.T) {
+		_, ok := s.Operator("1")
+		assert.False(t, ok)
+		_, ok = s.Stop("1")
+		assert.False(t, ok)
+		_, ok = s.Route("1")
+		assert.False(t, ok)
+		_, ok = s.Trip("1")
+		assert.False(t, ok)
+		_, ok = s.Shape("1")
+		assert.False(t, ok)
+	})
+}
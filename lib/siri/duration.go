@@ -0,0 +1,68 @@
+package siri
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidDuration = errors.New("must be an ISO 8601 duration such as PT30M")
+
+var durationRegexp = regexp.MustCompile(`^(-)?P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// ParseDuration parses an ISO 8601 duration of days, hours, minutes and
+// seconds, such as the Delay of a journey, which is negative when it is
+// early. Years and months are not a fixed length and are rejected
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	m := durationRegexp.FindStringSubmatch(s)
+	if m == nil || strings.HasSuffix(s, "P") || strings.HasSuffix(s, "T") {
+		return 0, ErrInvalidDuration
+	}
+
+	var d time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if m[i+2] == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(m[i+2], 64)
+		if err != nil {
+			return 0, ErrInvalidDuration
+		}
+		d += time.Duration(v * float64(unit))
+	}
+	if m[1] == "-" {
+		d = -d
+	}
+
+	return d, nil
+}
+
+// FormatDuration formats a duration as an ISO 8601 duration of whole
+// seconds, such as PT2M or -PT30S
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	seconds := int64(d.Round(time.Second) / time.Second)
+	if seconds == 0 {
+		return "PT0S"
+	}
+
+	s := sign + "PT"
+	if h := seconds / 3600; h > 0 {
+		s += fmt.Sprintf("%dH", h)
+	}
+	if m := seconds / 60 % 60; m > 0 {
+		s += fmt.Sprintf("%dM", m)
+	}
+	if sec := seconds % 60; sec > 0 {
+		s += fmt.Sprintf("%dS", sec)
+	}
+
+	return s
+}
@@ -0,0 +1,47 @@
+package siri
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	for s, d := range map[string]time.Duration{
+		"PT30M":      30 * time.Minute,
+		"PT1H":       time.Hour,
+		"P1D":        24 * time.Hour,
+		"P1DT2H3M4S": 26*time.Hour + 3*time.Minute + 4*time.Second,
+		"PT90S":      90 * time.Second,
+		"PT1.5S":     1500 * time.Millisecond,
+		"-PT45S":     -45 * time.Second,
+		" PT10S\n":   10 * time.Second,
+	} {
+		t.Run("parses "+s, func(t *testing.T) {
+			parsed, err := ParseDuration(s)
+			assert.NoError(t, err)
+			assert.Equal(t, d, parsed)
+		})
+	}
+
+	for _, s := range []string{"", "P", "-P", "PT", "P1DT", "30M", "120", "PT30", "P1M", "P1Y", "PT-1M", "PT30M1H"} {
+		t.Run("rejects "+s, func(t *testing.T) {
+			_, err := ParseDuration(s)
+			assert.ErrorIs(t, err, ErrInvalidDuration)
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	for d, s := range map[time.Duration]string{
+		0:                              "PT0S",
+		2 * time.Minute:                "PT2M",
+		-30 * time.Second:              "-PT30S",
+		time.Hour + 90*time.Second:     "PT1H1M30S",
+		1400 * time.Millisecond:        "PT1S",
+		-(2*time.Hour + 5*time.Minute): "-PT2H5M",
+	} {
+		assert.Equal(t, s, FormatDuration(d), "expected %s formatted as %s", d, s)
+	}
+}
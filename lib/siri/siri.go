@@ -0,0 +1,73 @@
+// Package siri is the XML model of SIRI 2.0, the European standard for
+// realtime public transport information. It covers the VehicleMonitoring and
+// SituationExchange services, which the aggregator consumes and the API
+// produces
+package siri
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+const Version = "2.0"
+
+// Siri is the root of every SIRI document, requests sent to a producer and
+// what it responds with or pushes are all one of its elements
+type Siri struct {
+	XMLName                       xml.Name                       `xml:"http://www.siri.org.uk/siri Siri"`
+	Version                       string                         `xml:"version,attr"`
+	ServiceRequest                *ServiceRequest                `xml:"ServiceRequest,omitempty"`
+	SubscriptionRequest           *SubscriptionRequest           `xml:"SubscriptionRequest,omitempty"`
+	TerminateSubscriptionRequest  *TerminateSubscriptionRequest  `xml:"TerminateSubscriptionRequest,omitempty"`
+	ServiceDelivery               *ServiceDelivery               `xml:"ServiceDelivery,omitempty"`
+	SubscriptionResponse          *SubscriptionResponse          `xml:"SubscriptionResponse,omitempty"`
+	TerminateSubscriptionResponse *TerminateSubscriptionResponse `xml:"TerminateSubscriptionResponse,omitempty"`
+	HeartbeatNotification         *HeartbeatNotification         `xml:"HeartbeatNotification,omitempty"`
+}
+
+type ServiceDelivery struct {
+	ResponseTimestamp           time.Time                   `xml:"ResponseTimestamp"`
+	ProducerRef                 string                      `xml:"ProducerRef"`
+	Status                      string                      `xml:"Status,omitempty"`
+	ErrorCondition              *ErrorCondition             `xml:"ErrorCondition,omitempty"`
+	VehicleMonitoringDeliveries []VehicleMonitoringDelivery `xml:"VehicleMonitoringDelivery"`
+	SituationExchangeDeliveries []SituationExchangeDelivery `xml:"SituationExchangeDelivery"`
+}
+
+// Text is a natural language text, in the language of Lang when it is known
+type Text struct {
+	Lang  string `xml:"http://www.w3.org/XML/1998/namespace lang,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+// ErrorType is one of the errors of an ErrorCondition, such as a
+// CapabilityNotSupportedError, named by its element
+type ErrorType struct {
+	XMLName   xml.Name
+	ErrorText string `xml:"ErrorText"`
+}
+
+type ErrorCondition struct {
+	Errors      []ErrorType `xml:",any"`
+	Description string      `xml:"Description"`
+}
+
+func (e *ErrorCondition) Error() string {
+	var parts []string
+	for _, t := range e.Errors {
+		part := t.XMLName.Local
+		if t.ErrorText != "" {
+			part += " " + t.ErrorText
+		}
+		parts = append(parts, part)
+	}
+	if e.Description != "" {
+		parts = append(parts, e.Description)
+	}
+	if len(parts) == 0 {
+		return "unknown error"
+	}
+
+	return strings.Join(parts, ": ")
+}
@@ -0,0 +1,49 @@
+package siri
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCondition(t *testing.T) {
+	t.Run("names every error and the description", func(t *testing.T) {
+		var e ErrorCondition
+		assert.NoError(t, xml.Unmarshal([]byte(`<ErrorCondition><ServiceNotAvailableError><ErrorText>maintenance</ErrorText></ServiceNotAvailableError>`+
+			`<Description>back at 10:00</Description></ErrorCondition>`), &e))
+		assert.EqualError(t, &e, "ServiceNotAvailableError maintenance: back at 10:00")
+	})
+
+	t.Run("is unknown without errors", func(t *testing.T) {
+		assert.EqualError(t, &ErrorCondition{}, "unknown error")
+	})
+}
+
+func TestServiceDelivery(t *testing.T) {
+	t.Run("leaves out what a producer does not report", func(t *testing.T) {
+		b, err := xml.Marshal(Siri{Version: Version, ServiceDelivery: &ServiceDelivery{ProducerRef: "WheresMyLift"}})
+		assert.NoError(t, err, "could not marshal")
+		assert.Equal(t, `<Siri xmlns="http://www.siri.org.uk/siri" version="2.0"><ServiceDelivery>`+
+			`<ResponseTimestamp>0001-01-01T00:00:00Z</ResponseTimestamp><ProducerRef>WheresMyLift</ProducerRef>`+
+			`</ServiceDelivery></Siri>`, string(b))
+	})
+
+	t.Run("reads the deliveries of a producer", func(t *testing.T) {
+		var s Siri
+		assert.NoError(t, xml.Unmarshal([]byte(`<Siri xmlns="http://www.siri.org.uk/siri" version="2.0"><ServiceDelivery>`+
+			`<VehicleMonitoringDelivery><SubscriptionRef>S1</SubscriptionRef><VehicleActivity><ValidUntilTime>2025-01-21T09:35:00Z</ValidUntilTime>`+
+			`<MonitoredVehicleJourney><DestinationName>UCD Belfield</DestinationName><VehicleRef>GAI-11042</VehicleRef></MonitoredVehicleJourney>`+
+			`</VehicleActivity></VehicleMonitoringDelivery></ServiceDelivery></Siri>`), &s))
+		if assert.NotNil(t, s.ServiceDelivery) && assert.Len(t, s.ServiceDelivery.VehicleMonitoringDeliveries, 1) {
+			d := s.ServiceDelivery.VehicleMonitoringDeliveries[0]
+			assert.Equal(t, "S1", d.SubscriptionRef)
+			if assert.Len(t, d.VehicleActivities, 1) {
+				a := d.VehicleActivities[0]
+				assert.NotNil(t, a.ValidUntilTime)
+				assert.Equal(t, []string{"UCD Belfield"}, a.MonitoredVehicleJourney.DestinationNames)
+				assert.Nil(t, a.MonitoredVehicleJourney.FramedVehicleJourneyRef)
+			}
+		}
+	})
+}
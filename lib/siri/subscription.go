@@ -0,0 +1,61 @@
+package siri
+
+import "time"
+
+type VehicleMonitoringRequest struct {
+	Version          string    `xml:"version,attr"`
+	RequestTimestamp time.Time `xml:"RequestTimestamp"`
+}
+
+type ServiceRequest struct {
+	RequestTimestamp         time.Time                `xml:"RequestTimestamp"`
+	RequestorRef             string                   `xml:"RequestorRef"`
+	VehicleMonitoringRequest VehicleMonitoringRequest `xml:"VehicleMonitoringRequest"`
+}
+
+type SubscriptionContext struct {
+	HeartbeatInterval string `xml:"HeartbeatInterval"`
+}
+
+type VehicleMonitoringSubscriptionRequest struct {
+	SubscriptionIdentifier   string                   `xml:"SubscriptionIdentifier"`
+	InitialTerminationTime   time.Time                `xml:"InitialTerminationTime"`
+	VehicleMonitoringRequest VehicleMonitoringRequest `xml:"VehicleMonitoringRequest"`
+	IncrementalUpdates       bool                     `xml:"IncrementalUpdates"`
+}
+
+type SubscriptionRequest struct {
+	RequestTimestamp                     time.Time                            `xml:"RequestTimestamp"`
+	RequestorRef                         string                               `xml:"RequestorRef"`
+	ConsumerAddress                      string                               `xml:"ConsumerAddress"`
+	SubscriptionContext                  SubscriptionContext                  `xml:"SubscriptionContext"`
+	VehicleMonitoringSubscriptionRequest VehicleMonitoringSubscriptionRequest `xml:"VehicleMonitoringSubscriptionRequest"`
+}
+
+type TerminateSubscriptionRequest struct {
+	RequestTimestamp time.Time `xml:"RequestTimestamp"`
+	RequestorRef     string    `xml:"RequestorRef"`
+	SubscriptionRef  string    `xml:"SubscriptionRef"`
+}
+
+type ResponseStatus struct {
+	SubscriptionRef string          `xml:"SubscriptionRef"`
+	Status          string          `xml:"Status"`
+	ErrorCondition  *ErrorCondition `xml:"ErrorCondition"`
+	ValidUntil      time.Time       `xml:"ValidUntil"`
+}
+
+type SubscriptionResponse struct {
+	ResponseTimestamp time.Time        `xml:"ResponseTimestamp"`
+	ResponseStatuses  []ResponseStatus `xml:"ResponseStatus"`
+}
+
+type TerminateSubscriptionResponse struct {
+	TerminationResponseStatuses []ResponseStatus `xml:"TerminationResponseStatus"`
+}
+
+type HeartbeatNotification struct {
+	RequestTimestamp time.Time `xml:"RequestTimestamp"`
+	ProducerRef      string    `xml:"ProducerRef"`
+	Status           string    `xml:"Status"`
+}
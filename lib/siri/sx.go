@@ -0,0 +1,86 @@
+package siri
+
+import "time"
+
+type SituationExchangeDelivery struct {
+	Version           string               `xml:"version,attr"`
+	ResponseTimestamp time.Time            `xml:"ResponseTimestamp"`
+	Situations        []PtSituationElement `xml:"Situations>PtSituationElement"`
+}
+
+// ValidityPeriod is left open at the end when EndTime is missing
+type ValidityPeriod struct {
+	StartTime time.Time  `xml:"StartTime"`
+	EndTime   *time.Time `xml:"EndTime,omitempty"`
+}
+
+type InfoLink struct {
+	URI string `xml:"Uri"`
+}
+
+type AffectedOperator struct {
+	OperatorRef string `xml:"OperatorRef"`
+}
+
+type AffectedLine struct {
+	LineRef string `xml:"LineRef"`
+}
+
+type AffectedStopPoint struct {
+	StopPointRef string `xml:"StopPointRef"`
+}
+
+type AffectedVehicleJourney struct {
+	VehicleJourneyRef string `xml:"VehicleJourneyRef"`
+}
+
+type AffectedOperators struct {
+	AffectedOperators []AffectedOperator `xml:"AffectedOperator"`
+}
+
+type AffectedNetworks struct {
+	AffectedLines []AffectedLine `xml:"AffectedNetwork>AffectedLine"`
+}
+
+type AffectedStopPoints struct {
+	AffectedStopPoints []AffectedStopPoint `xml:"AffectedStopPoint"`
+}
+
+type AffectedVehicleJourneys struct {
+	AffectedVehicleJourneys []AffectedVehicleJourney `xml:"AffectedVehicleJourney"`
+}
+
+// Affects leaves out the kinds of things which are not affected, encoding/xml
+// would otherwise write their empty parents
+type Affects struct {
+	Operators       *AffectedOperators       `xml:"Operators,omitempty"`
+	Networks        *AffectedNetworks        `xml:"Networks,omitempty"`
+	StopPoints      *AffectedStopPoints      `xml:"StopPoints,omitempty"`
+	VehicleJourneys *AffectedVehicleJourneys `xml:"VehicleJourneys,omitempty"`
+}
+
+type InfoLinks struct {
+	InfoLinks []InfoLink `xml:"InfoLink"`
+}
+
+type Consequence struct {
+	Condition string `xml:"Condition"`
+}
+
+type Consequences struct {
+	Consequences []Consequence `xml:"Consequence"`
+}
+
+type PtSituationElement struct {
+	CreationTime    time.Time        `xml:"CreationTime"`
+	ParticipantRef  string           `xml:"ParticipantRef"`
+	SituationNumber string           `xml:"SituationNumber"`
+	Progress        string           `xml:"Progress"`
+	ValidityPeriods []ValidityPeriod `xml:"ValidityPeriod"`
+	AlertCause      string           `xml:"AlertCause"`
+	Summaries       []Text           `xml:"Summary"`
+	Descriptions    []Text           `xml:"Description,omitempty"`
+	InfoLinks       *InfoLinks       `xml:"InfoLinks,omitempty"`
+	Affects         *Affects         `xml:"Affects,omitempty"`
+	Consequences    *Consequences    `xml:"Consequences,omitempty"`
+}
@@ -0,0 +1,83 @@
+package siri
+
+import "time"
+
+// VehicleMonitoringDelivery is the activity of vehicles, a producer pushing
+// to a subscription names it by SubscriptionRef
+type VehicleMonitoringDelivery struct {
+	Version                      string                        `xml:"version,attr,omitempty"`
+	ResponseTimestamp            time.Time                     `xml:"ResponseTimestamp"`
+	SubscriptionRef              string                        `xml:"SubscriptionRef,omitempty"`
+	Status                       string                        `xml:"Status,omitempty"`
+	ErrorCondition               *ErrorCondition               `xml:"ErrorCondition,omitempty"`
+	VehicleActivities            []VehicleActivity             `xml:"VehicleActivity"`
+	VehicleActivityCancellations []VehicleActivityCancellation `xml:"VehicleActivityCancellation"`
+}
+
+type VehicleActivity struct {
+	RecordedAtTime          time.Time               `xml:"RecordedAtTime"`
+	ValidUntilTime          *time.Time              `xml:"ValidUntilTime,omitempty"`
+	MonitoredVehicleJourney MonitoredVehicleJourney `xml:"MonitoredVehicleJourney"`
+}
+
+// VehicleActivityCancellation withdraws the vehicle activity of a journey,
+// such as one which has finished
+type VehicleActivityCancellation struct {
+	RecordedAtTime       time.Time               `xml:"RecordedAtTime"`
+	VehicleMonitoringRef string                  `xml:"VehicleMonitoringRef"`
+	VehicleJourneyRef    FramedVehicleJourneyRef `xml:"VehicleJourneyRef"`
+	LineRef              string                  `xml:"LineRef"`
+}
+
+// FramedVehicleJourneyRef is a trip on an operating day
+type FramedVehicleJourneyRef struct {
+	DataFrameRef           string `xml:"DataFrameRef"`
+	DatedVehicleJourneyRef string `xml:"DatedVehicleJourneyRef"`
+}
+
+type VehicleLocation struct {
+	Longitude float64 `xml:"Longitude"`
+	Latitude  float64 `xml:"Latitude"`
+}
+
+type MonitoredCall struct {
+	StopPointRef  string `xml:"StopPointRef"`
+	VehicleAtStop bool   `xml:"VehicleAtStop"`
+}
+
+type OnwardCall struct {
+	StopPointRef          string     `xml:"StopPointRef"`
+	Order                 int        `xml:"Order,omitempty"`
+	StopPointName         string     `xml:"StopPointName,omitempty"`
+	AimedArrivalTime      *time.Time `xml:"AimedArrivalTime,omitempty"`
+	ExpectedArrivalTime   *time.Time `xml:"ExpectedArrivalTime,omitempty"`
+	ArrivalStatus         string     `xml:"ArrivalStatus,omitempty"`
+	ArrivalPlatformName   string     `xml:"ArrivalPlatformName,omitempty"`
+	AimedDepartureTime    *time.Time `xml:"AimedDepartureTime,omitempty"`
+	ExpectedDepartureTime *time.Time `xml:"ExpectedDepartureTime,omitempty"`
+	DepartureStatus       string     `xml:"DepartureStatus,omitempty"`
+}
+
+type OnwardCalls struct {
+	OnwardCalls []OnwardCall `xml:"OnwardCall"`
+}
+
+// MonitoredVehicleJourney is the journey a vehicle is making, the destination
+// is named once for each language
+type MonitoredVehicleJourney struct {
+	LineRef                 string                   `xml:"LineRef,omitempty"`
+	FramedVehicleJourneyRef *FramedVehicleJourneyRef `xml:"FramedVehicleJourneyRef,omitempty"`
+	VehicleMode             string                   `xml:"VehicleMode,omitempty"`
+	PublishedLineName       string                   `xml:"PublishedLineName,omitempty"`
+	OperatorRef             string                   `xml:"OperatorRef,omitempty"`
+	DestinationNames        []string                 `xml:"DestinationName,omitempty"`
+	Monitored               bool                     `xml:"Monitored"`
+	VehicleLocation         *VehicleLocation         `xml:"VehicleLocation,omitempty"`
+	Bearing                 *float64                 `xml:"Bearing,omitempty"`
+	Velocity                *float64                 `xml:"Velocity,omitempty"`
+	Delay                   string                   `xml:"Delay,omitempty"`
+	VehicleStatus           string                   `xml:"VehicleStatus,omitempty"`
+	VehicleRef              string                   `xml:"VehicleRef"`
+	MonitoredCall           *MonitoredCall           `xml:"MonitoredCall,omitempty"`
+	OnwardCalls             *OnwardCalls             `xml:"OnwardCalls,omitempty"`
+}
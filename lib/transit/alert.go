@@ -0,0 +1,83 @@
+package transit
+
+import (
+	"time"
+)
+
+type Translation struct {
+	Text     string `json:"text" example:"Route 46A diverted"`
+	Language string `json:"language,omitempty" example:"en"`
+}
+
+// ActivePeriod is left open on either side when the start or end is missing
+type ActivePeriod struct {
+	Start *time.Time `json:"start,omitempty" example:"2025-01-21T09:00:00Z"`
+	End   *time.Time `json:"end,omitempty" example:"2025-01-21T18:00:00Z"`
+}
+
+type InformedEntity struct {
+	OperatorID  string `json:"operator_id,omitempty" example:"7778019"`
+	RouteID     string `json:"route_id,omitempty" example:"3249_46342"`
+	RouteType   *int   `json:"route_type,omitempty" example:"3"`
+	TripID      string `json:"trip_id,omitempty" example:"3249_10466"`
+	StopID      string `json:"stop_id,omitempty" example:"8220DB000334"`
+	DirectionID *int   `json:"direction_id,omitempty" example:"1"`
+}
+
+type Alert struct {
+	ID               string           `json:"id" example:"A1"`
+	ActivePeriods    []ActivePeriod   `json:"active_periods"`
+	InformedEntities []InformedEntity `json:"informed_entities"`
+	Cause            string           `json:"cause" example:"CONSTRUCTION"`
+	Effect           string           `json:"effect" example:"DETOUR"`
+	URL              []Translation    `json:"url,omitempty"`
+	HeaderText       []Translation    `json:"header_text"`
+	DescriptionText  []Translation    `json:"description_text,omitempty"`
+}
+
+// ActiveAt reports whether t falls in one of the alerts active periods, an
+// alert without active periods is always active
+func (a Alert) ActiveAt(t time.Time) bool {
+	if len(a.ActivePeriods) == 0 {
+		return true
+	}
+
+	for _, p := range a.ActivePeriods {
+		if (p.Start == nil || !t.Before(*p.Start)) && (p.End == nil || !t.After(*p.End)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ActiveBetween reports whether one of the alerts active periods overlaps the
+// time from from until until, an alert without active periods is always active
+func (a Alert) ActiveBetween(from, until time.Time) bool {
+	if len(a.ActivePeriods) == 0 {
+		return true
+	}
+
+	for _, p := range a.ActivePeriods {
+		if (p.Start == nil || !until.Before(*p.Start)) && (p.End == nil || !from.After(*p.End)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExpiredAt reports whether every active period of the alert ended before t
+func (a Alert) ExpiredAt(t time.Time) bool {
+	if len(a.ActivePeriods) == 0 {
+		return false
+	}
+
+	for _, p := range a.ActivePeriods {
+		if p.End == nil || !t.After(*p.End) {
+			return false
+		}
+	}
+
+	return true
+}
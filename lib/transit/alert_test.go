@@ -0,0 +1,81 @@
+package transit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func at(s string) *time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+
+	return &t
+}
+
+func TestAlertActiveAt(t *testing.T) {
+	a := Alert{ActivePeriods: []ActivePeriod{
+		{Start: at("2025-01-21T09:00:00Z"), End: at("2025-01-21T18:00:00Z")},
+		{Start: at("2025-01-22T09:00:00Z")},
+	}}
+
+	t.Run("is active within a period", func(t *testing.T) {
+		assert.True(t, a.ActiveAt(*at("2025-01-21T09:00:00Z")))
+		assert.True(t, a.ActiveAt(*at("2025-01-21T18:00:00Z")))
+		assert.True(t, a.ActiveAt(*at("2030-01-01T00:00:00Z")), "expected open ended period to be active")
+	})
+
+	t.Run("is not active outside of every period", func(t *testing.T) {
+		assert.False(t, a.ActiveAt(*at("2025-01-21T08:59:59Z")))
+		assert.False(t, a.ActiveAt(*at("2025-01-21T20:00:00Z")))
+	})
+
+	t.Run("is always active without periods", func(t *testing.T) {
+		assert.True(t, Alert{}.ActiveAt(time.Now()))
+	})
+}
+
+func TestAlertActiveBetween(t *testing.T) {
+	a := Alert{ActivePeriods: []ActivePeriod{
+		{Start: at("2025-01-21T09:00:00Z"), End: at("2025-01-21T18:00:00Z")},
+		{Start: at("2025-01-23T09:00:00Z")},
+	}}
+
+	for _, tt := range []struct {
+		name        string
+		from, until string
+		active      bool
+	}{
+		{"within a period", "2025-01-21T10:00:00Z", "2025-01-21T11:00:00Z", true},
+		{"overlapping the start of a period", "2025-01-21T08:00:00Z", "2025-01-21T09:00:00Z", true},
+		{"overlapping the end of a period", "2025-01-21T18:00:00Z", "2025-01-21T19:00:00Z", true},
+		{"between periods", "2025-01-21T18:00:01Z", "2025-01-23T08:59:59Z", false},
+		{"reaching an open ended period", "2025-01-22T00:00:00Z", "2025-01-23T09:00:00Z", true},
+		{"before every period", "2025-01-20T00:00:00Z", "2025-01-21T08:59:59Z", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.active, a.ActiveBetween(*at(tt.from), *at(tt.until)))
+		})
+	}
+
+	t.Run("is always active without periods", func(t *testing.T) {
+		assert.True(t, Alert{}.ActiveBetween(time.Now(), time.Now()))
+	})
+}
+
+func TestAlertExpiredAt(t *testing.T) {
+	t.Run("expires once every period has ended", func(t *testing.T) {
+		a := Alert{ActivePeriods: []ActivePeriod{{End: at("2025-01-21T18:00:00Z")}}}
+		assert.False(t, a.ExpiredAt(*at("2025-01-21T18:00:00Z")))
+		assert.True(t, a.ExpiredAt(*at("2025-01-21T18:00:01Z")))
+	})
+
+	t.Run("never expires with an open ended period", func(t *testing.T) {
+		a := Alert{ActivePeriods: []ActivePeriod{{End: at("2025-01-21T18:00:00Z")}, {}}}
+		assert.False(t, a.ExpiredAt(*at("2030-01-01T00:00:00Z")))
+	})
+
+	t.Run("never expires without periods", func(t *testing.T) {
+		assert.False(t, Alert{}.ExpiredAt(time.Now()))
+	})
+}
@@ -0,0 +1,18 @@
+package transit
+
+// Dataset is everything known about the network at one moment, it is what
+// the aggregator hands over to the API
+type Dataset struct {
+	Vehicles     []Vehicle    `json:"vehicles"`
+	Departures   []Departure  `json:"departures"`
+	Alerts       []Alert      `json:"alerts"`
+	LineStatuses []LineStatus `json:"line_statuses"`
+}
+
+// Merge appends the contents of other to d
+func (d *Dataset) Merge(other Dataset) {
+	d.Vehicles = append(d.Vehicles, other.Vehicles...)
+	d.Departures = append(d.Departures, other.Departures...)
+	d.Alerts = append(d.Alerts, other.Alerts...)
+	d.LineStatuses = append(d.LineStatuses, other.LineStatuses...)
+}
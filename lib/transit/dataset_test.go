@@ -0,0 +1,24 @@
+package transit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatasetMerge(t *testing.T) {
+	d := Dataset{Vehicles: []Vehicle{{ID: "V1"}}}
+	d.Merge(Dataset{
+		Vehicles:     []Vehicle{{ID: "V2"}},
+		Departures:   []Departure{{StopID: "RAN"}},
+		Alerts:       []Alert{{ID: "A1"}},
+		LineStatuses: []LineStatus{{RouteID: "red"}},
+	})
+
+	assert.Equal(t, Dataset{
+		Vehicles:     []Vehicle{{ID: "V1"}, {ID: "V2"}},
+		Departures:   []Departure{{StopID: "RAN"}},
+		Alerts:       []Alert{{ID: "A1"}},
+		LineStatuses: []LineStatus{{RouteID: "red"}},
+	}, d)
+}
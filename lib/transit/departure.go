@@ -0,0 +1,97 @@
+package transit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type DepartureStatus string
+
+const (
+	DepartureScheduled DepartureStatus = "scheduled"
+	DepartureCancelled DepartureStatus = "cancelled"
+	DepartureSkipped   DepartureStatus = "skipped"
+	DepartureNoData    DepartureStatus = "no_data"
+)
+
+// Departure is a call of a trip at a stop, times which are not known are left
+// as the zero time and are not marshalled. Realtime is set when the expected
+// times or delay come from a realtime source rather than the timetable
+type Departure struct {
+	StopID             string          `json:"stop_id" example:"8220DB000334"`
+	Source             string          `json:"source" example:"gtfsr"`
+	Operator           string          `json:"operator,omitempty" example:"7778019"`
+	Mode               Mode            `json:"mode,omitempty" example:"bus"`
+	RouteID            string          `json:"route_id,omitempty" example:"3249_46342"`
+	TripID             string          `json:"trip_id,omitempty" example:"3249_10466"`
+	VehicleID          string          `json:"vehicle_id,omitempty" example:"V1"`
+	Headsign           string          `json:"headsign,omitempty" example:"Liffey Valley"`
+	Direction          string          `json:"direction,omitempty" example:"Southbound"`
+	Platform           string          `json:"platform,omitempty" example:"2"`
+	StopSequence       int             `json:"stop_sequence,omitempty" example:"12"`
+	ScheduledArrival   time.Time       `json:"scheduled_arrival,omitempty" example:"2025-01-21T12:00:00Z"`
+	ScheduledDeparture time.Time       `json:"scheduled_departure,omitempty" example:"2025-01-21T12:00:00Z"`
+	ExpectedArrival    time.Time       `json:"expected_arrival,omitempty" example:"2025-01-21T12:02:00Z"`
+	ExpectedDeparture  time.Time       `json:"expected_departure,omitempty" example:"2025-01-21T12:02:00Z"`
+	Delay              *time.Duration  `json:"delay,omitempty" swaggertype:"integer" example:"120"` // seconds, negative when early
+	Status             DepartureStatus `json:"status" example:"scheduled"`
+	Realtime           bool            `json:"realtime" example:"true"`
+}
+
+func (d Departure) MarshalJSON() ([]byte, error) {
+	type departure Departure
+
+	return json.Marshal(struct {
+		departure
+		ScheduledArrival   *time.Time `json:"scheduled_arrival,omitempty"`
+		ScheduledDeparture *time.Time `json:"scheduled_departure,omitempty"`
+		ExpectedArrival    *time.Time `json:"expected_arrival,omitempty"`
+		ExpectedDeparture  *time.Time `json:"expected_departure,omitempty"`
+		Delay              *int64     `json:"delay,omitempty"`
+	}{
+		departure(d),
+		optionalTime(d.ScheduledArrival),
+		optionalTime(d.ScheduledDeparture),
+		optionalTime(d.ExpectedArrival),
+		optionalTime(d.ExpectedDeparture),
+		seconds(d.Delay),
+	})
+}
+
+func (d *Departure) UnmarshalJSON(b []byte) error {
+	type departure Departure
+	aux := struct {
+		*departure
+		Delay *int64 `json:"delay"`
+	}{departure: (*departure)(d)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	d.Delay = duration(aux.Delay)
+
+	return nil
+}
+
+// Time returns the best known time of the departure, the expected time is
+// preferred over the scheduled one and the departure over the arrival. It is
+// the zero time when nothing is known
+func (d Departure) Time() time.Time {
+	for _, t := range []time.Time{d.ExpectedDeparture, d.ExpectedArrival, d.ScheduledDeparture, d.ScheduledArrival} {
+		if !t.IsZero() {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// LineStatus is the service status a source reports for a whole line, such
+// as each Luas line
+type LineStatus struct {
+	Operator string    `json:"operator" example:"luas"`
+	RouteID  string    `json:"route_id" example:"red"`
+	Name     string    `json:"name" example:"Luas Red Line"`
+	Normal   bool      `json:"normal" example:"true"`
+	Message  string    `json:"message,omitempty" example:"Red Line services operating normally"`
+	Updated  time.Time `json:"updated" example:"2025-01-21T12:00:00Z"`
+}
@@ -0,0 +1,72 @@
+package transit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDepartureJSON(t *testing.T) {
+	delay := -time.Minute
+	d := Departure{
+		StopID:            "8220DB000334",
+		Source:            "gtfsr",
+		TripID:            "3249_10466",
+		StopSequence:      12,
+		ExpectedDeparture: *at("2025-01-21T12:02:00Z"),
+		Delay:             &delay,
+		Status:            DepartureScheduled,
+		Realtime:          true,
+	}
+
+	t.Run("leaves out the times which are not known", func(t *testing.T) {
+		b, err := json.Marshal(d)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"stop_id": "8220DB000334",
+			"source": "gtfsr",
+			"trip_id": "3249_10466",
+			"stop_sequence": 12,
+			"expected_departure": "2025-01-21T12:02:00Z",
+			"delay": -60,
+			"status": "scheduled",
+			"realtime": true
+		}`, string(b))
+	})
+
+	t.Run("unmarshals what it marshals", func(t *testing.T) {
+		b, err := json.Marshal(d)
+		assert.NoError(t, err)
+
+		var got Departure
+		assert.NoError(t, json.Unmarshal(b, &got))
+		assert.Equal(t, d, got)
+	})
+
+	t.Run("fails on invalid JSON", func(t *testing.T) {
+		var got Departure
+		assert.Error(t, json.Unmarshal([]byte(`{"delay": "late"}`), &got))
+	})
+}
+
+func TestDepartureTime(t *testing.T) {
+	t.Run("prefers the expected departure", func(t *testing.T) {
+		d := Departure{
+			ScheduledDeparture: *at("2025-01-21T12:00:00Z"),
+			ExpectedArrival:    *at("2025-01-21T12:01:00Z"),
+			ExpectedDeparture:  *at("2025-01-21T12:02:00Z"),
+		}
+		assert.Equal(t, *at("2025-01-21T12:02:00Z"), d.Time())
+	})
+
+	t.Run("falls back to the scheduled arrival", func(t *testing.T) {
+		d := Departure{ScheduledArrival: *at("2025-01-21T12:00:00Z")}
+		assert.Equal(t, *at("2025-01-21T12:00:00Z"), d.Time())
+	})
+
+	t.Run("is zero when nothing is known", func(t *testing.T) {
+		assert.True(t, Departure{}.Time().IsZero())
+	})
+}
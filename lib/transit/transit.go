@@ -0,0 +1,129 @@
+package transit
+
+import "time"
+
+type Mode string
+
+const (
+	ModeBus   Mode = "bus"
+	ModeRail  Mode = "rail"
+	ModeTram  Mode = "tram"
+	ModeFerry Mode = "ferry"
+)
+
+// Modes is every mode a vehicle, route or departure can have
+var Modes = []Mode{ModeBus, ModeRail, ModeTram, ModeFerry}
+
+// RouteTypeMode returns the mode of a GTFS route type, both the basic and the
+// extended route types are known. It returns an empty mode for any other type
+func RouteTypeMode(routeType int) Mode {
+	switch {
+	case routeType == 0 || routeType == 5 || routeType == 6 || routeType == 7:
+		return ModeTram
+	case routeType == 1 || routeType == 2 || routeType == 12:
+		return ModeRail
+	case routeType == 3 || routeType == 11:
+		return ModeBus
+	case routeType == 4:
+		return ModeFerry
+	case routeType >= 100 && routeType < 200, routeType >= 400 && routeType < 500:
+		return ModeRail
+	case routeType >= 200 && routeType < 300, routeType >= 700 && routeType < 800:
+		return ModeBus
+	case routeType >= 900 && routeType < 1000:
+		return ModeTram
+	case routeType >= 1000 && routeType < 1100, routeType >= 1200 && routeType < 1300:
+		return ModeFerry
+	default:
+		return ""
+	}
+}
+
+// Operator runs the services of one or more routes, such as Dublin Bus
+type Operator struct {
+	ID       string `json:"id" example:"7778019"`
+	Name     string `json:"name" example:"Dublin Bus"`
+	URL      string `json:"url,omitempty" example:"https://www.dublinbus.ie"`
+	Phone    string `json:"phone,omitempty" example:"+353 1 873 4222"`
+	Timezone string `json:"timezone,omitempty" example:"Europe/Dublin"`
+}
+
+type Stop struct {
+	ID            string        `json:"id" example:"8220DB000334"`
+	Code          string        `json:"code,omitempty" example:"334"`
+	Name          string        `json:"name" example:"O'Connell Street Upper"`
+	Description   string        `json:"description,omitempty"`
+	Latitude      float64       `json:"latitude" example:"53.3498"`
+	Longitude     float64       `json:"longitude" example:"-6.2603"`
+	ParentStation string        `json:"parent_station,omitempty" example:"8220DB000333"`
+	Platform      string        `json:"platform,omitempty" example:"2"`
+	Translations  []Translation `json:"translations,omitempty"` // of the name, such as the Irish name
+}
+
+type Route struct {
+	ID        string `json:"id" example:"3249_46342"`
+	Operator  string `json:"operator" example:"7778019"`
+	ShortName string `json:"short_name,omitempty" example:"46A"`
+	LongName  string `json:"long_name,omitempty" example:"Phoenix Park - Dun Laoghaire"`
+	Mode      Mode   `json:"mode,omitempty" example:"bus"`
+	Color     string `json:"color,omitempty" example:"FFD200"`
+	TextColor string `json:"text_color,omitempty" example:"000000"`
+}
+
+type Trip struct {
+	ID          string `json:"id" example:"3249_10466"`
+	Operator    string `json:"operator,omitempty" example:"7778019"`
+	RouteID     string `json:"route_id" example:"3249_46342"`
+	Headsign    string `json:"headsign,omitempty" example:"Dun Laoghaire"`
+	ShortName   string `json:"short_name,omitempty" example:"E109"`
+	DirectionID int    `json:"direction_id" example:"1"`
+	ShapeID     string `json:"shape_id,omitempty" example:"3249_123"`
+}
+
+// Shape is the path vehicles take along a route, its coordinates are
+// longitude and latitude pairs as in GeoJSON
+type Shape struct {
+	ID          string      `json:"id" example:"3249_46342_1"`
+	Coordinates [][]float64 `json:"coordinates"`
+}
+
+// StopPattern is an ordered list of stops which some of the trips of a route
+// call at, Trips is how many of them do
+type StopPattern struct {
+	ID          string   `json:"id" example:"3249_46342:0:1"`
+	DirectionID int      `json:"direction_id" example:"0"`
+	Headsign    string   `json:"headsign,omitempty" example:"Dun Laoghaire"`
+	ShapeID     string   `json:"shape_id,omitempty" example:"3249_46342_1"`
+	StopIDs     []string `json:"stop_ids" example:"8220DB000334,8220DB000335"`
+	Trips       int      `json:"trips" example:"42"`
+}
+
+// seconds returns a duration as a whole number of seconds, which is how
+// durations are marshalled
+func seconds(d *time.Duration) *int64 {
+	if d == nil {
+		return nil
+	}
+	s := int64(d.Round(time.Second) / time.Second)
+
+	return &s
+}
+
+func duration(s *int64) *time.Duration {
+	if s == nil {
+		return nil
+	}
+	d := time.Duration(*s) * time.Second
+
+	return &d
+}
+
+// optionalTime returns nil for the zero time so that it is left out once
+// marshalled
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
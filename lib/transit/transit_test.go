@@ -0,0 +1,31 @@
+package transit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteTypeMode(t *testing.T) {
+	t.Run("maps the basic route types", func(t *testing.T) {
+		assert.Equal(t, ModeTram, RouteTypeMode(0))
+		assert.Equal(t, ModeRail, RouteTypeMode(1))
+		assert.Equal(t, ModeRail, RouteTypeMode(2))
+		assert.Equal(t, ModeBus, RouteTypeMode(3))
+		assert.Equal(t, ModeFerry, RouteTypeMode(4))
+		assert.Equal(t, ModeBus, RouteTypeMode(11))
+	})
+
+	t.Run("maps the extended route types", func(t *testing.T) {
+		assert.Equal(t, ModeRail, RouteTypeMode(109), "expected suburban railway to be rail")
+		assert.Equal(t, ModeBus, RouteTypeMode(200), "expected coach to be a bus")
+		assert.Equal(t, ModeBus, RouteTypeMode(700))
+		assert.Equal(t, ModeTram, RouteTypeMode(900))
+		assert.Equal(t, ModeFerry, RouteTypeMode(1200))
+	})
+
+	t.Run("leaves other route types without a mode", func(t *testing.T) {
+		assert.Empty(t, RouteTypeMode(1100), "expected air service to have no mode")
+		assert.Empty(t, RouteTypeMode(-1))
+	})
+}
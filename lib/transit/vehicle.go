@@ -0,0 +1,61 @@
+package transit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type VehicleStatus string
+
+const (
+	VehicleScheduled  VehicleStatus = "scheduled"
+	VehicleIncomingAt VehicleStatus = "incoming_at"
+	VehicleStoppedAt  VehicleStatus = "stopped_at"
+	VehicleInTransit  VehicleStatus = "in_transit_to"
+	VehicleTerminated VehicleStatus = "terminated"
+)
+
+// Vehicle is the latest known position of a vehicle, every source is mapped to
+// it so that vehicles are served the same way wherever they came from.
+// Optional values are left nil or empty when the source lacks them
+type Vehicle struct {
+	ID        string         `json:"id" example:"V1"`
+	Source    string         `json:"source" example:"gtfsr"`
+	Operator  string         `json:"operator,omitempty" example:"7778019"`
+	Mode      Mode           `json:"mode,omitempty" example:"bus"`
+	RouteID   string         `json:"route_id,omitempty" example:"3249_46342"`
+	TripID    string         `json:"trip_id,omitempty" example:"3249_10466"`
+	Label     string         `json:"label,omitempty" example:"SG1"`
+	Headsign  string         `json:"headsign,omitempty" example:"Liffey Valley"`
+	Latitude  float64        `json:"latitude" example:"53.3498"`
+	Longitude float64        `json:"longitude" example:"-6.2603"`
+	Bearing   *float64       `json:"bearing,omitempty" example:"90"`
+	Speed     *float64       `json:"speed,omitempty" example:"8.5"`
+	Status    VehicleStatus  `json:"status,omitempty" example:"in_transit_to"`
+	StopID    string         `json:"stop_id,omitempty" example:"8220DB000334"`
+	Delay     *time.Duration `json:"delay,omitempty" swaggertype:"integer" example:"120"` // seconds, negative when early
+	Timestamp time.Time      `json:"timestamp" example:"2025-01-21T12:00:00Z"`
+}
+
+func (v Vehicle) MarshalJSON() ([]byte, error) {
+	type vehicle Vehicle
+
+	return json.Marshal(struct {
+		vehicle
+		Delay *int64 `json:"delay,omitempty"`
+	}{vehicle(v), seconds(v.Delay)})
+}
+
+func (v *Vehicle) UnmarshalJSON(b []byte) error {
+	type vehicle Vehicle
+	aux := struct {
+		*vehicle
+		Delay *int64 `json:"delay"`
+	}{vehicle: (*vehicle)(v)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	v.Delay = duration(aux.Delay)
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package transit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehicleJSON(t *testing.T) {
+	bearing := 90.0
+	delay := 2*time.Minute + 400*time.Millisecond
+	v := Vehicle{
+		ID:        "V1",
+		Source:    "gtfsr",
+		Mode:      ModeBus,
+		Latitude:  53.3498,
+		Longitude: -6.2603,
+		Bearing:   &bearing,
+		Delay:     &delay,
+		Timestamp: *at("2025-01-21T12:00:00Z"),
+	}
+
+	t.Run("marshals the delay in seconds", func(t *testing.T) {
+		b, err := json.Marshal(v)
+		assert.NoError(t, err)
+		assert.JSONEq(t, `{
+			"id": "V1",
+			"source": "gtfsr",
+			"mode": "bus",
+			"latitude": 53.3498,
+			"longitude": -6.2603,
+			"bearing": 90,
+			"delay": 120,
+			"timestamp": "2025-01-21T12:00:00Z"
+		}`, string(b))
+	})
+
+	t.Run("leaves out a missing delay", func(t *testing.T) {
+		b, err := json.Marshal(Vehicle{ID: "V1"})
+		assert.NoError(t, err)
+		assert.NotContains(t, string(b), "delay")
+	})
+
+	t.Run("unmarshals what it marshals", func(t *testing.T) {
+		b, err := json.Marshal(v)
+		assert.NoError(t, err)
+
+		var got Vehicle
+		assert.NoError(t, json.Unmarshal(b, &got))
+		want := v
+		rounded := 2 * time.Minute
+		want.Delay = &rounded
+		assert.Equal(t, want, got)
+	})
+
+	t.Run("fails on invalid JSON", func(t *testing.T) {
+		var got Vehicle
+		assert.Error(t, json.Unmarshal([]byte(`{"delay": "late"}`), &got))
+	})
+}
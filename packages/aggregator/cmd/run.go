@@ -1,11 +1,168 @@
 package cmd
 
-import "github.com/rs/zerolog/log"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/handoff"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/gtfsr"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/irishrail"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/luas"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/scheduler"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/siri"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+var (
+	Providers []provider.Provider
+	Publisher *handoff.Publisher
+)
+
+var (
+	cancelMu sync.Mutex
+	cancel   context.CancelFunc
+	stopped  chan struct{}
+)
+
+// registry holds every provider the aggregator can run, which of them are run
+// is decided by the providers setting
+func registry() *provider.Registry {
+	r := provider.NewRegistry()
+	r.Register("gtfsr", gtfsr.FromConfig)
+	r.Register("irishrail", irishrail.FromConfig)
+	r.Register("luas", luas.FromConfig)
+	r.Register("siri", siri.FromConfig)
+
+	return r
+}
 
 func Start() {
+	viper.SetEnvPrefix("WML")
+	viper.AutomaticEnv()
+
+	cfg := config.Load(viper.GetViper())
+	issues := cfg.Verify()
+	if len(issues) != 0 {
+		log.Log().Strs("config_issues", issues).Msg("configuration issues")
+
+		return
+	}
+	log.Log().Any("config", cfg).Msg("got config")
+
+	zerologLevel := cfg.GetZeroLogLevel()
+	zerolog.SetGlobalLevel(zerologLevel)
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	defer close(done)
+	cancelMu.Lock()
+	cancel, stopped = stop, done
+	cancelMu.Unlock()
+
 	log.Info().Msg("starting server")
+	entries, err := registry().Build(cfg)
+	if err != nil {
+		log.Error().Err(err).Msg("could not create providers")
+
+		return
+	}
+
+	if len(entries) == 0 {
+		log.Warn().Msg("no providers configured")
+	}
+
+	providers := make([]provider.Provider, 0, len(entries))
+	sched := scheduler.New()
+	for _, e := range entries {
+		log.Info().
+			Str("provider", e.Provider.Name()).
+			Strs("operators", e.Provider.Operators()).
+			Dur("interval", e.Interval).
+			Msg("starting provider")
+
+		providers = append(providers, e.Provider)
+		sched.Add(e.Provider, e.Interval)
+	}
+	Providers = providers
+
+	if cfg.Sink == "handoff" {
+		srv, err := serveHandoff(cfg.Handoff.ListenAddress, providers, sched)
+		if err != nil {
+			log.Error().Err(err).Str("listen_address", cfg.Handoff.ListenAddress).Msg("could not serve handoff")
+
+			return
+		}
+		defer srv.Close()
+	}
+
+	sched.Run(ctx)
 }
 
+// serveHandoff publishes the dataset of every provider after each poll and
+// streams it to the API from /v0/handoff
+func serveHandoff(addr string, providers []provider.Provider, sched *scheduler.Scheduler) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen: %w", err)
+	}
+
+	publisher := handoff.NewPublisher()
+	Publisher = publisher
+
+	// Polls finish concurrently, merging under the lock keeps an older
+	// dataset from being published after a newer one
+	var mu sync.Mutex
+	sched.Polled = func(provider.Provider) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		d := transit.Dataset{}
+		for _, p := range providers {
+			d.Merge(p.Dataset())
+		}
+		publisher.Publish(d)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /v0/handoff", publisher)
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 100 * time.Millisecond,
+	}
+	go func() {
+		if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Msg("could not serve handoff")
+		}
+	}()
+	log.Info().Str("listen_address", ln.Addr().String()).Msg("serving handoff")
+
+	return srv, nil
+}
+
+// Stop cancels every provider and waits for the polls in progress to return,
+// so that nothing is still polling when the process exits
 func Stop() {
 	log.Log().Msg("stopping server")
+
+	cancelMu.Lock()
+	stop, done := cancel, stopped
+	cancelMu.Unlock()
+	if stop != nil {
+		stop()
+	}
+	if done != nil {
+		<-done
+	}
+
+	log.Log().Msg("stopped server successfully")
 }
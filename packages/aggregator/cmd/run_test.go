@@ -1,21 +1,49 @@
 package cmd
 
 import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/mcgovman/wheresmylift/lib/go-test-utils"
+	"github.com/mcgovman/wheresmylift/lib/handoff"
+	libsiri "github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/gtfsr"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/irishrail"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/luas"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/siri"
 	"github.com/nsf/jsondiff"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
 )
 
+// providerNamed returns the running provider with the given name, or nil
+func providerNamed(name string) provider.Provider {
+	for _, p := range Providers {
+		if p.Name() == name {
+			return p
+		}
+	}
+
+	return nil
+}
+
 var assertionStepTimeout time.Duration = 10 * time.Second
 var assertionPollInterval time.Duration = 100 * time.Millisecond
 
 func TestStart(t *testing.T) {
 	t.Run("cmd will start", func(t *testing.T) {
+		t.Setenv("WML_LOG_LEVEL", "info")
 		logSink := test.LogSink{}
 		log.Logger = zerolog.New(&logSink)
 
@@ -23,6 +51,19 @@ func TestStart(t *testing.T) {
 			Start()
 		}()
 
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.True(
+				c,
+				logSink.ContainsLog(
+					map[string]interface{}{
+						"config":  config.Config{LogLevel: "info", Sink: "memory"},
+						"message": "got config",
+					},
+					jsondiff.FullMatch,
+				),
+				"could not find config log",
+			)
+		}, assertionStepTimeout, assertionPollInterval)
 		assert.EventuallyWithT(t, func(c *assert.CollectT) {
 			assert.True(
 				c,
@@ -36,12 +77,380 @@ func TestStart(t *testing.T) {
 				"could not find server starting log",
 			)
 		}, assertionStepTimeout, assertionPollInterval)
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.True(
+				c,
+				logSink.ContainsLog(
+					map[string]interface{}{
+						"level":   "warn",
+						"message": "no providers configured",
+					},
+					jsondiff.FullMatch,
+				),
+				"could not find unconfigured feed log",
+			)
+		}, assertionStepTimeout, assertionPollInterval)
+		assert.Len(t, logSink.Logs, 3, "expected length of logs")
+	})
+
+	t.Run("cmd will poll the configured GTFS-Realtime feeds", func(t *testing.T) {
+		t.Setenv("WML_LOG_LEVEL", "info")
+		srv := httptest.NewServer(http.FileServer(http.Dir("../internal/gtfsr/testdata")))
+		defer srv.Close()
+		t.Setenv("WML_GTFSR_VEHICLE_POSITIONS_URL", srv.URL+"/vehicle_positions.pb")
+		t.Setenv("WML_GTFSR_TRIP_UPDATES_URL", srv.URL+"/trip_updates.pb")
+		t.Setenv("WML_GTFSR_ALERTS_URL", srv.URL+"/alerts.pb")
+		t.Setenv("WML_GTFSR_POLL_INTERVAL", "1h")
+
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		done := make(chan struct{})
+		go func() {
+			Start()
+			close(done)
+		}()
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			p, ok := providerNamed("gtfsr").(*gtfsr.Provider)
+			if assert.True(c, ok, "expected GTFS-Realtime provider") {
+				assert.Equal(c, 3, p.VehiclePositions.Len(), "expected recorded vehicles")
+				assert.Equal(c, 3, p.TripUpdates.Len(), "expected recorded trips")
+				assert.NotZero(c, len(p.Alerts.Alerts()), "expected recorded alerts")
+			}
+		}, assertionStepTimeout, assertionPollInterval)
+
+		Stop()
+		assert.Eventually(t, func() bool {
+			select {
+			case <-done:
+				return true
+			default:
+				return false
+			}
+		}, assertionStepTimeout, assertionPollInterval, "expected start to return once stopped")
+	})
+
+	t.Run("cmd will poll the Irish Rail realtime API", func(t *testing.T) {
+		t.Setenv("WML_LOG_LEVEL", "info")
+		mux := http.NewServeMux()
+		mux.HandleFunc("/getCurrentTrainsXML", func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, "../internal/irishrail/testdata/current_trains.xml")
+		})
+		mux.HandleFunc("/getStationDataByCodeXML", func(w http.ResponseWriter, r *http.Request) {
+			http.ServeFile(w, r, "../internal/irishrail/testdata/station_data_mhide.xml")
+		})
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+		t.Setenv("WML_IRISHRAIL_URL", srv.URL)
+		t.Setenv("WML_IRISHRAIL_STATIONS", "mhide, ")
+		t.Setenv("WML_IRISHRAIL_POLL_INTERVAL", "1h")
+
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		done := make(chan struct{})
+		go func() {
+			Start()
+			close(done)
+		}()
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			p, ok := providerNamed("irishrail").(*irishrail.Provider)
+			if assert.True(c, ok, "expected Irish Rail provider") {
+				assert.Equal(c, 4, p.Len(), "expected recorded trains")
+				assert.Len(c, p.Departures("MHIDE"), 3, "expected recorded departures")
+			}
+		}, assertionStepTimeout, assertionPollInterval)
+
+		Stop()
+		assert.Eventually(t, func() bool {
+			select {
+			case <-done:
+				return true
+			default:
+				return false
+			}
+		}, assertionStepTimeout, assertionPollInterval, "expected start to return once stopped")
+	})
+
+	t.Run("cmd will poll the Luas forecasting API", func(t *testing.T) {
+		t.Setenv("WML_LOG_LEVEL", "info")
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			file := "stops.xml"
+			if r.URL.Query().Get("action") == "forecast" {
+				file = "forecast_ran.xml"
+			}
+			http.ServeFile(w, r, "../internal/luas/testdata/"+file)
+		}))
+		defer srv.Close()
+		t.Setenv("WML_LUAS_URL", srv.URL)
+		t.Setenv("WML_LUAS_STOPS", "ran")
+		t.Setenv("WML_LUAS_POLL_INTERVAL", "1h")
+
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		done := make(chan struct{})
+		go func() {
+			Start()
+			close(done)
+		}()
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			p, ok := providerNamed("luas").(*luas.Provider)
+			if assert.True(c, ok, "expected Luas provider") {
+				assert.Len(c, p.Departures("RAN"), 4, "expected recorded departures")
+				assert.Len(c, p.LineStatuses(), 1, "expected recorded line status")
+			}
+		}, assertionStepTimeout, assertionPollInterval)
+
+		Stop()
+		assert.Eventually(t, func() bool {
+			select {
+			case <-done:
+				return true
+			default:
+				return false
+			}
+		}, assertionStepTimeout, assertionPollInterval, "expected start to return once stopped")
+	})
+
+	t.Run("cmd will stream what it polls to handoff subscribers", func(t *testing.T) {
+		t.Setenv("WML_LOG_LEVEL", "info")
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			file := "stops.xml"
+			if r.URL.Query().Get("action") == "forecast" {
+				file = "forecast_ran.xml"
+			}
+			http.ServeFile(w, r, "../internal/luas/testdata/"+file)
+		}))
+		defer srv.Close()
+		t.Setenv("WML_LUAS_URL", srv.URL)
+		t.Setenv("WML_LUAS_STOPS", "ran")
+		t.Setenv("WML_LUAS_POLL_INTERVAL", "1h")
+		t.Setenv("WML_SINK", "handoff")
+		t.Setenv("WML_HANDOFF_LISTEN_ADDRESS", "127.0.0.1:18081")
+
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		done := make(chan struct{})
+		go func() {
+			Start()
+			close(done)
+		}()
+
+		var mu sync.Mutex
+		var got transit.Dataset
+		sub := handoff.NewSubscriber("http://127.0.0.1:18081/v0/handoff", func(d transit.Dataset) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = d
+		})
+		sub.RetryInterval = assertionPollInterval
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go sub.Run(ctx)
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			mu.Lock()
+			defer mu.Unlock()
+			assert.Len(c, got.Departures, 4, "expected handed off departures")
+			assert.Len(c, got.LineStatuses, 1, "expected handed off line status")
+		}, assertionStepTimeout, assertionPollInterval)
+
+		Stop()
+		assert.Eventually(t, func() bool {
+			select {
+			case <-done:
+				return true
+			default:
+				return false
+			}
+		}, assertionStepTimeout, assertionPollInterval, "expected start to return once stopped")
+		assert.Eventually(t, func() bool { return !sub.Connected() }, assertionStepTimeout, assertionPollInterval, "expected the subscriber to be disconnected")
+	})
+
+	t.Run("cmd will hand off what a SIRI producer pushes", func(t *testing.T) {
+		t.Setenv("WML_LOG_LEVEL", "info")
+		delivery, err := os.ReadFile("../internal/siri/testdata/vehicle_monitoring.xml")
+		assert.NoError(t, err, "could not read recorded delivery")
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var req libsiri.Siri
+			if err := xml.NewDecoder(r.Body).Decode(&req); err != nil || req.SubscriptionRequest == nil {
+				return
+			}
+			_, _ = w.Write([]byte(`<Siri xmlns="http://www.siri.org.uk/siri" version="2.0"><SubscriptionResponse>` +
+				`<ResponseStatus><Status>true</Status></ResponseStatus></SubscriptionResponse></Siri>`))
+			ref := `<VehicleMonitoringDelivery version="2.0"><SubscriptionRef>` +
+				req.SubscriptionRequest.VehicleMonitoringSubscriptionRequest.SubscriptionIdentifier + `</SubscriptionRef>`
+			body := strings.Replace(string(delivery), `<VehicleMonitoringDelivery version="2.0">`, ref, 1)
+			go func() {
+				resp, err := http.Post(req.SubscriptionRequest.ConsumerAddress, "application/xml", strings.NewReader(body))
+				if err == nil {
+					resp.Body.Close()
+				}
+			}()
+		}))
+		defer srv.Close()
+		t.Setenv("WML_SIRI_URL", srv.URL)
+		t.Setenv("WML_SIRI_MODE", "subscribe")
+		t.Setenv("WML_SIRI_LISTEN_ADDRESS", "127.0.0.1:18082")
+		t.Setenv("WML_SIRI_CONSUMER_ADDRESS", "http://127.0.0.1:18082/siri")
+		t.Setenv("WML_SINK", "handoff")
+		t.Setenv("WML_HANDOFF_LISTEN_ADDRESS", "127.0.0.1:18083")
+
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		done := make(chan struct{})
+		go func() {
+			Start()
+			close(done)
+		}()
+
+		var mu sync.Mutex
+		var got transit.Dataset
+		sub := handoff.NewSubscriber("http://127.0.0.1:18083/v0/handoff", func(d transit.Dataset) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = d
+		})
+		sub.RetryInterval = assertionPollInterval
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go sub.Run(ctx)
+
+		// Only the activity without a ValidUntilTime has not expired
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			mu.Lock()
+			defer mu.Unlock()
+			if assert.Len(c, got.Vehicles, 1, "expected handed off vehicle") {
+				assert.Equal(c, "GAI-11107", got.Vehicles[0].ID)
+			}
+		}, assertionStepTimeout, assertionPollInterval)
+		_, ok := providerNamed("siri").(*siri.Subscriber)
+		assert.True(t, ok, "expected the SIRI producer to be subscribed to")
+
+		Stop()
+		assert.Eventually(t, func() bool {
+			select {
+			case <-done:
+				return true
+			default:
+				return false
+			}
+		}, assertionStepTimeout, assertionPollInterval, "expected start to return once stopped")
+	})
+
+	t.Run("cmd will only run the enabled providers", func(t *testing.T) {
+		t.Setenv("WML_LOG_LEVEL", "info")
+		srv := httptest.NewServer(http.FileServer(http.Dir("../internal/gtfsr/testdata")))
+		defer srv.Close()
+		t.Setenv("WML_GTFSR_VEHICLE_POSITIONS_URL", srv.URL+"/vehicle_positions.pb")
+		t.Setenv("WML_LUAS_URL", srv.URL)
+		t.Setenv("WML_LUAS_POLL_INTERVAL", "1h")
+		t.Setenv("WML_PROVIDERS", "Luas")
+
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		done := make(chan struct{})
+		go func() {
+			Start()
+			close(done)
+		}()
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.True(
+				c,
+				logSink.ContainsLog(
+					map[string]interface{}{
+						"level":     "info",
+						"provider":  "luas",
+						"operators": []string{"luas"},
+						"interval":  3600000,
+						"message":   "starting provider",
+					},
+					jsondiff.FullMatch,
+				),
+				"could not find starting provider log",
+			)
+		}, assertionStepTimeout, assertionPollInterval)
+		assert.Nil(t, providerNamed("gtfsr"), "expected provider which is not enabled not to run")
+
+		Stop()
+		assert.Eventually(t, func() bool {
+			select {
+			case <-done:
+				return true
+			default:
+				return false
+			}
+		}, assertionStepTimeout, assertionPollInterval, "expected start to return once stopped")
+	})
+
+	t.Run("cmd will fail with an invalid config", func(t *testing.T) {
+		t.Setenv("WML_LOG_LEVEL", "some random level")
+		t.Setenv("WML_PROVIDERS", "irishrail")
+		t.Setenv("WML_LUAS_POLL_INTERVAL", "soon")
+
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		Start()
+		assert.True(
+			t,
+			logSink.ContainsLog(
+				map[string]interface{}{
+					"config_issues": []string{
+						"An invalid log level was specified",
+						"The provider irishrail is enabled but not configured",
+						"The Luas poll interval soon is invalid",
+					},
+					"message": "configuration issues",
+				},
+				jsondiff.FullMatch,
+			),
+			"could not find config issues log",
+		)
 		assert.Len(t, logSink.Logs, 1, "expected length of logs")
 	})
 }
 
 func TestStop(t *testing.T) {
+	t.Run("will wait for the polls in progress", func(t *testing.T) {
+		t.Setenv("WML_LOG_LEVEL", "info")
+		polling := make(chan struct{})
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(polling)
+			<-r.Context().Done()
+		}))
+		defer srv.Close()
+		t.Setenv("WML_GTFSR_VEHICLE_POSITIONS_URL", srv.URL)
+
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		done := make(chan struct{})
+		go func() {
+			Start()
+			close(done)
+		}()
+
+		<-polling
+		Stop()
+		select {
+		case <-done:
+		default:
+			assert.Fail(t, "expected start to have returned once stopped")
+		}
+		assert.Zero(t, providerNamed("gtfsr").Health().ConsecutiveFailures, "expected the cancelled poll not to be retried")
+	})
+
 	t.Run("will stop the server", func(t *testing.T) {
+		t.Setenv("WML_LOG_LEVEL", "info")
 		logSink := test.LogSink{}
 		log.Logger = zerolog.New(&logSink)
 
@@ -49,6 +458,19 @@ func TestStop(t *testing.T) {
 			Start()
 		}()
 
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.True(
+				c,
+				logSink.ContainsLog(
+					map[string]interface{}{
+						"config":  config.Config{LogLevel: "info", Sink: "memory"},
+						"message": "got config",
+					},
+					jsondiff.FullMatch,
+				),
+				"could not find config log",
+			)
+		}, assertionStepTimeout, assertionPollInterval)
 		assert.EventuallyWithT(t, func(c *assert.CollectT) {
 			assert.True(
 				c,
@@ -62,7 +484,20 @@ func TestStop(t *testing.T) {
 				"could not find server starting log",
 			)
 		}, assertionStepTimeout, assertionPollInterval)
-		assert.Len(t, logSink.Logs, 1, "expected length of logs")
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.True(
+				c,
+				logSink.ContainsLog(
+					map[string]interface{}{
+						"level":   "warn",
+						"message": "no providers configured",
+					},
+					jsondiff.FullMatch,
+				),
+				"could not find unconfigured feed log",
+			)
+		}, assertionStepTimeout, assertionPollInterval)
+		assert.Len(t, logSink.Logs, 3, "expected length of logs")
 
 		Stop()
 		assert.EventuallyWithT(t, func(c *assert.CollectT) {
@@ -77,6 +512,16 @@ func TestStop(t *testing.T) {
 				"could not find stopping server log",
 			)
 		}, assertionStepTimeout, assertionPollInterval)
-		assert.Len(t, logSink.Logs, 2, "expected length of logs")
+		assert.True(
+			t,
+			logSink.ContainsLog(
+				map[string]interface{}{
+					"message": "stopped server successfully",
+				},
+				jsondiff.FullMatch,
+			),
+			"could not find stopped server log",
+		)
+		assert.Len(t, logSink.Logs, 5, "expected length of logs")
 	})
 }
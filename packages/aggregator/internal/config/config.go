@@ -0,0 +1,334 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+)
+
+const DefaultPollInterval = 30 * time.Second
+
+// Providers is every provider the aggregator knows how to run
+var Providers = []string{"gtfsr", "irishrail", "luas", "siri"}
+
+// SiriModes is how the SIRI provider gets its deliveries, poll requests them
+// and subscribe has them pushed to a receiver
+var SiriModes = []string{"poll", "subscribe"}
+
+// Sinks is every place the aggregator can send what it gathers to, memory
+// keeps it in the aggregator and handoff streams it to the API
+var Sinks = []string{"memory", "handoff"}
+
+type GTFSR struct {
+	VehiclePositionsURL string   `mapstructure:"vehicle_positions_url" yaml:"vehicle_positions_url"`
+	TripUpdatesURL      string   `mapstructure:"trip_updates_url" yaml:"trip_updates_url"`
+	AlertsURL           string   `mapstructure:"alerts_url" yaml:"alerts_url"`
+	APIKey              string   `mapstructure:"api_key" yaml:"api_key" json:"-"`
+	Operators           []string `mapstructure:"operators" yaml:"operators"`
+	PollInterval        string   `mapstructure:"poll_interval" yaml:"poll_interval"`
+}
+
+type IrishRail struct {
+	URL          string   `mapstructure:"url" yaml:"url"`
+	Stations     []string `mapstructure:"stations" yaml:"stations"`
+	PollInterval string   `mapstructure:"poll_interval" yaml:"poll_interval"`
+}
+
+type Luas struct {
+	URL          string   `mapstructure:"url" yaml:"url"`
+	Stops        []string `mapstructure:"stops" yaml:"stops"`
+	PollInterval string   `mapstructure:"poll_interval" yaml:"poll_interval"`
+}
+
+// Siri is a SIRI VehicleMonitoring producer, ListenAddress and
+// ConsumerAddress are only used when subscribing. ConsumerAddress is the URL
+// the producer pushes deliveries to, which reaches the receiver listening on
+// ListenAddress
+type Siri struct {
+	URL             string   `mapstructure:"url" yaml:"url"`
+	Mode            string   `mapstructure:"mode" yaml:"mode"`
+	RequestorRef    string   `mapstructure:"requestor_ref" yaml:"requestor_ref"`
+	Operators       []string `mapstructure:"operators" yaml:"operators"`
+	ListenAddress   string   `mapstructure:"listen_address" yaml:"listen_address"`
+	ConsumerAddress string   `mapstructure:"consumer_address" yaml:"consumer_address"`
+	PollInterval    string   `mapstructure:"poll_interval" yaml:"poll_interval"`
+}
+
+// Handoff is where the API subscribes to the handoff stream
+type Handoff struct {
+	ListenAddress string `mapstructure:"listen_address" yaml:"listen_address"`
+}
+
+// Config describes the configuration for the aggregator
+type Config struct {
+	LogLevel  string    `mapstructure:"log_level" yaml:"log_level"`
+	Providers []string  `mapstructure:"providers" yaml:"providers"`
+	Sink      string    `mapstructure:"sink" yaml:"sink"`
+	GTFSR     GTFSR     `mapstructure:"gtfsr" yaml:"gtfsr"`
+	IrishRail IrishRail `mapstructure:"irishrail" yaml:"irishrail"`
+	Luas      Luas      `mapstructure:"luas" yaml:"luas"`
+	Siri      Siri      `mapstructure:"siri" yaml:"siri"`
+	Handoff   Handoff   `mapstructure:"handoff" yaml:"handoff"`
+}
+
+// Load reads the configuration from v, lists are comma separated and the
+// sink defaults to memory
+func Load(v *viper.Viper) Config {
+	cfg := Config{
+		LogLevel:  v.GetString("LOG_LEVEL"),
+		Providers: splitList(strings.ToLower(v.GetString("PROVIDERS"))),
+		Sink:      v.GetString("SINK"),
+		GTFSR: GTFSR{
+			VehiclePositionsURL: v.GetString("GTFSR_VEHICLE_POSITIONS_URL"),
+			TripUpdatesURL:      v.GetString("GTFSR_TRIP_UPDATES_URL"),
+			AlertsURL:           v.GetString("GTFSR_ALERTS_URL"),
+			APIKey:              v.GetString("GTFSR_API_KEY"),
+			Operators:           splitList(v.GetString("GTFSR_OPERATORS")),
+			PollInterval:        v.GetString("GTFSR_POLL_INTERVAL"),
+		},
+		IrishRail: IrishRail{
+			URL:          v.GetString("IRISHRAIL_URL"),
+			Stations:     splitList(strings.ToUpper(v.GetString("IRISHRAIL_STATIONS"))),
+			PollInterval: v.GetString("IRISHRAIL_POLL_INTERVAL"),
+		},
+		Luas: Luas{
+			URL:          v.GetString("LUAS_URL"),
+			Stops:        splitList(strings.ToUpper(v.GetString("LUAS_STOPS"))),
+			PollInterval: v.GetString("LUAS_POLL_INTERVAL"),
+		},
+		Siri: Siri{
+			URL:             v.GetString("SIRI_URL"),
+			Mode:            strings.ToLower(v.GetString("SIRI_MODE")),
+			RequestorRef:    v.GetString("SIRI_REQUESTOR_REF"),
+			Operators:       splitList(v.GetString("SIRI_OPERATORS")),
+			ListenAddress:   v.GetString("SIRI_LISTEN_ADDRESS"),
+			ConsumerAddress: v.GetString("SIRI_CONSUMER_ADDRESS"),
+			PollInterval:    v.GetString("SIRI_POLL_INTERVAL"),
+		},
+		Handoff: Handoff{
+			ListenAddress: v.GetString("HANDOFF_LISTEN_ADDRESS"),
+		},
+	}
+	if cfg.Sink == "" {
+		cfg.Sink = "memory"
+	}
+
+	return cfg
+}
+
+func (c *Config) GetZeroLogLevel() zerolog.Level {
+	switch c.LogLevel {
+	case "trace":
+		return zerolog.TraceLevel
+	case "disabled":
+		return zerolog.Disabled
+	case "panic":
+		return zerolog.PanicLevel
+	case "fatal":
+		return zerolog.FatalLevel
+	case "error":
+		return zerolog.ErrorLevel
+	case "warn":
+		return zerolog.WarnLevel
+	case "info":
+		return zerolog.InfoLevel
+	case "debug":
+		return zerolog.DebugLevel
+	default:
+		return zerolog.NoLevel
+	}
+}
+
+// Configured reports whether the settings a provider needs to run are set
+func (c *Config) Configured(name string) bool {
+	switch name {
+	case "gtfsr":
+		return c.GTFSR.VehiclePositionsURL != "" || c.GTFSR.TripUpdatesURL != "" || c.GTFSR.AlertsURL != ""
+	case "irishrail":
+		return c.IrishRail.URL != ""
+	case "luas":
+		return c.Luas.URL != ""
+	case "siri":
+		return c.Siri.URL != ""
+	default:
+		return false
+	}
+}
+
+// PollInterval returns how often a provider is polled, it defaults to
+// DefaultPollInterval
+func (c *Config) PollInterval(name string) time.Duration {
+	switch name {
+	case "gtfsr":
+		return pollInterval(c.GTFSR.PollInterval)
+	case "irishrail":
+		return pollInterval(c.IrishRail.PollInterval)
+	case "luas":
+		return pollInterval(c.Luas.PollInterval)
+	case "siri":
+		return pollInterval(c.Siri.PollInterval)
+	default:
+		return DefaultPollInterval
+	}
+}
+
+func (g *GTFSR) Verify() []string {
+	issues := []string{}
+	feeds := []struct{ name, url string }{
+		{"vehicle positions", g.VehiclePositionsURL},
+		{"trip updates", g.TripUpdatesURL},
+		{"alerts", g.AlertsURL},
+	}
+	for _, feed := range feeds {
+		if feed.url != "" && !validURL(feed.url) {
+			issues = append(issues, fmt.Sprintf("The GTFS-Realtime %s URL %s is invalid", feed.name, feed.url))
+		}
+	}
+
+	if !validPollInterval(g.PollInterval) {
+		issues = append(issues, fmt.Sprintf("The GTFS-Realtime poll interval %s is invalid", g.PollInterval))
+	}
+
+	return issues
+}
+
+func (i *IrishRail) Verify() []string {
+	issues := []string{}
+	if i.URL != "" && !validURL(i.URL) {
+		issues = append(issues, fmt.Sprintf("The Irish Rail URL %s is invalid", i.URL))
+	}
+
+	if !validPollInterval(i.PollInterval) {
+		issues = append(issues, fmt.Sprintf("The Irish Rail poll interval %s is invalid", i.PollInterval))
+	}
+
+	return issues
+}
+
+func (l *Luas) Verify() []string {
+	issues := []string{}
+	if l.URL != "" && !validURL(l.URL) {
+		issues = append(issues, fmt.Sprintf("The Luas URL %s is invalid", l.URL))
+	}
+
+	if !validPollInterval(l.PollInterval) {
+		issues = append(issues, fmt.Sprintf("The Luas poll interval %s is invalid", l.PollInterval))
+	}
+
+	return issues
+}
+
+// Subscribes reports whether deliveries are pushed rather than polled, the
+// mode defaults to poll
+func (s *Siri) Subscribes() bool {
+	return s.Mode == "subscribe"
+}
+
+func (s *Siri) Verify() []string {
+	issues := []string{}
+	if s.URL != "" && !validURL(s.URL) {
+		issues = append(issues, fmt.Sprintf("The SIRI URL %s is invalid", s.URL))
+	}
+
+	if s.Mode != "" && !slices.Contains(SiriModes, s.Mode) {
+		issues = append(issues, fmt.Sprintf("The SIRI mode %s is invalid", s.Mode))
+	}
+
+	if s.URL != "" && s.Subscribes() {
+		if _, _, err := net.SplitHostPort(s.ListenAddress); err != nil {
+			issues = append(issues, fmt.Sprintf("The SIRI listen address %s is invalid", s.ListenAddress))
+		}
+		if !validURL(s.ConsumerAddress) {
+			issues = append(issues, fmt.Sprintf("The SIRI consumer address %s is invalid", s.ConsumerAddress))
+		}
+	}
+
+	if !validPollInterval(s.PollInterval) {
+		issues = append(issues, fmt.Sprintf("The SIRI poll interval %s is invalid", s.PollInterval))
+	}
+
+	return issues
+}
+
+func (h *Handoff) Verify() []string {
+	issues := []string{}
+	if _, _, err := net.SplitHostPort(h.ListenAddress); err != nil {
+		issues = append(issues, fmt.Sprintf("The handoff listen address %s is invalid", h.ListenAddress))
+	}
+
+	return issues
+}
+
+func (c *Config) Verify() []string {
+	issues := []string{}
+
+	if c.GetZeroLogLevel() == zerolog.NoLevel {
+		issues = append(issues, "An invalid log level was specified")
+	}
+
+	for _, name := range c.Providers {
+		switch {
+		case !slices.Contains(Providers, name):
+			issues = append(issues, fmt.Sprintf("The provider %s is unknown", name))
+		case !c.Configured(name):
+			issues = append(issues, fmt.Sprintf("The provider %s is enabled but not configured", name))
+		}
+	}
+
+	if !slices.Contains(Sinks, c.Sink) {
+		issues = append(issues, fmt.Sprintf("The sink %s is invalid", c.Sink))
+	}
+
+	issues = append(issues, c.GTFSR.Verify()...)
+	issues = append(issues, c.IrishRail.Verify()...)
+	issues = append(issues, c.Luas.Verify()...)
+	issues = append(issues, c.Siri.Verify()...)
+	if c.Sink == "handoff" {
+		issues = append(issues, c.Handoff.Verify()...)
+	}
+
+	return issues
+}
+
+func validURL(s string) bool {
+	u, err := url.Parse(s)
+
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validPollInterval(s string) bool {
+	if s == "" {
+		return true
+	}
+	d, err := time.ParseDuration(s)
+
+	return err == nil && d > 0
+}
+
+func pollInterval(s string) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return DefaultPollInterval
+	}
+
+	return d
+}
+
+// splitList splits a comma separated setting such as a list of station codes,
+// empty entries are dropped
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
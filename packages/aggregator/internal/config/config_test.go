@@ -0,0 +1,539 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetZeroLogLevel(t *testing.T) {
+	t.Run("check all levels can be converted", func(t *testing.T) {
+		c := &Config{}
+
+		c.LogLevel = "trace"
+		assert.Equal(t, zerolog.TraceLevel, c.GetZeroLogLevel(), "expected to return trace zerolog ENUM")
+
+		c.LogLevel = "disabled"
+		assert.Equal(t, zerolog.Disabled, c.GetZeroLogLevel(), "expected to return disabled zerolog ENUM")
+
+		c.LogLevel = "panic"
+		assert.Equal(t, zerolog.PanicLevel, c.GetZeroLogLevel(), "expected to return panic zerolog ENUM")
+
+		c.LogLevel = "fatal"
+		assert.Equal(t, zerolog.FatalLevel, c.GetZeroLogLevel(), "expected to return fatal zerolog ENUM")
+
+		c.LogLevel = "error"
+		assert.Equal(t, zerolog.ErrorLevel, c.GetZeroLogLevel(), "expected to return error zerolog ENUM")
+
+		c.LogLevel = "warn"
+		assert.Equal(t, zerolog.WarnLevel, c.GetZeroLogLevel(), "expected to return warn zerolog ENUM")
+
+		c.LogLevel = "info"
+		assert.Equal(t, zerolog.InfoLevel, c.GetZeroLogLevel(), "expected to return info zerolog ENUM")
+
+		c.LogLevel = "debug"
+		assert.Equal(t, zerolog.DebugLevel, c.GetZeroLogLevel(), "expected to return debug zerolog ENUM")
+
+		c.LogLevel = "dummy"
+		assert.Equal(t, zerolog.NoLevel, c.GetZeroLogLevel(), "expected to return nolevel zerolog ENUM")
+	})
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("reads every setting", func(t *testing.T) {
+		v := viper.New()
+		v.Set("LOG_LEVEL", "info")
+		v.Set("PROVIDERS", "GTFSR, luas,")
+		v.Set("SINK", "memory")
+		v.Set("GTFSR_VEHICLE_POSITIONS_URL", "https://api.nationaltransport.ie/gtfsr/v2/Vehicles")
+		v.Set("GTFSR_API_KEY", "secret")
+		v.Set("GTFSR_OPERATORS", "7778019, 7778020")
+		v.Set("GTFSR_POLL_INTERVAL", "1m")
+		v.Set("IRISHRAIL_URL", "http://api.irishrail.ie/realtime/realtime.asmx")
+		v.Set("IRISHRAIL_STATIONS", "mhide, CNLLY,")
+		v.Set("LUAS_URL", "https://luasforecasts.rpa.ie/xml/get.ashx")
+		v.Set("LUAS_STOPS", "ran")
+		v.Set("SIRI_URL", "https://siri.example.ie/vm")
+		v.Set("SIRI_MODE", "Subscribe")
+		v.Set("SIRI_REQUESTOR_REF", "wheresmylift")
+		v.Set("SIRI_OPERATORS", "goahead")
+		v.Set("SIRI_LISTEN_ADDRESS", ":8082")
+		v.Set("SIRI_CONSUMER_ADDRESS", "https://aggregator.example.ie/siri")
+		v.Set("SIRI_POLL_INTERVAL", "10s")
+		v.Set("HANDOFF_LISTEN_ADDRESS", ":8081")
+
+		assert.Equal(t, Config{
+			LogLevel:  "info",
+			Providers: []string{"gtfsr", "luas"},
+			Sink:      "memory",
+			GTFSR: GTFSR{
+				VehiclePositionsURL: "https://api.nationaltransport.ie/gtfsr/v2/Vehicles",
+				APIKey:              "secret",
+				Operators:           []string{"7778019", "7778020"},
+				PollInterval:        "1m",
+			},
+			IrishRail: IrishRail{
+				URL:      "http://api.irishrail.ie/realtime/realtime.asmx",
+				Stations: []string{"MHIDE", "CNLLY"},
+			},
+			Luas: Luas{
+				URL:   "https://luasforecasts.rpa.ie/xml/get.ashx",
+				Stops: []string{"RAN"},
+			},
+			Siri: Siri{
+				URL:             "https://siri.example.ie/vm",
+				Mode:            "subscribe",
+				RequestorRef:    "wheresmylift",
+				Operators:       []string{"goahead"},
+				ListenAddress:   ":8082",
+				ConsumerAddress: "https://aggregator.example.ie/siri",
+				PollInterval:    "10s",
+			},
+			Handoff: Handoff{
+				ListenAddress: ":8081",
+			},
+		}, Load(v))
+	})
+
+	t.Run("defaults the sink to memory", func(t *testing.T) {
+		assert.Equal(t, "memory", Load(viper.New()).Sink)
+	})
+}
+
+func TestConfigured(t *testing.T) {
+	c := validConfig
+	assert.True(t, c.Configured("gtfsr"), "expected gtfsr to be configured")
+	assert.True(t, c.Configured("irishrail"), "expected irishrail to be configured")
+	assert.False(t, c.Configured("luas"), "expected luas not to be configured")
+	assert.False(t, c.Configured("siri"), "expected siri not to be configured")
+	assert.False(t, c.Configured("bus-atha-cliath"), "expected unknown provider not to be configured")
+}
+
+func TestPollInterval(t *testing.T) {
+	c := validConfig
+	assert.Equal(t, time.Minute, c.PollInterval("gtfsr"))
+	assert.Equal(t, DefaultPollInterval, c.PollInterval("irishrail"), "expected the default when not set")
+	assert.Equal(t, DefaultPollInterval, c.PollInterval("bus-atha-cliath"), "expected the default for an unknown provider")
+}
+
+func TestSplitList(t *testing.T) {
+	assert.Equal(t, []string{"MHIDE", "CNLLY"}, splitList(" MHIDE,,CNLLY , "))
+	assert.Nil(t, splitList(""))
+}
+
+func TestConfigJSON(t *testing.T) {
+	b, err := json.Marshal(validConfig)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "secret", "expected the API key not to be logged")
+}
+
+var validConfig Config = Config{
+	LogLevel:  "debug",
+	Providers: []string{"gtfsr", "irishrail"},
+	Sink:      "memory",
+	GTFSR: GTFSR{
+		VehiclePositionsURL: "http://localhost/vehicles",
+		APIKey:              "secret",
+		PollInterval:        "1m",
+	},
+	IrishRail: IrishRail{
+		URL: "http://localhost/realtime.asmx",
+	},
+}
+
+type Run struct {
+	name        string
+	beforeWork  func()
+	verifyFunc  func() []string
+	issue       string
+	expectIssue bool
+}
+
+func (r *Run) verifyIssuesAndError(t *testing.T) {
+	r.beforeWork()
+	issues := r.verifyFunc()
+	if r.expectIssue {
+		assert.Contains(t, issues, r.issue, "expected an issue but was not found")
+	} else {
+		assert.NotContains(t, issues, r.issue, "expected no issue but was found")
+	}
+}
+
+func TestGTFSRVerify(t *testing.T) {
+	var testConfig Config
+
+	runs := []Run{
+		{
+			name:        "expect no vehicle positions URL issue",
+			beforeWork:  func() {},
+			issue:       "The GTFS-Realtime vehicle positions URL http://localhost/vehicles is invalid",
+			expectIssue: false,
+		},
+		{
+			name: "expect vehicle positions URL issue without a scheme",
+			beforeWork: func() {
+				testConfig.GTFSR.VehiclePositionsURL = "localhost/vehicles"
+			},
+			issue:       "The GTFS-Realtime vehicle positions URL localhost/vehicles is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect trip updates URL issue with an unsupported scheme",
+			beforeWork: func() {
+				testConfig.GTFSR.TripUpdatesURL = "ftp://localhost/trips"
+			},
+			issue:       "The GTFS-Realtime trip updates URL ftp://localhost/trips is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect alerts URL issue without a host",
+			beforeWork: func() {
+				testConfig.GTFSR.AlertsURL = "http://"
+			},
+			issue:       "The GTFS-Realtime alerts URL http:// is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect poll interval issue when it cannot be parsed",
+			beforeWork: func() {
+				testConfig.GTFSR.PollInterval = "soon"
+			},
+			issue:       "The GTFS-Realtime poll interval soon is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect poll interval issue when it is negative",
+			beforeWork: func() {
+				testConfig.GTFSR.PollInterval = "-1m"
+			},
+			issue:       "The GTFS-Realtime poll interval -1m is invalid",
+			expectIssue: true,
+		},
+	}
+
+	for _, run := range runs {
+		t.Run(run.name, func(t *testing.T) {
+			testConfig = validConfig
+			run.verifyFunc = testConfig.GTFSR.Verify
+			run.verifyIssuesAndError(t)
+		})
+	}
+}
+
+func TestIrishRailVerify(t *testing.T) {
+	var testConfig Config
+
+	runs := []Run{
+		{
+			name:        "expect no URL issue",
+			beforeWork:  func() {},
+			issue:       "The Irish Rail URL http://localhost/realtime.asmx is invalid",
+			expectIssue: false,
+		},
+		{
+			name: "expect URL issue when it cannot be parsed",
+			beforeWork: func() {
+				testConfig.IrishRail.URL = "http://%zz"
+			},
+			issue:       "The Irish Rail URL http://%zz is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect poll interval issue when it is zero",
+			beforeWork: func() {
+				testConfig.IrishRail.PollInterval = "0s"
+			},
+			issue:       "The Irish Rail poll interval 0s is invalid",
+			expectIssue: true,
+		},
+	}
+
+	for _, run := range runs {
+		t.Run(run.name, func(t *testing.T) {
+			testConfig = validConfig
+			run.verifyFunc = testConfig.IrishRail.Verify
+			run.verifyIssuesAndError(t)
+		})
+	}
+}
+
+func TestLuasVerify(t *testing.T) {
+	var testConfig Config
+
+	runs := []Run{
+		{
+			name: "expect no URL issue",
+			beforeWork: func() {
+				testConfig.Luas.URL = "https://luasforecasts.rpa.ie/xml/get.ashx"
+			},
+			issue:       "The Luas URL https://luasforecasts.rpa.ie/xml/get.ashx is invalid",
+			expectIssue: false,
+		},
+		{
+			name: "expect URL issue without a scheme",
+			beforeWork: func() {
+				testConfig.Luas.URL = "luasforecasts.rpa.ie"
+			},
+			issue:       "The Luas URL luasforecasts.rpa.ie is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect poll interval issue when it cannot be parsed",
+			beforeWork: func() {
+				testConfig.Luas.PollInterval = "30"
+			},
+			issue:       "The Luas poll interval 30 is invalid",
+			expectIssue: true,
+		},
+	}
+
+	for _, run := range runs {
+		t.Run(run.name, func(t *testing.T) {
+			testConfig = validConfig
+			run.verifyFunc = testConfig.Luas.Verify
+			run.verifyIssuesAndError(t)
+		})
+	}
+}
+
+func TestSiriVerify(t *testing.T) {
+	var testConfig Config
+
+	runs := []Run{
+		{
+			name: "expect no URL issue",
+			beforeWork: func() {
+				testConfig.Siri.URL = "https://siri.example.ie/vm"
+			},
+			issue:       "The SIRI URL https://siri.example.ie/vm is invalid",
+			expectIssue: false,
+		},
+		{
+			name: "expect URL issue without a scheme",
+			beforeWork: func() {
+				testConfig.Siri.URL = "siri.example.ie/vm"
+			},
+			issue:       "The SIRI URL siri.example.ie/vm is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect mode issue when it is unknown",
+			beforeWork: func() {
+				testConfig.Siri.Mode = "push"
+			},
+			issue:       "The SIRI mode push is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect no listen address issue when polling",
+			beforeWork: func() {
+				testConfig.Siri.URL = "https://siri.example.ie/vm"
+				testConfig.Siri.Mode = "poll"
+			},
+			issue:       "The SIRI listen address  is invalid",
+			expectIssue: false,
+		},
+		{
+			name: "expect listen address issue when subscribing without one",
+			beforeWork: func() {
+				testConfig.Siri.URL = "https://siri.example.ie/vm"
+				testConfig.Siri.Mode = "subscribe"
+			},
+			issue:       "The SIRI listen address  is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect consumer address issue when subscribing without a scheme",
+			beforeWork: func() {
+				testConfig.Siri.URL = "https://siri.example.ie/vm"
+				testConfig.Siri.Mode = "subscribe"
+				testConfig.Siri.ConsumerAddress = "aggregator.example.ie/siri"
+			},
+			issue:       "The SIRI consumer address aggregator.example.ie/siri is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect poll interval issue when it is negative",
+			beforeWork: func() {
+				testConfig.Siri.PollInterval = "-10s"
+			},
+			issue:       "The SIRI poll interval -10s is invalid",
+			expectIssue: true,
+		},
+	}
+
+	for _, run := range runs {
+		t.Run(run.name, func(t *testing.T) {
+			testConfig = validConfig
+			run.verifyFunc = testConfig.Siri.Verify
+			run.verifyIssuesAndError(t)
+		})
+	}
+}
+
+func TestHandoffVerify(t *testing.T) {
+	var testConfig Config
+
+	runs := []Run{
+		{
+			name: "expect no listen address issue",
+			beforeWork: func() {
+				testConfig.Handoff.ListenAddress = ":8081"
+			},
+			issue:       "The handoff listen address :8081 is invalid",
+			expectIssue: false,
+		},
+		{
+			name: "expect listen address issue without a port",
+			beforeWork: func() {
+				testConfig.Handoff.ListenAddress = "localhost"
+			},
+			issue:       "The handoff listen address localhost is invalid",
+			expectIssue: true,
+		},
+	}
+
+	for _, run := range runs {
+		t.Run(run.name, func(t *testing.T) {
+			testConfig = validConfig
+			run.verifyFunc = testConfig.Handoff.Verify
+			run.verifyIssuesAndError(t)
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	var testConfig Config
+
+	t.Run("expect no issues or error", func(t *testing.T) {
+		testConfig := validConfig
+		issues := testConfig.Verify()
+		assert.Empty(t, issues, "expected no issues from verify function")
+	})
+
+	t.Run("expect multiple issues", func(t *testing.T) {
+		testConfig := validConfig
+		testConfig.LogLevel = ""
+		testConfig.Sink = "kafka"
+		testConfig.Luas.PollInterval = "soon"
+		issues := testConfig.Verify()
+		assert.Contains(t, issues, "An invalid log level was specified", "expected an issue but was not found")
+		assert.Contains(t, issues, "The sink kafka is invalid", "expected an issue but was not found")
+		assert.Contains(t, issues, "The Luas poll interval soon is invalid", "expected an issue but was not found")
+	})
+
+	runs := []Run{
+		// Log level
+		{
+			name:        "expect no log level error",
+			beforeWork:  func() {},
+			issue:       "An invalid log level was specified",
+			expectIssue: false,
+		},
+		{
+			name: "expect log level error when invalid log level is given",
+			beforeWork: func() {
+				testConfig.LogLevel = "a"
+			},
+			issue:       "An invalid log level was specified",
+			expectIssue: true,
+		},
+		{
+			name: "expect log level error when no LogLevel is not given",
+			beforeWork: func() {
+				testConfig.LogLevel = ""
+			},
+			issue:       "An invalid log level was specified",
+			expectIssue: true,
+		},
+		// Providers
+		{
+			name: "expect no provider issue when none are enabled",
+			beforeWork: func() {
+				testConfig.Providers = nil
+				testConfig.GTFSR = GTFSR{}
+			},
+			issue:       "The provider gtfsr is enabled but not configured",
+			expectIssue: false,
+		},
+		{
+			name: "expect provider issue when an unknown provider is enabled",
+			beforeWork: func() {
+				testConfig.Providers = []string{"bus-atha-cliath"}
+			},
+			issue:       "The provider bus-atha-cliath is unknown",
+			expectIssue: true,
+		},
+		{
+			name: "expect provider issue when an enabled provider is not configured",
+			beforeWork: func() {
+				testConfig.Providers = []string{"luas"}
+			},
+			issue:       "The provider luas is enabled but not configured",
+			expectIssue: true,
+		},
+		// Sink
+		{
+			name: "expect sink issue when an unknown sink is given",
+			beforeWork: func() {
+				testConfig.Sink = "kafka"
+			},
+			issue:       "The sink kafka is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect no handoff issue when the sink is memory",
+			beforeWork: func() {
+				testConfig.Handoff.ListenAddress = ""
+			},
+			issue:       "The handoff listen address  is invalid",
+			expectIssue: false,
+		},
+		{
+			name: "expect handoff issue when the sink is handoff",
+			beforeWork: func() {
+				testConfig.Sink = "handoff"
+				testConfig.Handoff.ListenAddress = ""
+			},
+			issue:       "The handoff listen address  is invalid",
+			expectIssue: true,
+		},
+		// Provider issues retrieved sanity check
+		{
+			name: "expect gtfsr issue to exist",
+			beforeWork: func() {
+				testConfig.GTFSR.AlertsURL = "localhost"
+			},
+			issue:       "The GTFS-Realtime alerts URL localhost is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect irishrail issue to exist",
+			beforeWork: func() {
+				testConfig.IrishRail.URL = "localhost"
+			},
+			issue:       "The Irish Rail URL localhost is invalid",
+			expectIssue: true,
+		},
+		{
+			name: "expect luas issue to exist",
+			beforeWork: func() {
+				testConfig.Luas.URL = "localhost"
+			},
+			issue:       "The Luas URL localhost is invalid",
+			expectIssue: true,
+		},
+	}
+
+	for _, run := range runs {
+		t.Run(run.name, func(t *testing.T) {
+			testConfig = validConfig
+			run.verifyFunc = testConfig.Verify
+			run.verifyIssuesAndError(t)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package gtfsr
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+)
+
+// Alerts polls a GTFS-Realtime ServiceAlerts feed and keeps every alert that
+// has not expired keyed by entity id
+type Alerts struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+
+	mu     sync.RWMutex
+	alerts map[string]*gtfs.Alert
+	now    func() time.Time
+}
+
+func NewAlerts(url, apiKey string) *Alerts {
+	return &Alerts{
+		URL:    url,
+		APIKey: apiKey,
+		Client: &http.Client{Timeout: 10 * time.Second},
+		alerts: map[string]*gtfs.Alert{},
+		now:    time.Now,
+	}
+}
+
+// expiredAt reports whether every active period of an alert ended before t, a
+// period without an end never ends and an alert without periods never expires
+func expiredAt(a *gtfs.Alert, t time.Time) bool {
+	if len(a.GetActivePeriod()) == 0 {
+		return false
+	}
+
+	now := uint64(max(t.Unix(), 0))
+	for _, p := range a.GetActivePeriod() {
+		if p.GetEnd() == 0 || now <= p.GetEnd() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Poll fetches the feed once, a full dataset replaces every known alert
+// whereas a differential feed is merged into them. Expired alerts are dropped
+func (p *Alerts) Poll(ctx context.Context) error {
+	feed, err := fetchFeed(ctx, p.Client, p.URL, p.APIKey)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	alerts := p.alerts
+	if feed.GetHeader().GetIncrementality() == gtfs.FeedHeader_FULL_DATASET {
+		alerts = make(map[string]*gtfs.Alert, len(feed.GetEntity()))
+	}
+
+	for _, e := range feed.GetEntity() {
+		if e.GetIsDeleted() {
+			delete(alerts, e.GetId())
+
+			continue
+		}
+
+		if e.Alert == nil {
+			continue
+		}
+		alerts[e.GetId()] = e.Alert
+	}
+
+	now := p.now()
+	for id, a := range alerts {
+		if expiredAt(a, now) {
+			delete(alerts, id)
+		}
+	}
+	p.alerts = alerts
+
+	return nil
+}
+
+func (p *Alerts) Len() int {
+	return len(p.Alerts())
+}
+
+// Alerts returns every alert that has not expired, alerts which expire
+// between polls are left out
+func (p *Alerts) Alerts() map[string]*gtfs.Alert {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	now := p.now()
+	alerts := make(map[string]*gtfs.Alert, len(p.alerts))
+	for id, a := range p.alerts {
+		if !expiredAt(a, now) {
+			alerts[id] = a
+		}
+	}
+
+	return alerts
+}
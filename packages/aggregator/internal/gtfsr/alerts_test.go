@@ -0,0 +1,64 @@
+package gtfsr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertsPoll(t *testing.T) {
+	t.Run("keeps every alert that has not expired", func(t *testing.T) {
+		srv := feedServer(t, "alerts.pb")
+		p := NewAlerts(srv.URL, "secret")
+		p.now = func() time.Time { return time.Unix(1737460000, 0) }
+
+		assert.NoError(t, p.Poll(context.Background()), "expected poll to succeed")
+		alerts := p.Alerts()
+		assert.Len(t, alerts, 3, "expected expired alert to be dropped")
+		assert.NotContains(t, alerts, "A2")
+		assert.Contains(t, alerts, "A4", "expected upcoming alert to be kept")
+
+		a := alerts["A1"]
+		assert.Equal(t, gtfs.Alert_CONSTRUCTION, a.GetCause())
+		assert.Equal(t, gtfs.Alert_DETOUR, a.GetEffect())
+		assert.Equal(t, "3249_46342", a.GetInformedEntity()[0].GetRouteId())
+		assert.Equal(t, []transit.Translation{
+			{Text: "Route 46A diverted", Language: "en"},
+			{Text: "Atreorú ar bhealach 46A", Language: "ga"},
+		}, translations(a.HeaderText))
+	})
+
+	t.Run("alerts drop out once they expire", func(t *testing.T) {
+		srv := feedServer(t, "alerts.pb")
+		p := NewAlerts(srv.URL, "secret")
+		now := time.Unix(1737460000, 0)
+		p.now = func() time.Time { return now }
+		assert.NoError(t, p.Poll(context.Background()))
+		assert.Equal(t, 3, p.Len())
+
+		now = time.Unix(1737480000, 0)
+		assert.NotContains(t, p.Alerts(), "A1", "expected alert to expire without polling")
+		assert.Equal(t, 2, p.Len())
+	})
+
+	t.Run("removes alerts deleted by a differential feed", func(t *testing.T) {
+		srv := feedServer(t, "trip_updates_differential.pb")
+		p := NewAlerts(srv.URL, "secret")
+		kept := &gtfs.Alert{}
+		p.alerts["T2"] = &gtfs.Alert{}
+		p.alerts["A9"] = kept
+
+		assert.NoError(t, p.Poll(context.Background()))
+		assert.Equal(t, map[string]*gtfs.Alert{"A9": kept}, p.Alerts(), "expected deleted alert to be removed")
+	})
+
+	t.Run("errors when the feed cannot be fetched", func(t *testing.T) {
+		srv := feedServer(t, "alerts.pb")
+		p := NewAlerts(srv.URL, "wrong")
+		assert.Error(t, p.Poll(context.Background()))
+	})
+}
@@ -0,0 +1,47 @@
+package gtfsr
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+	"google.golang.org/protobuf/proto"
+)
+
+// fetchFeed downloads and decodes a GTFS-Realtime feed, the NTA expects the
+// API key to be sent in the x-api-key header
+func fetchFeed(ctx context.Context, client *http.Client, url, apiKey string) (*gtfs.FeedMessage, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create feed request: %w", err)
+	}
+	req.Header.Set("Accept", "application/x-protobuf")
+	if apiKey != "" {
+		req.Header.Set("x-api-key", apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if err := provider.CheckResponse(resp, "feed"); err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read feed: %w", err)
+	}
+
+	feed := &gtfs.FeedMessage{}
+	if err := proto.Unmarshal(body, feed); err != nil {
+		return nil, fmt.Errorf("could not decode feed message: %w", err)
+	}
+
+	return feed, nil
+}
@@ -0,0 +1,71 @@
+package gtfsr
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// feedServer serves the recorded feeds in testdata, one per request, repeating
+// the last one once they have all been served
+func feedServer(t *testing.T, files ...string) *httptest.Server {
+	served := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		file := files[min(served, len(files)-1)]
+		served++
+		b, err := os.ReadFile("testdata/" + file)
+		assert.NoError(t, err, "could not read recorded feed")
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		_, _ = w.Write(b)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestFetchFeed(t *testing.T) {
+	t.Run("fetches and decodes a feed", func(t *testing.T) {
+		srv := feedServer(t, "vehicle_positions.pb")
+		feed, err := fetchFeed(context.Background(), srv.Client(), srv.URL, "secret")
+		assert.NoError(t, err, "expected feed to be fetched")
+		assert.Equal(t, "2.0", feed.GetHeader().GetGtfsRealtimeVersion())
+		assert.Len(t, feed.GetEntity(), 4, "expected all recorded entities")
+	})
+
+	t.Run("errors on an unexpected status", func(t *testing.T) {
+		srv := feedServer(t, "vehicle_positions.pb")
+		_, err := fetchFeed(context.Background(), srv.Client(), srv.URL, "")
+		assert.EqualError(t, err, "unexpected feed response status 401")
+	})
+
+	t.Run("errors on an invalid url", func(t *testing.T) {
+		_, err := fetchFeed(context.Background(), http.DefaultClient, "://nope", "")
+		assert.ErrorContains(t, err, "could not create feed request")
+	})
+
+	t.Run("errors when the upstream is unreachable", func(t *testing.T) {
+		srv := feedServer(t, "vehicle_positions.pb")
+		srv.Close()
+		_, err := fetchFeed(context.Background(), http.DefaultClient, srv.URL, "")
+		assert.ErrorContains(t, err, "could not fetch feed")
+	})
+
+	t.Run("errors on a body that is not a feed", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte{0xff})
+		}))
+		defer srv.Close()
+		_, err := fetchFeed(context.Background(), srv.Client(), srv.URL, "")
+		assert.ErrorContains(t, err, "could not decode feed message")
+	})
+}
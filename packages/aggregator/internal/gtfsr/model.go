@@ -0,0 +1,206 @@
+package gtfsr
+
+import (
+	"sort"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+const source = "gtfsr"
+
+var vehicleStatuses = map[gtfs.VehiclePosition_VehicleStopStatus]transit.VehicleStatus{
+	gtfs.VehiclePosition_INCOMING_AT:   transit.VehicleIncomingAt,
+	gtfs.VehiclePosition_STOPPED_AT:    transit.VehicleStoppedAt,
+	gtfs.VehiclePosition_IN_TRANSIT_TO: transit.VehicleInTransit,
+}
+
+func unixTime(secs int64) time.Time {
+	if secs == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(secs, 0)
+}
+
+func toVehicle(id string, v *gtfs.VehiclePosition) transit.Vehicle {
+	vehicle := transit.Vehicle{
+		ID:        id,
+		Source:    source,
+		RouteID:   v.GetTrip().GetRouteId(),
+		TripID:    v.GetTrip().GetTripId(),
+		Label:     v.GetVehicle().GetLabel(),
+		Status:    vehicleStatuses[v.GetCurrentStatus()],
+		StopID:    v.GetStopId(),
+		Timestamp: unixTime(int64(v.GetTimestamp())),
+	}
+	if v.Position != nil {
+		vehicle.Latitude = float64(v.Position.GetLatitude())
+		vehicle.Longitude = float64(v.Position.GetLongitude())
+		if v.Position.Bearing != nil {
+			bearing := float64(*v.Position.Bearing)
+			vehicle.Bearing = &bearing
+		}
+		if v.Position.Speed != nil {
+			speed := float64(*v.Position.Speed)
+			vehicle.Speed = &speed
+		}
+	}
+
+	return vehicle
+}
+
+// CanonicalVehicles returns every known vehicle mapped to the canonical model
+// ordered by vehicle id
+func (p *VehiclePositions) CanonicalVehicles() []transit.Vehicle {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	vehicles := make([]transit.Vehicle, 0, len(p.vehicles))
+	for id, v := range p.vehicles {
+		vehicles = append(vehicles, toVehicle(id, v))
+	}
+	sort.Slice(vehicles, func(i, j int) bool { return vehicles[i].ID < vehicles[j].ID })
+
+	return vehicles
+}
+
+func delay(e *gtfs.TripUpdate_StopTimeEvent) *time.Duration {
+	if e == nil || e.Delay == nil {
+		return nil
+	}
+	d := time.Duration(*e.Delay) * time.Second
+
+	return &d
+}
+
+func eventTime(e *gtfs.TripUpdate_StopTimeEvent) time.Time {
+	if e == nil || e.Time == nil {
+		return time.Time{}
+	}
+
+	return unixTime(*e.Time)
+}
+
+func toDepartures(t *gtfs.TripUpdate) []transit.Departure {
+	departures := make([]transit.Departure, 0, len(t.GetStopTimeUpdate()))
+	for _, u := range t.GetStopTimeUpdate() {
+		d := transit.Departure{
+			StopID:            u.GetStopId(),
+			Source:            source,
+			RouteID:           t.GetTrip().GetRouteId(),
+			TripID:            t.GetTrip().GetTripId(),
+			VehicleID:         t.GetVehicle().GetId(),
+			StopSequence:      int(u.GetStopSequence()),
+			ExpectedArrival:   eventTime(u.Arrival),
+			ExpectedDeparture: eventTime(u.Departure),
+			Status:            transit.DepartureScheduled,
+			Realtime:          true,
+		}
+
+		if d.Delay = delay(u.Departure); d.Delay == nil {
+			d.Delay = delay(u.Arrival)
+		}
+
+		switch {
+		case t.GetTrip().GetScheduleRelationship() == gtfs.TripDescriptor_CANCELED:
+			d.Status = transit.DepartureCancelled
+		case u.GetScheduleRelationship() == gtfs.TripUpdate_StopTimeUpdate_SKIPPED:
+			d.Status = transit.DepartureSkipped
+		case u.GetScheduleRelationship() == gtfs.TripUpdate_StopTimeUpdate_NO_DATA:
+			d.Status = transit.DepartureNoData
+		}
+		departures = append(departures, d)
+	}
+
+	return departures
+}
+
+// Departures returns the stops of every known trip mapped to the canonical
+// model ordered by trip id and stop sequence
+func (p *TripUpdates) Departures() []transit.Departure {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	ids := make([]string, 0, len(p.trips))
+	for id := range p.trips {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var departures []transit.Departure
+	for _, id := range ids {
+		departures = append(departures, toDepartures(p.trips[id])...)
+	}
+
+	return departures
+}
+
+func translations(s *gtfs.TranslatedString) []transit.Translation {
+	var translations []transit.Translation
+	for _, t := range s.GetTranslation() {
+		translations = append(translations, transit.Translation{Text: t.GetText(), Language: t.GetLanguage()})
+	}
+
+	return translations
+}
+
+func toAlert(id string, a *gtfs.Alert) transit.Alert {
+	alert := transit.Alert{
+		ID:               id,
+		ActivePeriods:    []transit.ActivePeriod{},
+		InformedEntities: []transit.InformedEntity{},
+		Cause:            a.GetCause().String(),
+		Effect:           a.GetEffect().String(),
+		URL:              translations(a.Url),
+		HeaderText:       translations(a.HeaderText),
+		DescriptionText:  translations(a.DescriptionText),
+	}
+
+	// A zero start or end leaves that side of the period open
+	for _, r := range a.GetActivePeriod() {
+		p := transit.ActivePeriod{}
+		if r.GetStart() != 0 {
+			start := time.Unix(int64(r.GetStart()), 0)
+			p.Start = &start
+		}
+		if r.GetEnd() != 0 {
+			end := time.Unix(int64(r.GetEnd()), 0)
+			p.End = &end
+		}
+		alert.ActivePeriods = append(alert.ActivePeriods, p)
+	}
+
+	for _, s := range a.GetInformedEntity() {
+		e := transit.InformedEntity{
+			OperatorID: s.GetAgencyId(),
+			RouteID:    s.GetRouteId(),
+			TripID:     s.GetTrip().GetTripId(),
+			StopID:     s.GetStopId(),
+		}
+		if s.RouteType != nil {
+			routeType := int(*s.RouteType)
+			e.RouteType = &routeType
+		}
+		if s.DirectionId != nil {
+			direction := int(*s.DirectionId)
+			e.DirectionID = &direction
+		}
+		alert.InformedEntities = append(alert.InformedEntities, e)
+	}
+
+	return alert
+}
+
+// CanonicalAlerts returns every alert that has not expired mapped to the
+// canonical model ordered by alert id
+func (p *Alerts) CanonicalAlerts() []transit.Alert {
+	alerts := []transit.Alert{}
+	for id, a := range p.Alerts() {
+		alerts = append(alerts, toAlert(id, a))
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].ID < alerts[j].ID })
+
+	return alerts
+}
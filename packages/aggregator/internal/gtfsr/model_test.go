@@ -0,0 +1,177 @@
+package gtfsr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestToVehicle(t *testing.T) {
+	t.Run("maps every known value", func(t *testing.T) {
+		v := toVehicle("1", &gtfs.VehiclePosition{
+			Trip:          &gtfs.TripDescriptor{TripId: proto.String("3249_10466"), RouteId: proto.String("3249_46342"), DirectionId: proto.Uint32(1)},
+			Vehicle:       &gtfs.VehicleDescriptor{Id: proto.String("1"), Label: proto.String("SG1")},
+			Position:      &gtfs.Position{Latitude: proto.Float32(53.5), Longitude: proto.Float32(-6.25), Bearing: proto.Float32(90), Speed: proto.Float32(12.5)},
+			StopId:        proto.String("8220DB000334"),
+			CurrentStatus: gtfs.VehiclePosition_STOPPED_AT.Enum(),
+			Timestamp:     proto.Uint64(1737459990),
+		})
+
+		expectedBearing, expectedSpeed := 90.0, 12.5
+		assert.Equal(t, transit.Vehicle{
+			ID:        "1",
+			Source:    "gtfsr",
+			RouteID:   "3249_46342",
+			TripID:    "3249_10466",
+			Label:     "SG1",
+			Latitude:  53.5,
+			Longitude: -6.25,
+			Bearing:   &expectedBearing,
+			Speed:     &expectedSpeed,
+			Status:    transit.VehicleStoppedAt,
+			StopID:    "8220DB000334",
+			Timestamp: time.Unix(1737459990, 0),
+		}, v)
+	})
+
+	t.Run("leaves unknown values empty", func(t *testing.T) {
+		v := toVehicle("2", &gtfs.VehiclePosition{})
+		assert.Equal(t, transit.Vehicle{ID: "2", Source: "gtfsr", Status: transit.VehicleInTransit}, v, "expected a vehicle without a status to be in transit as GTFS-Realtime defaults to")
+	})
+}
+
+func TestVehiclePositionsCanonicalVehicles(t *testing.T) {
+	srv := feedServer(t, "vehicle_positions.pb")
+	p := NewVehiclePositions(srv.URL, "secret")
+	assert.NoError(t, p.Poll(context.Background()))
+
+	vehicles := p.CanonicalVehicles()
+	assert.Len(t, vehicles, 3)
+	assert.Equal(t, "1", vehicles[0].ID, "expected vehicles to be ordered by id")
+	assert.Equal(t, "3249_10466", vehicles[0].TripID)
+	assert.InDelta(t, 53.3498, vehicles[0].Latitude, 0.0001)
+}
+
+func TestToDepartures(t *testing.T) {
+	t.Run("maps every stop of the trip", func(t *testing.T) {
+		departures := toDepartures(&gtfs.TripUpdate{
+			Trip:    &gtfs.TripDescriptor{TripId: proto.String("3249_10466"), RouteId: proto.String("3249_46342")},
+			Vehicle: &gtfs.VehicleDescriptor{Id: proto.String("1")},
+			StopTimeUpdate: []*gtfs.TripUpdate_StopTimeUpdate{
+				{StopSequence: proto.Uint32(12), StopId: proto.String("8220DB000334"), Arrival: &gtfs.TripUpdate_StopTimeEvent{Delay: proto.Int32(120), Time: proto.Int64(1737460120)}},
+				{StopId: proto.String("8220DB000335"), ScheduleRelationship: gtfs.TripUpdate_StopTimeUpdate_SKIPPED.Enum()},
+				{StopId: proto.String("8220DB000336"), ScheduleRelationship: gtfs.TripUpdate_StopTimeUpdate_NO_DATA.Enum()},
+			},
+		})
+
+		expectedDelay := 2 * time.Minute
+		assert.Equal(t, []transit.Departure{
+			{
+				StopID:          "8220DB000334",
+				Source:          "gtfsr",
+				RouteID:         "3249_46342",
+				TripID:          "3249_10466",
+				VehicleID:       "1",
+				StopSequence:    12,
+				ExpectedArrival: time.Unix(1737460120, 0),
+				Delay:           &expectedDelay,
+				Status:          transit.DepartureScheduled,
+				Realtime:        true,
+			},
+			{StopID: "8220DB000335", Source: "gtfsr", RouteID: "3249_46342", TripID: "3249_10466", VehicleID: "1", Status: transit.DepartureSkipped, Realtime: true},
+			{StopID: "8220DB000336", Source: "gtfsr", RouteID: "3249_46342", TripID: "3249_10466", VehicleID: "1", Status: transit.DepartureNoData, Realtime: true},
+		}, departures)
+	})
+
+	t.Run("prefers the departure delay", func(t *testing.T) {
+		departures := toDepartures(&gtfs.TripUpdate{
+			StopTimeUpdate: []*gtfs.TripUpdate_StopTimeUpdate{
+				{Arrival: &gtfs.TripUpdate_StopTimeEvent{Delay: proto.Int32(120)}, Departure: &gtfs.TripUpdate_StopTimeEvent{Delay: proto.Int32(150)}},
+			},
+		})
+		assert.Equal(t, 150*time.Second, *departures[0].Delay)
+	})
+
+	t.Run("cancels every stop of a cancelled trip", func(t *testing.T) {
+		departures := toDepartures(&gtfs.TripUpdate{
+			Trip:           &gtfs.TripDescriptor{ScheduleRelationship: gtfs.TripDescriptor_CANCELED.Enum()},
+			StopTimeUpdate: []*gtfs.TripUpdate_StopTimeUpdate{{StopId: proto.String("1")}, {StopId: proto.String("2"), ScheduleRelationship: gtfs.TripUpdate_StopTimeUpdate_SKIPPED.Enum()}},
+		})
+		for _, d := range departures {
+			assert.Equal(t, transit.DepartureCancelled, d.Status)
+		}
+	})
+}
+
+func TestTripUpdatesDepartures(t *testing.T) {
+	srv := feedServer(t, "trip_updates.pb")
+	p := NewTripUpdates(srv.URL, "secret")
+	assert.NoError(t, p.Poll(context.Background()))
+
+	departures := p.Departures()
+	assert.Equal(t, "3249_10466", departures[0].TripID, "expected departures to be ordered by trip")
+	assert.Equal(t, 2*time.Minute+30*time.Second, *departures[0].Delay)
+	assert.Equal(t, transit.DepartureSkipped, departures[1].Status)
+	assert.Equal(t, "3264_7765", departures[len(departures)-1].TripID)
+}
+
+func TestToAlert(t *testing.T) {
+	t.Run("maps every known value", func(t *testing.T) {
+		a := toAlert("A1", &gtfs.Alert{
+			ActivePeriod: []*gtfs.TimeRange{{Start: proto.Uint64(1737450000), End: proto.Uint64(1737470000)}, {End: proto.Uint64(1737480000)}},
+			InformedEntity: []*gtfs.EntitySelector{
+				{AgencyId: proto.String("7778019"), RouteId: proto.String("3249_46342"), RouteType: proto.Int32(3), DirectionId: proto.Uint32(1)},
+				{Trip: &gtfs.TripDescriptor{TripId: proto.String("3249_10466")}, StopId: proto.String("8220DB000334")},
+			},
+			Cause:      gtfs.Alert_CONSTRUCTION.Enum(),
+			Effect:     gtfs.Alert_DETOUR.Enum(),
+			HeaderText: &gtfs.TranslatedString{Translation: []*gtfs.TranslatedString_Translation{{Text: proto.String("Route 46A diverted"), Language: proto.String("en")}}},
+		})
+
+		start, end, openEnd := time.Unix(1737450000, 0), time.Unix(1737470000, 0), time.Unix(1737480000, 0)
+		expectedRouteType, expectedDirectionID := 3, 1
+		assert.Equal(t, transit.Alert{
+			ID:            "A1",
+			ActivePeriods: []transit.ActivePeriod{{Start: &start, End: &end}, {End: &openEnd}},
+			InformedEntities: []transit.InformedEntity{
+				{OperatorID: "7778019", RouteID: "3249_46342", RouteType: &expectedRouteType, DirectionID: &expectedDirectionID},
+				{TripID: "3249_10466", StopID: "8220DB000334"},
+			},
+			Cause:      "CONSTRUCTION",
+			Effect:     "DETOUR",
+			HeaderText: []transit.Translation{{Text: "Route 46A diverted", Language: "en"}},
+		}, a)
+	})
+
+	t.Run("leaves unknown values empty", func(t *testing.T) {
+		a := toAlert("A2", &gtfs.Alert{})
+		assert.Equal(t, transit.Alert{
+			ID:               "A2",
+			ActivePeriods:    []transit.ActivePeriod{},
+			InformedEntities: []transit.InformedEntity{},
+			Cause:            "UNKNOWN_CAUSE",
+			Effect:           "UNKNOWN_EFFECT",
+		}, a)
+	})
+}
+
+func TestAlertsCanonicalAlerts(t *testing.T) {
+	srv := feedServer(t, "alerts.pb")
+	p := NewAlerts(srv.URL, "secret")
+	p.now = func() time.Time { return time.Unix(1737460000, 0) }
+	assert.NoError(t, p.Poll(context.Background()))
+
+	alerts := p.CanonicalAlerts()
+	assert.Len(t, alerts, 3)
+	assert.Equal(t, "A1", alerts[0].ID, "expected alerts to be ordered by id")
+	assert.Equal(t, "DETOUR", alerts[0].Effect)
+	assert.Equal(t, []transit.Translation{
+		{Text: "Route 46A diverted", Language: "en"},
+		{Text: "Atreorú ar bhealach 46A", Language: "ga"},
+	}, alerts[0].HeaderText)
+}
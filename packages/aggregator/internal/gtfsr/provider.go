@@ -0,0 +1,97 @@
+package gtfsr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+)
+
+// Provider polls the GTFS-Realtime VehiclePositions, TripUpdates and
+// ServiceAlerts feeds, a feed without a URL is left nil and is not polled
+type Provider struct {
+	provider.HealthTracker
+
+	VehiclePositions *VehiclePositions
+	TripUpdates      *TripUpdates
+	Alerts           *Alerts
+
+	operators []string
+}
+
+// FromConfig creates the provider from the GTFS-Realtime settings, only the
+// feeds with a URL are polled
+func FromConfig(cfg config.Config) (provider.Provider, error) {
+	if !cfg.Configured("gtfsr") {
+		return nil, provider.ErrNotConfigured
+	}
+
+	c := cfg.GTFSR
+	p := &Provider{operators: c.Operators}
+	if c.VehiclePositionsURL != "" {
+		p.VehiclePositions = NewVehiclePositions(c.VehiclePositionsURL, c.APIKey)
+	}
+	if c.TripUpdatesURL != "" {
+		p.TripUpdates = NewTripUpdates(c.TripUpdatesURL, c.APIKey)
+	}
+	if c.AlertsURL != "" {
+		p.Alerts = NewAlerts(c.AlertsURL, c.APIKey)
+	}
+
+	return p, nil
+}
+
+func (p *Provider) Name() string {
+	return "gtfsr"
+}
+
+// Operators returns the configured operators, a feed such as the NTA's covers
+// every operator in the GTFS static feed so none are configured by default
+func (p *Provider) Operators() []string {
+	return p.operators
+}
+
+// Poll fetches every configured feed once, a feed which fails does not stop
+// the others from being polled
+func (p *Provider) Poll(ctx context.Context) error {
+	var errs []error
+	if p.VehiclePositions != nil {
+		if err := p.VehiclePositions.Poll(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("vehicle positions: %w", err))
+		}
+	}
+	if p.TripUpdates != nil {
+		if err := p.TripUpdates.Poll(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("trip updates: %w", err))
+		}
+	}
+	if p.Alerts != nil {
+		if err := p.Alerts.Poll(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("alerts: %w", err))
+		}
+	}
+
+	err := errors.Join(errs...)
+	p.Record(err)
+
+	return err
+}
+
+// Dataset returns the vehicles, departures and alerts of every configured feed
+func (p *Provider) Dataset() transit.Dataset {
+	d := transit.Dataset{}
+	if p.VehiclePositions != nil {
+		d.Vehicles = p.VehiclePositions.CanonicalVehicles()
+	}
+	if p.TripUpdates != nil {
+		d.Departures = p.TripUpdates.Departures()
+	}
+	if p.Alerts != nil {
+		d.Alerts = p.Alerts.CanonicalAlerts()
+	}
+
+	return d
+}
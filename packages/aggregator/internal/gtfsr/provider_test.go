@@ -0,0 +1,79 @@
+package gtfsr
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromConfig(t *testing.T) {
+	t.Run("creates the configured feeds", func(t *testing.T) {
+		p, err := FromConfig(config.Config{GTFSR: config.GTFSR{
+			VehiclePositionsURL: "http://localhost/vehicles",
+			APIKey:              "secret",
+			Operators:           []string{"7778019", "7778020"},
+		}})
+		assert.NoError(t, err)
+
+		gtfsr := p.(*Provider)
+		assert.Equal(t, "gtfsr", gtfsr.Name())
+		assert.Equal(t, []string{"7778019", "7778020"}, gtfsr.Operators())
+		assert.Equal(t, "http://localhost/vehicles", gtfsr.VehiclePositions.URL)
+		assert.Equal(t, "secret", gtfsr.VehiclePositions.APIKey)
+		assert.Nil(t, gtfsr.TripUpdates, "expected feed without a URL to be left out")
+		assert.Nil(t, gtfsr.Alerts, "expected feed without a URL to be left out")
+	})
+
+	t.Run("is not configured without any feed", func(t *testing.T) {
+		_, err := FromConfig(config.Config{GTFSR: config.GTFSR{APIKey: "secret"}})
+		assert.ErrorIs(t, err, provider.ErrNotConfigured)
+	})
+}
+
+func TestProviderPoll(t *testing.T) {
+	t.Run("polls every configured feed", func(t *testing.T) {
+		vehicles := feedServer(t, "vehicle_positions.pb")
+		trips := feedServer(t, "trip_updates.pb")
+		alerts := feedServer(t, "alerts.pb")
+		p, err := FromConfig(config.Config{GTFSR: config.GTFSR{
+			VehiclePositionsURL: vehicles.URL,
+			TripUpdatesURL:      trips.URL,
+			AlertsURL:           alerts.URL,
+			APIKey:              "secret",
+		}})
+		assert.NoError(t, err)
+
+		gtfsr := p.(*Provider)
+		gtfsr.Alerts.now = func() time.Time { return time.Unix(1737460000, 0) }
+		assert.NoError(t, p.Poll(context.Background()), "expected poll to succeed")
+		assert.Equal(t, 3, gtfsr.VehiclePositions.Len())
+		assert.Equal(t, 3, gtfsr.TripUpdates.Len())
+		assert.Equal(t, 3, gtfsr.Alerts.Len())
+		assert.True(t, p.Health().Healthy, "expected provider to be healthy")
+	})
+
+	t.Run("polls the other feeds when one fails", func(t *testing.T) {
+		vehicles := feedServer(t, "vehicle_positions.pb")
+		trips := feedServer(t, "trip_updates.pb")
+		trips.Close()
+		p, err := FromConfig(config.Config{GTFSR: config.GTFSR{
+			VehiclePositionsURL: vehicles.URL,
+			TripUpdatesURL:      trips.URL,
+			APIKey:              "secret",
+		}})
+		assert.NoError(t, err)
+
+		err = p.Poll(context.Background())
+		assert.ErrorContains(t, err, "trip updates: could not fetch feed")
+		assert.Equal(t, 3, p.(*Provider).VehiclePositions.Len())
+
+		health := p.Health()
+		assert.False(t, health.Healthy, "expected provider to be unhealthy")
+		assert.Equal(t, 1, health.ConsecutiveFailures)
+		assert.Equal(t, err.Error(), health.LastError)
+	})
+}
@@ -0,0 +1,4 @@
+
+
+2.0ܒ
+T2
\ No newline at end of file
@@ -0,0 +1,12 @@
+
+
+2.0@
+T1:
+
+
+3249_10466*
+3249_46342"8220DB000336-< 
+T2
+
+
+3249_10511
\ No newline at end of file
@@ -0,0 +1,4 @@
+
+
+2.0ܒ
+V2
\ No newline at end of file
@@ -0,0 +1,115 @@
+package gtfsr
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+)
+
+// TripUpdates polls a GTFS-Realtime TripUpdates feed and keeps the latest
+// update of every active trip keyed by trip id, including the arrival and
+// departure delay and schedule relationship of each of its stops
+type TripUpdates struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+
+	mu    sync.RWMutex
+	trips map[string]*gtfs.TripUpdate
+	// entities maps the id of each entity to the trip it updated, so a
+	// deletion which only has the entity id removes that update
+	entities map[string]string
+}
+
+func NewTripUpdates(url, apiKey string) *TripUpdates {
+	return &TripUpdates{
+		URL:      url,
+		APIKey:   apiKey,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		trips:    map[string]*gtfs.TripUpdate{},
+		entities: map[string]string{},
+	}
+}
+
+func tripID(e *gtfs.FeedEntity) string {
+	if id := e.GetTripUpdate().GetTrip().GetTripId(); id != "" {
+		return id
+	}
+
+	return e.GetId()
+}
+
+// Poll fetches the feed once, a full dataset replaces every known trip
+// whereas a differential feed is merged into them. When a trip is updated more
+// than once the update with the latest timestamp is kept
+func (p *TripUpdates) Poll(ctx context.Context) error {
+	feed, err := fetchFeed(ctx, p.Client, p.URL, p.APIKey)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	trips, entities := p.trips, p.entities
+	if feed.GetHeader().GetIncrementality() == gtfs.FeedHeader_FULL_DATASET {
+		trips = make(map[string]*gtfs.TripUpdate, len(feed.GetEntity()))
+		entities = make(map[string]string, len(feed.GetEntity()))
+	}
+
+	for _, e := range feed.GetEntity() {
+		id := tripID(e)
+		if e.GetIsDeleted() {
+			if known, ok := entities[e.GetId()]; ok && e.TripUpdate == nil {
+				id = known
+			}
+			delete(trips, id)
+			delete(entities, e.GetId())
+
+			continue
+		}
+
+		if e.TripUpdate == nil {
+			continue
+		}
+
+		entities[e.GetId()] = id
+		if existing, ok := trips[id]; ok && existing.GetTimestamp() > e.TripUpdate.GetTimestamp() {
+			continue
+		}
+		trips[id] = e.TripUpdate
+	}
+	p.trips, p.entities = trips, entities
+
+	return nil
+}
+
+func (p *TripUpdates) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return len(p.trips)
+}
+
+func (p *TripUpdates) Trip(id string) (*gtfs.TripUpdate, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	t, ok := p.trips[id]
+
+	return t, ok
+}
+
+func (p *TripUpdates) Trips() map[string]*gtfs.TripUpdate {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	trips := make(map[string]*gtfs.TripUpdate, len(p.trips))
+	for id, t := range p.trips {
+		trips[id] = t
+	}
+
+	return trips
+}
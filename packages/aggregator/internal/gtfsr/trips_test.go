@@ -0,0 +1,85 @@
+package gtfsr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestTripUpdatesPoll(t *testing.T) {
+	t.Run("keeps every trip keyed by trip id", func(t *testing.T) {
+		srv := feedServer(t, "trip_updates.pb")
+		p := NewTripUpdates(srv.URL, "secret")
+
+		assert.NoError(t, p.Poll(context.Background()), "expected poll to succeed")
+		assert.Equal(t, 3, p.Len(), "expected only trip updates to be kept")
+
+		trip, ok := p.Trip("3249_10466")
+		assert.True(t, ok, "expected trip to be known")
+		assert.Equal(t, uint64(1737459990), trip.GetTimestamp(), "expected the latest update to be kept")
+		assert.Len(t, trip.GetStopTimeUpdate(), 3)
+
+		first := trip.GetStopTimeUpdate()[0]
+		assert.Equal(t, int32(120), first.GetArrival().GetDelay())
+		assert.Equal(t, int32(150), first.GetDeparture().GetDelay())
+		assert.Equal(t, gtfs.TripUpdate_StopTimeUpdate_SKIPPED, trip.GetStopTimeUpdate()[1].GetScheduleRelationship())
+		assert.Equal(t, int32(-30), trip.GetStopTimeUpdate()[2].GetArrival().GetDelay())
+	})
+
+	t.Run("keeps cancelled trips and stops without data", func(t *testing.T) {
+		srv := feedServer(t, "trip_updates.pb")
+		p := NewTripUpdates(srv.URL, "secret")
+		assert.NoError(t, p.Poll(context.Background()))
+
+		cancelled, ok := p.Trip("3249_10511")
+		assert.True(t, ok, "expected cancelled trip to be known")
+		assert.Equal(t, gtfs.TripDescriptor_CANCELED, cancelled.GetTrip().GetScheduleRelationship())
+
+		noData, ok := p.Trip("3264_7765")
+		assert.True(t, ok)
+		assert.Equal(t, gtfs.TripUpdate_StopTimeUpdate_NO_DATA, noData.GetStopTimeUpdate()[0].GetScheduleRelationship())
+	})
+
+	t.Run("merges differential feeds", func(t *testing.T) {
+		srv := feedServer(t, "trip_updates.pb", "trip_updates_differential.pb")
+		p := NewTripUpdates(srv.URL, "secret")
+		assert.NoError(t, p.Poll(context.Background()))
+		assert.NoError(t, p.Poll(context.Background()))
+
+		trips := p.Trips()
+		assert.Len(t, trips, 2, "expected deleted trip to be removed")
+		assert.Equal(t, int32(45), trips["3249_10466"].GetStopTimeUpdate()[0].GetArrival().GetDelay(), "expected trip to be updated")
+		assert.Contains(t, trips, "3264_7765", "expected untouched trip to remain")
+	})
+
+	t.Run("deletes the trip of an entity deleted by its id alone", func(t *testing.T) {
+		srv := feedServer(t, "trip_updates.pb", "trip_updates_deleted.pb")
+		p := NewTripUpdates(srv.URL, "secret")
+		assert.NoError(t, p.Poll(context.Background()))
+		assert.NoError(t, p.Poll(context.Background()))
+
+		_, ok := p.Trip("3249_10511")
+		assert.False(t, ok, "expected cancellation of entity T2 to be removed")
+		assert.Equal(t, 2, p.Len())
+	})
+
+	t.Run("ignores updates older than the one held", func(t *testing.T) {
+		srv := feedServer(t, "trip_updates_differential.pb")
+		p := NewTripUpdates(srv.URL, "secret")
+		p.trips["3249_10466"] = &gtfs.TripUpdate{Timestamp: proto.Uint64(1737470000)}
+
+		assert.NoError(t, p.Poll(context.Background()))
+		trip, _ := p.Trip("3249_10466")
+		assert.Equal(t, uint64(1737470000), trip.GetTimestamp(), "expected newer update to be kept")
+	})
+
+	t.Run("errors when the feed cannot be fetched", func(t *testing.T) {
+		srv := feedServer(t, "trip_updates.pb")
+		p := NewTripUpdates(srv.URL, "wrong")
+		assert.Error(t, p.Poll(context.Background()))
+		assert.Equal(t, 0, p.Len())
+	})
+}
@@ -0,0 +1,109 @@
+package gtfsr
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+)
+
+// VehiclePositions polls a GTFS-Realtime VehiclePositions feed and keeps the
+// latest position of every vehicle in the feed keyed by vehicle id
+type VehiclePositions struct {
+	URL    string
+	APIKey string
+	Client *http.Client
+
+	mu       sync.RWMutex
+	vehicles map[string]*gtfs.VehiclePosition
+	// entities maps the id of each entity to the vehicle it carried, so a
+	// deletion which only has the entity id removes that vehicle
+	entities map[string]string
+}
+
+func NewVehiclePositions(url, apiKey string) *VehiclePositions {
+	return &VehiclePositions{
+		URL:      url,
+		APIKey:   apiKey,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+		vehicles: map[string]*gtfs.VehiclePosition{},
+		entities: map[string]string{},
+	}
+}
+
+func vehicleID(e *gtfs.FeedEntity) string {
+	if id := e.GetVehicle().GetVehicle().GetId(); id != "" {
+		return id
+	}
+
+	return e.GetId()
+}
+
+// Poll fetches the feed once, a full dataset replaces every known vehicle
+// whereas a differential feed is merged into them
+func (p *VehiclePositions) Poll(ctx context.Context) error {
+	feed, err := fetchFeed(ctx, p.Client, p.URL, p.APIKey)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	vehicles, entities := p.vehicles, p.entities
+	if feed.GetHeader().GetIncrementality() == gtfs.FeedHeader_FULL_DATASET {
+		vehicles = make(map[string]*gtfs.VehiclePosition, len(feed.GetEntity()))
+		entities = make(map[string]string, len(feed.GetEntity()))
+	}
+
+	for _, e := range feed.GetEntity() {
+		id := vehicleID(e)
+		if e.GetIsDeleted() {
+			if known, ok := entities[e.GetId()]; ok && e.GetVehicle().GetVehicle() == nil {
+				id = known
+			}
+			delete(vehicles, id)
+			delete(entities, e.GetId())
+
+			continue
+		}
+
+		if e.GetVehicle().GetPosition() == nil {
+			continue
+		}
+		vehicles[id] = e.Vehicle
+		entities[e.GetId()] = id
+	}
+	p.vehicles, p.entities = vehicles, entities
+
+	return nil
+}
+
+func (p *VehiclePositions) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return len(p.vehicles)
+}
+
+func (p *VehiclePositions) Vehicle(id string) (*gtfs.VehiclePosition, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	v, ok := p.vehicles[id]
+
+	return v, ok
+}
+
+func (p *VehiclePositions) Vehicles() map[string]*gtfs.VehiclePosition {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	vehicles := make(map[string]*gtfs.VehiclePosition, len(p.vehicles))
+	for id, v := range p.vehicles {
+		vehicles[id] = v
+	}
+
+	return vehicles
+}
@@ -0,0 +1,76 @@
+package gtfsr
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehiclePositionsPoll(t *testing.T) {
+	t.Run("keeps every positioned vehicle keyed by vehicle id", func(t *testing.T) {
+		srv := feedServer(t, "vehicle_positions.pb")
+		p := NewVehiclePositions(srv.URL, "secret")
+
+		assert.NoError(t, p.Poll(context.Background()), "expected poll to succeed")
+		assert.Equal(t, 3, p.Len(), "vehicles without a position should be skipped")
+
+		v, ok := p.Vehicle("1")
+		assert.True(t, ok, "expected vehicle 1 to be known")
+		assert.Equal(t, "3249_10466", v.GetTrip().GetTripId())
+		assert.Equal(t, "3249_46342", v.GetTrip().GetRouteId())
+		assert.InDelta(t, 53.3498, v.GetPosition().GetLatitude(), 0.0001)
+		assert.InDelta(t, -6.2603, v.GetPosition().GetLongitude(), 0.0001)
+		assert.Equal(t, uint64(1737459990), v.GetTimestamp())
+
+		_, ok = p.Vehicle("4")
+		assert.False(t, ok, "expected vehicle 4 to be skipped")
+	})
+
+	t.Run("merges differential feeds", func(t *testing.T) {
+		srv := feedServer(t, "vehicle_positions.pb", "vehicle_positions_differential.pb")
+		p := NewVehiclePositions(srv.URL, "secret")
+
+		assert.NoError(t, p.Poll(context.Background()))
+		assert.NoError(t, p.Poll(context.Background()))
+
+		vehicles := p.Vehicles()
+		assert.Len(t, vehicles, 2, "expected deleted vehicle to be removed")
+		assert.InDelta(t, 53.3512, vehicles["1"].GetPosition().GetLatitude(), 0.0001, "expected vehicle 1 to move")
+		assert.Contains(t, vehicles, "3", "expected untouched vehicle to remain")
+	})
+
+	t.Run("deletes the vehicle of an entity deleted by its id alone", func(t *testing.T) {
+		srv := feedServer(t, "vehicle_positions.pb", "vehicle_positions_deleted.pb")
+		p := NewVehiclePositions(srv.URL, "secret")
+
+		assert.NoError(t, p.Poll(context.Background()))
+		assert.NoError(t, p.Poll(context.Background()))
+
+		_, ok := p.Vehicle("2")
+		assert.False(t, ok, "expected vehicle of entity V2 to be removed")
+		assert.Equal(t, 2, p.Len())
+	})
+
+	t.Run("full datasets replace every vehicle", func(t *testing.T) {
+		srv := feedServer(t, "vehicle_positions_differential.pb", "vehicle_positions.pb")
+		p := NewVehiclePositions(srv.URL, "secret")
+		p.vehicles["gone"] = p.vehicles["1"]
+
+		assert.NoError(t, p.Poll(context.Background()))
+		assert.NoError(t, p.Poll(context.Background()))
+		_, ok := p.Vehicle("gone")
+		assert.False(t, ok, "expected stale vehicle to be dropped")
+		assert.Equal(t, 3, p.Len())
+	})
+
+	t.Run("keeps the previous vehicles when polling fails", func(t *testing.T) {
+		srv := feedServer(t, "vehicle_positions.pb")
+		p := NewVehiclePositions(srv.URL, "secret")
+		assert.NoError(t, p.Poll(context.Background()))
+
+		p.APIKey = "wrong"
+		assert.Error(t, p.Poll(context.Background()))
+		assert.Equal(t, 3, p.Len())
+	})
+}
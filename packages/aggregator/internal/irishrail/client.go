@@ -0,0 +1,94 @@
+package irishrail
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+)
+
+// Client calls the Irish Rail realtime API, such as
+// http://api.irishrail.ie/realtime/realtime.asmx
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *Client) get(ctx context.Context, method string, query url.Values, v any) error {
+	u := c.BaseURL + "/" + method
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return fmt.Errorf("could not create %s request: %w", method, err)
+	}
+	req.Header.Set("Accept", "application/xml")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not fetch %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	if err := provider.CheckResponse(resp, method); err != nil {
+		return err
+	}
+
+	if err := xml.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("could not decode %s: %w", method, err)
+	}
+
+	return nil
+}
+
+// CurrentTrains returns every train which is running or due to run shortly
+func (c *Client) CurrentTrains(ctx context.Context) ([]TrainPosition, error) {
+	var resp struct {
+		Trains []TrainPosition `xml:"objTrainPositions"`
+	}
+	if err := c.get(ctx, "getCurrentTrainsXML", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Trains, nil
+}
+
+// StationData returns the trains due at a station in the next 90 minutes
+func (c *Client) StationData(ctx context.Context, stationCode string) ([]StationData, error) {
+	var resp struct {
+		Data []StationData `xml:"objStationData"`
+	}
+	query := url.Values{"StationCode": {stationCode}}
+	if err := c.get(ctx, "getStationDataByCodeXML", query, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Data, nil
+}
+
+// TrainMovements returns every location a train passes on a day
+func (c *Client) TrainMovements(ctx context.Context, trainCode string, date time.Time) ([]TrainMovement, error) {
+	var resp struct {
+		Movements []TrainMovement `xml:"objTrainMovements"`
+	}
+	query := url.Values{"TrainId": {trainCode}, "TrainDate": {date.Format(trainDateLayout)}}
+	if err := c.get(ctx, "getTrainMovementsXML", query, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Movements, nil
+}
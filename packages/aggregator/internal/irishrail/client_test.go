@@ -0,0 +1,128 @@
+package irishrail
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// apiServer stands in for the Irish Rail realtime API, serving the recorded
+// responses in testdata
+func apiServer(t *testing.T) *httptest.Server {
+	serve := func(file string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			b, err := os.ReadFile("testdata/" + file)
+			assert.NoError(t, err, "could not read recorded response")
+			w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+			_, _ = w.Write(b)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/getCurrentTrainsXML", serve("current_trains.xml"))
+	mux.HandleFunc("/getStationDataByCodeXML", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("StationCode") != "MHIDE" {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+		serve("station_data_mhide.xml")(w, r)
+	})
+	mux.HandleFunc("/getTrainMovementsXML", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("TrainId") != "E109" || q.Get("TrainDate") != "21 Jan 2025" {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+		serve("train_movements_e109.xml")(w, r)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestClientCurrentTrains(t *testing.T) {
+	t.Run("decodes every train", func(t *testing.T) {
+		srv := apiServer(t)
+		trains, err := NewClient(srv.URL + "/").CurrentTrains(context.Background())
+		assert.NoError(t, err, "expected trains to be fetched")
+		assert.Len(t, trains, 4)
+		assert.Equal(t, TrainPosition{
+			TrainStatus:    "R",
+			TrainLatitude:  53.3915,
+			TrainLongitude: -6.15657,
+			TrainCode:      "E109",
+			TrainDate:      "21 Jan 2025",
+			PublicMessage:  `E109\n09:25 - Malahide to Bray (2 mins late)\nDeparted Howth Junction next stop Kilbarrack`,
+			Direction:      "Southbound",
+		}, trains[0])
+	})
+
+	t.Run("errors on an unexpected status", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		defer srv.Close()
+		_, err := NewClient(srv.URL).CurrentTrains(context.Background())
+		assert.EqualError(t, err, "unexpected getCurrentTrainsXML response status 404")
+	})
+
+	t.Run("errors on an invalid response", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("<ArrayOfObjTrainPositions><objTrainPositions>"))
+		}))
+		defer srv.Close()
+		_, err := NewClient(srv.URL).CurrentTrains(context.Background())
+		assert.ErrorContains(t, err, "could not decode getCurrentTrainsXML")
+	})
+
+	t.Run("errors when the API cannot be reached", func(t *testing.T) {
+		srv := apiServer(t)
+		srv.Close()
+		_, err := NewClient(srv.URL).CurrentTrains(context.Background())
+		assert.ErrorContains(t, err, "could not fetch getCurrentTrainsXML")
+	})
+}
+
+func TestClientStationData(t *testing.T) {
+	t.Run("decodes the trains due at a station", func(t *testing.T) {
+		srv := apiServer(t)
+		data, err := NewClient(srv.URL).StationData(context.Background(), "MHIDE")
+		assert.NoError(t, err)
+		assert.Len(t, data, 3)
+		assert.Equal(t, "A141", data[1].Traincode)
+		assert.Equal(t, 4, data[1].Late)
+		assert.Equal(t, "Departed Drogheda", data[1].Lastlocation)
+		assert.Equal(t, "", data[0].Lastlocation, "expected empty element to be decoded")
+	})
+
+	t.Run("errors on an unexpected status", func(t *testing.T) {
+		srv := apiServer(t)
+		_, err := NewClient(srv.URL).StationData(context.Background(), "XXXX")
+		assert.EqualError(t, err, "unexpected getStationDataByCodeXML response status 500")
+	})
+}
+
+func TestClientTrainMovements(t *testing.T) {
+	t.Run("decodes every location of the train", func(t *testing.T) {
+		srv := apiServer(t)
+		date := time.Date(2025, 1, 21, 0, 0, 0, 0, dublin)
+		movements, err := NewClient(srv.URL).TrainMovements(context.Background(), "E109", date)
+		assert.NoError(t, err)
+		assert.Len(t, movements, 5)
+		assert.Equal(t, "HWTHJ", movements[2].LocationCode)
+		assert.Equal(t, "C", movements[2].StopType)
+		assert.Equal(t, "09:35:41", movements[2].Departure)
+	})
+
+	t.Run("errors on an unexpected status", func(t *testing.T) {
+		srv := apiServer(t)
+		_, err := NewClient(srv.URL).TrainMovements(context.Background(), "E109", time.Date(2025, 1, 22, 0, 0, 0, 0, dublin))
+		assert.EqualError(t, err, "unexpected getTrainMovementsXML response status 500")
+	})
+}
@@ -0,0 +1,224 @@
+package irishrail
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+const (
+	source          = "irishrail"
+	operator        = "irishrail"
+	trainDateLayout = "02 Jan 2006"
+	serverLayout    = "2006-01-02T15:04:05.999"
+)
+
+// The API reports every time in Irish local time without an offset
+var dublin, _ = time.LoadLocation("Europe/Dublin")
+
+var (
+	lateRegexp     = regexp.MustCompile(`\((\d+) mins? (late|early)\)`)
+	headsignRegexp = regexp.MustCompile(`^\d\d:\d\d - .+ to (.+?)(?: \(|$)`)
+)
+
+func parseTrainDate(s string) (time.Time, error) {
+	date, err := time.ParseInLocation(trainDateLayout, strings.TrimSpace(s), dublin)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid train date %q", s)
+	}
+
+	return date, nil
+}
+
+// clockOn returns the time of a HH:MM or HH:MM:SS clock within 12 hours of
+// near, as the API leaves out the date of times past midnight. The API uses
+// 00:00 for times which do not apply, such as the arrival at an origin, these
+// are returned as the zero time
+func clockOn(clock string, near time.Time) (time.Time, error) {
+	clock = strings.TrimSpace(clock)
+	if clock == "" || clock == "00:00" || clock == "00:00:00" {
+		return time.Time{}, nil
+	}
+
+	layout := "15:04:05"
+	if len(clock) == len("15:04") {
+		layout = "15:04"
+	}
+	c, err := time.Parse(layout, clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", clock)
+	}
+
+	t := time.Date(near.Year(), near.Month(), near.Day(), c.Hour(), c.Minute(), c.Second(), 0, dublin)
+	switch {
+	case t.Sub(near) > 12*time.Hour:
+		t = t.AddDate(0, 0, -1)
+	case near.Sub(t) > 12*time.Hour:
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t, nil
+}
+
+// toVehicle maps a train position, the API only describes the trip in the
+// public message, e.g. "E109\n09:25 - Malahide to Bray (2 mins late)\nDeparted
+// Howth Junction next stop Kilbarrack" where the new lines are escaped
+func toVehicle(p TrainPosition, now time.Time) transit.Vehicle {
+	v := transit.Vehicle{
+		ID:        p.TrainCode,
+		Source:    source,
+		Operator:  operator,
+		Mode:      transit.ModeRail,
+		TripID:    p.TrainCode,
+		Label:     p.TrainCode,
+		Latitude:  p.TrainLatitude,
+		Longitude: p.TrainLongitude,
+		Status:    transit.VehicleInTransit,
+		Timestamp: now,
+	}
+
+	lines := strings.Split(strings.ReplaceAll(p.PublicMessage, `\n`, "\n"), "\n")
+	if len(lines) > 1 {
+		if m := headsignRegexp.FindStringSubmatch(lines[1]); m != nil {
+			v.Headsign = m[1]
+		}
+	}
+
+	if m := lateRegexp.FindStringSubmatch(p.PublicMessage); m != nil {
+		late, _ := strconv.Atoi(m[1])
+		if m[2] == "early" {
+			late = -late
+		}
+		v.Delay = durationPtr(time.Duration(late) * time.Minute)
+	}
+
+	switch p.TrainStatus {
+	case "N":
+		v.Status = transit.VehicleScheduled
+	case "T":
+		v.Status = transit.VehicleTerminated
+	default:
+		if len(lines) > 2 && strings.HasPrefix(lines[2], "Arrived") {
+			v.Status = transit.VehicleStoppedAt
+		}
+	}
+
+	return v
+}
+
+// toDeparture maps a train due at a station, the times are placed relative to
+// the time of the server as the API only gives the time of day
+func toDeparture(d StationData) (transit.Departure, error) {
+	near, err := time.ParseInLocation(serverLayout, strings.TrimSpace(d.Servertime), dublin)
+	if err != nil {
+		return transit.Departure{}, fmt.Errorf("invalid server time %q", d.Servertime)
+	}
+
+	dep := transit.Departure{
+		StopID:    d.Stationcode,
+		Source:    source,
+		Operator:  operator,
+		Mode:      transit.ModeRail,
+		TripID:    d.Traincode,
+		VehicleID: d.Traincode,
+		Headsign:  d.Destination,
+		Direction: d.Direction,
+		Delay:     durationPtr(time.Duration(d.Late) * time.Minute),
+		Status:    transit.DepartureScheduled,
+		Realtime:  true,
+	}
+
+	times := []struct {
+		clock string
+		t     *time.Time
+	}{
+		{d.Scharrival, &dep.ScheduledArrival},
+		{d.Schdepart, &dep.ScheduledDeparture},
+		{d.Exparrival, &dep.ExpectedArrival},
+		{d.Expdepart, &dep.ExpectedDeparture},
+	}
+	for _, c := range times {
+		if *c.t, err = clockOn(c.clock, near); err != nil {
+			return transit.Departure{}, err
+		}
+	}
+
+	// Trains end at their destination, the departure is left unset
+	if d.Locationtype == "D" {
+		dep.ScheduledDeparture, dep.ExpectedDeparture = time.Time{}, time.Time{}
+	}
+
+	return dep, nil
+}
+
+// toDepartures maps the movements of a train to a departure for each station
+// it stops at, timing points which it passes without stopping are left out
+func toDepartures(movements []TrainMovement) ([]transit.Departure, error) {
+	departures := make([]transit.Departure, 0, len(movements))
+	var near time.Time
+	for _, m := range movements {
+		if m.LocationType == "T" {
+			continue
+		}
+
+		if near.IsZero() {
+			date, err := parseTrainDate(m.TrainDate)
+			if err != nil {
+				return nil, err
+			}
+			near = date.Add(12 * time.Hour)
+		}
+
+		d := transit.Departure{
+			StopID:       m.LocationCode,
+			Source:       source,
+			Operator:     operator,
+			Mode:         transit.ModeRail,
+			TripID:       m.TrainCode,
+			VehicleID:    m.TrainCode,
+			Headsign:     m.TrainDestination,
+			StopSequence: m.LocationOrder,
+			Status:       transit.DepartureScheduled,
+			Realtime:     true,
+		}
+
+		times := []struct {
+			clock string
+			t     *time.Time
+		}{
+			{m.ScheduledArrival, &d.ScheduledArrival},
+			{m.ScheduledDeparture, &d.ScheduledDeparture},
+			{m.ExpectedArrival, &d.ExpectedArrival},
+			{m.ExpectedDeparture, &d.ExpectedDeparture},
+		}
+		for _, c := range times {
+			t, err := clockOn(c.clock, near)
+			if err != nil {
+				return nil, err
+			}
+			*c.t = t
+			if !t.IsZero() {
+				near = t
+			}
+		}
+
+		switch {
+		case !d.ScheduledDeparture.IsZero() && !d.ExpectedDeparture.IsZero():
+			d.Delay = durationPtr(d.ExpectedDeparture.Sub(d.ScheduledDeparture))
+		case !d.ScheduledArrival.IsZero() && !d.ExpectedArrival.IsZero():
+			d.Delay = durationPtr(d.ExpectedArrival.Sub(d.ScheduledArrival))
+		}
+		departures = append(departures, d)
+	}
+
+	return departures, nil
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -0,0 +1,184 @@
+package irishrail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClockOn(t *testing.T) {
+	near := time.Date(2025, 1, 21, 23, 40, 0, 0, dublin)
+
+	tests := []struct {
+		name     string
+		clock    string
+		expected time.Time
+	}{
+		{"same day", "23:45", time.Date(2025, 1, 21, 23, 45, 0, 0, dublin)},
+		{"with seconds", "23:45:30", time.Date(2025, 1, 21, 23, 45, 30, 0, dublin)},
+		{"past midnight", "00:15", time.Date(2025, 1, 22, 0, 15, 0, 0, dublin)},
+		{"not applicable", "00:00", time.Time{}},
+		{"empty", "", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := clockOn(tt.clock, near)
+			assert.NoError(t, err)
+			assert.True(t, tt.expected.Equal(actual), "expected %s got %s", tt.expected, actual)
+		})
+	}
+
+	t.Run("before midnight", func(t *testing.T) {
+		actual, err := clockOn("23:50", time.Date(2025, 1, 22, 0, 10, 0, 0, dublin))
+		assert.NoError(t, err)
+		assert.Equal(t, time.Date(2025, 1, 21, 23, 50, 0, 0, dublin), actual)
+	})
+
+	t.Run("errors on an invalid time", func(t *testing.T) {
+		_, err := clockOn("25:61", near)
+		assert.EqualError(t, err, `invalid time "25:61"`)
+	})
+}
+
+func TestToVehicle(t *testing.T) {
+	now := time.Date(2025, 1, 21, 9, 36, 0, 0, dublin)
+
+	t.Run("maps a running train", func(t *testing.T) {
+		delay := 2 * time.Minute
+		assert.Equal(t, transit.Vehicle{
+			ID:        "E109",
+			Source:    "irishrail",
+			Operator:  "irishrail",
+			Mode:      transit.ModeRail,
+			TripID:    "E109",
+			Label:     "E109",
+			Headsign:  "Bray",
+			Latitude:  53.3915,
+			Longitude: -6.15657,
+			Status:    transit.VehicleInTransit,
+			Delay:     &delay,
+			Timestamp: now,
+		}, toVehicle(TrainPosition{
+			TrainStatus:    "R",
+			TrainLatitude:  53.3915,
+			TrainLongitude: -6.15657,
+			TrainCode:      "E109",
+			PublicMessage:  `E109\n09:25 - Malahide to Bray (2 mins late)\nDeparted Howth Junction next stop Kilbarrack`,
+		}, now))
+	})
+
+	t.Run("maps a train stopped at a station running early", func(t *testing.T) {
+		v := toVehicle(TrainPosition{
+			TrainStatus:   "R",
+			PublicMessage: `A409\n09:00 - Dublin Heuston to Cork (1 min early)\nArrived Dublin Heuston next stop Portlaoise`,
+		}, now)
+		assert.Equal(t, transit.VehicleStoppedAt, v.Status)
+		assert.Equal(t, "Cork", v.Headsign)
+		assert.Equal(t, -time.Minute, *v.Delay)
+	})
+
+	t.Run("maps trains which are not running", func(t *testing.T) {
+		notRunning := toVehicle(TrainPosition{TrainStatus: "N", PublicMessage: `E218\n10:15 - Greystones to Howth (0 mins late)\nTRAIN NOT YET RUNNING`}, now)
+		assert.Equal(t, transit.VehicleScheduled, notRunning.Status)
+		assert.Equal(t, "Howth", notRunning.Headsign)
+
+		terminated := toVehicle(TrainPosition{TrainStatus: "T", PublicMessage: `P602\nT:Terminated Dublin Connolly at 09:41(3 mins late)`}, now)
+		assert.Equal(t, transit.VehicleTerminated, terminated.Status)
+		assert.Equal(t, "", terminated.Headsign)
+		assert.Equal(t, 3*time.Minute, *terminated.Delay)
+	})
+}
+
+func TestToDeparture(t *testing.T) {
+	t.Run("maps a train calling at a station after midnight", func(t *testing.T) {
+		delay := 4 * time.Minute
+		d, err := toDeparture(StationData{
+			Servertime:   "2025-01-21T23:40:12.487",
+			Traincode:    "A141",
+			Stationcode:  "MHIDE",
+			Destination:  "Dublin Connolly",
+			Direction:    "Southbound",
+			Late:         4,
+			Exparrival:   "00:01",
+			Expdepart:    "00:02",
+			Scharrival:   "23:57",
+			Schdepart:    "23:58",
+			Locationtype: "S",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, transit.Departure{
+			StopID:             "MHIDE",
+			Source:             "irishrail",
+			Operator:           "irishrail",
+			Mode:               transit.ModeRail,
+			TripID:             "A141",
+			VehicleID:          "A141",
+			Headsign:           "Dublin Connolly",
+			Direction:          "Southbound",
+			ScheduledArrival:   time.Date(2025, 1, 21, 23, 57, 0, 0, dublin),
+			ScheduledDeparture: time.Date(2025, 1, 21, 23, 58, 0, 0, dublin),
+			ExpectedArrival:    time.Date(2025, 1, 22, 0, 1, 0, 0, dublin),
+			ExpectedDeparture:  time.Date(2025, 1, 22, 0, 2, 0, 0, dublin),
+			Delay:              &delay,
+			Status:             transit.DepartureScheduled,
+			Realtime:           true,
+		}, d)
+	})
+
+	t.Run("leaves out the arrival at the origin and departure at the destination", func(t *testing.T) {
+		origin, err := toDeparture(StationData{Servertime: "2025-01-21T23:40:12.487", Scharrival: "00:00", Schdepart: "23:45", Locationtype: "O"})
+		assert.NoError(t, err)
+		assert.True(t, origin.ScheduledArrival.IsZero())
+		assert.False(t, origin.ScheduledDeparture.IsZero())
+
+		destination, err := toDeparture(StationData{Servertime: "2025-01-21T23:40:12.487", Scharrival: "23:52", Schdepart: "00:00", Expdepart: "00:00", Locationtype: "D"})
+		assert.NoError(t, err)
+		assert.False(t, destination.ScheduledArrival.IsZero())
+		assert.True(t, destination.ScheduledDeparture.IsZero())
+		assert.True(t, destination.ExpectedDeparture.IsZero())
+	})
+
+	t.Run("errors on an invalid server time", func(t *testing.T) {
+		_, err := toDeparture(StationData{Servertime: "yesterday"})
+		assert.EqualError(t, err, `invalid server time "yesterday"`)
+	})
+
+	t.Run("errors on an invalid time", func(t *testing.T) {
+		_, err := toDeparture(StationData{Servertime: "2025-01-21T23:40:12.487", Schdepart: "soon"})
+		assert.EqualError(t, err, `invalid time "soon"`)
+	})
+}
+
+func TestToDepartures(t *testing.T) {
+	t.Run("maps the stations a train stops at", func(t *testing.T) {
+		departures, err := toDepartures([]TrainMovement{
+			{TrainCode: "E119", TrainDate: "21 Jan 2025", LocationCode: "MHIDE", LocationOrder: 1, LocationType: "O", TrainDestination: "Bray", ScheduledArrival: "00:00:00", ScheduledDeparture: "23:45:00", ExpectedDeparture: "23:46:00"},
+			{TrainCode: "E119", TrainDate: "21 Jan 2025", LocationCode: "CLONF", LocationOrder: 2, LocationType: "T", ScheduledArrival: "23:50:00"},
+			{TrainCode: "E119", TrainDate: "21 Jan 2025", LocationCode: "BRAY", LocationOrder: 3, LocationType: "D", TrainDestination: "Bray", ScheduledArrival: "00:58:00", ExpectedArrival: "00:59:30"},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, departures, 2, "expected timing points to be left out")
+
+		assert.Equal(t, "MHIDE", departures[0].StopID)
+		assert.Equal(t, 1, departures[0].StopSequence)
+		assert.Equal(t, time.Minute, *departures[0].Delay)
+
+		assert.Equal(t, "BRAY", departures[1].StopID)
+		assert.Equal(t, 3, departures[1].StopSequence)
+		assert.Equal(t, time.Date(2025, 1, 22, 0, 58, 0, 0, dublin), departures[1].ScheduledArrival, "expected arrival after midnight")
+		assert.Equal(t, 90*time.Second, *departures[1].Delay)
+	})
+
+	t.Run("errors on an invalid train date", func(t *testing.T) {
+		_, err := toDepartures([]TrainMovement{{TrainDate: "someday"}})
+		assert.EqualError(t, err, `invalid train date "someday"`)
+	})
+
+	t.Run("errors on an invalid time", func(t *testing.T) {
+		_, err := toDepartures([]TrainMovement{{TrainDate: "21 Jan 2025", ScheduledArrival: "noon"}})
+		assert.EqualError(t, err, `invalid time "noon"`)
+	})
+}
@@ -0,0 +1,183 @@
+package irishrail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+)
+
+// Provider polls the Irish Rail realtime API for the position of every train
+// and the departures from a set of stations, mapped to the canonical model
+type Provider struct {
+	provider.HealthTracker
+
+	Client   *Client
+	Stations []string
+
+	now        func() time.Time
+	mu         sync.RWMutex
+	vehicles   map[string]transit.Vehicle
+	departures map[string][]transit.Departure
+}
+
+func New(baseURL string, stations []string) *Provider {
+	return &Provider{
+		Client:     NewClient(baseURL),
+		Stations:   stations,
+		now:        time.Now,
+		vehicles:   map[string]transit.Vehicle{},
+		departures: map[string][]transit.Departure{},
+	}
+}
+
+// FromConfig creates the provider from the Irish Rail settings
+func FromConfig(cfg config.Config) (provider.Provider, error) {
+	if !cfg.Configured("irishrail") {
+		return nil, provider.ErrNotConfigured
+	}
+
+	return New(cfg.IrishRail.URL, cfg.IrishRail.Stations), nil
+}
+
+func (p *Provider) Name() string {
+	return source
+}
+
+func (p *Provider) Operators() []string {
+	return []string{operator}
+}
+
+// Poll fetches the current trains and the departures of every station once,
+// a station which cannot be fetched keeps its previous departures
+func (p *Provider) Poll(ctx context.Context) error {
+	err := p.poll(ctx)
+	p.Record(err)
+
+	return err
+}
+
+func (p *Provider) poll(ctx context.Context) error {
+	trains, err := p.Client.CurrentTrains(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := p.now()
+	vehicles := make(map[string]transit.Vehicle, len(trains))
+	for _, t := range trains {
+		vehicles[t.TrainCode] = toVehicle(t, now)
+	}
+
+	var errs []error
+	departures := make(map[string][]transit.Departure, len(p.Stations))
+	for _, station := range p.Stations {
+		deps, err := p.stationDepartures(ctx, station)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+		departures[station] = deps
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.vehicles = vehicles
+	for station, deps := range departures {
+		p.departures[station] = deps
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p *Provider) stationDepartures(ctx context.Context, station string) ([]transit.Departure, error) {
+	data, err := p.Client.StationData(ctx, station)
+	if err != nil {
+		return nil, err
+	}
+
+	departures := make([]transit.Departure, 0, len(data))
+	for _, d := range data {
+		dep, err := toDeparture(d)
+		if err != nil {
+			return nil, fmt.Errorf("station %s train %s: %w", station, d.Traincode, err)
+		}
+		departures = append(departures, dep)
+	}
+
+	return departures, nil
+}
+
+func (p *Provider) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return len(p.vehicles)
+}
+
+func (p *Provider) Vehicle(id string) (transit.Vehicle, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	v, ok := p.vehicles[id]
+
+	return v, ok
+}
+
+// Vehicles returns every known train ordered by train code
+func (p *Provider) Vehicles() []transit.Vehicle {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	vehicles := make([]transit.Vehicle, 0, len(p.vehicles))
+	for _, v := range p.vehicles {
+		vehicles = append(vehicles, v)
+	}
+	sort.Slice(vehicles, func(i, j int) bool { return vehicles[i].ID < vehicles[j].ID })
+
+	return vehicles
+}
+
+// Departures returns the departures last polled for a station
+func (p *Provider) Departures(station string) []transit.Departure {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]transit.Departure(nil), p.departures[station]...)
+}
+
+// Dataset returns every known train and the departures of every station
+// ordered by station
+func (p *Provider) Dataset() transit.Dataset {
+	p.mu.RLock()
+	stations := make([]string, 0, len(p.departures))
+	for station := range p.departures {
+		stations = append(stations, station)
+	}
+	p.mu.RUnlock()
+	sort.Strings(stations)
+
+	d := transit.Dataset{Vehicles: p.Vehicles()}
+	for _, station := range stations {
+		d.Departures = append(d.Departures, p.Departures(station)...)
+	}
+
+	return d
+}
+
+// TrainMovements fetches the stations a train calls at on a day, these are
+// not polled as there would be a request for every train
+func (p *Provider) TrainMovements(ctx context.Context, trainCode string, date time.Time) ([]transit.Departure, error) {
+	movements, err := p.Client.TrainMovements(ctx, trainCode, date)
+	if err != nil {
+		return nil, err
+	}
+
+	return toDepartures(movements)
+}
@@ -0,0 +1,107 @@
+package irishrail
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromConfig(t *testing.T) {
+	t.Run("creates the provider", func(t *testing.T) {
+		p, err := FromConfig(config.Config{IrishRail: config.IrishRail{URL: "http://localhost", Stations: []string{"MHIDE", "CNLLY"}}})
+		assert.NoError(t, err)
+		assert.Equal(t, "irishrail", p.Name())
+		assert.Equal(t, []string{"irishrail"}, p.Operators())
+		assert.Equal(t, []string{"MHIDE", "CNLLY"}, p.(*Provider).Stations)
+	})
+
+	t.Run("is not configured without a URL", func(t *testing.T) {
+		_, err := FromConfig(config.Config{IrishRail: config.IrishRail{Stations: []string{"MHIDE", "CNLLY"}}})
+		assert.ErrorIs(t, err, provider.ErrNotConfigured)
+	})
+}
+
+func TestProviderPoll(t *testing.T) {
+	t.Run("keeps every train and the departures of each station", func(t *testing.T) {
+		srv := apiServer(t)
+		p := New(srv.URL, []string{"MHIDE"})
+		now := time.Date(2025, 1, 21, 23, 40, 0, 0, dublin)
+		p.now = func() time.Time { return now }
+
+		assert.NoError(t, p.Poll(context.Background()), "expected poll to succeed")
+		assert.Equal(t, 4, p.Len())
+
+		v, ok := p.Vehicle("E109")
+		assert.True(t, ok, "expected train to be known")
+		assert.Equal(t, "Bray", v.Headsign)
+		assert.Equal(t, now, v.Timestamp)
+
+		vehicles := p.Vehicles()
+		assert.Equal(t, "A409", vehicles[0].ID, "expected trains to be ordered by code")
+
+		departures := p.Departures("MHIDE")
+		assert.Len(t, departures, 3)
+		assert.Equal(t, "E119", departures[0].TripID)
+		assert.Equal(t, time.Date(2025, 1, 22, 0, 2, 0, 0, dublin), departures[1].ExpectedDeparture)
+		assert.Empty(t, p.Departures("BRAY"), "expected no departures for a station which is not polled")
+	})
+
+	t.Run("keeps the previous departures of a station which cannot be fetched", func(t *testing.T) {
+		srv := apiServer(t)
+		p := New(srv.URL, []string{"MHIDE", "BRAY"})
+		p.departures["BRAY"] = []transit.Departure{{StopID: "BRAY"}}
+
+		assert.EqualError(t, p.Poll(context.Background()), "unexpected getStationDataByCodeXML response status 500")
+		assert.Equal(t, 4, p.Len(), "expected trains to be updated")
+		assert.Len(t, p.Departures("MHIDE"), 3)
+		assert.Equal(t, []transit.Departure{{StopID: "BRAY"}}, p.Departures("BRAY"))
+	})
+
+	t.Run("errors when the trains cannot be fetched", func(t *testing.T) {
+		srv := apiServer(t)
+		srv.Close()
+		p := New(srv.URL, nil)
+		assert.Error(t, p.Poll(context.Background()))
+		assert.Equal(t, 0, p.Len())
+	})
+}
+
+func TestProviderTrainMovements(t *testing.T) {
+	t.Run("maps the stations the train stops at", func(t *testing.T) {
+		srv := apiServer(t)
+		p := New(srv.URL, nil)
+		departures, err := p.TrainMovements(context.Background(), "E109", time.Date(2025, 1, 21, 0, 0, 0, 0, dublin))
+		assert.NoError(t, err)
+		assert.Len(t, departures, 4, "expected the timing point to be left out")
+		assert.Equal(t, "HWTHJ", departures[1].StopID)
+		assert.Equal(t, 2*time.Minute, *departures[1].Delay)
+		assert.Equal(t, time.Date(2025, 1, 21, 10, 42, 0, 0, dublin), departures[3].ExpectedArrival)
+	})
+
+	t.Run("errors when the movements cannot be fetched", func(t *testing.T) {
+		srv := apiServer(t)
+		p := New(srv.URL, nil)
+		_, err := p.TrainMovements(context.Background(), "E999", time.Date(2025, 1, 21, 0, 0, 0, 0, dublin))
+		assert.Error(t, err)
+	})
+}
+
+func TestProviderHealth(t *testing.T) {
+	t.Run("records the outcome of every poll", func(t *testing.T) {
+		srv := apiServer(t)
+		p := New(srv.URL, []string{"XXXX"})
+		assert.Error(t, p.Poll(context.Background()))
+		assert.False(t, p.Health().Healthy, "expected provider to be unhealthy")
+		assert.Equal(t, 1, p.Health().ConsecutiveFailures)
+
+		p.Stations = []string{"MHIDE"}
+		assert.NoError(t, p.Poll(context.Background()))
+		assert.True(t, p.Health().Healthy, "expected provider to be healthy")
+		assert.Equal(t, 0, p.Health().ConsecutiveFailures)
+	})
+}
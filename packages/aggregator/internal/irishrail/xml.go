@@ -0,0 +1,53 @@
+package irishrail
+
+// TrainPosition is an objTrainPositions element of getCurrentTrainsXML
+type TrainPosition struct {
+	TrainStatus    string  `xml:"TrainStatus"`
+	TrainLatitude  float64 `xml:"TrainLatitude"`
+	TrainLongitude float64 `xml:"TrainLongitude"`
+	TrainCode      string  `xml:"TrainCode"`
+	TrainDate      string  `xml:"TrainDate"`
+	PublicMessage  string  `xml:"PublicMessage"`
+	Direction      string  `xml:"Direction"`
+}
+
+// StationData is an objStationData element of getStationDataByCodeXML
+type StationData struct {
+	Servertime      string `xml:"Servertime"`
+	Traincode       string `xml:"Traincode"`
+	Stationfullname string `xml:"Stationfullname"`
+	Stationcode     string `xml:"Stationcode"`
+	Traindate       string `xml:"Traindate"`
+	Origin          string `xml:"Origin"`
+	Destination     string `xml:"Destination"`
+	Status          string `xml:"Status"`
+	Lastlocation    string `xml:"Lastlocation"`
+	Duein           int    `xml:"Duein"`
+	Late            int    `xml:"Late"`
+	Exparrival      string `xml:"Exparrival"`
+	Expdepart       string `xml:"Expdepart"`
+	Scharrival      string `xml:"Scharrival"`
+	Schdepart       string `xml:"Schdepart"`
+	Direction       string `xml:"Direction"`
+	Traintype       string `xml:"Traintype"`
+	Locationtype    string `xml:"Locationtype"`
+}
+
+// TrainMovement is an objTrainMovements element of getTrainMovementsXML
+type TrainMovement struct {
+	TrainCode          string `xml:"TrainCode"`
+	TrainDate          string `xml:"TrainDate"`
+	LocationCode       string `xml:"LocationCode"`
+	LocationFullName   string `xml:"LocationFullName"`
+	LocationOrder      int    `xml:"LocationOrder"`
+	LocationType       string `xml:"LocationType"`
+	TrainOrigin        string `xml:"TrainOrigin"`
+	TrainDestination   string `xml:"TrainDestination"`
+	ScheduledArrival   string `xml:"ScheduledArrival"`
+	ScheduledDeparture string `xml:"ScheduledDeparture"`
+	ExpectedArrival    string `xml:"ExpectedArrival"`
+	ExpectedDeparture  string `xml:"ExpectedDeparture"`
+	Arrival            string `xml:"Arrival"`
+	Departure          string `xml:"Departure"`
+	StopType           string `xml:"StopType"`
+}
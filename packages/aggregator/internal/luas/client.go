@@ -0,0 +1,76 @@
+package luas
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+)
+
+// Client calls the Luas forecasting API, such as
+// https://luasforecasts.rpa.ie/xml/get.ashx
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL:    baseURL,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *Client) get(ctx context.Context, query url.Values, v any) error {
+	action := query.Get("action")
+	query.Set("encrypt", "false")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return fmt.Errorf("could not create %s request: %w", action, err)
+	}
+	req.Header.Set("Accept", "application/xml")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not fetch %s: %w", action, err)
+	}
+	defer resp.Body.Close()
+
+	if err := provider.CheckResponse(resp, action); err != nil {
+		return err
+	}
+
+	if err := xml.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("could not decode %s: %w", action, err)
+	}
+
+	return nil
+}
+
+// Lines returns every line and the stops along it
+func (c *Client) Lines(ctx context.Context) ([]Line, error) {
+	var resp struct {
+		Lines []Line `xml:"line"`
+	}
+	if err := c.get(ctx, url.Values{"action": {"stops"}}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.Lines, nil
+}
+
+// Forecast returns the trams due at a stop in each direction and the status
+// message of its line
+func (c *Client) Forecast(ctx context.Context, stop string) (StopInfo, error) {
+	var info StopInfo
+	if err := c.get(ctx, url.Values{"action": {"forecast"}, "stop": {stop}}, &info); err != nil {
+		return StopInfo{}, err
+	}
+
+	return info, nil
+}
@@ -0,0 +1,117 @@
+package luas
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// apiServer stands in for the Luas forecasting API, serving the recorded
+// responses in testdata
+func apiServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("encrypt") != "false" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		file := ""
+		switch q.Get("action") {
+		case "stops":
+			file = "stops.xml"
+		case "forecast":
+			switch q.Get("stop") {
+			case "RAN":
+				file = "forecast_ran.xml"
+			case "ABB":
+				file = "forecast_abb.xml"
+			}
+		}
+		if file == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		b, err := os.ReadFile("testdata/" + file)
+		assert.NoError(t, err, "could not read recorded response")
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		_, _ = w.Write(b)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func TestClientLines(t *testing.T) {
+	t.Run("decodes every line and stop", func(t *testing.T) {
+		srv := apiServer(t)
+		lines, err := NewClient(srv.URL).Lines(context.Background())
+		assert.NoError(t, err, "expected lines to be fetched")
+		assert.Len(t, lines, 2)
+		assert.Equal(t, "Luas Red Line", lines[0].Name)
+		assert.Len(t, lines[0].Stops, 3)
+		assert.Equal(t, Stop{
+			Abbreviation:  "TAL",
+			Name:          "Tallaght",
+			Pronunciation: "Tallaght",
+			Latitude:      53.28733,
+			Longitude:     -6.37456,
+			ParkRide:      true,
+			CycleRide:     true,
+		}, lines[0].Stops[2])
+	})
+
+	t.Run("errors on an unexpected status", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		defer srv.Close()
+		_, err := NewClient(srv.URL).Lines(context.Background())
+		assert.EqualError(t, err, "unexpected stops response status 404")
+	})
+
+	t.Run("errors on an invalid response", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("<stops><line>"))
+		}))
+		defer srv.Close()
+		_, err := NewClient(srv.URL).Lines(context.Background())
+		assert.ErrorContains(t, err, "could not decode stops")
+	})
+
+	t.Run("errors when the API cannot be reached", func(t *testing.T) {
+		srv := apiServer(t)
+		srv.Close()
+		_, err := NewClient(srv.URL).Lines(context.Background())
+		assert.ErrorContains(t, err, "could not fetch stops")
+	})
+}
+
+func TestClientForecast(t *testing.T) {
+	t.Run("decodes the trams due in each direction", func(t *testing.T) {
+		srv := apiServer(t)
+		info, err := NewClient(srv.URL).Forecast(context.Background(), "RAN")
+		assert.NoError(t, err)
+		assert.Equal(t, StopInfo{
+			Created:      "2025-01-21T10:00:12",
+			Stop:         "Ranelagh",
+			Abbreviation: "RAN",
+			Message:      "Green Line services operating normally",
+			Directions: []Direction{
+				{Name: "Inbound", Trams: []Tram{{DueMins: "DUE", Destination: "Parnell"}, {DueMins: "7", Destination: "Broombridge"}}},
+				{Name: "Outbound", Trams: []Tram{{DueMins: "3", Destination: "Brides Glen"}, {DueMins: "12", Destination: "Sandyford"}}},
+			},
+		}, info)
+	})
+
+	t.Run("errors on an unexpected status", func(t *testing.T) {
+		srv := apiServer(t)
+		_, err := NewClient(srv.URL).Forecast(context.Background(), "XXX")
+		assert.EqualError(t, err, "unexpected forecast response status 500")
+	})
+}
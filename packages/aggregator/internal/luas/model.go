@@ -0,0 +1,99 @@
+package luas
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+const (
+	source        = "luas"
+	operator      = "luas"
+	createdLayout = "2006-01-02T15:04:05"
+)
+
+// The API reports every time in Irish local time without an offset
+var dublin, _ = time.LoadLocation("Europe/Dublin")
+
+// lineID turns a line name such as "Luas Red Line" into "red"
+func lineID(name string) string {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "Luas ")
+
+	return strings.ToLower(strings.TrimSuffix(name, " Line"))
+}
+
+func parseCreated(s string) (time.Time, error) {
+	created, err := time.ParseInLocation(createdLayout, strings.TrimSpace(s), dublin)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid forecast time %q", s)
+	}
+
+	return created, nil
+}
+
+// toDepartures turns the trams forecast at a stop into departures, Luas does
+// not publish a timetable or identify trams so only the expected time is known
+func toDepartures(info StopInfo, line string) ([]transit.Departure, error) {
+	created, err := parseCreated(info.Created)
+	if err != nil {
+		return nil, err
+	}
+
+	var departures []transit.Departure
+	for _, direction := range info.Directions {
+		for _, tram := range direction.Trams {
+			due := 0
+			switch mins := strings.TrimSpace(tram.DueMins); mins {
+			case "":
+				// "No trams forecast" is sent as a tram without a due time
+				continue
+			case "DUE":
+			default:
+				if due, err = strconv.Atoi(mins); err != nil {
+					return nil, fmt.Errorf("invalid due time %q", tram.DueMins)
+				}
+			}
+
+			expected := created.Add(time.Duration(due) * time.Minute)
+			departures = append(departures, transit.Departure{
+				StopID:            info.Abbreviation,
+				Source:            source,
+				Operator:          operator,
+				Mode:              transit.ModeTram,
+				RouteID:           line,
+				Headsign:          tram.Destination,
+				Direction:         direction.Name,
+				ExpectedArrival:   expected,
+				ExpectedDeparture: expected,
+				Status:            transit.DepartureScheduled,
+				Realtime:          true,
+			})
+		}
+	}
+
+	return departures, nil
+}
+
+// toLineStatus reads the status of a line from the message sent with the
+// forecast of any of its stops
+func toLineStatus(info StopInfo, line, name string) (transit.LineStatus, error) {
+	created, err := parseCreated(info.Created)
+	if err != nil {
+		return transit.LineStatus{}, err
+	}
+
+	message := strings.TrimSpace(info.Message)
+
+	return transit.LineStatus{
+		Operator: operator,
+		RouteID:  line,
+		Name:     name,
+		Normal:   strings.Contains(strings.ToLower(message), "operating normally"),
+		Message:  message,
+		Updated:  created,
+	}, nil
+}
@@ -0,0 +1,114 @@
+package luas
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineID(t *testing.T) {
+	assert.Equal(t, "red", lineID("Luas Red Line"))
+	assert.Equal(t, "green", lineID(" Luas Green Line "))
+}
+
+func TestToDepartures(t *testing.T) {
+	t.Run("maps every forecast tram", func(t *testing.T) {
+		departures, err := toDepartures(StopInfo{
+			Created:      "2025-01-21T23:55:40",
+			Abbreviation: "ABB",
+			Directions: []Direction{
+				{Name: "Inbound", Trams: []Tram{{DueMins: "DUE", Destination: "The Point"}}},
+				{Name: "Outbound", Trams: []Tram{{DueMins: "8", Destination: "Tallaght"}}},
+			},
+		}, "red")
+		assert.NoError(t, err)
+
+		now := time.Date(2025, 1, 21, 23, 55, 40, 0, dublin)
+		later := time.Date(2025, 1, 22, 0, 3, 40, 0, dublin)
+		assert.Equal(t, []transit.Departure{
+			{
+				StopID:            "ABB",
+				Source:            "luas",
+				Operator:          "luas",
+				Mode:              transit.ModeTram,
+				RouteID:           "red",
+				Headsign:          "The Point",
+				Direction:         "Inbound",
+				ExpectedArrival:   now,
+				ExpectedDeparture: now,
+				Status:            transit.DepartureScheduled,
+				Realtime:          true,
+			},
+			{
+				StopID:            "ABB",
+				Source:            "luas",
+				Operator:          "luas",
+				Mode:              transit.ModeTram,
+				RouteID:           "red",
+				Headsign:          "Tallaght",
+				Direction:         "Outbound",
+				ExpectedArrival:   later,
+				ExpectedDeparture: later,
+				Status:            transit.DepartureScheduled,
+				Realtime:          true,
+			},
+		}, departures)
+	})
+
+	t.Run("leaves out directions without trams forecast", func(t *testing.T) {
+		departures, err := toDepartures(StopInfo{
+			Created:    "2025-01-21T23:55:40",
+			Directions: []Direction{{Name: "Inbound", Trams: []Tram{{Destination: "No trams forecast"}}}},
+		}, "red")
+		assert.NoError(t, err)
+		assert.Empty(t, departures)
+	})
+
+	t.Run("errors on an invalid due time", func(t *testing.T) {
+		_, err := toDepartures(StopInfo{
+			Created:    "2025-01-21T23:55:40",
+			Directions: []Direction{{Trams: []Tram{{DueMins: "soon"}}}},
+		}, "red")
+		assert.EqualError(t, err, `invalid due time "soon"`)
+	})
+
+	t.Run("errors on an invalid forecast time", func(t *testing.T) {
+		_, err := toDepartures(StopInfo{Created: "now"}, "red")
+		assert.EqualError(t, err, `invalid forecast time "now"`)
+	})
+}
+
+func TestToLineStatus(t *testing.T) {
+	t.Run("reports a line operating normally", func(t *testing.T) {
+		status, err := toLineStatus(StopInfo{
+			Created: "2025-01-21T10:00:12",
+			Message: " Green Line services operating normally ",
+		}, "green", "Luas Green Line")
+		assert.NoError(t, err)
+		assert.Equal(t, transit.LineStatus{
+			Operator: "luas",
+			RouteID:  "green",
+			Name:     "Luas Green Line",
+			Normal:   true,
+			Message:  "Green Line services operating normally",
+			Updated:  time.Date(2025, 1, 21, 10, 0, 12, 0, dublin),
+		}, status)
+	})
+
+	t.Run("reports a disrupted line", func(t *testing.T) {
+		status, err := toLineStatus(StopInfo{
+			Created: "2025-01-21T23:55:40",
+			Message: "Red Line: No service between Heuston and The Point",
+		}, "red", "Luas Red Line")
+		assert.NoError(t, err)
+		assert.False(t, status.Normal)
+		assert.Equal(t, "Red Line: No service between Heuston and The Point", status.Message)
+	})
+
+	t.Run("errors on an invalid forecast time", func(t *testing.T) {
+		_, err := toLineStatus(StopInfo{Created: ""}, "red", "Luas Red Line")
+		assert.EqualError(t, err, `invalid forecast time ""`)
+	})
+}
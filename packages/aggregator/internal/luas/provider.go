@@ -0,0 +1,222 @@
+package luas
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+)
+
+// Provider polls the Luas forecasting API for the trams due at a set of stops
+// and the status of each line. The line of every stop is looked up from the
+// list of stops the first time it is polled
+type Provider struct {
+	provider.HealthTracker
+
+	Client *Client
+	Stops  []string
+
+	mu         sync.RWMutex
+	lines      map[string]Line
+	stopLines  map[string]string
+	departures map[string][]transit.Departure
+	statuses   map[string]transit.LineStatus
+}
+
+// New creates a provider for the given stop abbreviations, every stop is
+// polled when none are given
+func New(baseURL string, stops []string) *Provider {
+	return &Provider{
+		Client:     NewClient(baseURL),
+		Stops:      stops,
+		departures: map[string][]transit.Departure{},
+		statuses:   map[string]transit.LineStatus{},
+	}
+}
+
+func (p *Provider) loadLines(ctx context.Context) error {
+	p.mu.RLock()
+	loaded := p.stopLines != nil
+	p.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+
+	lines, err := p.Client.Lines(ctx)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]Line, len(lines))
+	stopLines := map[string]string{}
+	for _, l := range lines {
+		id := lineID(l.Name)
+		byID[id] = l
+		for _, s := range l.Stops {
+			stopLines[s.Abbreviation] = id
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lines, p.stopLines = byID, stopLines
+
+	return nil
+}
+
+func (p *Provider) stops() []string {
+	if len(p.Stops) != 0 {
+		return p.Stops
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	stops := make([]string, 0, len(p.stopLines))
+	for stop := range p.stopLines {
+		stops = append(stops, stop)
+	}
+	sort.Strings(stops)
+
+	return stops
+}
+
+// FromConfig creates the provider from the Luas settings, every stop is
+// polled when none are configured
+func FromConfig(cfg config.Config) (provider.Provider, error) {
+	if !cfg.Configured("luas") {
+		return nil, provider.ErrNotConfigured
+	}
+
+	return New(cfg.Luas.URL, cfg.Luas.Stops), nil
+}
+
+func (p *Provider) Name() string {
+	return source
+}
+
+func (p *Provider) Operators() []string {
+	return []string{operator}
+}
+
+// Poll fetches the forecast of every stop once, a stop which cannot be
+// fetched keeps its previous departures
+func (p *Provider) Poll(ctx context.Context) error {
+	err := p.poll(ctx)
+	p.Record(err)
+
+	return err
+}
+
+func (p *Provider) poll(ctx context.Context) error {
+	if err := p.loadLines(ctx); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, stop := range p.stops() {
+		if err := p.pollStop(ctx, stop); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p *Provider) pollStop(ctx context.Context, stop string) error {
+	info, err := p.Client.Forecast(ctx, stop)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	line, ok := p.stopLines[stop]
+	if !ok {
+		return fmt.Errorf("unknown stop %s", stop)
+	}
+
+	departures, err := toDepartures(info, line)
+	if err != nil {
+		return fmt.Errorf("stop %s: %w", stop, err)
+	}
+
+	status, err := toLineStatus(info, line, p.lines[line].Name)
+	if err != nil {
+		return fmt.Errorf("stop %s: %w", stop, err)
+	}
+
+	p.departures[stop] = departures
+	if !status.Updated.Before(p.statuses[line].Updated) {
+		p.statuses[line] = status
+	}
+
+	return nil
+}
+
+// Len returns the number of departures held across every stop
+func (p *Provider) Len() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	n := 0
+	for _, departures := range p.departures {
+		n += len(departures)
+	}
+
+	return n
+}
+
+// Departures returns the departures last polled for a stop
+func (p *Provider) Departures(stop string) []transit.Departure {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return append([]transit.Departure(nil), p.departures[stop]...)
+}
+
+func (p *Provider) LineStatus(line string) (transit.LineStatus, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	s, ok := p.statuses[line]
+
+	return s, ok
+}
+
+// LineStatuses returns the status of every line ordered by line
+func (p *Provider) LineStatuses() []transit.LineStatus {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	statuses := make([]transit.LineStatus, 0, len(p.statuses))
+	for _, s := range p.statuses {
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].RouteID < statuses[j].RouteID })
+
+	return statuses
+}
+
+// Dataset returns the departures of every stop ordered by stop, and the
+// status of every line
+func (p *Provider) Dataset() transit.Dataset {
+	p.mu.RLock()
+	stops := make([]string, 0, len(p.departures))
+	for stop := range p.departures {
+		stops = append(stops, stop)
+	}
+	p.mu.RUnlock()
+	sort.Strings(stops)
+
+	d := transit.Dataset{LineStatuses: p.LineStatuses()}
+	for _, stop := range stops {
+		d.Departures = append(d.Departures, p.Departures(stop)...)
+	}
+
+	return d
+}
@@ -0,0 +1,107 @@
+package luas
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromConfig(t *testing.T) {
+	t.Run("creates the provider", func(t *testing.T) {
+		p, err := FromConfig(config.Config{Luas: config.Luas{URL: "http://localhost", Stops: []string{"RAN", "ABB"}}})
+		assert.NoError(t, err)
+		assert.Equal(t, "luas", p.Name())
+		assert.Equal(t, []string{"luas"}, p.Operators())
+		assert.Equal(t, []string{"RAN", "ABB"}, p.(*Provider).Stops)
+	})
+
+	t.Run("is not configured without a URL", func(t *testing.T) {
+		_, err := FromConfig(config.Config{Luas: config.Luas{Stops: []string{"RAN", "ABB"}}})
+		assert.ErrorIs(t, err, provider.ErrNotConfigured)
+	})
+}
+
+func TestProviderPoll(t *testing.T) {
+	t.Run("keeps the departures of each stop and the status of each line", func(t *testing.T) {
+		srv := apiServer(t)
+		p := New(srv.URL, []string{"RAN", "ABB"})
+
+		assert.NoError(t, p.Poll(context.Background()), "expected poll to succeed")
+		assert.Equal(t, 5, p.Len())
+
+		ranelagh := p.Departures("RAN")
+		assert.Len(t, ranelagh, 4)
+		assert.Equal(t, "green", ranelagh[0].RouteID)
+		assert.Equal(t, "Parnell", ranelagh[0].Headsign)
+
+		abbey := p.Departures("ABB")
+		assert.Len(t, abbey, 1, "expected no trams forecast to be left out")
+		assert.Equal(t, "red", abbey[0].RouteID)
+
+		statuses := p.LineStatuses()
+		assert.Len(t, statuses, 2)
+		assert.Equal(t, "green", statuses[0].RouteID, "expected statuses to be ordered by line")
+		assert.True(t, statuses[0].Normal)
+		assert.Equal(t, "Luas Green Line", statuses[0].Name)
+
+		red, ok := p.LineStatus("red")
+		assert.True(t, ok, "expected red line status")
+		assert.False(t, red.Normal, "expected red line to be disrupted")
+	})
+
+	t.Run("keeps the previous departures of a stop which cannot be fetched", func(t *testing.T) {
+		srv := apiServer(t)
+		p := New(srv.URL, []string{"RAN", "TAL"})
+		p.departures["TAL"] = []transit.Departure{{StopID: "TAL"}}
+
+		assert.EqualError(t, p.Poll(context.Background()), "unexpected forecast response status 500")
+		assert.Len(t, p.Departures("RAN"), 4)
+		assert.Equal(t, []transit.Departure{{StopID: "TAL"}}, p.Departures("TAL"))
+	})
+
+	t.Run("polls every stop when none are given", func(t *testing.T) {
+		srv := apiServer(t)
+		p := New(srv.URL, nil)
+
+		assert.Error(t, p.Poll(context.Background()), "expected the stops without recordings to fail")
+		assert.Len(t, p.Departures("RAN"), 4)
+		assert.Len(t, p.Departures("ABB"), 1)
+	})
+
+	t.Run("errors on a stop which is not on any line", func(t *testing.T) {
+		srv := apiServer(t)
+		p := New(srv.URL, []string{"RAN"})
+		assert.NoError(t, p.Poll(context.Background()))
+
+		p.Stops = []string{"ABB"}
+		delete(p.stopLines, "ABB")
+		assert.EqualError(t, p.Poll(context.Background()), "unknown stop ABB")
+	})
+
+	t.Run("errors when the stops cannot be fetched", func(t *testing.T) {
+		srv := apiServer(t)
+		srv.Close()
+		p := New(srv.URL, []string{"RAN"})
+		assert.ErrorContains(t, p.Poll(context.Background()), "could not fetch stops")
+		assert.Equal(t, 0, p.Len())
+	})
+}
+
+func TestProviderHealth(t *testing.T) {
+	t.Run("records the outcome of every poll", func(t *testing.T) {
+		srv := apiServer(t)
+		p := New(srv.URL, []string{"TAL"})
+		assert.Error(t, p.Poll(context.Background()))
+		assert.False(t, p.Health().Healthy, "expected provider to be unhealthy")
+		assert.Equal(t, 1, p.Health().ConsecutiveFailures)
+
+		p.Stops = []string{"RAN"}
+		assert.NoError(t, p.Poll(context.Background()))
+		assert.True(t, p.Health().Healthy, "expected provider to be healthy")
+		assert.Equal(t, 0, p.Health().ConsecutiveFailures)
+	})
+}
@@ -0,0 +1,38 @@
+package luas
+
+// Line is a line element of the stops action
+type Line struct {
+	Name  string `xml:"name,attr"`
+	Stops []Stop `xml:"stop"`
+}
+
+type Stop struct {
+	Abbreviation  string  `xml:"abrev,attr"`
+	Name          string  `xml:",chardata"`
+	Pronunciation string  `xml:"pronunciation,attr"`
+	Latitude      float64 `xml:"lat,attr"`
+	Longitude     float64 `xml:"long,attr"`
+	ParkRide      bool    `xml:"isParkRide,attr"`
+	CycleRide     bool    `xml:"isCycleRide,attr"`
+}
+
+// StopInfo is the stopInfo element of the forecast action
+type StopInfo struct {
+	Created      string      `xml:"created,attr"`
+	Stop         string      `xml:"stop,attr"`
+	Abbreviation string      `xml:"stopAbv,attr"`
+	Message      string      `xml:"message"`
+	Directions   []Direction `xml:"direction"`
+}
+
+type Direction struct {
+	Name  string `xml:"name,attr"`
+	Trams []Tram `xml:"tram"`
+}
+
+// Tram is a forecast tram, DueMins is either a number of minutes, DUE or
+// empty when no trams are forecast
+type Tram struct {
+	DueMins     string `xml:"dueMins,attr"`
+	Destination string `xml:"destination,attr"`
+}
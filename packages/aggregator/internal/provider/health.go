@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+type Health struct {
+	Healthy             bool      `json:"healthy"`
+	LastPoll            time.Time `json:"last_poll"`
+	LastSuccess         time.Time `json:"last_success"`
+	LastError           string    `json:"last_error,omitempty"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+}
+
+// HealthTracker records the outcome of every poll, providers embed it to
+// implement Health
+type HealthTracker struct {
+	mu     sync.RWMutex
+	health Health
+	now    func() time.Time
+}
+
+// Record records the outcome of a poll, polls cancelled on shutdown are not
+// the upstream's fault and are ignored
+func (t *HealthTracker) Record(err error) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if t.now != nil {
+		now = t.now()
+	}
+
+	t.health.LastPoll = now
+	if err != nil {
+		t.health.Healthy = false
+		t.health.LastError = err.Error()
+		t.health.ConsecutiveFailures++
+
+		return
+	}
+
+	t.health.Healthy = true
+	t.health.LastSuccess = now
+	t.health.LastError = ""
+	t.health.ConsecutiveFailures = 0
+}
+
+func (t *HealthTracker) Health() Health {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.health
+}
@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthTracker(t *testing.T) {
+	t.Run("is unhealthy until polled", func(t *testing.T) {
+		tracker := HealthTracker{}
+		assert.Equal(t, Health{}, tracker.Health())
+	})
+
+	t.Run("records failures and recoveries", func(t *testing.T) {
+		now := time.Unix(1737460000, 0)
+		tracker := HealthTracker{now: func() time.Time { return now }}
+
+		tracker.Record(nil)
+		assert.Equal(t, Health{Healthy: true, LastPoll: now, LastSuccess: now}, tracker.Health())
+
+		success := now
+		now = now.Add(time.Minute)
+		tracker.Record(errors.New("unexpected feed response status 503"))
+		tracker.Record(errors.New("unexpected feed response status 503"))
+		assert.Equal(t, Health{
+			LastPoll:            now,
+			LastSuccess:         success,
+			LastError:           "unexpected feed response status 503",
+			ConsecutiveFailures: 2,
+		}, tracker.Health())
+
+		tracker.Record(nil)
+		assert.Equal(t, Health{Healthy: true, LastPoll: now, LastSuccess: now}, tracker.Health())
+	})
+
+	t.Run("ignores cancelled polls", func(t *testing.T) {
+		tracker := HealthTracker{}
+		tracker.Record(fmt.Errorf("could not fetch feed: %w", context.Canceled))
+		assert.Equal(t, Health{}, tracker.Health())
+	})
+}
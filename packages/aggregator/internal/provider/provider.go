@@ -0,0 +1,29 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+// Provider is a source of realtime data for one or more operators, each
+// adapter is its own package which implements it
+type Provider interface {
+	Name() string
+	// Operators returns the ids of the operators the provider has data for
+	Operators() []string
+	// Poll fetches the source once
+	Poll(ctx context.Context) error
+	Health() Health
+	// Dataset returns everything the provider currently knows mapped to the
+	// canonical model
+	Dataset() transit.Dataset
+}
+
+// Streamer is implemented by providers which are pushed updates rather than
+// being polled, Stream blocks until the context is cancelled and calls updated
+// after every update it is pushed
+type Streamer interface {
+	Provider
+	Stream(ctx context.Context, updated func()) error
+}
@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+type fakeProvider struct {
+	HealthTracker
+
+	name string
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Operators() []string {
+	return []string{p.name}
+}
+
+func (p *fakeProvider) Poll(ctx context.Context) error {
+	p.Record(nil)
+
+	return nil
+}
+
+func (p *fakeProvider) Dataset() transit.Dataset {
+	return transit.Dataset{}
+}
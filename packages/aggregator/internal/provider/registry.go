@@ -0,0 +1,77 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/config"
+)
+
+// ErrNotConfigured is returned by a factory when the settings the provider
+// needs, such as its URL, are not set
+var ErrNotConfigured = errors.New("provider is not configured")
+
+type Factory func(cfg config.Config) (Provider, error)
+
+// Entry is a provider built by the registry and the interval to poll it at
+type Entry struct {
+	Provider Provider
+	Interval time.Duration
+}
+
+// Registry holds the factory of every known provider by name
+type Registry struct {
+	names     []string
+	factories map[string]Factory
+}
+
+func NewRegistry() *Registry {
+	return &Registry{factories: map[string]Factory{}}
+}
+
+// Register adds a factory, it panics if the name is already registered
+func (r *Registry) Register(name string, f Factory) {
+	if _, ok := r.factories[name]; ok {
+		panic(fmt.Sprintf("provider %s is already registered", name))
+	}
+	r.names = append(r.names, name)
+	r.factories[name] = f
+}
+
+// Names returns the name of every registered provider in the order they were
+// registered
+func (r *Registry) Names() []string {
+	return append([]string(nil), r.names...)
+}
+
+// Build creates the providers enabled in the config, every registered provider
+// which is configured is created when none are enabled. A provider which is
+// enabled but not configured is an error
+func (r *Registry) Build(cfg config.Config) ([]Entry, error) {
+	enabled := cfg.Providers
+	names := enabled
+	if len(names) == 0 {
+		names = r.names
+	}
+
+	var entries []Entry
+	for _, name := range names {
+		f, ok := r.factories[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown provider %s", name)
+		}
+
+		p, err := f(cfg)
+		if errors.Is(err, ErrNotConfigured) && len(enabled) == 0 {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not create provider %s: %w", name, err)
+		}
+
+		entries = append(entries, Entry{Provider: p, Interval: cfg.PollInterval(name)})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRegistry() *Registry {
+	r := NewRegistry()
+	r.Register("gtfsr", func(cfg config.Config) (Provider, error) {
+		if !cfg.Configured("gtfsr") {
+			return nil, ErrNotConfigured
+		}
+
+		return &fakeProvider{name: "gtfsr"}, nil
+	})
+	r.Register("luas", func(cfg config.Config) (Provider, error) {
+		return &fakeProvider{name: "luas"}, nil
+	})
+	r.Register("broken", func(cfg config.Config) (Provider, error) {
+		return nil, errors.New("invalid URL")
+	})
+
+	return r
+}
+
+func TestRegistryRegister(t *testing.T) {
+	t.Run("keeps the order providers are registered in", func(t *testing.T) {
+		assert.Equal(t, []string{"gtfsr", "luas", "broken"}, testRegistry().Names())
+	})
+
+	t.Run("panics when a name is registered twice", func(t *testing.T) {
+		r := testRegistry()
+		assert.PanicsWithValue(t, "provider gtfsr is already registered", func() {
+			r.Register("gtfsr", nil)
+		})
+	})
+}
+
+func TestRegistryBuild(t *testing.T) {
+	t.Run("builds the enabled providers", func(t *testing.T) {
+		entries, err := testRegistry().Build(config.Config{
+			Providers: []string{"luas"},
+			Luas:      config.Luas{PollInterval: "1m"},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, "luas", entries[0].Provider.Name())
+		assert.Equal(t, time.Minute, entries[0].Interval)
+	})
+
+	t.Run("defaults the interval to 30 seconds", func(t *testing.T) {
+		entries, err := testRegistry().Build(config.Config{
+			Providers: []string{"gtfsr"},
+			GTFSR:     config.GTFSR{AlertsURL: "http://localhost"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 30*time.Second, entries[0].Interval)
+	})
+
+	t.Run("errors when an enabled provider is not configured", func(t *testing.T) {
+		_, err := testRegistry().Build(config.Config{Providers: []string{"gtfsr"}})
+		assert.ErrorIs(t, err, ErrNotConfigured)
+		assert.EqualError(t, err, "could not create provider gtfsr: provider is not configured")
+	})
+
+	t.Run("errors on an unknown provider", func(t *testing.T) {
+		_, err := testRegistry().Build(config.Config{Providers: []string{"bus-atha-cliath"}})
+		assert.EqualError(t, err, "unknown provider bus-atha-cliath")
+	})
+
+	t.Run("errors when a provider cannot be created", func(t *testing.T) {
+		_, err := testRegistry().Build(config.Config{})
+		assert.EqualError(t, err, "could not create provider broken: invalid URL")
+	})
+
+	t.Run("builds every configured provider when none are enabled", func(t *testing.T) {
+		r := NewRegistry()
+		r.Register("gtfsr", testRegistry().factories["gtfsr"])
+		r.Register("luas", testRegistry().factories["luas"])
+
+		entries, err := r.Build(config.Config{})
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1, "expected provider which is not configured to be skipped")
+		assert.Equal(t, "luas", entries[0].Provider.Name())
+	})
+}
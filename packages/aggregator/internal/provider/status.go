@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatusError is returned when an upstream responds with a status other than
+// 200, RetryAfter is set when the response has a Retry-After header
+type StatusError struct {
+	Request    string
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected %s response status %d", e.Request, e.StatusCode)
+}
+
+// Throttled reports whether the upstream asked to be called less often
+func (e *StatusError) Throttled() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusServiceUnavailable
+}
+
+// CheckResponse returns a StatusError unless the response status is 200,
+// request names what was requested in the error, such as "feed"
+func CheckResponse(resp *http.Response, request string) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	return &StatusError{
+		Request:    request,
+		StatusCode: resp.StatusCode,
+		RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// retryAfter parses a Retry-After header which is either a number of seconds
+// or an HTTP date
+func retryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+
+	return 0
+}
@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckResponse(t *testing.T) {
+	t.Run("accepts a 200", func(t *testing.T) {
+		assert.NoError(t, CheckResponse(&http.Response{StatusCode: http.StatusOK}, "feed"))
+	})
+
+	t.Run("errors on any other status", func(t *testing.T) {
+		err := CheckResponse(&http.Response{StatusCode: http.StatusUnauthorized, Header: http.Header{}}, "feed")
+		assert.EqualError(t, err, "unexpected feed response status 401")
+		assert.Equal(t, &StatusError{Request: "feed", StatusCode: 401}, err)
+	})
+
+	t.Run("reads the Retry-After header", func(t *testing.T) {
+		err := CheckResponse(&http.Response{
+			StatusCode: http.StatusTooManyRequests,
+			Header:     http.Header{"Retry-After": {"120"}},
+		}, "stops")
+		assert.Equal(t, &StatusError{Request: "stops", StatusCode: 429, RetryAfter: 2 * time.Minute}, err)
+	})
+}
+
+func TestStatusErrorThrottled(t *testing.T) {
+	assert.True(t, (&StatusError{StatusCode: http.StatusTooManyRequests}).Throttled())
+	assert.True(t, (&StatusError{StatusCode: http.StatusServiceUnavailable}).Throttled())
+	assert.False(t, (&StatusError{StatusCode: http.StatusInternalServerError}).Throttled())
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 21, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		header   string
+		expected time.Duration
+	}{
+		{"seconds", "30", 30 * time.Second},
+		{"http date", "Tue, 21 Jan 2025 10:05:00 GMT", 5 * time.Minute},
+		{"date in the past", "Tue, 21 Jan 2025 09:00:00 GMT", 0},
+		{"negative seconds", "-5", 0},
+		{"missing", "", 0},
+		{"invalid", "soon", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, retryAfter(tt.header, now))
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultJitter     = 0.1
+	DefaultMaxBackoff = 10 * time.Minute
+)
+
+// Scheduler runs every provider on its own interval, the first poll is made
+// straight away. Each interval is randomly shifted by up to Jitter of itself
+// so that providers sharing an upstream do not poll it at the same moment. A
+// provider which fails is backed off exponentially up to MaxBackoff, and an
+// upstream which responds with 429 or 503 is not called again before its
+// Retry-After
+type Scheduler struct {
+	Jitter     float64
+	MaxBackoff time.Duration
+	// Polled is called after every poll, whether or not it failed, as a
+	// provider keeps what it last fetched. It is also called after every update
+	// pushed to a streamer
+	Polled func(p provider.Provider)
+
+	random  func() float64
+	entries []provider.Entry
+}
+
+func New() *Scheduler {
+	return &Scheduler{
+		Jitter:     DefaultJitter,
+		MaxBackoff: DefaultMaxBackoff,
+		random:     rand.Float64,
+	}
+}
+
+// Add schedules a provider, it must be called before Run
+func (s *Scheduler) Add(p provider.Provider, interval time.Duration) {
+	s.entries = append(s.entries, provider.Entry{Provider: p, Interval: interval})
+}
+
+// Run runs every provider until the context is cancelled, it only returns
+// once none of them are polling
+func (s *Scheduler) Run(ctx context.Context) {
+	wg := sync.WaitGroup{}
+	for _, e := range s.entries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.run(ctx, e)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) run(ctx context.Context, e provider.Entry) {
+	name := e.Provider.Name()
+	streamer, streams := e.Provider.(provider.Streamer)
+
+	polled := func() {
+		if s.Polled != nil {
+			s.Polled(e.Provider)
+		}
+	}
+
+	failures := 0
+	for ctx.Err() == nil {
+		var err error
+		if streams {
+			err = streamer.Stream(ctx, polled)
+		} else {
+			err = e.Provider.Poll(ctx)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		polled()
+
+		if err != nil {
+			failures++
+		} else {
+			failures = 0
+		}
+		wait := s.next(e.Interval, failures, err)
+
+		switch {
+		case err != nil && streams:
+			log.Error().Err(err).Str("provider", name).Dur("retry_in", wait).Msg("could not stream from provider")
+		case err != nil:
+			log.Error().Err(err).Str("provider", name).Dur("retry_in", wait).Msg("could not poll provider")
+		default:
+			log.Debug().Str("provider", name).Msg("polled provider")
+		}
+
+		if !sleep(ctx, wait) {
+			return
+		}
+	}
+}
+
+// next returns how long to wait before polling again. The interval is
+// doubled for every consecutive failure up to MaxBackoff, then jittered. A
+// Retry-After sent with a 429 or 503 is always waited for, even past
+// MaxBackoff
+func (s *Scheduler) next(interval time.Duration, failures int, err error) time.Duration {
+	d := interval
+	for i := 0; i < failures && d < s.MaxBackoff; i++ {
+		d *= 2
+	}
+	if failures > 0 {
+		d = max(min(d, s.MaxBackoff), interval)
+	}
+	d += time.Duration((s.random()*2 - 1) * s.Jitter * float64(d))
+
+	var statusErr *provider.StatusError
+	if errors.As(err, &statusErr) && statusErr.Throttled() {
+		d = max(d, statusErr.RetryAfter)
+	}
+
+	return d
+}
+
+// sleep waits for d, it returns false if the context is cancelled first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
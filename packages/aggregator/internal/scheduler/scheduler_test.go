@@ -0,0 +1,300 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/go-test-utils"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+	"github.com/nsf/jsondiff"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProvider counts its polls and returns the next error of errs on each,
+// once they run out every poll succeeds
+type fakeProvider struct {
+	provider.HealthTracker
+
+	mu    sync.Mutex
+	errs  []error
+	hits  int
+	block bool
+	done  bool
+}
+
+func (p *fakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeProvider) Operators() []string {
+	return nil
+}
+
+func (p *fakeProvider) Poll(ctx context.Context) error {
+	p.mu.Lock()
+	p.hits++
+	var err error
+	if len(p.errs) != 0 {
+		err, p.errs = p.errs[0], p.errs[1:]
+	}
+	block := p.block
+	p.mu.Unlock()
+
+	if block {
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		p.mu.Lock()
+		p.done = true
+		p.mu.Unlock()
+	}
+
+	return err
+}
+
+func (p *fakeProvider) Dataset() transit.Dataset {
+	return transit.Dataset{}
+}
+
+func (p *fakeProvider) polls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.hits
+}
+
+// fakeStreamer is pushed the given number of updates every time it streams,
+// then its stream ends
+type fakeStreamer struct {
+	fakeProvider
+
+	streams chan struct{}
+	pushes  int
+}
+
+func (s *fakeStreamer) Stream(ctx context.Context, updated func()) error {
+	s.streams <- struct{}{}
+	for range s.pushes {
+		updated()
+	}
+
+	return errors.New("connection reset")
+}
+
+func noJitter() *Scheduler {
+	s := New()
+	s.random = func() float64 { return 0.5 }
+
+	return s
+}
+
+func TestSchedulerNext(t *testing.T) {
+	throttled := &provider.StatusError{Request: "feed", StatusCode: 429, RetryAfter: 5 * time.Minute}
+	unavailable := &provider.StatusError{Request: "feed", StatusCode: 503, RetryAfter: time.Second}
+	serverError := &provider.StatusError{Request: "feed", StatusCode: 500, RetryAfter: time.Hour}
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		failures int
+		err      error
+		expected time.Duration
+	}{
+		{"polls on the interval", 30 * time.Second, 0, nil, 30 * time.Second},
+		{"doubles on the first failure", 30 * time.Second, 1, errors.New("timeout"), time.Minute},
+		{"doubles on every failure", 30 * time.Second, 3, errors.New("timeout"), 4 * time.Minute},
+		{"stops at the maximum backoff", 30 * time.Second, 10, errors.New("timeout"), 10 * time.Minute},
+		{"never polls faster than the interval", time.Hour, 1, errors.New("timeout"), time.Hour},
+		{"waits for Retry-After", 30 * time.Second, 1, throttled, 5 * time.Minute},
+		{"waits for Retry-After of a joined error", 30 * time.Second, 1, errors.Join(errors.New("timeout"), throttled), 5 * time.Minute},
+		{"backs off past a shorter Retry-After", 30 * time.Second, 1, unavailable, time.Minute},
+		{"backs off when throttled without Retry-After", 30 * time.Second, 2, &provider.StatusError{StatusCode: 503}, 2 * time.Minute},
+		{"ignores Retry-After of other statuses", 30 * time.Second, 1, serverError, time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, noJitter().next(tt.interval, tt.failures, tt.err))
+		})
+	}
+
+	t.Run("jitters either way", func(t *testing.T) {
+		s := New()
+		s.random = func() float64 { return 0 }
+		assert.Equal(t, 27*time.Second, s.next(30*time.Second, 0, nil))
+		s.random = func() float64 { return 1 }
+		assert.Equal(t, 33*time.Second, s.next(30*time.Second, 0, nil))
+	})
+
+	t.Run("does not jitter below Retry-After", func(t *testing.T) {
+		s := New()
+		s.random = func() float64 { return 0 }
+		assert.Equal(t, 5*time.Minute, s.next(30*time.Second, 1, throttled))
+	})
+}
+
+func TestSchedulerRun(t *testing.T) {
+	t.Run("polls every provider on its own interval", func(t *testing.T) {
+		fast, slow := &fakeProvider{}, &fakeProvider{}
+		s := noJitter()
+		s.Add(fast, 10*time.Millisecond)
+		s.Add(slow, time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			s.Run(ctx)
+			close(done)
+		}()
+
+		assert.Eventually(t, func() bool { return fast.polls() >= 3 }, time.Second, 10*time.Millisecond)
+		assert.Equal(t, 1, slow.polls(), "expected slow provider to be polled once")
+		cancel()
+		assert.Eventually(t, func() bool {
+			select {
+			case <-done:
+				return true
+			default:
+				return false
+			}
+		}, time.Second, 10*time.Millisecond, "expected run to return")
+	})
+
+	t.Run("calls polled after every poll", func(t *testing.T) {
+		p := &fakeProvider{errs: []error{errors.New("timeout")}}
+		s := noJitter()
+		s.Add(p, 10*time.Millisecond)
+
+		var mu sync.Mutex
+		polled := 0
+		s.Polled = func(got provider.Provider) {
+			assert.Same(t, p, got)
+			mu.Lock()
+			defer mu.Unlock()
+			polled++
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go s.Run(ctx)
+
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+
+			return polled >= 2
+		}, time.Second, 10*time.Millisecond, "expected a failed and a successful poll to be reported")
+	})
+
+	t.Run("calls polled after every update pushed to a streamer", func(t *testing.T) {
+		p := &fakeStreamer{streams: make(chan struct{}, 1), pushes: 2}
+		s := noJitter()
+		s.Add(p, time.Hour)
+
+		polled := make(chan provider.Provider, 3)
+		s.Polled = func(got provider.Provider) { polled <- got }
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go s.Run(ctx)
+
+		for range 3 {
+			select {
+			case got := <-polled:
+				assert.Same(t, p, got)
+			case <-time.After(time.Second):
+				assert.Fail(t, "expected both updates and the end of the stream to be reported")
+
+				return
+			}
+		}
+	})
+
+	t.Run("returns only once every poll has returned", func(t *testing.T) {
+		p := &fakeProvider{block: true}
+		s := noJitter()
+		s.Add(p, time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			assert.Eventually(t, func() bool { return p.polls() == 1 }, time.Second, time.Millisecond)
+			cancel()
+		}()
+		s.Run(ctx)
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		assert.True(t, p.done, "expected poll in progress to have returned")
+	})
+
+	t.Run("does not poll once cancelled", func(t *testing.T) {
+		p := &fakeProvider{}
+		s := New()
+		s.Add(p, time.Hour)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		s.Run(ctx)
+		assert.Zero(t, p.polls())
+	})
+
+	t.Run("logs failed polls with when they are retried", func(t *testing.T) {
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		p := &fakeProvider{errs: []error{errors.New("timeout"), errors.New("timeout")}}
+		s := noJitter()
+		s.Add(p, 10*time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go s.Run(ctx)
+
+		assert.Eventually(t, func() bool { return p.polls() >= 3 }, time.Second, 10*time.Millisecond)
+		cancel()
+		for _, retryIn := range []int{20, 40} {
+			assert.True(t, logSink.ContainsLog(map[string]interface{}{
+				"level":    "error",
+				"error":    "timeout",
+				"provider": "fake",
+				"retry_in": retryIn,
+				"message":  "could not poll provider",
+			}, jsondiff.FullMatch), fmt.Sprintf("could not find failed poll log retried in %dms", retryIn))
+		}
+	})
+
+	t.Run("restarts streams which end", func(t *testing.T) {
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		p := &fakeStreamer{streams: make(chan struct{})}
+		s := noJitter()
+		s.Add(p, time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			s.Run(ctx)
+			close(done)
+		}()
+
+		<-p.streams
+		<-p.streams
+		cancel()
+		<-done
+		assert.Zero(t, p.polls(), "expected streamer not to be polled")
+		assert.True(t, logSink.ContainsLog(map[string]interface{}{
+			"level":    "error",
+			"error":    "connection reset",
+			"provider": "fake",
+			"retry_in": 2,
+			"message":  "could not stream from provider",
+		}, jsondiff.FullMatch), "could not find failed stream log")
+	})
+}
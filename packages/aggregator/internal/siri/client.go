@@ -0,0 +1,135 @@
+package siri
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+)
+
+// heartbeatInterval is how often a producer is asked to show a subscription
+// is still alive
+const heartbeatInterval = "PT1M"
+
+var errNoResponseStatus = errors.New("subscription response has no ResponseStatus")
+
+// Client posts SIRI requests to a VehicleMonitoring producer, RequestorRef
+// identifies the aggregator to it
+type Client struct {
+	URL          string
+	RequestorRef string
+	HTTPClient   *http.Client
+}
+
+func NewClient(url, requestorRef string) *Client {
+	return &Client{
+		URL:          url,
+		RequestorRef: requestorRef,
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (c *Client) post(ctx context.Context, request string, body siri.Siri) (siri.Siri, error) {
+	body.Version = siri.Version
+	b, err := xml.Marshal(body)
+	if err != nil {
+		return siri.Siri{}, fmt.Errorf("could not marshal %s: %w", request, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, bytes.NewReader(append([]byte(xml.Header), b...)))
+	if err != nil {
+		return siri.Siri{}, fmt.Errorf("could not create %s: %w", request, err)
+	}
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	req.Header.Set("Accept", "application/xml")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return siri.Siri{}, fmt.Errorf("could not send %s: %w", request, err)
+	}
+	defer resp.Body.Close()
+
+	if err := provider.CheckResponse(resp, request); err != nil {
+		return siri.Siri{}, err
+	}
+
+	var s siri.Siri
+	if err := xml.NewDecoder(resp.Body).Decode(&s); err != nil {
+		return siri.Siri{}, fmt.Errorf("could not decode %s response: %w", request, err)
+	}
+
+	return s, nil
+}
+
+// VehicleMonitoring requests the activity of every vehicle the producer
+// monitors
+func (c *Client) VehicleMonitoring(ctx context.Context) ([]siri.VehicleMonitoringDelivery, error) {
+	now := time.Now()
+	s, err := c.post(ctx, "VehicleMonitoringRequest", siri.Siri{ServiceRequest: &siri.ServiceRequest{
+		RequestTimestamp:         now,
+		RequestorRef:             c.RequestorRef,
+		VehicleMonitoringRequest: siri.VehicleMonitoringRequest{Version: siri.Version, RequestTimestamp: now},
+	}})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.ServiceDelivery == nil {
+		return nil, errors.New("response has no ServiceDelivery")
+	}
+	if err := status(s.ServiceDelivery.Status, s.ServiceDelivery.ErrorCondition); err != nil {
+		return nil, err
+	}
+
+	return s.ServiceDelivery.VehicleMonitoringDeliveries, nil
+}
+
+// Subscribe asks the producer to push the activity of every vehicle to
+// consumerAddress until the subscription terminates, only the changes are
+// pushed after the first delivery
+func (c *Client) Subscribe(ctx context.Context, consumerAddress, id string, until time.Time) error {
+	now := time.Now()
+	s, err := c.post(ctx, "SubscriptionRequest", siri.Siri{SubscriptionRequest: &siri.SubscriptionRequest{
+		RequestTimestamp:    now,
+		RequestorRef:        c.RequestorRef,
+		ConsumerAddress:     consumerAddress,
+		SubscriptionContext: siri.SubscriptionContext{HeartbeatInterval: heartbeatInterval},
+		VehicleMonitoringSubscriptionRequest: siri.VehicleMonitoringSubscriptionRequest{
+			SubscriptionIdentifier:   id,
+			InitialTerminationTime:   until,
+			VehicleMonitoringRequest: siri.VehicleMonitoringRequest{Version: siri.Version, RequestTimestamp: now},
+			IncrementalUpdates:       true,
+		},
+	}})
+	if err != nil {
+		return err
+	}
+
+	if s.SubscriptionResponse == nil || len(s.SubscriptionResponse.ResponseStatuses) == 0 {
+		return errNoResponseStatus
+	}
+	for _, r := range s.SubscriptionResponse.ResponseStatuses {
+		if err := status(r.Status, r.ErrorCondition); err != nil {
+			return fmt.Errorf("could not subscribe: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Terminate ends a subscription, the producer stops pushing to it
+func (c *Client) Terminate(ctx context.Context, id string) error {
+	_, err := c.post(ctx, "TerminateSubscriptionRequest", siri.Siri{TerminateSubscriptionRequest: &siri.TerminateSubscriptionRequest{
+		RequestTimestamp: time.Now(),
+		RequestorRef:     c.RequestorRef,
+		SubscriptionRef:  id,
+	}})
+
+	return err
+}
@@ -0,0 +1,218 @@
+package siri
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+// producer stands in for a SIRI VehicleMonitoring producer. It responds to a
+// ServiceRequest with the recorded delivery in testdata, and once subscribed
+// to it pushes that delivery then the recorded incremental one to the
+// consumer address. Every request it is sent is kept
+type producer struct {
+	*httptest.Server
+
+	t        *testing.T
+	mu       sync.Mutex
+	requests []siri.Siri
+	// refuse has subscriptions refused as a producer which has reached its
+	// limit would
+	refuse bool
+	// silent has the producer never push to a subscription
+	silent bool
+	pushed chan error
+}
+
+// newProducer starts a producer, configure sets it up before it is started
+func newProducer(t *testing.T, configure ...func(p *producer)) *producer {
+	p := &producer{t: t, pushed: make(chan error, 10)}
+	for _, c := range configure {
+		c(p)
+	}
+	p.Server = httptest.NewServer(http.HandlerFunc(p.serve))
+	t.Cleanup(p.Close)
+
+	return p
+}
+
+func (p *producer) read(file string) []byte {
+	b, err := os.ReadFile("testdata/" + file)
+	assert.NoError(p.t, err, "could not read recorded delivery")
+
+	return b
+}
+
+func (p *producer) respond(w http.ResponseWriter, s siri.Siri) {
+	s.Version = siri.Version
+	b, err := xml.Marshal(s)
+	assert.NoError(p.t, err, "could not marshal response")
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	_, _ = w.Write(b)
+}
+
+func (p *producer) serve(w http.ResponseWriter, r *http.Request) {
+	var s siri.Siri
+	if r.Method != http.MethodPost || xml.NewDecoder(r.Body).Decode(&s) != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+	p.mu.Lock()
+	p.requests = append(p.requests, s)
+	p.mu.Unlock()
+
+	switch {
+	case s.ServiceRequest != nil:
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		_, _ = w.Write(p.read("vehicle_monitoring.xml"))
+	case s.SubscriptionRequest != nil && p.refuse:
+		p.respond(w, siri.Siri{SubscriptionResponse: &siri.SubscriptionResponse{ResponseStatuses: []siri.ResponseStatus{{
+			Status: "false",
+			ErrorCondition: &siri.ErrorCondition{
+				Errors:      []siri.ErrorType{{XMLName: xml.Name{Local: "CapabilityNotSupportedError"}}},
+				Description: "too many subscriptions",
+			},
+		}}}})
+	case s.SubscriptionRequest != nil:
+		req := s.SubscriptionRequest
+		id := req.VehicleMonitoringSubscriptionRequest.SubscriptionIdentifier
+		p.respond(w, siri.Siri{SubscriptionResponse: &siri.SubscriptionResponse{ResponseStatuses: []siri.ResponseStatus{{SubscriptionRef: id, Status: "true"}}}})
+		if !p.silent {
+			go p.push(req.ConsumerAddress, id)
+		}
+	case s.TerminateSubscriptionRequest != nil:
+		p.respond(w, siri.Siri{TerminateSubscriptionResponse: &siri.TerminateSubscriptionResponse{}})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+// push sends the full delivery then the incremental one of the subscription,
+// reporting each outcome on pushed
+func (p *producer) push(consumer, id string) {
+	ref := `<VehicleMonitoringDelivery version="2.0"><SubscriptionRef>` + id + `</SubscriptionRef>`
+	full := strings.Replace(string(p.read("vehicle_monitoring.xml")), `<VehicleMonitoringDelivery version="2.0">`, ref, 1)
+	incremental := strings.ReplaceAll(string(p.read("subscription_delivery.xml")), "{{.SubscriptionRef}}", id)
+	for _, body := range []string{full, incremental} {
+		resp, err := http.Post(consumer, "application/xml", strings.NewReader(body))
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				err = &provider.StatusError{Request: "push", StatusCode: resp.StatusCode}
+			}
+		}
+		p.pushed <- err
+	}
+}
+
+// received returns every request sent to the producer
+func (p *producer) received() []siri.Siri {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]siri.Siri(nil), p.requests...)
+}
+
+func TestClientVehicleMonitoring(t *testing.T) {
+	t.Run("requests the activity of every vehicle", func(t *testing.T) {
+		srv := newProducer(t)
+		deliveries, err := NewClient(srv.URL, "wheresmylift").VehicleMonitoring(context.Background())
+		assert.NoError(t, err, "expected deliveries to be fetched")
+		assert.Len(t, deliveries, 1)
+		assert.Len(t, deliveries[0].VehicleActivities, 3)
+
+		j := deliveries[0].VehicleActivities[0].MonitoredVehicleJourney
+		assert.Equal(t, "GAI-11042", j.VehicleRef)
+		assert.Equal(t, []string{"UCD Belfield", "An Coláiste Ollscoile, Belfield"}, j.DestinationNames)
+		assert.Equal(t, "4426_12704", j.FramedVehicleJourneyRef.DatedVehicleJourneyRef)
+
+		requests := srv.received()
+		assert.Len(t, requests, 1)
+		assert.Equal(t, "2.0", requests[0].Version)
+		assert.Equal(t, "wheresmylift", requests[0].ServiceRequest.RequestorRef)
+		assert.Equal(t, "2.0", requests[0].ServiceRequest.VehicleMonitoringRequest.Version)
+	})
+
+	t.Run("errors when the producer reports an error", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`<Siri xmlns="http://www.siri.org.uk/siri" version="2.0"><ServiceDelivery>` +
+				`<Status>false</Status><ErrorCondition><ServiceNotAvailableError><ErrorText>maintenance</ErrorText>` +
+				`</ServiceNotAvailableError><Description>back at 10:00</Description></ErrorCondition></ServiceDelivery></Siri>`))
+		}))
+		defer srv.Close()
+
+		_, err := NewClient(srv.URL, "").VehicleMonitoring(context.Background())
+		assert.EqualError(t, err, "producer reported ServiceNotAvailableError maintenance: back at 10:00")
+	})
+
+	t.Run("errors when the response is not a delivery", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`<Siri xmlns="http://www.siri.org.uk/siri" version="2.0"></Siri>`))
+		}))
+		defer srv.Close()
+
+		_, err := NewClient(srv.URL, "").VehicleMonitoring(context.Background())
+		assert.EqualError(t, err, "response has no ServiceDelivery")
+	})
+
+	t.Run("errors on a status other than 200", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer srv.Close()
+
+		_, err := NewClient(srv.URL, "").VehicleMonitoring(context.Background())
+		assert.EqualError(t, err, "unexpected VehicleMonitoringRequest response status 429")
+	})
+}
+
+func TestClientSubscribe(t *testing.T) {
+	t.Run("asks for incremental updates to be pushed", func(t *testing.T) {
+		srv := newProducer(t, func(p *producer) { p.silent = true })
+		until := time.Date(2025, 1, 21, 10, 30, 0, 0, time.UTC)
+		err := NewClient(srv.URL, "wheresmylift").Subscribe(context.Background(), "http://localhost:8082/siri", "wheresmylift-1", until)
+		assert.NoError(t, err, "expected subscription to be accepted")
+
+		req := srv.received()[0].SubscriptionRequest
+		assert.Equal(t, "http://localhost:8082/siri", req.ConsumerAddress)
+		assert.Equal(t, "PT1M", req.SubscriptionContext.HeartbeatInterval)
+		assert.Equal(t, "wheresmylift-1", req.VehicleMonitoringSubscriptionRequest.SubscriptionIdentifier)
+		assert.True(t, until.Equal(req.VehicleMonitoringSubscriptionRequest.InitialTerminationTime))
+		assert.True(t, req.VehicleMonitoringSubscriptionRequest.IncrementalUpdates)
+	})
+
+	t.Run("errors when the subscription is refused", func(t *testing.T) {
+		srv := newProducer(t, func(p *producer) { p.refuse = true })
+		err := NewClient(srv.URL, "").Subscribe(context.Background(), "http://localhost:8082", "1", time.Now())
+		assert.EqualError(t, err, "could not subscribe: producer reported CapabilityNotSupportedError: too many subscriptions")
+	})
+
+	t.Run("errors without a response status", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`<Siri xmlns="http://www.siri.org.uk/siri" version="2.0"><SubscriptionResponse/></Siri>`))
+		}))
+		defer srv.Close()
+
+		err := NewClient(srv.URL, "").Subscribe(context.Background(), "http://localhost:8082", "1", time.Now())
+		assert.ErrorIs(t, err, errNoResponseStatus)
+	})
+}
+
+func TestClientTerminate(t *testing.T) {
+	t.Run("terminates the subscription", func(t *testing.T) {
+		srv := newProducer(t)
+		assert.NoError(t, NewClient(srv.URL, "wheresmylift").Terminate(context.Background(), "wheresmylift-1"))
+		assert.Equal(t, "wheresmylift-1", srv.received()[0].TerminateSubscriptionRequest.SubscriptionRef)
+	})
+}
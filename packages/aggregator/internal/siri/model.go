@@ -0,0 +1,113 @@
+package siri
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+const source = "siri"
+
+// status returns an error unless status is true or left out, which is taken
+// as true
+func status(status string, condition *siri.ErrorCondition) error {
+	if strings.TrimSpace(status) != "false" {
+		return nil
+	}
+	if condition == nil {
+		condition = &siri.ErrorCondition{}
+	}
+
+	return fmt.Errorf("producer reported %w", condition)
+}
+
+// vehicleModes maps the modes of SIRI to the canonical modes, a journey of any
+// other mode is left without one
+var vehicleModes = map[string]transit.Mode{
+	"bus":   transit.ModeBus,
+	"coach": transit.ModeBus,
+	"rail":  transit.ModeRail,
+	"metro": transit.ModeRail,
+	"tram":  transit.ModeTram,
+	"ferry": transit.ModeFerry,
+	"water": transit.ModeFerry,
+}
+
+// vehicleID returns the id a vehicle is kept by, which is the trip when the
+// producer does not name the vehicle
+func vehicleID(j siri.MonitoredVehicleJourney) string {
+	if j.VehicleRef != "" {
+		return strings.TrimSpace(j.VehicleRef)
+	}
+
+	return tripID(j)
+}
+
+func tripID(j siri.MonitoredVehicleJourney) string {
+	if j.FramedVehicleJourneyRef == nil {
+		return ""
+	}
+
+	return strings.TrimSpace(j.FramedVehicleJourneyRef.DatedVehicleJourneyRef)
+}
+
+// toVehicle maps the activity of a vehicle, one without a location cannot be
+// served and is an error
+func toVehicle(a siri.VehicleActivity, now time.Time) (transit.Vehicle, error) {
+	j := a.MonitoredVehicleJourney
+	id := vehicleID(j)
+	if id == "" {
+		return transit.Vehicle{}, fmt.Errorf("vehicle activity recorded at %s has no VehicleRef", a.RecordedAtTime.Format(time.RFC3339))
+	}
+	if j.VehicleLocation == nil {
+		return transit.Vehicle{}, fmt.Errorf("vehicle %s has no location", id)
+	}
+
+	v := transit.Vehicle{
+		ID:        id,
+		Source:    source,
+		Operator:  strings.TrimSpace(j.OperatorRef),
+		Mode:      vehicleModes[strings.TrimSpace(j.VehicleMode)],
+		RouteID:   strings.TrimSpace(j.LineRef),
+		TripID:    tripID(j),
+		Latitude:  j.VehicleLocation.Latitude,
+		Longitude: j.VehicleLocation.Longitude,
+		Bearing:   j.Bearing,
+		Speed:     j.Velocity,
+		Status:    transit.VehicleInTransit,
+		Timestamp: a.RecordedAtTime,
+	}
+	if len(j.DestinationNames) > 0 {
+		v.Headsign = strings.TrimSpace(j.DestinationNames[0])
+	}
+	if v.Timestamp.IsZero() {
+		v.Timestamp = now
+	}
+
+	if j.Delay != "" {
+		delay, err := siri.ParseDuration(j.Delay)
+		if err != nil {
+			return transit.Vehicle{}, fmt.Errorf("vehicle %s: invalid Delay %q: %w", id, j.Delay, err)
+		}
+		v.Delay = &delay
+	}
+
+	if j.MonitoredCall != nil {
+		v.StopID = strings.TrimSpace(j.MonitoredCall.StopPointRef)
+		if j.MonitoredCall.VehicleAtStop {
+			v.Status = transit.VehicleStoppedAt
+		}
+	}
+
+	switch strings.TrimSpace(j.VehicleStatus) {
+	case "assigned", "notExpected":
+		v.Status = transit.VehicleScheduled
+	case "completed", "cancelled":
+		v.Status = transit.VehicleTerminated
+	}
+
+	return v, nil
+}
@@ -0,0 +1,114 @@
+package siri
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToVehicle(t *testing.T) {
+	now := time.Date(2025, 1, 21, 9, 30, 0, 0, time.UTC)
+	recorded := time.Date(2025, 1, 21, 9, 29, 58, 0, time.UTC)
+	bearing, velocity := 95.0, 7.5
+
+	t.Run("maps every element", func(t *testing.T) {
+		v, err := toVehicle(siri.VehicleActivity{
+			RecordedAtTime: recorded,
+			MonitoredVehicleJourney: siri.MonitoredVehicleJourney{
+				LineRef:                 "4426_73619",
+				FramedVehicleJourneyRef: &siri.FramedVehicleJourneyRef{DataFrameRef: "2025-01-21", DatedVehicleJourneyRef: "4426_12704"},
+				VehicleMode:             "bus",
+				OperatorRef:             "GAI",
+				DestinationNames:        []string{"UCD Belfield", "An Coláiste Ollscoile, Belfield"},
+				VehicleLocation:         &siri.VehicleLocation{Longitude: -6.3915, Latitude: 53.2887},
+				Bearing:                 &bearing,
+				Velocity:                &velocity,
+				Delay:                   "PT2M30S",
+				VehicleRef:              "GAI-11042",
+				MonitoredCall:           &siri.MonitoredCall{StopPointRef: "8240DB004728"},
+			},
+		}, now)
+		assert.NoError(t, err)
+
+		delay := 2*time.Minute + 30*time.Second
+		assert.Equal(t, transit.Vehicle{
+			ID:        "GAI-11042",
+			Source:    "siri",
+			Operator:  "GAI",
+			Mode:      transit.ModeBus,
+			RouteID:   "4426_73619",
+			TripID:    "4426_12704",
+			Headsign:  "UCD Belfield",
+			Latitude:  53.2887,
+			Longitude: -6.3915,
+			Bearing:   &bearing,
+			Speed:     &velocity,
+			Status:    transit.VehicleInTransit,
+			StopID:    "8240DB004728",
+			Delay:     &delay,
+			Timestamp: recorded,
+		}, v)
+	})
+
+	t.Run("is stopped at the monitored call when at the stop", func(t *testing.T) {
+		v, err := toVehicle(siri.VehicleActivity{MonitoredVehicleJourney: siri.MonitoredVehicleJourney{
+			VehicleRef:      "GAI-11107",
+			VehicleLocation: &siri.VehicleLocation{},
+			MonitoredCall:   &siri.MonitoredCall{StopPointRef: "8240DB002893", VehicleAtStop: true},
+		}}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, transit.VehicleStoppedAt, v.Status)
+		assert.Equal(t, now, v.Timestamp, "expected the time it was mapped without a recorded time")
+	})
+
+	t.Run("maps the status of the vehicle", func(t *testing.T) {
+		for status, want := range map[string]transit.VehicleStatus{
+			"assigned":   transit.VehicleScheduled,
+			"completed":  transit.VehicleTerminated,
+			"inProgress": transit.VehicleInTransit,
+		} {
+			v, err := toVehicle(siri.VehicleActivity{MonitoredVehicleJourney: siri.MonitoredVehicleJourney{
+				VehicleRef:      "GAI-11019",
+				VehicleLocation: &siri.VehicleLocation{},
+				VehicleStatus:   status,
+			}}, now)
+			assert.NoError(t, err)
+			assert.Equal(t, want, v.Status, status)
+		}
+	})
+
+	t.Run("maps the modes of siri", func(t *testing.T) {
+		for mode, want := range map[string]transit.Mode{"coach": transit.ModeBus, "metro": transit.ModeRail, "tram": transit.ModeTram, "water": transit.ModeFerry, "air": ""} {
+			v, err := toVehicle(siri.VehicleActivity{MonitoredVehicleJourney: siri.MonitoredVehicleJourney{VehicleRef: "1", VehicleLocation: &siri.VehicleLocation{}, VehicleMode: mode}}, now)
+			assert.NoError(t, err)
+			assert.Equal(t, want, v.Mode, mode)
+		}
+	})
+
+	t.Run("is kept by its journey without a VehicleRef", func(t *testing.T) {
+		v, err := toVehicle(siri.VehicleActivity{MonitoredVehicleJourney: siri.MonitoredVehicleJourney{
+			FramedVehicleJourneyRef: &siri.FramedVehicleJourneyRef{DatedVehicleJourneyRef: "4426_12704"},
+			VehicleLocation:         &siri.VehicleLocation{},
+		}}, now)
+		assert.NoError(t, err)
+		assert.Equal(t, "4426_12704", v.ID)
+	})
+
+	t.Run("errors without an id", func(t *testing.T) {
+		_, err := toVehicle(siri.VehicleActivity{RecordedAtTime: recorded, MonitoredVehicleJourney: siri.MonitoredVehicleJourney{VehicleLocation: &siri.VehicleLocation{}}}, now)
+		assert.EqualError(t, err, "vehicle activity recorded at 2025-01-21T09:29:58Z has no VehicleRef")
+	})
+
+	t.Run("errors without a location", func(t *testing.T) {
+		_, err := toVehicle(siri.VehicleActivity{MonitoredVehicleJourney: siri.MonitoredVehicleJourney{VehicleRef: "GAI-11042"}}, now)
+		assert.EqualError(t, err, "vehicle GAI-11042 has no location")
+	})
+
+	t.Run("errors on an invalid delay", func(t *testing.T) {
+		_, err := toVehicle(siri.VehicleActivity{MonitoredVehicleJourney: siri.MonitoredVehicleJourney{VehicleRef: "GAI-11042", VehicleLocation: &siri.VehicleLocation{}, Delay: "2 mins"}}, now)
+		assert.EqualError(t, err, `vehicle GAI-11042: invalid Delay "2 mins": must be an ISO 8601 duration such as PT30M`)
+	})
+}
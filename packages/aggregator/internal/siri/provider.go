@@ -0,0 +1,208 @@
+package siri
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+)
+
+// Provider polls a SIRI VehicleMonitoring producer, such as an operator or a
+// regional system, for the activity of every vehicle mapped to the canonical
+// model. A vehicle is kept until the ValidUntilTime of its activity, or until
+// the producer cancels its journey
+type Provider struct {
+	provider.HealthTracker
+
+	Client *Client
+
+	operators  []string
+	now        func() time.Time
+	mu         sync.RWMutex
+	vehicles   map[string]transit.Vehicle
+	validUntil map[string]time.Time
+}
+
+func New(url, requestorRef string, operators []string) *Provider {
+	return &Provider{
+		Client:     NewClient(url, requestorRef),
+		operators:  operators,
+		now:        time.Now,
+		vehicles:   map[string]transit.Vehicle{},
+		validUntil: map[string]time.Time{},
+	}
+}
+
+// FromConfig creates the provider from the SIRI settings, it subscribes to the
+// producer rather than polling it in the subscribe mode
+func FromConfig(cfg config.Config) (provider.Provider, error) {
+	if !cfg.Configured("siri") {
+		return nil, provider.ErrNotConfigured
+	}
+
+	c := cfg.Siri
+	p := New(c.URL, c.RequestorRef, c.Operators)
+	if c.Subscribes() {
+		return NewSubscriber(p, c.ListenAddress, c.ConsumerAddress), nil
+	}
+
+	return p, nil
+}
+
+func (p *Provider) Name() string {
+	return source
+}
+
+// Operators returns the configured operators, a producer such as a regional
+// system can cover several so none are configured by default
+func (p *Provider) Operators() []string {
+	return p.operators
+}
+
+// Poll requests the activity of every vehicle once, the vehicles which are
+// not in the response are dropped
+func (p *Provider) Poll(ctx context.Context) error {
+	err := p.poll(ctx)
+	p.Record(err)
+
+	return err
+}
+
+func (p *Provider) poll(ctx context.Context) error {
+	deliveries, err := p.Client.VehicleMonitoring(ctx)
+	if err != nil {
+		return err
+	}
+
+	return p.apply(deliveries, true)
+}
+
+// apply keeps the vehicles of the deliveries, replacing every vehicle when
+// replace is set and at least one delivery is successful, so a producer which
+// only reports errors does not drop every vehicle. A delivery which reports an
+// error, or an activity which cannot be mapped, does not stop the rest from
+// being kept
+func (p *Provider) apply(deliveries []siri.VehicleMonitoringDelivery, replace bool) error {
+	now := p.now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if replace && slices.ContainsFunc(deliveries, successful) {
+		p.vehicles = map[string]transit.Vehicle{}
+		p.validUntil = map[string]time.Time{}
+	}
+
+	var errs []error
+	for _, d := range deliveries {
+		if err := status(d.Status, d.ErrorCondition); err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		for _, c := range d.VehicleActivityCancellations {
+			p.cancel(c.VehicleJourneyRef.DatedVehicleJourneyRef)
+		}
+
+		for _, a := range d.VehicleActivities {
+			v, err := toVehicle(a, now)
+			if err != nil {
+				errs = append(errs, err)
+
+				continue
+			}
+			p.vehicles[v.ID] = v
+			delete(p.validUntil, v.ID)
+			if a.ValidUntilTime != nil && !a.ValidUntilTime.IsZero() {
+				p.validUntil[v.ID] = *a.ValidUntilTime
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// successful reports whether a delivery does not report an error
+func successful(d siri.VehicleMonitoringDelivery) bool {
+	return status(d.Status, d.ErrorCondition) == nil
+}
+
+// cancel drops the vehicles running a journey, the lock must be held
+func (p *Provider) cancel(tripID string) {
+	if tripID == "" {
+		return
+	}
+
+	for id, v := range p.vehicles {
+		if v.TripID == tripID {
+			delete(p.vehicles, id)
+			delete(p.validUntil, id)
+		}
+	}
+}
+
+// reset drops every vehicle, such as before a new subscription is sent its
+// first delivery
+func (p *Provider) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.vehicles = map[string]transit.Vehicle{}
+	p.validUntil = map[string]time.Time{}
+}
+
+// valid reports whether the activity of a vehicle has not expired, the lock
+// must be held
+func (p *Provider) valid(id string, now time.Time) bool {
+	until, ok := p.validUntil[id]
+
+	return !ok || now.Before(until)
+}
+
+// Len returns the number of vehicles whose activity has not expired
+func (p *Provider) Len() int {
+	return len(p.Vehicles())
+}
+
+func (p *Provider) Vehicle(id string) (transit.Vehicle, bool) {
+	now := p.now()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	v, ok := p.vehicles[id]
+	if !ok || !p.valid(id, now) {
+		return transit.Vehicle{}, false
+	}
+
+	return v, true
+}
+
+// Vehicles returns every vehicle whose activity has not expired ordered by id
+func (p *Provider) Vehicles() []transit.Vehicle {
+	now := p.now()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	vehicles := make([]transit.Vehicle, 0, len(p.vehicles))
+	for id, v := range p.vehicles {
+		if p.valid(id, now) {
+			vehicles = append(vehicles, v)
+		}
+	}
+	sort.Slice(vehicles, func(i, j int) bool { return vehicles[i].ID < vehicles[j].ID })
+
+	return vehicles
+}
+
+// Dataset returns every vehicle whose activity has not expired, SIRI
+// VehicleMonitoring only describes vehicles
+func (p *Provider) Dataset() transit.Dataset {
+	return transit.Dataset{Vehicles: p.Vehicles()}
+}
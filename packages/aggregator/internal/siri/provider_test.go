@@ -0,0 +1,163 @@
+package siri
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/aggregator/internal/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+// pollTime is shortly after the recorded delivery was made
+var pollTime = time.Date(2025, 1, 21, 9, 30, 10, 0, time.UTC)
+
+func TestFromConfig(t *testing.T) {
+	t.Run("creates a polling provider", func(t *testing.T) {
+		p, err := FromConfig(config.Config{Siri: config.Siri{URL: "http://localhost", RequestorRef: "wheresmylift", Operators: []string{"GAI"}}})
+		assert.NoError(t, err)
+		assert.Equal(t, "siri", p.Name())
+		assert.Equal(t, []string{"GAI"}, p.Operators())
+		assert.Equal(t, "wheresmylift", p.(*Provider).Client.RequestorRef)
+
+		_, streams := p.(provider.Streamer)
+		assert.False(t, streams, "expected the provider to be polled")
+	})
+
+	t.Run("creates a subscriber in the subscribe mode", func(t *testing.T) {
+		p, err := FromConfig(config.Config{Siri: config.Siri{
+			URL:             "http://localhost",
+			Mode:            "subscribe",
+			ListenAddress:   ":8082",
+			ConsumerAddress: "http://aggregator:8082/siri",
+		}})
+		assert.NoError(t, err)
+		assert.Equal(t, "siri", p.Name())
+
+		s, streams := p.(*Subscriber)
+		assert.True(t, streams, "expected a subscriber")
+		assert.Equal(t, ":8082", s.ListenAddress)
+		assert.Equal(t, "http://aggregator:8082/siri", s.ConsumerAddress)
+		assert.Equal(t, DefaultDuration, s.Duration)
+	})
+
+	t.Run("is not configured without a URL", func(t *testing.T) {
+		_, err := FromConfig(config.Config{Siri: config.Siri{Mode: "subscribe"}})
+		assert.ErrorIs(t, err, provider.ErrNotConfigured)
+	})
+}
+
+func TestProviderPoll(t *testing.T) {
+	t.Run("keeps every vehicle whose activity is valid", func(t *testing.T) {
+		srv := newProducer(t)
+		p := New(srv.URL, "wheresmylift", nil)
+		p.now = func() time.Time { return pollTime }
+
+		assert.NoError(t, p.Poll(context.Background()), "expected poll to succeed")
+		assert.Equal(t, 2, p.Len(), "expected the expired activity to be left out")
+
+		v, ok := p.Vehicle("GAI-11042")
+		assert.True(t, ok, "expected vehicle to be known")
+		assert.Equal(t, "UCD Belfield", v.Headsign)
+		assert.Equal(t, 150*time.Second, *v.Delay)
+
+		_, ok = p.Vehicle("GAI-11019")
+		assert.False(t, ok, "expected vehicle whose activity has expired not to be known")
+
+		vehicles := p.Dataset().Vehicles
+		assert.Equal(t, []string{"GAI-11042", "GAI-11107"}, []string{vehicles[0].ID, vehicles[1].ID}, "expected vehicles to be ordered by id")
+		assert.Equal(t, transit.VehicleStoppedAt, vehicles[1].Status)
+		assert.Equal(t, -45*time.Second, *vehicles[1].Delay)
+
+		p.now = func() time.Time { return pollTime.Add(15 * time.Minute) }
+		assert.Equal(t, []transit.Vehicle{vehicles[1]}, p.Vehicles(), "expected vehicle to expire at the end of its activity")
+	})
+
+	t.Run("drops the vehicles which are no longer monitored", func(t *testing.T) {
+		srv := newProducer(t)
+		p := New(srv.URL, "", nil)
+		p.now = func() time.Time { return pollTime }
+		p.vehicles["GAI-10001"] = transit.Vehicle{ID: "GAI-10001"}
+
+		assert.NoError(t, p.Poll(context.Background()))
+		_, ok := p.Vehicle("GAI-10001")
+		assert.False(t, ok, "expected vehicle missing from the delivery to be dropped")
+	})
+
+	t.Run("keeps the activities which can be mapped", func(t *testing.T) {
+		p := New("", "", nil)
+		p.now = func() time.Time { return pollTime }
+		err := p.apply([]siri.VehicleMonitoringDelivery{
+			{VehicleActivities: []siri.VehicleActivity{
+				{MonitoredVehicleJourney: siri.MonitoredVehicleJourney{VehicleRef: "GAI-11042"}},
+				{MonitoredVehicleJourney: siri.MonitoredVehicleJourney{VehicleRef: "GAI-11107", VehicleLocation: &siri.VehicleLocation{}}},
+			}},
+			{Status: "false", ErrorCondition: &siri.ErrorCondition{Description: "partial outage"}},
+		}, true)
+		assert.EqualError(t, err, "vehicle GAI-11042 has no location\nproducer reported partial outage")
+		assert.Equal(t, 1, p.Len())
+	})
+
+	t.Run("keeps the previous vehicles when every delivery reports an error", func(t *testing.T) {
+		p := New("", "", nil)
+		p.now = func() time.Time { return pollTime }
+		p.vehicles = map[string]transit.Vehicle{"GAI-11042": {ID: "GAI-11042"}}
+		err := p.apply([]siri.VehicleMonitoringDelivery{
+			{Status: "false", ErrorCondition: &siri.ErrorCondition{Description: "partial outage"}},
+		}, true)
+		assert.EqualError(t, err, "producer reported partial outage")
+		_, ok := p.Vehicle("GAI-11042")
+		assert.True(t, ok, "expected vehicle to be kept")
+	})
+
+	t.Run("errors when the producer cannot be reached", func(t *testing.T) {
+		srv := newProducer(t)
+		srv.Close()
+		p := New(srv.URL, "", nil)
+		assert.Error(t, p.Poll(context.Background()))
+		assert.Equal(t, 0, p.Len())
+	})
+}
+
+func TestProviderApply(t *testing.T) {
+	t.Run("updates and cancels vehicles incrementally", func(t *testing.T) {
+		p := New("", "", nil)
+		p.now = func() time.Time { return pollTime }
+		p.vehicles = map[string]transit.Vehicle{
+			"GAI-11042": {ID: "GAI-11042", TripID: "4426_12704"},
+			"GAI-11107": {ID: "GAI-11107", TripID: "4426_12811"},
+			"GAI-11230": {ID: "GAI-11230", TripID: "4426_12950"},
+		}
+
+		assert.NoError(t, p.apply([]siri.VehicleMonitoringDelivery{{
+			VehicleActivities: []siri.VehicleActivity{{MonitoredVehicleJourney: siri.MonitoredVehicleJourney{
+				VehicleRef:      "GAI-11042",
+				VehicleLocation: &siri.VehicleLocation{Latitude: 53.2891},
+			}}},
+			VehicleActivityCancellations: []siri.VehicleActivityCancellation{{VehicleJourneyRef: siri.FramedVehicleJourneyRef{DatedVehicleJourneyRef: "4426_12811"}}},
+		}}, false))
+
+		vehicles := p.Vehicles()
+		assert.Len(t, vehicles, 2)
+		assert.Equal(t, 53.2891, vehicles[0].Latitude, "expected vehicle to be updated")
+		assert.Equal(t, "GAI-11230", vehicles[1].ID, "expected vehicle which was not pushed to be kept")
+	})
+}
+
+func TestProviderHealth(t *testing.T) {
+	t.Run("records the outcome of every poll", func(t *testing.T) {
+		srv := newProducer(t)
+		p := New("http://127.0.0.1:0", "", nil)
+		assert.Error(t, p.Poll(context.Background()))
+		assert.False(t, p.Health().Healthy, "expected provider to be unhealthy")
+		assert.Equal(t, 1, p.Health().ConsecutiveFailures)
+
+		p.Client.URL = srv.URL
+		assert.NoError(t, p.Poll(context.Background()))
+		assert.True(t, p.Health().Healthy, "expected provider to be healthy")
+		assert.Equal(t, 0, p.Health().ConsecutiveFailures)
+	})
+}
@@ -0,0 +1,99 @@
+package siri
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/siri"
+)
+
+// maxPushSize is the largest delivery a producer can push, a full delivery of
+// a regional system is a few megabytes
+const maxPushSize = 32 << 20
+
+var errUnexpectedPush = errors.New("expected a ServiceDelivery or HeartbeatNotification")
+
+// Receiver accepts what a producer pushes to a subscription, the deliveries of
+// other subscriptions or without a SubscriptionRef are acknowledged but not
+// kept. Updated is called after every delivery which is kept
+type Receiver struct {
+	Provider        *Provider
+	SubscriptionRef string
+	Updated         func()
+
+	mu    sync.Mutex
+	heard time.Time
+}
+
+// LastHeard returns when the producer last pushed a delivery or heartbeat
+func (r *Receiver) LastHeard() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.heard
+}
+
+func (r *Receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		w.WriteHeader(http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	var s siri.Siri
+	if err := xml.NewDecoder(http.MaxBytesReader(w, req.Body, maxPushSize)).Decode(&s); err != nil {
+		r.Provider.Record(fmt.Errorf("could not decode push: %w", err))
+		http.Error(w, "could not decode SIRI", http.StatusBadRequest)
+
+		return
+	}
+
+	switch {
+	case s.ServiceDelivery != nil:
+		r.deliver(s.ServiceDelivery)
+	case s.HeartbeatNotification != nil:
+		r.hear()
+		r.Provider.Record(status(s.HeartbeatNotification.Status, nil))
+	default:
+		http.Error(w, errUnexpectedPush.Error(), http.StatusBadRequest)
+
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (r *Receiver) hear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.heard = r.Provider.now()
+}
+
+func (r *Receiver) deliver(d *siri.ServiceDelivery) {
+	r.hear()
+	if err := status(d.Status, d.ErrorCondition); err != nil {
+		r.Provider.Record(err)
+
+		return
+	}
+
+	var deliveries []siri.VehicleMonitoringDelivery
+	for _, vm := range d.VehicleMonitoringDeliveries {
+		if vm.SubscriptionRef == r.SubscriptionRef {
+			deliveries = append(deliveries, vm)
+		}
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	r.Provider.Record(r.Provider.apply(deliveries, false))
+	if r.Updated != nil {
+		r.Updated()
+	}
+}
@@ -0,0 +1,100 @@
+package siri
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReceiver(t *testing.T) {
+	push := func(r *Receiver, method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, "/siri", strings.NewReader(body)))
+
+		return w
+	}
+	delivery := func(t *testing.T, ref string) string {
+		b, err := os.ReadFile("testdata/subscription_delivery.xml")
+		assert.NoError(t, err, "could not read recorded delivery")
+
+		return strings.ReplaceAll(string(b), "{{.SubscriptionRef}}", ref)
+	}
+
+	t.Run("keeps the deliveries of its subscription", func(t *testing.T) {
+		p := New("", "", nil)
+		p.now = func() time.Time { return pollTime }
+		updates := 0
+		r := &Receiver{Provider: p, SubscriptionRef: "wheresmylift-1", Updated: func() { updates++ }}
+
+		w := push(r, http.MethodPost, delivery(t, "wheresmylift-1"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, 1, updates, "expected the update to be reported")
+		assert.Equal(t, pollTime, r.LastHeard())
+		assert.True(t, p.Health().Healthy, "expected provider to be healthy")
+
+		v, ok := p.Vehicle("GAI-11042")
+		assert.True(t, ok, "expected pushed vehicle to be known")
+		assert.Equal(t, 53.2891, v.Latitude)
+	})
+
+	t.Run("acknowledges but ignores the deliveries of other subscriptions", func(t *testing.T) {
+		p := New("", "", nil)
+		updates := 0
+		r := &Receiver{Provider: p, SubscriptionRef: "wheresmylift-2", Updated: func() { updates++ }}
+
+		w := push(r, http.MethodPost, delivery(t, "wheresmylift-1"))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Zero(t, updates)
+		assert.Zero(t, p.Len())
+	})
+
+	t.Run("acknowledges but ignores the deliveries without a subscription", func(t *testing.T) {
+		p := New("", "", nil)
+		updates := 0
+		r := &Receiver{Provider: p, SubscriptionRef: "wheresmylift-1", Updated: func() { updates++ }}
+
+		w := push(r, http.MethodPost, delivery(t, ""))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Zero(t, updates)
+		assert.Zero(t, p.Len())
+	})
+
+	t.Run("hears heartbeats", func(t *testing.T) {
+		p := New("", "", nil)
+		p.now = func() time.Time { return pollTime }
+		r := &Receiver{Provider: p}
+
+		w := push(r, http.MethodPost, `<Siri xmlns="http://www.siri.org.uk/siri" version="2.0"><HeartbeatNotification>`+
+			`<RequestTimestamp>2025-01-21T09:31:00Z</RequestTimestamp><Status>true</Status></HeartbeatNotification></Siri>`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, pollTime, r.LastHeard())
+		assert.True(t, p.Health().Healthy, "expected provider to be healthy")
+	})
+
+	t.Run("rejects what it cannot decode", func(t *testing.T) {
+		p := New("", "", nil)
+		r := &Receiver{Provider: p}
+
+		w := push(r, http.MethodPost, `{"vehicles": []}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.False(t, p.Health().Healthy, "expected provider to be unhealthy")
+		assert.True(t, r.LastHeard().IsZero(), "expected nothing to be heard")
+	})
+
+	t.Run("rejects requests", func(t *testing.T) {
+		w := push(&Receiver{Provider: New("", "", nil)}, http.MethodPost, `<Siri xmlns="http://www.siri.org.uk/siri" version="2.0"><ServiceRequest/></Siri>`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "expected a ServiceDelivery or HeartbeatNotification\n", w.Body.String())
+	})
+
+	t.Run("only accepts posts", func(t *testing.T) {
+		w := push(&Receiver{Provider: New("", "", nil)}, http.MethodGet, "")
+		assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+		assert.Equal(t, http.MethodPost, w.Header().Get("Allow"))
+	})
+}
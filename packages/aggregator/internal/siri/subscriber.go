@@ -0,0 +1,146 @@
+package siri
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// DefaultDuration is how long a subscription lasts, it is renewed once
+	// three quarters of it have passed
+	DefaultDuration = time.Hour
+	// DefaultSilenceTimeout is how long a producer can go without pushing a
+	// delivery or a heartbeat before the subscription is taken as lost, it is
+	// a few of the heartbeats asked for
+	DefaultSilenceTimeout = 3 * time.Minute
+)
+
+// Subscriber has a SIRI VehicleMonitoring producer push the activity of
+// vehicles to a receiver listening on ListenAddress rather than polling it.
+// ConsumerAddress is the URL the producer pushes to, it defaults to the
+// address the receiver listens on
+type Subscriber struct {
+	*Provider
+
+	ListenAddress   string
+	ConsumerAddress string
+	Duration        time.Duration
+	SilenceTimeout  time.Duration
+}
+
+func NewSubscriber(p *Provider, listenAddress, consumerAddress string) *Subscriber {
+	return &Subscriber{
+		Provider:        p,
+		ListenAddress:   listenAddress,
+		ConsumerAddress: consumerAddress,
+		Duration:        DefaultDuration,
+		SilenceTimeout:  DefaultSilenceTimeout,
+	}
+}
+
+// subscriptionID names a subscription, it is unique to the requestor and the
+// time it was created
+func subscriptionID(requestorRef string, now time.Time) string {
+	if requestorRef == "" {
+		requestorRef = "wheresmylift"
+	}
+
+	return fmt.Sprintf("%s-%d", requestorRef, now.UnixNano())
+}
+
+// Stream subscribes to the producer and receives what it pushes until the
+// context is cancelled, when the subscription is terminated. The subscription
+// is renewed before it ends. It returns an error when the producer cannot be
+// subscribed to or goes silent, so that it is subscribed to again
+func (s *Subscriber) Stream(ctx context.Context, updated func()) error {
+	ln, err := net.Listen("tcp", s.ListenAddress)
+	if err != nil {
+		err = fmt.Errorf("could not listen for pushes: %w", err)
+		s.Record(err)
+
+		return err
+	}
+
+	consumer := s.ConsumerAddress
+	if consumer == "" {
+		consumer = "http://" + ln.Addr().String()
+	}
+
+	id := subscriptionID(s.Client.RequestorRef, s.now())
+	r := &Receiver{Provider: s.Provider, SubscriptionRef: id, Updated: updated}
+	srv := &http.Server{
+		Handler:           r,
+		ReadHeaderTimeout: time.Second,
+	}
+	go func() {
+		if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			log.Error().Err(err).Str("provider", source).Msg("could not receive pushes")
+		}
+	}()
+	defer srv.Close()
+
+	// The first delivery of a subscription has every vehicle, those kept from
+	// an earlier one may have gone
+	s.reset()
+
+	err = s.subscribe(ctx, r, consumer, id)
+	if ctx.Err() != nil {
+		s.terminate(id)
+
+		return nil
+	}
+	s.Record(err)
+
+	return err
+}
+
+// subscribe subscribes and renews the subscription until the context is
+// cancelled or the producer goes silent
+func (s *Subscriber) subscribe(ctx context.Context, r *Receiver, consumer, id string) error {
+	check := time.NewTicker(max(s.SilenceTimeout/3, time.Millisecond))
+	defer check.Stop()
+
+	for {
+		if err := s.Client.Subscribe(ctx, consumer, id, s.now().Add(s.Duration)); err != nil {
+			return err
+		}
+		r.hear()
+		s.Record(nil)
+		log.Info().Str("provider", source).Str("subscription", id).Str("consumer_address", consumer).Msg("subscribed to producer")
+
+		renew := time.NewTimer(s.Duration * 3 / 4)
+		for renewing := false; !renewing; {
+			select {
+			case <-ctx.Done():
+				renew.Stop()
+
+				return ctx.Err()
+			case <-renew.C:
+				renewing = true
+			case <-check.C:
+				if silent := s.now().Sub(r.LastHeard()); silent > s.SilenceTimeout {
+					renew.Stop()
+
+					return fmt.Errorf("producer has pushed nothing for %s", silent.Round(time.Second))
+				}
+			}
+		}
+	}
+}
+
+// terminate asks the producer to stop pushing, a producer which cannot be
+// reached ends the subscription when it terminates anyway
+func (s *Subscriber) terminate(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.Client.Terminate(ctx, id); err != nil {
+		log.Warn().Err(err).Str("provider", source).Str("subscription", id).Msg("could not terminate subscription")
+	}
+}
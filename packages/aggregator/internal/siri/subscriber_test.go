@@ -0,0 +1,114 @@
+package siri
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/stretchr/testify/assert"
+)
+
+// stream runs the subscriber until the returned function is called, which
+// returns what Stream returned
+func stream(s *Subscriber, updated func()) func() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Stream(ctx, updated) }()
+
+	return func() error {
+		cancel()
+
+		return <-done
+	}
+}
+
+// subscriptions returns the subscription requests sent to the producer
+func subscriptions(p *producer) []*siri.SubscriptionRequest {
+	var requests []*siri.SubscriptionRequest
+	for _, r := range p.received() {
+		if r.SubscriptionRequest != nil {
+			requests = append(requests, r.SubscriptionRequest)
+		}
+	}
+
+	return requests
+}
+
+func TestSubscriberStream(t *testing.T) {
+	t.Run("keeps what the producer pushes", func(t *testing.T) {
+		srv := newProducer(t)
+		p := New(srv.URL, "wheresmylift", nil)
+		p.now = func() time.Time { return pollTime }
+		s := NewSubscriber(p, "127.0.0.1:0", "")
+
+		var updates atomic.Int32
+		stop := stream(s, func() { updates.Add(1) })
+		for range 2 {
+			select {
+			case err := <-srv.pushed:
+				assert.NoError(t, err, "expected push to be received")
+			case <-time.After(5 * time.Second):
+				assert.Fail(t, "expected the producer to push")
+			}
+		}
+		assert.Equal(t, int32(2), updates.Load(), "expected every push to be reported")
+
+		vehicles := p.Vehicles()
+		assert.Len(t, vehicles, 1, "expected the cancelled and expired vehicles to be dropped")
+		assert.Equal(t, "GAI-11042", vehicles[0].ID)
+		assert.Equal(t, 3*time.Minute, *vehicles[0].Delay, "expected the incremental update to be kept")
+		assert.True(t, p.Health().Healthy, "expected provider to be healthy")
+
+		assert.NoError(t, stop(), "expected stream to end without an error when cancelled")
+		requests := srv.received()
+		id := subscriptions(srv)[0].VehicleMonitoringSubscriptionRequest.SubscriptionIdentifier
+		assert.Equal(t, subscriptionID("wheresmylift", pollTime), id)
+		last := requests[len(requests)-1].TerminateSubscriptionRequest
+		assert.NotNil(t, last, "expected the subscription to be terminated")
+		assert.Equal(t, id, last.SubscriptionRef)
+	})
+
+	t.Run("renews the subscription before it terminates", func(t *testing.T) {
+		srv := newProducer(t, func(p *producer) { p.silent = true })
+		s := NewSubscriber(New(srv.URL, "", nil), "127.0.0.1:0", "http://aggregator.example.ie/siri")
+		s.Duration = 40 * time.Millisecond
+
+		stop := stream(s, func() {})
+		assert.Eventually(t, func() bool { return len(subscriptions(srv)) >= 3 }, 5*time.Second, 10*time.Millisecond)
+		assert.NoError(t, stop())
+
+		requests := subscriptions(srv)
+		assert.Equal(t, "http://aggregator.example.ie/siri", requests[0].ConsumerAddress)
+		assert.Equal(t, requests[0].VehicleMonitoringSubscriptionRequest.SubscriptionIdentifier,
+			requests[1].VehicleMonitoringSubscriptionRequest.SubscriptionIdentifier, "expected the same subscription to be renewed")
+	})
+
+	t.Run("errors when the producer goes silent", func(t *testing.T) {
+		srv := newProducer(t, func(p *producer) { p.silent = true })
+		s := NewSubscriber(New(srv.URL, "", nil), "127.0.0.1:0", "")
+		s.SilenceTimeout = 30 * time.Millisecond
+
+		err := s.Stream(context.Background(), func() {})
+		assert.ErrorContains(t, err, "producer has pushed nothing for")
+		assert.False(t, s.Health().Healthy, "expected provider to be unhealthy")
+	})
+
+	t.Run("errors when the subscription is refused", func(t *testing.T) {
+		srv := newProducer(t, func(p *producer) { p.refuse = true })
+		s := NewSubscriber(New(srv.URL, "", nil), "127.0.0.1:0", "")
+
+		err := s.Stream(context.Background(), func() {})
+		assert.EqualError(t, err, "could not subscribe: producer reported CapabilityNotSupportedError: too many subscriptions")
+		assert.Equal(t, 1, s.Health().ConsecutiveFailures)
+	})
+
+	t.Run("errors when it cannot listen", func(t *testing.T) {
+		s := NewSubscriber(New("http://localhost", "", nil), "localhost", "")
+
+		err := s.Stream(context.Background(), func() {})
+		assert.ErrorContains(t, err, "could not listen for pushes")
+		assert.False(t, s.Health().Healthy, "expected provider to be unhealthy")
+	})
+}
@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"context"
+	"runtime"
+	"time"
 
+	"github.com/mcgovman/wheresmylift/lib/schedule"
 	"github.com/mcgovman/wheresmylift/packages/api/internal/config"
 	"github.com/mcgovman/wheresmylift/packages/api/internal/server"
 	"github.com/rs/zerolog"
@@ -19,6 +22,8 @@ func Start() {
 	logLevel := viper.GetString("LOG_LEVEL")
 	httpListenAddr := viper.GetString("HTTP_LISTEN_ADDRESS")
 	httpTrustedProxy := viper.GetString("HTTP_TRUSTED_PROXY")
+	aggregatorURL := viper.GetString("AGGREGATOR_URL")
+	gtfsStaticPath := viper.GetString("GTFS_STATIC_PATH")
 
 	cfg := config.Config{
 		LogLevel: logLevel,
@@ -26,6 +31,10 @@ func Start() {
 			ListenAddress: httpListenAddr,
 			TrustedProxy:  httpTrustedProxy,
 		},
+		Aggregator: config.Aggregator{
+			URL: aggregatorURL,
+		},
+		GTFSStaticPath: gtfsStaticPath,
 	}
 
 	issues := cfg.Verify()
@@ -40,7 +49,19 @@ func Start() {
 	zerologLevel := cfg.GetZeroLogLevel()
 	zerolog.SetGlobalLevel(zerologLevel)
 
+	var sched *schedule.Schedule
+	if cfg.GTFSStaticPath != "" {
+		s, err := loadSchedule(cfg.GTFSStaticPath)
+		if err != nil {
+			log.Error().Err(err).Str("path", cfg.GTFSStaticPath).Msg("could not load static timetable")
+
+			return
+		}
+		sched = s
+	}
+
 	Srv = server.NewServer(cfg)
+	Srv.Schedule = sched
 
 	log.Info().Msg("starting server")
 	if err := Srv.Start(); err != nil {
@@ -58,3 +79,39 @@ func Stop() {
 
 	log.Log().Msg("stopped server successfully")
 }
+
+// loadSchedule loads a GTFS static feed or NeTEx and logs how long it took
+// and how much memory it is using
+func loadSchedule(path string) (*schedule.Schedule, error) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+
+	s, err := schedule.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	loadTime := time.Since(start)
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	// The heap can shrink while loading when the collector frees more than the
+	// timetable takes
+	memory := max(int64(after.HeapAlloc)-int64(before.HeapAlloc), 0)
+
+	log.Info().
+		Str("path", path).
+		Dur("load_time", loadTime).
+		Int64("memory_bytes", memory).
+		Int("agencies", len(s.Agencies)).
+		Int("stops", len(s.Stops)).
+		Int("routes", len(s.Routes)).
+		Int("trips", len(s.Trips)).
+		Int("stop_times", s.StopTimeCount()).
+		Int("shapes", len(s.Shapes)).
+		Msg("loaded static timetable")
+
+	return s, nil
+}
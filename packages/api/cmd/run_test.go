@@ -145,6 +145,85 @@ func TestStart(t *testing.T) {
 			)
 		}, assertionStepTimeout, assertionPollInterval)
 	})
+
+	t.Run("cmd will load the static timetable", func(t *testing.T) {
+		Srv = nil
+		cfg := validConfig()
+		t.Setenv("WML_LOG_LEVEL", cfg.LogLevel)
+		t.Setenv("WML_HTTP_LISTEN_ADDRESS", cfg.HTTP.ListenAddress)
+		t.Setenv("WML_HTTP_TRUSTED_PROXY", cfg.HTTP.TrustedProxy)
+		t.Setenv("WML_GTFS_STATIC_PATH", "../../../lib/schedule/testdata/gtfs")
+
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		go func() {
+			Start()
+		}()
+
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.True(
+				c,
+				logSink.ContainsLog(
+					map[string]interface{}{
+						"level":      "info",
+						"message":    "loaded static timetable",
+						"path":       "../../../lib/schedule/testdata/gtfs",
+						"agencies":   2,
+						"stops":      6,
+						"routes":     3,
+						"trips":      4,
+						"stop_times": 9,
+						"shapes":     1,
+					},
+					jsondiff.SupersetMatch,
+				),
+				"could not find loaded feed log",
+			)
+		}, assertionStepTimeout, assertionPollInterval)
+		assert.EventuallyWithT(t, func(c *assert.CollectT) {
+			assert.True(
+				c,
+				logSink.ContainsLog(
+					map[string]interface{}{
+						"level":   "info",
+						"message": "starting server",
+					},
+					jsondiff.FullMatch,
+				),
+				"could not find server starting log",
+			)
+		}, assertionStepTimeout, assertionPollInterval)
+		Stop()
+	})
+
+	t.Run("cmd will not start when the static timetable cannot be loaded", func(t *testing.T) {
+		Srv = nil
+		cfg := validConfig()
+		t.Setenv("WML_LOG_LEVEL", cfg.LogLevel)
+		t.Setenv("WML_HTTP_LISTEN_ADDRESS", cfg.HTTP.ListenAddress)
+		t.Setenv("WML_HTTP_TRUSTED_PROXY", cfg.HTTP.TrustedProxy)
+		t.Setenv("WML_GTFS_STATIC_PATH", "missing.zip")
+
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		Start()
+		assert.True(
+			t,
+			logSink.ContainsLog(
+				map[string]interface{}{
+					"level":   "error",
+					"message": "could not load static timetable",
+					"path":    "missing.zip",
+					"error":   "could not open timetable: stat missing.zip: no such file or directory",
+				},
+				jsondiff.FullMatch,
+			),
+			"could not find load failure log",
+		)
+		assert.Nil(t, Srv, "expected no server to be created")
+	})
 }
 
 func TestStop(t *testing.T) {
@@ -41,9 +41,180 @@ const docTemplate = `{
                 }
             }
         },
+        "/v0/alerts": {
+            "get": {
+                "description": "Alerts whose active periods have all ended are never returned",
+                "produces": [
+                    "application/json",
+                    "application/x-protobuf"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get service alerts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only alerts informing this route id",
+                        "name": "route",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only alerts informing this stop id",
+                        "name": "stop",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only alerts informing this operator id",
+                        "name": "operator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only alerts active at this RFC3339 time",
+                        "name": "active_at",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "protobuf"
+                        ],
+                        "type": "string",
+                        "description": "json, or protobuf for an Alerts message, instead of the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/transit.Alert"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/gtfs-rt/alerts": {
+            "get": {
+                "description": "A GTFS-Realtime FeedMessage with the alerts which have not expired and an alert for each Luas line which is not operating normally",
+                "produces": [
+                    "application/x-protobuf",
+                    "application/json"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get service alerts as GTFS-Realtime",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Respond with the feed as JSON instead",
+                        "name": "debug",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/gtfs-rt/trip-updates": {
+            "get": {
+                "description": "A GTFS-Realtime FeedMessage with a trip update for each trip with realtime departures, Irish Rail train codes and station codes are mapped to the ids of the static timetable when it is loaded\nLuas forecasts are not for a trip, each is a trip update of its route and the direction of its destination with the one stop, with the entity id source:line:direction:stop:n for the nth tram in order of time",
+                "produces": [
+                    "application/x-protobuf",
+                    "application/json"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get trip updates as GTFS-Realtime",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Respond with the feed as JSON instead",
+                        "name": "debug",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/gtfs-rt/vehicle-positions": {
+            "get": {
+                "description": "A GTFS-Realtime FeedMessage with the vehicles of every operator, Irish Rail and Luas ids are mapped to the ids of the static timetable when it is loaded",
+                "produces": [
+                    "application/x-protobuf",
+                    "application/json"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get vehicle positions as GTFS-Realtime",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Respond with the feed as JSON instead",
+                        "name": "debug",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
         "/v0/healthcheck": {
             "get": {
-                "description": "If accessing this endpoint via Cloudflare it will only accessible using the BetterStack user-agent https://betterstack.com/docs/uptime/frequently-asked-questions/#what-user-agent-does-uptime-use",
+                "description": "If accessing this endpoint via Cloudflare it will only accessible using the BetterStack user-agent https://betterstack.com/docs/uptime/frequently-asked-questions/#what-user-agent-does-uptime-use\nUnhealthy while an aggregator is configured but not connected",
                 "tags": [
                     "V0"
                 ],
@@ -51,9 +222,1469 @@ const docTemplate = `{
                 "responses": {
                     "204": {
                         "description": "No Content"
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/operators": {
+            "get": {
+                "description": "Every operator of the static timetable ordered by id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get operators",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/transit.Operator"
+                            }
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/proto": {
+            "get": {
+                "description": "The schema of the protobuf responses of /v0/vehicles, /v0/stops/{id}/departures and /v0/alerts, sent for Accept: application/x-protobuf or format=protobuf",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get the protobuf schema",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/routes": {
+            "get": {
+                "description": "Every route of the static timetable ordered by id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get routes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only routes of this operator id",
+                        "name": "operator",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "bus",
+                            "rail",
+                            "tram",
+                            "ferry"
+                        ],
+                        "type": "string",
+                        "description": "Only routes of this mode",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/transit.Route"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/routes/{id}": {
+            "get": {
+                "description": "A route of the static timetable with the shapes of its trips as longitude and latitude pairs and the orders of stops they call at",
+                "produces": [
+                    "application/json",
+                    "application/geo+json"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get a route",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Route id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "geojson"
+                        ],
+                        "type": "string",
+                        "description": "json, or geojson for a FeatureCollection, instead of the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dataset.RouteDetail"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/search": {
+            "get": {
+                "description": "Stops matched by their name, code or Irish name and routes matched by their short name, best match first\nEvery word of the query has to match a word of a name, exactly, as its start or with a typo or two",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Search stops and routes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "What to search for, such as heuston, Stáisiún Heuston or 46a",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Most results to return, between 1 and 50",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dataset.SearchResult"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/siri/situation-exchange": {
+            "get": {
+                "description": "A SIRI 2.0 SituationExchangeDelivery with a PtSituationElement for each alert which has not expired and for each Luas line which is not operating normally\nWith a PreviewInterval only the alerts active at some time within it are included",
+                "produces": [
+                    "application/xml"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get service alerts as SIRI SituationExchange",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only situations affecting this route id",
+                        "name": "LineRef",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 8601 duration such as PT30M of the situations to include",
+                        "name": "PreviewInterval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/siri/vehicle-monitoring": {
+            "get": {
+                "description": "A SIRI 2.0 VehicleMonitoringDelivery with a VehicleActivity for each vehicle, its ids are those of the JSON responses\nWith a PreviewInterval the onward calls of each vehicle expected within it are included",
+                "produces": [
+                    "application/xml"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get vehicle positions as SIRI VehicleMonitoring",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only vehicles on this route id",
+                        "name": "LineRef",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the vehicle with this id",
+                        "name": "VehicleRef",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ISO 8601 duration such as PT30M of the onward calls to include",
+                        "name": "PreviewInterval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/stops": {
+            "get": {
+                "description": "Every stop of the static timetable ordered by id",
+                "produces": [
+                    "application/json",
+                    "application/geo+json"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get stops",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only stops within minLon,minLat,maxLon,maxLat",
+                        "name": "bbox",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "geojson"
+                        ],
+                        "type": "string",
+                        "description": "json, or geojson for a FeatureCollection, instead of the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/transit.Stop"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/stops/nearby": {
+            "get": {
+                "description": "The stops nearest to a point ordered by great-circle distance, each with the routes calling at it and its next few departures\nPlatforms are left out in favour of their station",
+                "produces": [
+                    "application/json",
+                    "application/geo+json"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get the stops near a point",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Latitude",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude",
+                        "name": "lon",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "default": 500,
+                        "description": "Metres from the point, at most 2000",
+                        "name": "radius",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Most stops to return, between 1 and 50",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "geojson"
+                        ],
+                        "type": "string",
+                        "description": "json, or geojson for a FeatureCollection, instead of the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dataset.NearbyStop"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/stops/{id}": {
+            "get": {
+                "description": "A stop of the static timetable with its platforms and the routes calling at it",
+                "produces": [
+                    "application/json",
+                    "application/geo+json"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get a stop",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stop id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "geojson"
+                        ],
+                        "type": "string",
+                        "description": "json, or geojson for a FeatureCollection, instead of the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dataset.StopDetail"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/stops/{id}/departures": {
+            "get": {
+                "description": "The departures from a stop and its platforms, the timetable is merged with the realtime departures of every source\nEach departure is marked realtime, or not when it is only scheduled, and has a status of scheduled, cancelled, skipped or no_data",
+                "produces": [
+                    "application/json",
+                    "application/x-protobuf"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get the departures from a stop",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Stop id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Most departures to return, between 1 and 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "1h",
+                        "description": "Only departures within this duration of now, at most 24h",
+                        "name": "time_range",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "protobuf"
+                        ],
+                        "type": "string",
+                        "description": "json, or protobuf for a Departures message, instead of the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/transit.Departure"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/stream/vehicles": {
+            "get": {
+                "description": "A stream of server-sent events, a snapshot event with every vehicle and then a delta event with the vehicles which changed or were removed every time the aggregator hands off new positions\nA vehicle which leaves the filters is in the removed refs of a delta, and so is any vehicle not matching them after resuming. A ref is the source and id of a vehicle, as ids are only unique within a source. The id of each event is the version it brings the vehicles to, reconnecting with it as Last-Event-ID resumes the stream from that version, or starts it again from a snapshot when the version is too old\nA comment is sent as a heartbeat when nothing else has been sent for 15 seconds",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Stream vehicle positions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Version to resume the stream from",
+                        "name": "Last-Event-ID",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only vehicles within minLon,minLat,maxLon,maxLat",
+                        "name": "bbox",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only vehicles of this operator id",
+                        "name": "operator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only vehicles on this route id",
+                        "name": "route",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "bus",
+                            "rail",
+                            "tram",
+                            "ferry"
+                        ],
+                        "type": "string",
+                        "description": "Only vehicles of this mode",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dataset.VehicleDelta"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/vehicles": {
+            "get": {
+                "description": "The latest known position of every vehicle, across every source",
+                "produces": [
+                    "application/json",
+                    "application/geo+json",
+                    "application/x-protobuf"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get vehicle positions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only vehicles within minLon,minLat,maxLon,maxLat",
+                        "name": "bbox",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only vehicles of this operator id",
+                        "name": "operator",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only vehicles on this route id",
+                        "name": "route",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "bus",
+                            "rail",
+                            "tram",
+                            "ferry"
+                        ],
+                        "type": "string",
+                        "description": "Only vehicles of this mode",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "geojson",
+                            "protobuf"
+                        ],
+                        "type": "string",
+                        "description": "json, geojson for a FeatureCollection or protobuf for a Vehicles message, instead of the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/transit.Vehicle"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    }
+                }
+            }
+        },
+        "/v0/vehicles/{id}": {
+            "get": {
+                "description": "The latest position of a vehicle with its trip, route and the stops it has yet to call at with their predicted times\nWithout realtime departures for its trip the scheduled stops are shifted by the delay of the vehicle",
+                "produces": [
+                    "application/json",
+                    "application/geo+json"
+                ],
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Get a vehicle",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vehicle id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Source of the vehicle, needed when sources share the id",
+                        "name": "source",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "geojson"
+                        ],
+                        "type": "string",
+                        "description": "json, or geojson for a FeatureCollection, instead of the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dataset.VehicleDetail"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/helpers.Error"
+                        }
                     }
                 }
             }
+        },
+        "/v0/ws": {
+            "get": {
+                "description": "A WebSocket which is sent what it subscribes to. Send {\"type\":\"subscribe\",\"topic\":\"...\"} or {\"type\":\"unsubscribe\",\"topic\":\"...\"} with one of the topics\nvehicles, vehicles:bbox:{minLon,minLat,maxLon,maxLat}, vehicles:route:{id}, vehicles:operator:{id}, stop:{id}:departures, alerts, alerts:route:{id}, alerts:stop:{id} or alerts:operator:{id}\nVehicles topics are sent a snapshot and then deltas, the others are sent an update with everything whenever it changes. A connection can subscribe to at most 20 topics\nUpdates are never queued, a client which falls behind is sent the latest when it catches up and is disconnected when it does not take a message for 10 seconds",
+                "tags": [
+                    "V0"
+                ],
+                "summary": "Subscribe to vehicles, departures and alerts",
+                "responses": {
+                    "101": {
+                        "description": "Switching Protocols",
+                        "schema": {
+                            "$ref": "#/definitions/server.wsMessage"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dataset.NearbyStop": {
+            "type": "object",
+            "properties": {
+                "departures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.Departure"
+                    }
+                },
+                "distance": {
+                    "description": "metres",
+                    "type": "number",
+                    "example": 120
+                },
+                "routes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.Route"
+                    }
+                },
+                "stop": {
+                    "$ref": "#/definitions/transit.Stop"
+                }
+            }
+        },
+        "dataset.NextStop": {
+            "type": "object",
+            "properties": {
+                "departure": {
+                    "$ref": "#/definitions/transit.Departure"
+                },
+                "stop": {
+                    "$ref": "#/definitions/transit.Stop"
+                }
+            }
+        },
+        "dataset.RouteDetail": {
+            "type": "object",
+            "properties": {
+                "patterns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.StopPattern"
+                    }
+                },
+                "route": {
+                    "$ref": "#/definitions/transit.Route"
+                },
+                "shapes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.Shape"
+                    }
+                }
+            }
+        },
+        "dataset.SearchResult": {
+            "type": "object",
+            "properties": {
+                "route": {
+                    "$ref": "#/definitions/transit.Route"
+                },
+                "score": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "stop": {
+                    "$ref": "#/definitions/transit.Stop"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "stop",
+                        "route"
+                    ],
+                    "example": "stop"
+                }
+            }
+        },
+        "dataset.StopDetail": {
+            "type": "object",
+            "properties": {
+                "platforms": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.Stop"
+                    }
+                },
+                "routes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.Route"
+                    }
+                },
+                "stop": {
+                    "$ref": "#/definitions/transit.Stop"
+                }
+            }
+        },
+        "dataset.VehicleDelta": {
+            "type": "object",
+            "properties": {
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dataset.VehicleRef"
+                    }
+                },
+                "updated": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.Vehicle"
+                    }
+                },
+                "version": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "dataset.VehicleDetail": {
+            "type": "object",
+            "properties": {
+                "next_stops": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dataset.NextStop"
+                    }
+                },
+                "route": {
+                    "$ref": "#/definitions/transit.Route"
+                },
+                "service_day": {
+                    "type": "string",
+                    "example": "2025-01-21"
+                },
+                "trip": {
+                    "$ref": "#/definitions/transit.Trip"
+                },
+                "vehicle": {
+                    "$ref": "#/definitions/transit.Vehicle"
+                }
+            }
+        },
+        "dataset.VehicleRef": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "V1"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "gtfsr"
+                }
+            }
+        },
+        "helpers.Error": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "a server error was encountered"
+                }
+            }
+        },
+        "server.wsMessage": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "topic": {
+                    "type": "string",
+                    "example": "stop:8220DB000334:departures"
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "subscribed",
+                        "unsubscribed",
+                        "snapshot",
+                        "delta",
+                        "update",
+                        "error"
+                    ],
+                    "example": "update"
+                }
+            }
+        },
+        "transit.ActivePeriod": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string",
+                    "example": "2025-01-21T18:00:00Z"
+                },
+                "start": {
+                    "type": "string",
+                    "example": "2025-01-21T09:00:00Z"
+                }
+            }
+        },
+        "transit.Alert": {
+            "type": "object",
+            "properties": {
+                "active_periods": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.ActivePeriod"
+                    }
+                },
+                "cause": {
+                    "type": "string",
+                    "example": "CONSTRUCTION"
+                },
+                "description_text": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.Translation"
+                    }
+                },
+                "effect": {
+                    "type": "string",
+                    "example": "DETOUR"
+                },
+                "header_text": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.Translation"
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "A1"
+                },
+                "informed_entities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.InformedEntity"
+                    }
+                },
+                "url": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.Translation"
+                    }
+                }
+            }
+        },
+        "transit.Departure": {
+            "type": "object",
+            "properties": {
+                "delay": {
+                    "description": "seconds, negative when early",
+                    "type": "integer",
+                    "example": 120
+                },
+                "direction": {
+                    "type": "string",
+                    "example": "Southbound"
+                },
+                "expected_arrival": {
+                    "type": "string",
+                    "example": "2025-01-21T12:02:00Z"
+                },
+                "expected_departure": {
+                    "type": "string",
+                    "example": "2025-01-21T12:02:00Z"
+                },
+                "headsign": {
+                    "type": "string",
+                    "example": "Liffey Valley"
+                },
+                "mode": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/transit.Mode"
+                        }
+                    ],
+                    "example": "bus"
+                },
+                "operator": {
+                    "type": "string",
+                    "example": "7778019"
+                },
+                "platform": {
+                    "type": "string",
+                    "example": "2"
+                },
+                "realtime": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "route_id": {
+                    "type": "string",
+                    "example": "3249_46342"
+                },
+                "scheduled_arrival": {
+                    "type": "string",
+                    "example": "2025-01-21T12:00:00Z"
+                },
+                "scheduled_departure": {
+                    "type": "string",
+                    "example": "2025-01-21T12:00:00Z"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "gtfsr"
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/transit.DepartureStatus"
+                        }
+                    ],
+                    "example": "scheduled"
+                },
+                "stop_id": {
+                    "type": "string",
+                    "example": "8220DB000334"
+                },
+                "stop_sequence": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "trip_id": {
+                    "type": "string",
+                    "example": "3249_10466"
+                },
+                "vehicle_id": {
+                    "type": "string",
+                    "example": "V1"
+                }
+            }
+        },
+        "transit.DepartureStatus": {
+            "type": "string",
+            "enum": [
+                "scheduled",
+                "cancelled",
+                "skipped",
+                "no_data"
+            ],
+            "x-enum-varnames": [
+                "DepartureScheduled",
+                "DepartureCancelled",
+                "DepartureSkipped",
+                "DepartureNoData"
+            ]
+        },
+        "transit.InformedEntity": {
+            "type": "object",
+            "properties": {
+                "direction_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "operator_id": {
+                    "type": "string",
+                    "example": "7778019"
+                },
+                "route_id": {
+                    "type": "string",
+                    "example": "3249_46342"
+                },
+                "route_type": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "stop_id": {
+                    "type": "string",
+                    "example": "8220DB000334"
+                },
+                "trip_id": {
+                    "type": "string",
+                    "example": "3249_10466"
+                }
+            }
+        },
+        "transit.Mode": {
+            "type": "string",
+            "enum": [
+                "bus",
+                "rail",
+                "tram",
+                "ferry"
+            ],
+            "x-enum-varnames": [
+                "ModeBus",
+                "ModeRail",
+                "ModeTram",
+                "ModeFerry"
+            ]
+        },
+        "transit.Operator": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "example": "7778019"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Dublin Bus"
+                },
+                "phone": {
+                    "type": "string",
+                    "example": "+353 1 873 4222"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Dublin"
+                },
+                "url": {
+                    "type": "string",
+                    "example": "https://www.dublinbus.ie"
+                }
+            }
+        },
+        "transit.Route": {
+            "type": "object",
+            "properties": {
+                "color": {
+                    "type": "string",
+                    "example": "FFD200"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3249_46342"
+                },
+                "long_name": {
+                    "type": "string",
+                    "example": "Phoenix Park - Dun Laoghaire"
+                },
+                "mode": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/transit.Mode"
+                        }
+                    ],
+                    "example": "bus"
+                },
+                "operator": {
+                    "type": "string",
+                    "example": "7778019"
+                },
+                "short_name": {
+                    "type": "string",
+                    "example": "46A"
+                },
+                "text_color": {
+                    "type": "string",
+                    "example": "000000"
+                }
+            }
+        },
+        "transit.Shape": {
+            "type": "object",
+            "properties": {
+                "coordinates": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "number"
+                        }
+                    }
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3249_46342_1"
+                }
+            }
+        },
+        "transit.Stop": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "334"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "8220DB000334"
+                },
+                "latitude": {
+                    "type": "number",
+                    "example": 53.3498
+                },
+                "longitude": {
+                    "type": "number",
+                    "example": -6.2603
+                },
+                "name": {
+                    "type": "string",
+                    "example": "O'Connell Street Upper"
+                },
+                "parent_station": {
+                    "type": "string",
+                    "example": "8220DB000333"
+                },
+                "platform": {
+                    "type": "string",
+                    "example": "2"
+                },
+                "translations": {
+                    "description": "of the name, such as the Irish name",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/transit.Translation"
+                    }
+                }
+            }
+        },
+        "transit.StopPattern": {
+            "type": "object",
+            "properties": {
+                "direction_id": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "headsign": {
+                    "type": "string",
+                    "example": "Dun Laoghaire"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3249_46342:0:1"
+                },
+                "shape_id": {
+                    "type": "string",
+                    "example": "3249_46342_1"
+                },
+                "stop_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "8220DB000334",
+                        "8220DB000335"
+                    ]
+                },
+                "trips": {
+                    "type": "integer",
+                    "example": 42
+                }
+            }
+        },
+        "transit.Translation": {
+            "type": "object",
+            "properties": {
+                "language": {
+                    "type": "string",
+                    "example": "en"
+                },
+                "text": {
+                    "type": "string",
+                    "example": "Route 46A diverted"
+                }
+            }
+        },
+        "transit.Trip": {
+            "type": "object",
+            "properties": {
+                "direction_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "headsign": {
+                    "type": "string",
+                    "example": "Dun Laoghaire"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3249_10466"
+                },
+                "operator": {
+                    "type": "string",
+                    "example": "7778019"
+                },
+                "route_id": {
+                    "type": "string",
+                    "example": "3249_46342"
+                },
+                "shape_id": {
+                    "type": "string",
+                    "example": "3249_123"
+                },
+                "short_name": {
+                    "type": "string",
+                    "example": "E109"
+                }
+            }
+        },
+        "transit.Vehicle": {
+            "type": "object",
+            "properties": {
+                "bearing": {
+                    "type": "number",
+                    "example": 90
+                },
+                "delay": {
+                    "description": "seconds, negative when early",
+                    "type": "integer",
+                    "example": 120
+                },
+                "headsign": {
+                    "type": "string",
+                    "example": "Liffey Valley"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "V1"
+                },
+                "label": {
+                    "type": "string",
+                    "example": "SG1"
+                },
+                "latitude": {
+                    "type": "number",
+                    "example": 53.3498
+                },
+                "longitude": {
+                    "type": "number",
+                    "example": -6.2603
+                },
+                "mode": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/transit.Mode"
+                        }
+                    ],
+                    "example": "bus"
+                },
+                "operator": {
+                    "type": "string",
+                    "example": "7778019"
+                },
+                "route_id": {
+                    "type": "string",
+                    "example": "3249_46342"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "gtfsr"
+                },
+                "speed": {
+                    "type": "number",
+                    "example": 8.5
+                },
+                "status": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/transit.VehicleStatus"
+                        }
+                    ],
+                    "example": "in_transit_to"
+                },
+                "stop_id": {
+                    "type": "string",
+                    "example": "8220DB000334"
+                },
+                "timestamp": {
+                    "type": "string",
+                    "example": "2025-01-21T12:00:00Z"
+                },
+                "trip_id": {
+                    "type": "string",
+                    "example": "3249_10466"
+                }
+            }
+        },
+        "transit.VehicleStatus": {
+            "type": "string",
+            "enum": [
+                "scheduled",
+                "incoming_at",
+                "stopped_at",
+                "in_transit_to",
+                "terminated"
+            ],
+            "x-enum-varnames": [
+                "VehicleScheduled",
+                "VehicleIncomingAt",
+                "VehicleStoppedAt",
+                "VehicleInTransit",
+                "VehicleTerminated"
+            ]
         }
     }
 }`
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"net"
+	"net/url"
 
 	"github.com/rs/zerolog"
 )
@@ -12,10 +13,18 @@ type HTTP struct {
 	TrustedProxy  string `mapstructure:"trusted_proxy" yaml:"trusted_proxy"`
 }
 
+// Aggregator is the handoff stream of the aggregator, the API serves no
+// realtime data without it
+type Aggregator struct {
+	URL string `mapstructure:"url" yaml:"url"`
+}
+
 // Config describes the configuration for Server
 type Config struct {
-	LogLevel string `mapstructure:"log_level" yaml:"log_level"`
-	HTTP     HTTP   `mapstructure:"http" yaml:"http"`
+	LogLevel       string     `mapstructure:"log_level" yaml:"log_level"`
+	HTTP           HTTP       `mapstructure:"http" yaml:"http"`
+	Aggregator     Aggregator `mapstructure:"aggregator" yaml:"aggregator"`
+	GTFSStaticPath string     `mapstructure:"gtfs_static_path" yaml:"gtfs_static_path"`
 }
 
 func (c *Config) GetZeroLogLevel() zerolog.Level {
@@ -55,6 +64,20 @@ func (h *HTTP) Verify() []string {
 	return issues
 }
 
+func (a *Aggregator) Verify() []string {
+	issues := []string{}
+	if a.URL == "" {
+		return issues
+	}
+
+	u, err := url.Parse(a.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		issues = append(issues, fmt.Sprintf("The aggregator URL %s is invalid", a.URL))
+	}
+
+	return issues
+}
+
 func (c *Config) Verify() []string {
 	issues := []string{}
 
@@ -64,6 +87,7 @@ func (c *Config) Verify() []string {
 
 	httpIssues := c.HTTP.Verify()
 	issues = append(issues, httpIssues...)
+	issues = append(issues, c.Aggregator.Verify()...)
 
 	return issues
 }
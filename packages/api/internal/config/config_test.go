@@ -119,6 +119,43 @@ func TestHTTPVerify(t *testing.T) {
 	}
 }
 
+func TestAggregatorVerify(t *testing.T) {
+	var testConfig Config
+
+	runs := []Run{
+		{
+			name:        "expect no URL issue when given nothing",
+			beforeWork:  func() {},
+			issue:       "The aggregator URL  is invalid",
+			expectIssue: false,
+		},
+		{
+			name: "expect no URL issue with a valid URL",
+			beforeWork: func() {
+				testConfig.Aggregator.URL = "http://aggregator:8081/v0/handoff"
+			},
+			issue:       "The aggregator URL http://aggregator:8081/v0/handoff is invalid",
+			expectIssue: false,
+		},
+		{
+			name: "expect URL issue without a scheme",
+			beforeWork: func() {
+				testConfig.Aggregator.URL = "aggregator:8081"
+			},
+			issue:       "The aggregator URL aggregator:8081 is invalid",
+			expectIssue: true,
+		},
+	}
+
+	for _, run := range runs {
+		t.Run(run.name, func(t *testing.T) {
+			testConfig = validConfig
+			run.verifyFunc = testConfig.Aggregator.Verify
+			run.verifyIssuesAndError(t)
+		})
+	}
+}
+
 func TestConfig(t *testing.T) {
 	var testConfig Config
 
@@ -170,6 +207,14 @@ func TestConfig(t *testing.T) {
 			issue:       "HTTP listen address is not valid",
 			expectIssue: true,
 		},
+		{
+			name: "expect aggregator issue to exist",
+			beforeWork: func() {
+				testConfig.Aggregator.URL = "localhost"
+			},
+			issue:       "The aggregator URL localhost is invalid",
+			expectIssue: true,
+		},
 	}
 
 	for _, run := range runs {
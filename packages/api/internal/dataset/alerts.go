@@ -0,0 +1,65 @@
+package dataset
+
+import (
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+// AlertFilter leaves out alerts which do not inform the given route, stop or
+// operator, are not active at ActiveAt, or are not active at any time within
+// ActiveWithin of now. Empty fields are not filtered on
+type AlertFilter struct {
+	RouteID      string
+	StopID       string
+	OperatorID   string
+	ActiveAt     *time.Time
+	ActiveWithin time.Duration
+}
+
+func informs(a transit.Alert, match func(e transit.InformedEntity) bool) bool {
+	for _, e := range a.InformedEntities {
+		if match(e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (f AlertFilter) matches(a transit.Alert, now time.Time) bool {
+	if f.RouteID != "" && !informs(a, func(e transit.InformedEntity) bool { return e.RouteID == f.RouteID }) {
+		return false
+	}
+
+	if f.StopID != "" && !informs(a, func(e transit.InformedEntity) bool { return e.StopID == f.StopID }) {
+		return false
+	}
+
+	if f.OperatorID != "" && !informs(a, func(e transit.InformedEntity) bool { return e.OperatorID == f.OperatorID }) {
+		return false
+	}
+
+	if f.ActiveWithin != 0 && !a.ActiveBetween(now, now.Add(f.ActiveWithin)) {
+		return false
+	}
+
+	return f.ActiveAt == nil || a.ActiveAt(*f.ActiveAt)
+}
+
+// Alerts returns the alerts matching the filter, alerts that have expired are
+// never returned
+func (d *Dataset) Alerts(filter AlertFilter) []transit.Alert {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	now := d.now()
+	alerts := []transit.Alert{}
+	for _, a := range d.alerts {
+		if !a.ExpiredAt(now) && filter.matches(a, now) {
+			alerts = append(alerts, a)
+		}
+	}
+
+	return alerts
+}
@@ -0,0 +1,114 @@
+package dataset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+func at(s string) *time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+
+	return &t
+}
+
+var testAlerts = []transit.Alert{
+	{
+		ID:            "A1",
+		ActivePeriods: []transit.ActivePeriod{{Start: at("2025-01-21T09:00:00Z"), End: at("2025-01-21T18:00:00Z")}},
+		InformedEntities: []transit.InformedEntity{
+			{OperatorID: "7778019", RouteID: "3249_46342"},
+		},
+	},
+	{
+		ID:            "A2",
+		ActivePeriods: []transit.ActivePeriod{{End: at("2025-01-20T18:00:00Z")}},
+		InformedEntities: []transit.InformedEntity{
+			{RouteID: "3249_46350"},
+		},
+	},
+	{
+		ID: "A3",
+		InformedEntities: []transit.InformedEntity{
+			{StopID: "8220DB000334"},
+			{OperatorID: "7778019"},
+		},
+	},
+	{
+		ID:            "A4",
+		ActivePeriods: []transit.ActivePeriod{{Start: at("2025-01-23T00:00:00Z")}},
+		InformedEntities: []transit.InformedEntity{
+			{OperatorID: "7778020", RouteID: "3264_46711"},
+		},
+	},
+}
+
+func testDataset() *Dataset {
+	d := New()
+	d.now = func() time.Time { return *at("2025-01-21T12:00:00Z") }
+	d.SetAlerts(testAlerts)
+
+	return d
+}
+
+func ids(alerts []transit.Alert) []string {
+	ids := []string{}
+	for _, a := range alerts {
+		ids = append(ids, a.ID)
+	}
+
+	return ids
+}
+
+func TestAlerts(t *testing.T) {
+	t.Run("expired alerts are never returned", func(t *testing.T) {
+		d := testDataset()
+		assert.Equal(t, []string{"A1", "A3", "A4"}, ids(d.Alerts(AlertFilter{})))
+	})
+
+	t.Run("alerts drop out once they expire", func(t *testing.T) {
+		d := testDataset()
+		d.now = func() time.Time { return *at("2025-01-22T12:00:00Z") }
+		assert.Equal(t, []string{"A3", "A4"}, ids(d.Alerts(AlertFilter{})))
+	})
+
+	t.Run("filters on route", func(t *testing.T) {
+		d := testDataset()
+		assert.Equal(t, []string{"A1"}, ids(d.Alerts(AlertFilter{RouteID: "3249_46342"})))
+		assert.Empty(t, d.Alerts(AlertFilter{RouteID: "3249_46350"}), "expected expired alert to be left out")
+	})
+
+	t.Run("filters on stop", func(t *testing.T) {
+		d := testDataset()
+		assert.Equal(t, []string{"A3"}, ids(d.Alerts(AlertFilter{StopID: "8220DB000334"})))
+	})
+
+	t.Run("filters on operator", func(t *testing.T) {
+		d := testDataset()
+		assert.Equal(t, []string{"A1", "A3"}, ids(d.Alerts(AlertFilter{OperatorID: "7778019"})))
+	})
+
+	t.Run("filters on active at", func(t *testing.T) {
+		d := testDataset()
+		assert.Equal(t, []string{"A1", "A3"}, ids(d.Alerts(AlertFilter{ActiveAt: at("2025-01-21T12:00:00Z")})))
+		assert.Equal(t, []string{"A3", "A4"}, ids(d.Alerts(AlertFilter{ActiveAt: at("2025-01-24T12:00:00Z")})))
+	})
+
+	t.Run("filters on active within", func(t *testing.T) {
+		d := testDataset()
+		assert.Equal(t, []string{"A1", "A3"}, ids(d.Alerts(AlertFilter{ActiveWithin: 24 * time.Hour})))
+		assert.Equal(t, []string{"A1", "A3", "A4"}, ids(d.Alerts(AlertFilter{ActiveWithin: 36 * time.Hour})), "expected an alert starting within the interval")
+	})
+
+	t.Run("combines filters", func(t *testing.T) {
+		d := testDataset()
+		filter := AlertFilter{
+			OperatorID: "7778019",
+			StopID:     "8220DB000334",
+			ActiveAt:   at("2025-01-21T12:00:00Z"),
+		}
+		assert.Equal(t, []string{"A3"}, ids(d.Alerts(filter)))
+	})
+}
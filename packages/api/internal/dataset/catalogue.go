@@ -0,0 +1,164 @@
+package dataset
+
+import (
+	"sort"
+
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+// StopFilter leaves out stops outside BBox, an empty filter leaves out nothing
+type StopFilter struct {
+	BBox *BBox
+}
+
+// RouteFilter leaves out routes not of the given operator or mode, empty
+// fields are not filtered on
+type RouteFilter struct {
+	OperatorID string
+	Mode       transit.Mode
+}
+
+func (f RouteFilter) matches(r transit.Route) bool {
+	if f.OperatorID != "" && r.Operator != f.OperatorID {
+		return false
+	}
+
+	return f.Mode == "" || r.Mode == f.Mode
+}
+
+// StopDetail is a stop along with its platforms and the routes calling at it
+// or at any of its platforms
+type StopDetail struct {
+	Stop      transit.Stop    `json:"stop"`
+	Platforms []transit.Stop  `json:"platforms"`
+	Routes    []transit.Route `json:"routes"`
+}
+
+// RouteDetail is a route along with the shapes its trips follow and the
+// orders of stops they call at
+type RouteDetail struct {
+	Route    transit.Route         `json:"route"`
+	Shapes   []transit.Shape       `json:"shapes"`
+	Patterns []transit.StopPattern `json:"patterns"`
+}
+
+// Stops returns every stop of the static timetable matching the filter
+// ordered by id
+func Stops(sched *schedule.Schedule, filter StopFilter) []transit.Stop {
+	stops := []transit.Stop{}
+	for id, s := range sched.Stops {
+		if filter.BBox != nil && !filter.BBox.Contains(s.Latitude, s.Longitude) {
+			continue
+		}
+		stop, _ := sched.Stop(id)
+		stops = append(stops, stop)
+	}
+	sort.Slice(stops, func(i, j int) bool { return stops[i].ID < stops[j].ID })
+
+	return stops
+}
+
+// platforms returns the stops within a station ordered by id
+func platforms(sched *schedule.Schedule, stopID string) []transit.Stop {
+	platforms := []transit.Stop{}
+	for _, child := range sched.ChildStops(stopID) {
+		stop, _ := sched.Stop(child.ID)
+		platforms = append(platforms, stop)
+	}
+
+	return platforms
+}
+
+// stopRoutes returns the routes calling at any of the stops ordered by id
+func stopRoutes(sched *schedule.Schedule, stopIDs ...string) []transit.Route {
+	seen := map[string]bool{}
+	routes := []transit.Route{}
+	for _, stopID := range stopIDs {
+		for _, st := range sched.StopTimesForStop(stopID) {
+			trip, ok := sched.Trips[st.TripID]
+			if !ok || seen[trip.RouteID] {
+				continue
+			}
+			seen[trip.RouteID] = true
+
+			if r, ok := sched.Route(trip.RouteID); ok {
+				routes = append(routes, r)
+			}
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].ID < routes[j].ID })
+
+	return routes
+}
+
+// Stop returns a stop of the static timetable with its platforms and routes
+func Stop(sched *schedule.Schedule, id string) (StopDetail, bool) {
+	stop, ok := sched.Stop(id)
+	if !ok {
+		return StopDetail{}, false
+	}
+
+	detail := StopDetail{Stop: stop, Platforms: platforms(sched, id)}
+	stopIDs := []string{id}
+	for _, p := range detail.Platforms {
+		stopIDs = append(stopIDs, p.ID)
+	}
+	detail.Routes = stopRoutes(sched, stopIDs...)
+
+	return detail, true
+}
+
+// Routes returns every route of the static timetable matching the filter
+// ordered by id
+func Routes(sched *schedule.Schedule, filter RouteFilter) []transit.Route {
+	routes := []transit.Route{}
+	for id := range sched.Routes {
+		if r, _ := sched.Route(id); filter.matches(r) {
+			routes = append(routes, r)
+		}
+	}
+	sort.Slice(routes, func(i, j int) bool { return routes[i].ID < routes[j].ID })
+
+	return routes
+}
+
+// Route returns a route of the static timetable with its shapes and stop
+// patterns, the shapes are in the order the patterns first use them
+func Route(sched *schedule.Schedule, id string) (RouteDetail, bool) {
+	route, ok := sched.Route(id)
+	if !ok {
+		return RouteDetail{}, false
+	}
+
+	detail := RouteDetail{Route: route, Shapes: []transit.Shape{}, Patterns: sched.StopPatterns(id)}
+	if detail.Patterns == nil {
+		detail.Patterns = []transit.StopPattern{}
+	}
+
+	seen := map[string]bool{}
+	for _, p := range detail.Patterns {
+		if p.ShapeID == "" || seen[p.ShapeID] {
+			continue
+		}
+		seen[p.ShapeID] = true
+
+		if shape, ok := sched.Shape(p.ShapeID); ok {
+			detail.Shapes = append(detail.Shapes, shape)
+		}
+	}
+
+	return detail, true
+}
+
+// Operators returns every operator of the static timetable ordered by id
+func Operators(sched *schedule.Schedule) []transit.Operator {
+	operators := []transit.Operator{}
+	for id := range sched.Agencies {
+		o, _ := sched.Operator(id)
+		operators = append(operators, o)
+	}
+	sort.Slice(operators, func(i, j int) bool { return operators[i].ID < operators[j].ID })
+
+	return operators
+}
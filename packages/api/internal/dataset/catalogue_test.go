@@ -0,0 +1,116 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+func stopIDs(stops []transit.Stop) []string {
+	ids := []string{}
+	for _, s := range stops {
+		ids = append(ids, s.ID)
+	}
+
+	return ids
+}
+
+func routeIDs(routes []transit.Route) []string {
+	ids := []string{}
+	for _, r := range routes {
+		ids = append(ids, r.ID)
+	}
+
+	return ids
+}
+
+func TestStops(t *testing.T) {
+	sched := testSchedule(t)
+
+	t.Run("returns every stop ordered by id", func(t *testing.T) {
+		assert.Equal(t, []string{
+			"8220DB000334", "8220DB000335", "8220DB000336", "8220IR0132", "8220IR0133", "8250DB002002",
+		}, stopIDs(Stops(sched, StopFilter{})))
+	})
+
+	t.Run("filters on bounding box", func(t *testing.T) {
+		southside := BBox{MinLon: -6.2, MinLat: 53.2, MaxLon: -6.1, MaxLat: 53.3}
+		assert.Equal(t, []string{"8250DB002002"}, stopIDs(Stops(sched, StopFilter{BBox: &southside})))
+	})
+}
+
+func TestStop(t *testing.T) {
+	sched := testSchedule(t)
+
+	t.Run("returns a stop with the routes calling at it", func(t *testing.T) {
+		detail, ok := Stop(sched, "8220DB000334")
+		assert.True(t, ok, "expected stop to be found")
+		assert.Equal(t, "Parnell Square West, stop 2", detail.Stop.Name)
+		assert.Equal(t, []transit.Stop{}, detail.Platforms)
+		assert.Equal(t, []string{"3249_46342", "3249_46350"}, routeIDs(detail.Routes))
+	})
+
+	t.Run("returns the platforms of a station", func(t *testing.T) {
+		detail, ok := Stop(sched, "8220IR0132")
+		assert.True(t, ok, "expected station to be found")
+		assert.Equal(t, []string{"8220IR0133"}, stopIDs(detail.Platforms))
+		assert.Equal(t, []transit.Route{}, detail.Routes)
+	})
+
+	t.Run("is not found for an unknown stop", func(t *testing.T) {
+		_, ok := Stop(sched, "missing")
+		assert.False(t, ok, "expected stop not to be found")
+	})
+}
+
+func TestRoutes(t *testing.T) {
+	sched := testSchedule(t)
+
+	t.Run("returns every route ordered by id", func(t *testing.T) {
+		assert.Equal(t, []string{"3249_46342", "3249_46350", "3264_46711"}, routeIDs(Routes(sched, RouteFilter{})))
+	})
+
+	t.Run("filters on operator and mode", func(t *testing.T) {
+		assert.Equal(t, []string{"3264_46711"}, routeIDs(Routes(sched, RouteFilter{OperatorID: "7778020"})))
+		assert.Equal(t, []string{}, routeIDs(Routes(sched, RouteFilter{Mode: transit.ModeRail})))
+	})
+}
+
+func TestRoute(t *testing.T) {
+	sched := testSchedule(t)
+
+	t.Run("returns a route with its shapes and stop patterns", func(t *testing.T) {
+		detail, ok := Route(sched, "3249_46342")
+		assert.True(t, ok, "expected route to be found")
+		assert.Equal(t, "46A", detail.Route.ShortName)
+		if assert.Len(t, detail.Shapes, 1, "expected the shape shared by both patterns once") {
+			assert.Equal(t, "3249_46342_1", detail.Shapes[0].ID)
+		}
+		assert.Len(t, detail.Patterns, 2)
+	})
+
+	t.Run("returns empty shapes for a route without any", func(t *testing.T) {
+		detail, ok := Route(sched, "3249_46350")
+		assert.True(t, ok, "expected route to be found")
+		assert.Equal(t, []transit.Shape{}, detail.Shapes)
+	})
+
+	t.Run("returns empty patterns for a route without trips", func(t *testing.T) {
+		detail, ok := Route(irelandSchedule(), "3249_46341")
+		assert.True(t, ok, "expected route to be found")
+		assert.Equal(t, []transit.StopPattern{}, detail.Patterns)
+	})
+
+	t.Run("is not found for an unknown route", func(t *testing.T) {
+		_, ok := Route(sched, "missing")
+		assert.False(t, ok, "expected route not to be found")
+	})
+}
+
+func TestOperators(t *testing.T) {
+	operators := Operators(testSchedule(t))
+	assert.Len(t, operators, 2)
+	assert.Equal(t, "7778019", operators[0].ID)
+	assert.Equal(t, "7778020", operators[1].ID)
+}
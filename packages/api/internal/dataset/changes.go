@@ -0,0 +1,163 @@
+package dataset
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+// historySize is how many vehicle deltas are kept for streams to resume from
+const historySize = 256
+
+// VehicleRef identifies a vehicle, as two sources may use the same id
+type VehicleRef struct {
+	Source string `json:"source" example:"gtfsr"`
+	ID     string `json:"id" example:"V1"`
+}
+
+// RefOf returns the ref of a vehicle
+func RefOf(v transit.Vehicle) VehicleRef {
+	return VehicleRef{Source: v.Source, ID: v.ID}
+}
+
+func (r VehicleRef) less(o VehicleRef) bool {
+	if r.Source != o.Source {
+		return r.Source < o.Source
+	}
+
+	return r.ID < o.ID
+}
+
+// VehicleDelta is what changed about the vehicles from the version before it,
+// the vehicles which were added or moved and the refs of the ones which left
+type VehicleDelta struct {
+	Version uint64            `json:"version" example:"42"`
+	Updated []transit.Vehicle `json:"updated"`
+	Removed []VehicleRef      `json:"removed"`
+}
+
+// VehicleSnapshot is every vehicle at a version
+type VehicleSnapshot struct {
+	Version  uint64            `json:"version" example:"42"`
+	Vehicles []transit.Vehicle `json:"vehicles"`
+}
+
+// Filter returns the delta as seen by a client of the filter which holds the
+// known vehicles, and adds and removes vehicles from known to match. A vehicle
+// which no longer matches is removed when it is known. A nil known is for a
+// client which could hold any vehicle, every removal is reported to it
+func (v VehicleDelta) Filter(filter VehicleFilter, known map[VehicleRef]bool) VehicleDelta {
+	filtered := VehicleDelta{Version: v.Version, Updated: []transit.Vehicle{}, Removed: []VehicleRef{}}
+	remove := func(ref VehicleRef) {
+		if known == nil || known[ref] {
+			filtered.Removed = append(filtered.Removed, ref)
+			delete(known, ref)
+		}
+	}
+
+	for _, vehicle := range v.Updated {
+		if filter.matches(vehicle) {
+			filtered.Updated = append(filtered.Updated, vehicle)
+			if known != nil {
+				known[RefOf(vehicle)] = true
+			}
+		} else {
+			remove(RefOf(vehicle))
+		}
+	}
+	for _, ref := range v.Removed {
+		remove(ref)
+	}
+	sort.Slice(filtered.Removed, func(i, j int) bool { return filtered.Removed[i].less(filtered.Removed[j]) })
+
+	return filtered
+}
+
+// diffVehicles returns the vehicles which are new or changed in after, ordered
+// by source and id, and the refs of the ones which are gone
+func diffVehicles(before, after []transit.Vehicle) VehicleDelta {
+	delta := VehicleDelta{Updated: []transit.Vehicle{}, Removed: []VehicleRef{}}
+	previous := make(map[VehicleRef]transit.Vehicle, len(before))
+	for _, v := range before {
+		previous[RefOf(v)] = v
+	}
+
+	for _, v := range after {
+		if p, ok := previous[RefOf(v)]; !ok || !reflect.DeepEqual(p, v) {
+			delta.Updated = append(delta.Updated, v)
+		}
+		delete(previous, RefOf(v))
+	}
+	for ref := range previous {
+		delta.Removed = append(delta.Removed, ref)
+	}
+	sort.Slice(delta.Updated, func(i, j int) bool { return RefOf(delta.Updated[i]).less(RefOf(delta.Updated[j])) })
+	sort.Slice(delta.Removed, func(i, j int) bool { return delta.Removed[i].less(delta.Removed[j]) })
+
+	return delta
+}
+
+// recordVehicles keeps what changed about the vehicles, the lock must be held
+func (d *Dataset) recordVehicles(vehicles []transit.Vehicle) {
+	delta := diffVehicles(d.vehicles, vehicles)
+	if len(delta.Updated) == 0 && len(delta.Removed) == 0 {
+		return
+	}
+
+	d.version++
+	delta.Version = d.version
+	d.history = append(d.history, delta)
+	if len(d.history) > historySize {
+		d.history = append([]VehicleDelta{}, d.history[len(d.history)-historySize:]...)
+	}
+}
+
+// notify wakes up anything waiting on Changed, the lock must be held
+func (d *Dataset) notify() {
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+// Changed returns a channel which is closed the next time the dataset is set
+func (d *Dataset) Changed() <-chan struct{} {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.changed
+}
+
+// VehicleSnapshot returns the vehicles matching the filter with the version
+// they are at
+func (d *Dataset) VehicleSnapshot(filter VehicleFilter) VehicleSnapshot {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	snapshot := VehicleSnapshot{Version: d.version, Vehicles: []transit.Vehicle{}}
+	for _, v := range d.vehicles {
+		if filter.matches(v) {
+			snapshot.Vehicles = append(snapshot.Vehicles, v)
+		}
+	}
+
+	return snapshot
+}
+
+// VehicleChanges returns the deltas after a version in order. It is not ok
+// when the version is unknown or too old to still have every delta since
+func (d *Dataset) VehicleChanges(since uint64) ([]VehicleDelta, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if since > d.version {
+		return nil, false
+	}
+	if since == d.version {
+		return nil, true
+	}
+	if len(d.history) == 0 || d.history[0].Version > since+1 {
+		return nil, false
+	}
+
+	return append([]VehicleDelta{}, d.history[since+1-d.history[0].Version:]...), true
+}
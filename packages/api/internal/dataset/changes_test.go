@@ -0,0 +1,136 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehicleChanges(t *testing.T) {
+	moved := testVehicles[0]
+	moved.Latitude = 53.35
+
+	t.Run("records a delta when the vehicles change", func(t *testing.T) {
+		d := New()
+		d.Set(transit.Dataset{Vehicles: testVehicles})
+		d.Set(transit.Dataset{Vehicles: []transit.Vehicle{moved, testVehicles[1]}})
+
+		deltas, ok := d.VehicleChanges(1)
+		assert.True(t, ok, "expected the changes since version 1 to be known")
+		assert.Equal(t, []VehicleDelta{{
+			Version: 2,
+			Updated: []transit.Vehicle{moved},
+			Removed: []VehicleRef{{ID: "E109"}},
+		}}, deltas)
+		assert.Equal(t, uint64(2), d.VehicleSnapshot(VehicleFilter{}).Version)
+	})
+
+	t.Run("tells apart the vehicles of two sources sharing an id", func(t *testing.T) {
+		gtfsr := transit.Vehicle{ID: "V1", Source: "gtfsr", Mode: transit.ModeBus, Latitude: 53.3498}
+		siri := transit.Vehicle{ID: "V1", Source: "siri", Mode: transit.ModeBus, Latitude: 53.2707}
+		d := New()
+		d.Set(transit.Dataset{Vehicles: []transit.Vehicle{gtfsr, siri}})
+		d.Set(transit.Dataset{Vehicles: []transit.Vehicle{siri, gtfsr}})
+		d.Set(transit.Dataset{Vehicles: []transit.Vehicle{siri}})
+
+		deltas, ok := d.VehicleChanges(0)
+		assert.True(t, ok)
+		assert.Equal(t, []VehicleDelta{
+			{Version: 1, Updated: []transit.Vehicle{gtfsr, siri}, Removed: []VehicleRef{}},
+			{Version: 2, Updated: []transit.Vehicle{}, Removed: []VehicleRef{{Source: "gtfsr", ID: "V1"}}},
+		}, deltas, "expected neither vehicle to change when reordered and only the one which left to be removed")
+	})
+
+	t.Run("orders the removed vehicles by source and id", func(t *testing.T) {
+		d := New()
+		d.Set(transit.Dataset{Vehicles: []transit.Vehicle{{ID: "V2", Source: "gtfsr"}, {ID: "V1", Source: "siri"}, {ID: "V1", Source: "gtfsr"}}})
+		d.Set(transit.Dataset{})
+
+		deltas, ok := d.VehicleChanges(1)
+		assert.True(t, ok)
+		if assert.Len(t, deltas, 1) {
+			assert.Equal(t, []VehicleRef{{Source: "gtfsr", ID: "V1"}, {Source: "gtfsr", ID: "V2"}, {Source: "siri", ID: "V1"}}, deltas[0].Removed)
+		}
+	})
+
+	t.Run("does not record a delta when nothing changed", func(t *testing.T) {
+		d := New()
+		d.Set(transit.Dataset{Vehicles: testVehicles})
+		d.Set(transit.Dataset{Vehicles: testVehicles, Alerts: []transit.Alert{{ID: "A1"}}})
+		assert.Equal(t, uint64(1), d.VehicleSnapshot(VehicleFilter{}).Version)
+	})
+
+	t.Run("returns nothing for the latest version", func(t *testing.T) {
+		d := New()
+		d.Set(transit.Dataset{Vehicles: testVehicles})
+		deltas, ok := d.VehicleChanges(1)
+		assert.True(t, ok)
+		assert.Empty(t, deltas)
+	})
+
+	t.Run("is not ok for a version it does not have every change since", func(t *testing.T) {
+		d := New()
+		for i := 0; i < historySize+2; i++ {
+			v := moved
+			v.Latitude += float64(i)
+			d.Set(transit.Dataset{Vehicles: []transit.Vehicle{v}})
+		}
+
+		_, ok := d.VehicleChanges(1)
+		assert.False(t, ok, "expected changes dropped from the history to be unknown")
+		_, ok = d.VehicleChanges(100000)
+		assert.False(t, ok, "expected a future version to be unknown")
+		deltas, ok := d.VehicleChanges(historySize)
+		assert.True(t, ok)
+		assert.Len(t, deltas, 2)
+	})
+
+	t.Run("closes changed when the dataset is set", func(t *testing.T) {
+		d := New()
+		changed := d.Changed()
+		d.Set(transit.Dataset{Vehicles: testVehicles})
+		select {
+		case <-changed:
+		default:
+			assert.Fail(t, "expected changed to be closed")
+		}
+		assert.NotEqual(t, changed, d.Changed(), "expected a new channel for the next change")
+	})
+}
+
+func TestVehicleDeltaFilter(t *testing.T) {
+	delta := VehicleDelta{Version: 3, Updated: testVehicles, Removed: []VehicleRef{{ID: "V8"}, {ID: "V9"}}}
+	rail := VehicleFilter{Mode: transit.ModeRail}
+
+	t.Run("removes known vehicles which no longer match", func(t *testing.T) {
+		known := map[VehicleRef]bool{{ID: "V1"}: true, {ID: "V9"}: true}
+		assert.Equal(t, VehicleDelta{
+			Version: 3,
+			Updated: []transit.Vehicle{testVehicles[2]},
+			Removed: []VehicleRef{{ID: "V1"}, {ID: "V9"}},
+		}, delta.Filter(rail, known))
+		assert.Equal(t, map[VehicleRef]bool{{ID: "E109"}: true}, known)
+	})
+
+	t.Run("only removes the known vehicle of the source which left", func(t *testing.T) {
+		delta := VehicleDelta{Version: 4, Updated: []transit.Vehicle{}, Removed: []VehicleRef{{Source: "siri", ID: "V1"}}}
+		known := map[VehicleRef]bool{{Source: "gtfsr", ID: "V1"}: true}
+		assert.Equal(t, VehicleDelta{Version: 4, Updated: []transit.Vehicle{}, Removed: []VehicleRef{}}, delta.Filter(VehicleFilter{}, known))
+		assert.Equal(t, map[VehicleRef]bool{{Source: "gtfsr", ID: "V1"}: true}, known)
+	})
+
+	t.Run("removes every vehicle which does not match without known vehicles", func(t *testing.T) {
+		assert.Equal(t, VehicleDelta{
+			Version: 3,
+			Updated: []transit.Vehicle{testVehicles[2]},
+			Removed: []VehicleRef{{ID: "V1"}, {ID: "V2"}, {ID: "V8"}, {ID: "V9"}},
+		}, delta.Filter(rail, nil))
+	})
+}
+
+func TestVehicleSnapshot(t *testing.T) {
+	d := New()
+	d.Set(transit.Dataset{Vehicles: testVehicles})
+	assert.Equal(t, VehicleSnapshot{Version: 1, Vehicles: []transit.Vehicle{testVehicles[2]}}, d.VehicleSnapshot(VehicleFilter{OperatorID: "irishrail"}))
+}
@@ -0,0 +1,67 @@
+package dataset
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+// Dataset holds the realtime data served by the API
+type Dataset struct {
+	mu           sync.RWMutex
+	vehicles     []transit.Vehicle
+	departures   []transit.Departure
+	alerts       []transit.Alert
+	lineStatuses []transit.LineStatus
+	byStop       map[string][]transit.Departure
+	byTrip       map[string][]transit.Departure
+	now          func() time.Time
+
+	// version counts the changes to the vehicles and history has the latest
+	// of them, changed is closed the next time anything is set
+	version uint64
+	history []VehicleDelta
+	changed chan struct{}
+}
+
+func New() *Dataset {
+	return &Dataset{
+		vehicles:     []transit.Vehicle{},
+		departures:   []transit.Departure{},
+		alerts:       []transit.Alert{},
+		lineStatuses: []transit.LineStatus{},
+		byStop:       map[string][]transit.Departure{},
+		byTrip:       map[string][]transit.Departure{},
+		now:          time.Now,
+		changed:      make(chan struct{}),
+	}
+}
+
+// Set replaces everything held with what the aggregator handed off
+func (d *Dataset) Set(data transit.Dataset) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.recordVehicles(data.Vehicles)
+	d.vehicles = data.Vehicles
+	d.departures = data.Departures
+	d.alerts = data.Alerts
+	d.lineStatuses = data.LineStatuses
+	d.index()
+	d.notify()
+}
+
+// LineStatuses returns the latest status of every line which reports one
+func (d *Dataset) LineStatuses() []transit.LineStatus {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return append([]transit.LineStatus{}, d.lineStatuses...)
+}
+
+func (d *Dataset) SetAlerts(alerts []transit.Alert) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.alerts = alerts
+	d.notify()
+}
@@ -0,0 +1,43 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("starts empty", func(t *testing.T) {
+		d := New()
+		assert.Empty(t, d.Alerts(AlertFilter{}), "expected no alerts")
+	})
+}
+
+func TestSetAlerts(t *testing.T) {
+	t.Run("replaces every alert", func(t *testing.T) {
+		d := New()
+		d.SetAlerts([]transit.Alert{{ID: "A1"}, {ID: "A2"}})
+		d.SetAlerts([]transit.Alert{{ID: "A3"}})
+		assert.Equal(t, []transit.Alert{{ID: "A3"}}, d.Alerts(AlertFilter{}), "expected alerts to be replaced")
+	})
+}
+
+func TestSet(t *testing.T) {
+	t.Run("replaces everything", func(t *testing.T) {
+		d := New()
+		d.SetAlerts([]transit.Alert{{ID: "A1"}})
+		d.Set(transit.Dataset{
+			Vehicles:     []transit.Vehicle{{ID: "V1"}},
+			Departures:   []transit.Departure{{StopID: "RAN"}},
+			Alerts:       []transit.Alert{{ID: "A2"}},
+			LineStatuses: []transit.LineStatus{{RouteID: "red"}},
+		})
+
+		assert.Equal(t, []transit.Alert{{ID: "A2"}}, d.Alerts(AlertFilter{}), "expected alerts to be replaced")
+		assert.Equal(t, []transit.Vehicle{{ID: "V1"}}, d.vehicles)
+		assert.Equal(t, []transit.Departure{{StopID: "RAN"}}, d.departures)
+		assert.Equal(t, []transit.LineStatus{{RouteID: "red"}}, d.lineStatuses)
+		assert.Equal(t, []transit.LineStatus{{RouteID: "red"}}, d.LineStatuses())
+	})
+}
@@ -0,0 +1,300 @@
+package dataset
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+// maxDelay is how late a scheduled departure can run and still be predicted
+// to depart after now
+const maxDelay = time.Hour
+
+// DepartureFilter limits a departure board to the first Limit departures
+// within TimeRange of now, a zero Limit does not limit it
+type DepartureFilter struct {
+	TimeRange time.Duration
+	Limit     int
+}
+
+// bySequence orders the departures of a trip by stop sequence, or by time when
+// the source has no stop sequences
+func bySequence(departures []transit.Departure) {
+	sort.SliceStable(departures, func(i, j int) bool {
+		a, b := departures[i], departures[j]
+		if a.StopSequence != 0 && b.StopSequence != 0 {
+			return a.StopSequence < b.StopSequence
+		}
+
+		return a.Time().Before(b.Time())
+	})
+}
+
+// index groups the realtime departures by stop and by trip
+func (d *Dataset) index() {
+	d.byStop = map[string][]transit.Departure{}
+	d.byTrip = map[string][]transit.Departure{}
+	for _, dep := range d.departures {
+		d.byStop[dep.StopID] = append(d.byStop[dep.StopID], dep)
+		if dep.TripID != "" {
+			d.byTrip[dep.TripID] = append(d.byTrip[dep.TripID], dep)
+		}
+	}
+
+	for _, departures := range d.byTrip {
+		bySequence(departures)
+	}
+}
+
+// boardStops returns the stop and the platforms within it, and whether the
+// stop is known at all
+func (d *Dataset) boardStops(stopID string, sched *schedule.Schedule) ([]string, bool) {
+	stops := []string{stopID}
+	if sched == nil {
+		return stops, true
+	}
+
+	for _, child := range sched.ChildStops(stopID) {
+		stops = append(stops, child.ID)
+	}
+
+	if _, ok := sched.Stops[stopID]; ok {
+		return stops, true
+	}
+	_, ok := d.byStop[stopID]
+
+	return stops, ok
+}
+
+// stopRealtime returns the realtime departures from a stop of the static
+// timetable with their stop and trip mapped to GTFS ids, including those of
+// sources which refer to the stop by its code such as Irish Rail and Luas
+func (d *Dataset) stopRealtime(ids gtfsIDs, stopID string) []transit.Departure {
+	keys := []string{stopID}
+	if stop, ok := ids.sched.Stops[stopID]; ok && stop.Code != "" && stop.Code != stopID {
+		keys = append(keys, stop.Code)
+	}
+
+	realtime := []transit.Departure{}
+	for _, key := range keys {
+		for _, rt := range d.byStop[key] {
+			if ids.stop(rt.StopID) != stopID {
+				continue
+			}
+			rt.StopID = stopID
+			if trip := ids.trip(rt.Operator, rt.TripID, rt.RouteID); trip != nil {
+				rt.TripID = trip.GetTripId()
+			}
+			realtime = append(realtime, rt)
+		}
+	}
+
+	return realtime
+}
+
+// sameCall reports whether a realtime departure is the call a scheduled one is
+// for, sources without stop sequences match on the stop alone
+func sameCall(scheduled, rt transit.Departure) bool {
+	return scheduled.StopID == rt.StopID &&
+		(rt.StopSequence == 0 || rt.StopSequence == scheduled.StopSequence)
+}
+
+// realtimeDelay returns the delay of a realtime departure, working it out from
+// its expected and scheduled times when the source did not give one
+func realtimeDelay(rt transit.Departure) *time.Duration {
+	if rt.Delay != nil {
+		return rt.Delay
+	}
+
+	for _, times := range [][2]time.Time{
+		{rt.ExpectedDeparture, rt.ScheduledDeparture},
+		{rt.ExpectedArrival, rt.ScheduledArrival},
+	} {
+		if !times[0].IsZero() && !times[1].IsZero() {
+			delay := times[0].Sub(times[1])
+
+			return &delay
+		}
+	}
+
+	return nil
+}
+
+// merge fills a scheduled departure with the realtime departure for the same
+// call. The timetable keeps the scheduled times, the expected times are taken
+// from the realtime departure or from its delay when it only has that
+func merge(scheduled, rt transit.Departure) transit.Departure {
+	merged := scheduled
+	merged.Source = rt.Source
+	merged.Status = rt.Status
+	merged.Realtime = true
+	merged.ExpectedArrival = rt.ExpectedArrival
+	merged.ExpectedDeparture = rt.ExpectedDeparture
+	merged.Delay = realtimeDelay(rt)
+	if merged.Delay == nil && !merged.ExpectedDeparture.IsZero() {
+		delay := merged.ExpectedDeparture.Sub(merged.ScheduledDeparture)
+		merged.Delay = &delay
+	}
+	if merged.Delay != nil {
+		if merged.ExpectedArrival.IsZero() {
+			merged.ExpectedArrival = merged.ScheduledArrival.Add(*merged.Delay)
+		}
+		if merged.ExpectedDeparture.IsZero() {
+			merged.ExpectedDeparture = merged.ScheduledDeparture.Add(*merged.Delay)
+		}
+	}
+
+	for _, field := range []struct{ to, from *string }{
+		{&merged.VehicleID, &rt.VehicleID},
+		{&merged.Direction, &rt.Direction},
+		{&merged.Platform, &rt.Platform},
+	} {
+		if *field.to == "" {
+			*field.to = *field.from
+		}
+	}
+
+	return merged
+}
+
+// propagate predicts a scheduled departure from the last update of its trip
+// at an earlier stop. A delay carries on to later stops and so does a
+// cancelled trip, whereas skipped stops and stops without data do not
+func propagate(scheduled transit.Departure, trip []transit.Departure) transit.Departure {
+	var last *transit.Departure
+	for i, rt := range trip {
+		if rt.StopSequence == 0 || rt.StopSequence >= scheduled.StopSequence {
+			break
+		}
+		last = &trip[i]
+	}
+	if last == nil {
+		return scheduled
+	}
+
+	switch last.Status {
+	case transit.DepartureCancelled:
+		scheduled.Source = last.Source
+		scheduled.Status = transit.DepartureCancelled
+		scheduled.Realtime = true
+	case transit.DepartureScheduled:
+		if delay := realtimeDelay(*last); delay != nil {
+			scheduled.Source = last.Source
+			scheduled.Realtime = true
+			scheduled = delayed([]transit.Departure{scheduled}, delay)[0]
+		}
+	}
+	if scheduled.VehicleID == "" {
+		scheduled.VehicleID = last.VehicleID
+	}
+
+	return scheduled
+}
+
+// scheduledStop returns every scheduled departure from a stop on the service
+// days which could have a departure between from and until
+func scheduledStop(sched *schedule.Schedule, stopID string, from, until time.Time) []transit.Departure {
+	first := from.In(dublin).AddDate(0, 0, -1)
+	last := until.In(dublin)
+
+	departures := []transit.Departure{}
+	for _, st := range sched.StopTimesForStop(stopID) {
+		trip, ok := sched.Trips[st.TripID]
+		if !ok {
+			continue
+		}
+
+		for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+			if !sched.ServiceRunsOn(trip.ServiceID, day) {
+				continue
+			}
+
+			dep := scheduledDeparture(sched, trip, st, day)
+			if t := dep.Time(); !t.Before(from) && !t.After(until) {
+				departures = append(departures, dep)
+			}
+		}
+	}
+
+	return departures
+}
+
+// StopDepartures returns the departure board of a stop and its platforms. The
+// scheduled departures of the static timetable are merged with the realtime
+// departures of their trips, whose stops and trips are first mapped to GTFS
+// ids for sources such as Irish Rail and Luas which have their own. A
+// departure with no realtime update of its own takes on the delay or
+// cancellation of an earlier stop of its trip. Realtime departures not in the
+// timetable are added as they are. It is not found when a static timetable is
+// given and neither it nor the realtime departures have the stop
+func (d *Dataset) StopDepartures(stopID string, sched *schedule.Schedule, filter DepartureFilter) ([]transit.Departure, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	now := d.now()
+	until := now.Add(filter.TimeRange)
+	ids := gtfsIDs{sched: sched, now: now}
+	stopID = ids.stop(stopID)
+
+	stops, ok := d.boardStops(stopID, sched)
+	if !ok {
+		return nil, false
+	}
+
+	board := []transit.Departure{}
+	for _, id := range stops {
+		matched := map[int]bool{}
+		realtime := d.byStop[id]
+
+		if sched != nil {
+			realtime = d.stopRealtime(ids, id)
+			for _, dep := range scheduledStop(sched, id, now.Add(-maxDelay), until) {
+				i := -1
+				for j, rt := range realtime {
+					if !matched[j] && rt.TripID == dep.TripID && sameCall(dep, rt) {
+						i = j
+
+						break
+					}
+				}
+
+				if i >= 0 {
+					matched[i] = true
+					dep = merge(dep, realtime[i])
+				} else {
+					dep = propagate(dep, d.byTrip[dep.TripID])
+				}
+				board = append(board, dep)
+			}
+		}
+
+		for j, rt := range realtime {
+			if !matched[j] {
+				board = append(board, rt)
+			}
+		}
+	}
+
+	departures := []transit.Departure{}
+	for _, dep := range board {
+		if t := dep.Time(); !t.Before(now) && !t.After(until) {
+			departures = append(departures, dep)
+		}
+	}
+	sort.SliceStable(departures, func(i, j int) bool {
+		a, b := departures[i].Time(), departures[j].Time()
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+
+		return departures[i].TripID < departures[j].TripID
+	})
+
+	if filter.Limit > 0 && len(departures) > filter.Limit {
+		departures = departures[:filter.Limit]
+	}
+
+	return departures, true
+}
@@ -0,0 +1,314 @@
+package dataset
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+// board summarises departures as trip, stop, time, status and whether it is
+// realtime
+func board(departures []transit.Departure) []string {
+	summary := []string{}
+	for _, d := range departures {
+		summary = append(summary, fmt.Sprintf("%s %s %s %s %t", d.TripID, d.StopID, d.Time().UTC().Format(time.RFC3339), d.Status, d.Realtime))
+	}
+
+	return summary
+}
+
+func TestStopDepartures(t *testing.T) {
+	sched := testSchedule(t)
+	hour := DepartureFilter{TimeRange: time.Hour}
+	delay := func(d time.Duration) *time.Duration { return &d }
+	departuresAt := func(now string, departures ...transit.Departure) *Dataset {
+		d := New()
+		d.now = func() time.Time { return *at(now) }
+		d.Set(transit.Dataset{Departures: departures})
+
+		return d
+	}
+
+	t.Run("marks timetable departures without realtime data as scheduled", func(t *testing.T) {
+		departures, ok := departuresAt("2025-01-21T10:30:00Z").StopDepartures("8220DB000334", sched, hour)
+		assert.True(t, ok, "expected stop to be found")
+		assert.Equal(t, []string{
+			"3249_10466 8220DB000334 2025-01-21T10:35:30Z scheduled false",
+			"3249_10511 8220DB000334 2025-01-21T10:40:00Z scheduled false",
+		}, board(departures))
+	})
+
+	t.Run("merges realtime departures with the timetable", func(t *testing.T) {
+		d := departuresAt("2025-01-21T10:30:00Z", transit.Departure{
+			StopID:       "8220DB000334",
+			Source:       "gtfsr",
+			TripID:       "3249_10466",
+			VehicleID:    "V1",
+			StopSequence: 12,
+			Delay:        delay(2 * time.Minute),
+			Status:       transit.DepartureScheduled,
+			Realtime:     true,
+		})
+		departures, _ := d.StopDepartures("8220DB000334", sched, hour)
+		assert.Equal(t, []string{
+			"3249_10466 8220DB000334 2025-01-21T10:37:30Z scheduled true",
+			"3249_10511 8220DB000334 2025-01-21T10:40:00Z scheduled false",
+		}, board(departures))
+		assert.Equal(t, transit.Departure{
+			StopID:             "8220DB000334",
+			Source:             "gtfsr",
+			Operator:           "7778019",
+			Mode:               transit.ModeBus,
+			RouteID:            "3249_46342",
+			TripID:             "3249_10466",
+			VehicleID:          "V1",
+			Headsign:           "Dún Laoghaire",
+			StopSequence:       12,
+			ScheduledArrival:   *at("2025-01-21T10:35:00Z"),
+			ScheduledDeparture: *at("2025-01-21T10:35:30Z"),
+			ExpectedArrival:    *at("2025-01-21T10:37:00Z"),
+			ExpectedDeparture:  *at("2025-01-21T10:37:30Z"),
+			Delay:              delay(2 * time.Minute),
+			Status:             transit.DepartureScheduled,
+			Realtime:           true,
+		}, withUTC(departures[0]))
+	})
+
+	t.Run("works out the delay from the expected time", func(t *testing.T) {
+		d := departuresAt("2025-01-21T10:30:00Z", transit.Departure{
+			StopID:            "8220DB000334",
+			Source:            "gtfsr",
+			TripID:            "3249_10511",
+			ExpectedDeparture: *at("2025-01-21T10:39:00Z"),
+			Status:            transit.DepartureScheduled,
+			Realtime:          true,
+		})
+		departures, _ := d.StopDepartures("8220DB000334", sched, hour)
+		if assert.Len(t, departures, 2) {
+			assert.Equal(t, delay(-time.Minute), departures[1].Delay)
+		}
+	})
+
+	t.Run("carries the delay of an earlier stop on", func(t *testing.T) {
+		d := departuresAt("2025-01-21T10:40:00Z", transit.Departure{
+			StopID:       "8220DB000335",
+			Source:       "gtfsr",
+			TripID:       "3249_10466",
+			StopSequence: 13,
+			Delay:        delay(3 * time.Minute),
+			Status:       transit.DepartureScheduled,
+			Realtime:     true,
+		})
+		departures, _ := d.StopDepartures("8220DB000336", sched, hour)
+		assert.Equal(t, []string{
+			"3249_10511 8220DB000336 2025-01-21T10:44:00Z scheduled false",
+			"3249_10466 8220DB000336 2025-01-21T11:01:30Z scheduled true",
+		}, board(departures))
+	})
+
+	t.Run("works out the delay of an earlier stop from its times", func(t *testing.T) {
+		d := departuresAt("2025-01-21T10:40:00Z", transit.Departure{
+			StopID:             "8220DB000335",
+			Source:             "gtfsr",
+			TripID:             "3249_10466",
+			StopSequence:       13,
+			ScheduledDeparture: *at("2025-01-21T10:37:00Z"),
+			ExpectedDeparture:  *at("2025-01-21T10:40:00Z"),
+			Status:             transit.DepartureScheduled,
+			Realtime:           true,
+		})
+		departures, _ := d.StopDepartures("8220DB000336", sched, hour)
+		assert.Equal(t, []string{
+			"3249_10511 8220DB000336 2025-01-21T10:44:00Z scheduled false",
+			"3249_10466 8220DB000336 2025-01-21T11:01:30Z scheduled true",
+		}, board(departures))
+	})
+
+	t.Run("carries nothing on from departures without stop sequences", func(t *testing.T) {
+		d := departuresAt("2025-01-21T10:40:00Z", transit.Departure{
+			StopID:            "8220DB000335",
+			Source:            "gtfsr",
+			TripID:            "3249_10466",
+			ExpectedDeparture: *at("2025-01-21T10:40:00Z"),
+			Status:            transit.DepartureScheduled,
+			Realtime:          true,
+		}, transit.Departure{
+			StopID:            "8220DB000334",
+			Source:            "gtfsr",
+			TripID:            "3249_10466",
+			ExpectedDeparture: *at("2025-01-21T10:38:00Z"),
+			Status:            transit.DepartureScheduled,
+			Realtime:          true,
+		})
+		assert.Equal(t, "8220DB000334", d.byTrip["3249_10466"][0].StopID, "expected the trip in order of time")
+
+		departures, _ := d.StopDepartures("8220DB000336", sched, hour)
+		assert.Equal(t, []string{
+			"3249_10511 8220DB000336 2025-01-21T10:44:00Z scheduled false",
+			"3249_10466 8220DB000336 2025-01-21T10:58:30Z scheduled false",
+		}, board(departures))
+	})
+
+	t.Run("carries the cancellation of a trip on", func(t *testing.T) {
+		d := departuresAt("2025-01-21T10:30:00Z", transit.Departure{
+			StopID:       "8220DB000334",
+			Source:       "gtfsr",
+			TripID:       "3249_10511",
+			StopSequence: 1,
+			Status:       transit.DepartureCancelled,
+			Realtime:     true,
+		})
+		departures, _ := d.StopDepartures("8220DB000336", sched, hour)
+		assert.Equal(t, []string{
+			"3249_10511 8220DB000336 2025-01-21T10:44:00Z cancelled true",
+			"3249_10466 8220DB000336 2025-01-21T10:58:30Z scheduled false",
+		}, board(departures))
+	})
+
+	t.Run("marks skipped stops", func(t *testing.T) {
+		d := departuresAt("2025-01-21T10:30:00Z", transit.Departure{
+			StopID:       "8220DB000334",
+			Source:       "gtfsr",
+			TripID:       "3249_10466",
+			StopSequence: 12,
+			Status:       transit.DepartureSkipped,
+			Realtime:     true,
+		})
+		departures, _ := d.StopDepartures("8220DB000334", sched, hour)
+		assert.Equal(t, []string{
+			"3249_10466 8220DB000334 2025-01-21T10:35:30Z skipped true",
+			"3249_10511 8220DB000334 2025-01-21T10:40:00Z scheduled false",
+		}, board(departures))
+	})
+
+	t.Run("keeps late departures scheduled before now", func(t *testing.T) {
+		d := departuresAt("2025-01-21T10:50:00Z", transit.Departure{
+			StopID:       "8220DB000334",
+			Source:       "gtfsr",
+			TripID:       "3249_10511",
+			StopSequence: 1,
+			Delay:        delay(15 * time.Minute),
+			Status:       transit.DepartureScheduled,
+			Realtime:     true,
+		})
+		departures, _ := d.StopDepartures("8220DB000334", sched, hour)
+		assert.Equal(t, []string{"3249_10511 8220DB000334 2025-01-21T10:55:00Z scheduled true"}, board(departures))
+	})
+
+	t.Run("adds realtime departures which are not in the timetable", func(t *testing.T) {
+		d := departuresAt("2025-01-21T10:30:00Z", transit.Departure{
+			StopID:            "8220IR0133",
+			Source:            "gtfsr",
+			TripID:            "3249_99999",
+			ExpectedDeparture: *at("2025-01-21T10:45:00Z"),
+			Status:            transit.DepartureScheduled,
+			Realtime:          true,
+		}, transit.Departure{
+			StopID:            "LUAS24",
+			Source:            "luas",
+			ExpectedDeparture: *at("2025-01-21T10:32:00Z"),
+			Status:            transit.DepartureScheduled,
+			Realtime:          true,
+		})
+
+		departures, ok := d.StopDepartures("8220IR0132", sched, hour)
+		assert.True(t, ok, "expected station to be found")
+		assert.Equal(t, []string{"3249_99999 8220IR0133 2025-01-21T10:45:00Z scheduled true"}, board(departures), "expected departures from the platforms of the station")
+
+		departures, ok = d.StopDepartures("LUAS24", sched, hour)
+		assert.True(t, ok, "expected stop with realtime departures to be found")
+		assert.Equal(t, []string{" LUAS24 2025-01-21T10:32:00Z scheduled true"}, board(departures))
+	})
+
+	t.Run("merges the departures of sources with their own ids", func(t *testing.T) {
+		ireland := irelandSchedule()
+		d := departuresAt("2025-01-21T09:50:00Z", transit.Departure{
+			StopID:            "HSTON",
+			Source:            "irishrail",
+			Operator:          "irishrail",
+			TripID:            "E109",
+			VehicleID:         "E109",
+			ExpectedDeparture: *at("2025-01-21T10:04:00Z"),
+			Status:            transit.DepartureScheduled,
+			Realtime:          true,
+		}, transit.Departure{
+			StopID:            "STS",
+			Source:            "luas",
+			Operator:          "luas",
+			ExpectedDeparture: *at("2025-01-21T09:53:00Z"),
+			Status:            transit.DepartureScheduled,
+			Realtime:          true,
+		})
+
+		departures, ok := d.StopDepartures("8220IR0132", ireland, hour)
+		assert.True(t, ok)
+		assert.Equal(t, []string{"4452_1 8220IR0132 2025-01-21T10:04:00Z scheduled true"}, board(departures), "expected the train code to be merged with its trip")
+		assert.Equal(t, "E109", departures[0].VehicleID)
+		assert.Equal(t, 4*time.Minute, *departures[0].Delay)
+
+		departures, ok = d.StopDepartures("HSTON", ireland, hour)
+		assert.True(t, ok, "expected the station code to be found")
+		assert.Equal(t, []string{"4452_1 8220IR0132 2025-01-21T10:04:00Z scheduled true"}, board(departures))
+
+		departures, _ = d.StopDepartures("8220GA00024", ireland, hour)
+		assert.Equal(t, []string{" 8220GA00024 2025-01-21T09:53:00Z scheduled true"}, board(departures), "expected the Luas stop code to be mapped")
+	})
+
+	t.Run("places trips past midnight on the day before", func(t *testing.T) {
+		departures, _ := departuresAt("2025-01-26T00:00:00Z").StopDepartures("8250DB002002", sched, hour)
+		assert.Equal(t, []string{"3249_10467 8250DB002002 2025-01-26T00:05:00Z scheduled false"}, board(departures))
+	})
+
+	t.Run("skips the stop times of trips not in the timetable", func(t *testing.T) {
+		ireland := irelandSchedule()
+		ireland.AddStopTime(schedule.StopTime{TripID: "missing", StopID: "8220IR0025", StopSequence: 1, Arrival: 9 * 3600, Departure: 9 * 3600})
+		ireland.Index()
+		departures, _ := departuresAt("2025-01-21T08:30:00Z").StopDepartures("8220IR0025", ireland, hour)
+		assert.Equal(t, []string{"4452_1 8220IR0025 2025-01-21T09:00:00Z scheduled false"}, board(departures))
+	})
+
+	t.Run("only returns departures within the time range", func(t *testing.T) {
+		departures, _ := departuresAt("2025-01-21T10:30:00Z").StopDepartures("8220DB000334", sched, DepartureFilter{TimeRange: 8 * time.Minute})
+		assert.Equal(t, []string{"3249_10466 8220DB000334 2025-01-21T10:35:30Z scheduled false"}, board(departures))
+	})
+
+	t.Run("limits the number of departures", func(t *testing.T) {
+		departures, _ := departuresAt("2025-01-21T10:30:00Z").StopDepartures("8220DB000334", sched, DepartureFilter{TimeRange: time.Hour, Limit: 1})
+		assert.Equal(t, []string{"3249_10466 8220DB000334 2025-01-21T10:35:30Z scheduled false"}, board(departures))
+	})
+
+	t.Run("orders departures at the same time by trip", func(t *testing.T) {
+		d := departuresAt("2025-01-21T10:30:00Z", transit.Departure{
+			StopID:            "LUAS24",
+			Source:            "gtfsr",
+			TripID:            "T2",
+			ExpectedDeparture: *at("2025-01-21T10:32:00Z"),
+			Status:            transit.DepartureScheduled,
+			Realtime:          true,
+		}, transit.Departure{
+			StopID:            "LUAS24",
+			Source:            "gtfsr",
+			TripID:            "T1",
+			ExpectedDeparture: *at("2025-01-21T10:32:00Z"),
+			Status:            transit.DepartureScheduled,
+			Realtime:          true,
+		})
+		departures, _ := d.StopDepartures("LUAS24", nil, hour)
+		assert.Equal(t, []string{"T1 LUAS24 2025-01-21T10:32:00Z scheduled true", "T2 LUAS24 2025-01-21T10:32:00Z scheduled true"}, board(departures))
+	})
+
+	t.Run("is not found for an unknown stop", func(t *testing.T) {
+		_, ok := New().StopDepartures("missing", sched, hour)
+		assert.False(t, ok, "expected stop not to be found")
+	})
+
+	t.Run("returns realtime departures without a timetable", func(t *testing.T) {
+		departures, ok := departuresAt("2025-01-21T10:30:00Z").StopDepartures("missing", nil, hour)
+		assert.True(t, ok, "expected any stop to be found without a timetable")
+		assert.Equal(t, []transit.Departure{}, departures)
+	})
+}
@@ -0,0 +1,356 @@
+package dataset
+
+import (
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"google.golang.org/protobuf/proto"
+)
+
+// gtfsrtVersion is the version of GTFS-Realtime the feeds are published in
+const gtfsrtVersion = "2.0"
+
+func feed(now time.Time, entities []*gtfs.FeedEntity) *gtfs.FeedMessage {
+	return &gtfs.FeedMessage{
+		Header: &gtfs.FeedHeader{
+			GtfsRealtimeVersion: proto.String(gtfsrtVersion),
+			Incrementality:      gtfs.FeedHeader_FULL_DATASET.Enum(),
+			Timestamp:           proto.Uint64(uint64(now.Unix())),
+		},
+		Entity: entities,
+	}
+}
+
+// optional leaves an empty id out of a feed rather than sending it empty
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+// posix is nil for a zero time, which leaves it out of a feed
+func posix(t time.Time) *uint64 {
+	if t.IsZero() {
+		return nil
+	}
+
+	return proto.Uint64(uint64(max(t.Unix(), 0)))
+}
+
+func float32Ptr(v *float64) *float32 {
+	if v == nil {
+		return nil
+	}
+	f := float32(*v)
+
+	return &f
+}
+
+func vehicleStopStatus(status transit.VehicleStatus) *gtfs.VehiclePosition_VehicleStopStatus {
+	switch status {
+	case transit.VehicleIncomingAt:
+		return gtfs.VehiclePosition_INCOMING_AT.Enum()
+	case transit.VehicleStoppedAt:
+		return gtfs.VehiclePosition_STOPPED_AT.Enum()
+	default:
+		return gtfs.VehiclePosition_IN_TRANSIT_TO.Enum()
+	}
+}
+
+// VehiclePositionsFeed returns the vehicles of every source as a GTFS-Realtime
+// feed of vehicle positions, with their ids mapped to the static timetable
+// when it is loaded
+func (d *Dataset) VehiclePositionsFeed(sched *schedule.Schedule) *gtfs.FeedMessage {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	now := d.now()
+	ids := gtfsIDs{sched: sched, now: now}
+	entities := make([]*gtfs.FeedEntity, 0, len(d.vehicles))
+	for _, v := range d.vehicles {
+		entities = append(entities, &gtfs.FeedEntity{
+			// Vehicle ids are only unique within their source
+			Id: proto.String(v.Source + ":" + v.ID),
+			Vehicle: &gtfs.VehiclePosition{
+				Trip:    ids.trip(v.Operator, v.TripID, v.RouteID),
+				Vehicle: &gtfs.VehicleDescriptor{Id: optional(v.ID), Label: optional(v.Label)},
+				Position: &gtfs.Position{
+					Latitude:  proto.Float32(float32(v.Latitude)),
+					Longitude: proto.Float32(float32(v.Longitude)),
+					Bearing:   float32Ptr(v.Bearing),
+					Speed:     float32Ptr(v.Speed),
+				},
+				StopId:        optional(ids.stop(v.StopID)),
+				CurrentStatus: vehicleStopStatus(v.Status),
+				Timestamp:     posix(v.Timestamp),
+			},
+		})
+	}
+
+	return feed(now, entities)
+}
+
+func stopTimeEvent(expected time.Time, delay *time.Duration) *gtfs.TripUpdate_StopTimeEvent {
+	if expected.IsZero() && delay == nil {
+		return nil
+	}
+
+	e := &gtfs.TripUpdate_StopTimeEvent{}
+	if !expected.IsZero() {
+		e.Time = proto.Int64(expected.Unix())
+	}
+	if delay != nil {
+		e.Delay = proto.Int32(int32(delay.Seconds()))
+	}
+
+	return e
+}
+
+func stopTimeUpdate(ids gtfsIDs, dep transit.Departure) *gtfs.TripUpdate_StopTimeUpdate {
+	stu := &gtfs.TripUpdate_StopTimeUpdate{StopId: optional(ids.stop(dep.StopID))}
+	if dep.StopSequence != 0 {
+		stu.StopSequence = proto.Uint32(uint32(dep.StopSequence))
+	}
+	switch dep.Status {
+	case transit.DepartureCancelled, transit.DepartureSkipped:
+		stu.ScheduleRelationship = gtfs.TripUpdate_StopTimeUpdate_SKIPPED.Enum()
+	case transit.DepartureNoData:
+		stu.ScheduleRelationship = gtfs.TripUpdate_StopTimeUpdate_NO_DATA.Enum()
+	default:
+		delay := realtimeDelay(dep)
+		stu.Arrival = stopTimeEvent(dep.ExpectedArrival, delay)
+		stu.Departure = stopTimeEvent(dep.ExpectedDeparture, delay)
+	}
+
+	return stu
+}
+
+// tripUpdate maps the realtime departures of a trip ordered by stop sequence,
+// a trip whose every departure is cancelled is a cancelled trip
+func tripUpdate(ids gtfsIDs, departures []transit.Departure) *gtfs.TripUpdate {
+	first := departures[0]
+	u := &gtfs.TripUpdate{
+		Trip:      ids.trip(first.Operator, first.TripID, first.RouteID),
+		Timestamp: posix(ids.now),
+	}
+
+	cancelled := true
+	for _, dep := range departures {
+		if u.Vehicle == nil && dep.VehicleID != "" {
+			u.Vehicle = &gtfs.VehicleDescriptor{Id: proto.String(dep.VehicleID)}
+		}
+		if dep.Status != transit.DepartureCancelled {
+			cancelled = false
+		}
+
+		u.StopTimeUpdate = append(u.StopTimeUpdate, stopTimeUpdate(ids, dep))
+	}
+
+	if cancelled {
+		u.Trip.ScheduleRelationship = gtfs.TripDescriptor_CANCELED.Enum()
+		u.StopTimeUpdate = nil
+	}
+
+	return u
+}
+
+// stopForecast is a departure without a trip, such as a Luas tram, keyed by
+// its route, direction and stop along with its place in the order of the
+// departures of that key
+type stopForecast struct {
+	key       string
+	departure transit.Departure
+}
+
+// stopForecasts returns the departures without a trip but with a route, in the
+// order of their key and then of their time. The lock must be held
+func (d *Dataset) stopForecasts() []stopForecast {
+	byKey := map[string][]transit.Departure{}
+	for _, dep := range d.departures {
+		if dep.TripID == "" && dep.RouteID != "" {
+			key := dep.Source + ":" + dep.RouteID + ":" + dep.Direction + ":" + dep.StopID
+			byKey[key] = append(byKey[key], dep)
+		}
+	}
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	forecasts := []stopForecast{}
+	for _, key := range keys {
+		departures := byKey[key]
+		sort.SliceStable(departures, func(i, j int) bool { return departures[i].Time().Before(departures[j].Time()) })
+		for i, dep := range departures {
+			forecasts = append(forecasts, stopForecast{key: key + ":" + strconv.Itoa(i+1), departure: dep})
+		}
+	}
+
+	return forecasts
+}
+
+// stopUpdate maps a departure without a trip to a trip update of its route,
+// in the direction of its headsign, with the one stop
+func stopUpdate(ids gtfsIDs, dep transit.Departure) *gtfs.TripUpdate {
+	routeID := ids.route(dep.Operator, dep.RouteID)
+
+	return &gtfs.TripUpdate{
+		Trip:           &gtfs.TripDescriptor{RouteId: proto.String(routeID), DirectionId: ids.direction(routeID, dep.Headsign)},
+		Timestamp:      posix(ids.now),
+		StopTimeUpdate: []*gtfs.TripUpdate_StopTimeUpdate{stopTimeUpdate(ids, dep)},
+	}
+}
+
+// TripUpdatesFeed returns the realtime departures of every source as a
+// GTFS-Realtime feed of trip updates, one for each trip. Departures without a
+// trip, such as those of Luas, are a trip update of their route and direction
+// at their stop each
+func (d *Dataset) TripUpdatesFeed(sched *schedule.Schedule) *gtfs.FeedMessage {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	now := d.now()
+	ids := gtfsIDs{sched: sched, now: now}
+	tripIDs := make([]string, 0, len(d.byTrip))
+	for id := range d.byTrip {
+		tripIDs = append(tripIDs, id)
+	}
+	sort.Strings(tripIDs)
+	forecasts := d.stopForecasts()
+
+	entities := make([]*gtfs.FeedEntity, 0, len(tripIDs)+len(forecasts))
+	for _, id := range tripIDs {
+		departures := d.byTrip[id]
+		u := tripUpdate(ids, departures)
+		entities = append(entities, &gtfs.FeedEntity{Id: proto.String(departures[0].Source + ":" + id), TripUpdate: u})
+	}
+	for _, f := range forecasts {
+		entities = append(entities, &gtfs.FeedEntity{Id: proto.String(f.key), TripUpdate: stopUpdate(ids, f.departure)})
+	}
+
+	return feed(now, entities)
+}
+
+// translatedString is nil without translations, which leaves it out of a feed
+func translatedString(translations []transit.Translation) *gtfs.TranslatedString {
+	if len(translations) == 0 {
+		return nil
+	}
+
+	s := &gtfs.TranslatedString{}
+	for _, t := range translations {
+		s.Translation = append(s.Translation, &gtfs.TranslatedString_Translation{Text: proto.String(t.Text), Language: optional(t.Language)})
+	}
+
+	return s
+}
+
+// cause returns the cause named s, UNKNOWN_CAUSE when there is none
+func cause(s string) *gtfs.Alert_Cause {
+	if c, ok := gtfs.Alert_Cause_value[s]; ok {
+		return gtfs.Alert_Cause(c).Enum()
+	}
+
+	return gtfs.Alert_UNKNOWN_CAUSE.Enum()
+}
+
+// effect returns the effect named s, UNKNOWN_EFFECT when there is none
+func effect(s string) *gtfs.Alert_Effect {
+	if e, ok := gtfs.Alert_Effect_value[s]; ok {
+		return gtfs.Alert_Effect(e).Enum()
+	}
+
+	return gtfs.Alert_UNKNOWN_EFFECT.Enum()
+}
+
+func gtfsrtAlert(ids gtfsIDs, a transit.Alert) *gtfs.Alert {
+	alert := &gtfs.Alert{
+		Cause:           cause(a.Cause),
+		Effect:          effect(a.Effect),
+		Url:             translatedString(a.URL),
+		HeaderText:      translatedString(a.HeaderText),
+		DescriptionText: translatedString(a.DescriptionText),
+	}
+
+	for _, p := range a.ActivePeriods {
+		r := &gtfs.TimeRange{}
+		if p.Start != nil {
+			r.Start = posix(*p.Start)
+		}
+		if p.End != nil {
+			r.End = posix(*p.End)
+		}
+		alert.ActivePeriod = append(alert.ActivePeriod, r)
+	}
+
+	for _, e := range a.InformedEntities {
+		selector := &gtfs.EntitySelector{
+			AgencyId: optional(ids.operator(e.OperatorID)),
+			RouteId:  optional(ids.route(e.OperatorID, e.RouteID)),
+			StopId:   optional(ids.stop(e.StopID)),
+		}
+		if e.RouteType != nil {
+			selector.RouteType = proto.Int32(int32(*e.RouteType))
+		}
+		if e.DirectionID != nil {
+			selector.DirectionId = proto.Uint32(uint32(*e.DirectionID))
+		}
+		if e.TripID != "" {
+			selector.Trip = ids.trip(e.OperatorID, e.TripID, "")
+		}
+		alert.InformedEntity = append(alert.InformedEntity, selector)
+	}
+
+	return alert
+}
+
+// lineStatusAlert maps the status of a line which is not running normally, such
+// as a Luas line, to an alert informing its route
+func lineStatusAlert(ids gtfsIDs, s transit.LineStatus) *gtfs.Alert {
+	alert := &gtfs.Alert{
+		InformedEntity: []*gtfs.EntitySelector{{
+			AgencyId: optional(ids.operator(s.Operator)),
+			RouteId:  optional(ids.route(s.Operator, s.RouteID)),
+		}},
+		Cause:           gtfs.Alert_UNKNOWN_CAUSE.Enum(),
+		Effect:          gtfs.Alert_UNKNOWN_EFFECT.Enum(),
+		HeaderText:      translatedString([]transit.Translation{{Text: s.Name}}),
+		DescriptionText: translatedString([]transit.Translation{{Text: s.Message}}),
+	}
+	if !s.Updated.IsZero() {
+		alert.ActivePeriod = []*gtfs.TimeRange{{Start: posix(s.Updated)}}
+	}
+
+	return alert
+}
+
+// AlertsFeed returns the alerts of every source which have not expired as a
+// GTFS-Realtime feed of alerts, along with an alert for each line which is not
+// running normally
+func (d *Dataset) AlertsFeed(sched *schedule.Schedule) *gtfs.FeedMessage {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	now := d.now()
+	ids := gtfsIDs{sched: sched, now: now}
+	entities := []*gtfs.FeedEntity{}
+	for _, a := range d.alerts {
+		if !a.ExpiredAt(now) {
+			entities = append(entities, &gtfs.FeedEntity{Id: proto.String(a.ID), Alert: gtfsrtAlert(ids, a)})
+		}
+	}
+	for _, s := range d.lineStatuses {
+		if !s.Normal {
+			entities = append(entities, &gtfs.FeedEntity{Id: proto.String(s.Operator + "-" + s.RouteID), Alert: lineStatusAlert(ids, s)})
+		}
+	}
+
+	return feed(now, entities)
+}
@@ -0,0 +1,167 @@
+package dataset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// protoJSON formats messages as JSON to compare them, as the messages carry
+// internal state once they have been marshalled
+func protoJSON[M proto.Message](messages ...M) []string {
+	formatted := make([]string, 0, len(messages))
+	for _, m := range messages {
+		formatted = append(formatted, protojson.Format(m))
+	}
+
+	return formatted
+}
+
+func TestVehiclePositionsFeed(t *testing.T) {
+	d := New()
+	d.now = func() time.Time { return *at("2025-01-21T09:30:00Z") }
+	bearing := 90.0
+	d.Set(transit.Dataset{Vehicles: []transit.Vehicle{
+		{ID: "V1", Source: "gtfsr", Operator: "7778019", RouteID: "3249_46342", TripID: "3249_10466", Label: "SG1", Latitude: 53.3498, Longitude: -6.2603, Bearing: &bearing, Status: transit.VehicleStoppedAt, StopID: "8220DB000334", Timestamp: *at("2025-01-21T09:29:30Z")},
+		{ID: "E109", Source: "irishrail", Operator: "irishrail", TripID: "E109", Label: "E109", Latitude: 53.3464, Longitude: -6.2927, Status: transit.VehicleInTransit},
+		{ID: "V2", Source: "gtfsr", Status: transit.VehicleIncomingAt, StopID: "8220DB000335"},
+	}})
+
+	f := d.VehiclePositionsFeed(irelandSchedule())
+	assert.Equal(t, protoJSON(&gtfs.FeedHeader{
+		GtfsRealtimeVersion: proto.String("2.0"),
+		Incrementality:      gtfs.FeedHeader_FULL_DATASET.Enum(),
+		Timestamp:           proto.Uint64(uint64(at("2025-01-21T09:30:00Z").Unix())),
+	}), protoJSON(f.Header))
+	if assert.Len(t, f.Entity, 3) {
+		v1 := f.Entity[0].Vehicle
+		assert.Equal(t, "gtfsr:V1", f.Entity[0].GetId(), "expected the entity id to be prefixed with the source")
+		assert.Equal(t, protoJSON(&gtfs.TripDescriptor{TripId: proto.String("3249_10466"), RouteId: proto.String("3249_46342")}), protoJSON(v1.Trip), "expected ids unknown to the timetable to be kept")
+		assert.Equal(t, protoJSON(&gtfs.VehicleDescriptor{Id: proto.String("V1"), Label: proto.String("SG1")}), protoJSON(v1.Vehicle))
+		assert.Equal(t, float32(53.3498), v1.GetPosition().GetLatitude())
+		assert.Equal(t, float32(90), v1.GetPosition().GetBearing())
+		assert.Nil(t, v1.Position.Speed)
+		assert.Equal(t, gtfs.VehiclePosition_STOPPED_AT, v1.GetCurrentStatus())
+		assert.Equal(t, "8220DB000334", v1.GetStopId())
+		assert.Equal(t, uint64(at("2025-01-21T09:29:30Z").Unix()), v1.GetTimestamp())
+
+		assert.Equal(t, "irishrail:E109", f.Entity[1].GetId())
+		train := f.Entity[1].Vehicle
+		assert.Equal(t, "4452_1", train.GetTrip().GetTripId(), "expected the train code to be mapped to its trip")
+		assert.Equal(t, gtfs.VehiclePosition_IN_TRANSIT_TO, train.GetCurrentStatus())
+		assert.Nil(t, train.Timestamp, "expected no timestamp when the source has none")
+
+		assert.Equal(t, gtfs.VehiclePosition_INCOMING_AT, f.Entity[2].GetVehicle().GetCurrentStatus())
+	}
+
+	b, err := proto.Marshal(f)
+	assert.NoError(t, err, "expected the feed to encode")
+	decoded := &gtfs.FeedMessage{}
+	assert.NoError(t, proto.Unmarshal(b, decoded), "expected the feed to decode")
+	assert.Len(t, decoded.Entity, 3)
+}
+
+func TestTripUpdatesFeed(t *testing.T) {
+	d := New()
+	d.now = func() time.Time { return *at("2025-01-21T09:30:00Z") }
+	late := 2 * time.Minute
+	d.Set(transit.Dataset{Departures: []transit.Departure{
+		{StopID: "HSTON", Source: "irishrail", Operator: "irishrail", TripID: "E109", VehicleID: "E109", StopSequence: 2, ScheduledArrival: *at("2025-01-21T10:00:00Z"), ExpectedArrival: *at("2025-01-21T10:02:00Z"), Delay: &late, Status: transit.DepartureScheduled, Realtime: true},
+		{StopID: "PERSE", Source: "irishrail", Operator: "irishrail", TripID: "E109", VehicleID: "E109", StopSequence: 1, Status: transit.DepartureSkipped, Realtime: true},
+		{StopID: "CNLLY", Source: "irishrail", Operator: "irishrail", TripID: "E109", VehicleID: "E109", StopSequence: 3, Status: transit.DepartureNoData, Realtime: true},
+		{StopID: "TARA", Source: "irishrail", Operator: "irishrail", TripID: "E109", VehicleID: "E109", StopSequence: 4, Status: transit.DepartureScheduled, Realtime: true},
+		{StopID: "8220DB000334", Source: "gtfsr", Operator: "7778019", TripID: "3249_10466", StopSequence: 12, Status: transit.DepartureCancelled, Realtime: true},
+		{StopID: "STS", Source: "luas", Operator: "luas", RouteID: "green", Headsign: "Brides Glen", Direction: "Outbound", ExpectedArrival: *at("2025-01-21T09:41:00Z"), ExpectedDeparture: *at("2025-01-21T09:41:00Z"), Status: transit.DepartureScheduled, Realtime: true},
+		{StopID: "STS", Source: "luas", Operator: "luas", RouteID: "green", Headsign: "Broombridge", Direction: "Inbound", ExpectedArrival: *at("2025-01-21T09:35:00Z"), ExpectedDeparture: *at("2025-01-21T09:35:00Z"), Status: transit.DepartureScheduled, Realtime: true},
+		{StopID: "STS", Source: "luas", Operator: "luas", RouteID: "green", Headsign: "Sandyford", Direction: "Outbound", ExpectedArrival: *at("2025-01-21T09:33:00Z"), ExpectedDeparture: *at("2025-01-21T09:33:00Z"), Status: transit.DepartureScheduled, Realtime: true},
+	}})
+
+	f := d.TripUpdatesFeed(irelandSchedule())
+	var entityIDs []string
+	for _, e := range f.Entity {
+		entityIDs = append(entityIDs, e.GetId())
+	}
+	assert.Equal(t, []string{
+		"gtfsr:3249_10466",
+		"irishrail:E109",
+		"luas:green:Inbound:STS:1",
+		"luas:green:Outbound:STS:1",
+		"luas:green:Outbound:STS:2",
+	}, entityIDs, "expected a trip update for each trip and then one for each luas forecast by route, direction and stop in order of time")
+	if len(f.Entity) != 5 {
+		return
+	}
+
+	cancelled := f.Entity[0]
+	assert.Equal(t, "gtfsr:3249_10466", cancelled.GetId(), "expected the entity id to be prefixed with the source")
+	assert.Equal(t, gtfs.TripDescriptor_CANCELED, cancelled.GetTripUpdate().GetTrip().GetScheduleRelationship())
+	assert.Empty(t, cancelled.GetTripUpdate().GetStopTimeUpdate())
+
+	train := f.Entity[1]
+	delay, expected := proto.Int32(120), proto.Int64(at("2025-01-21T10:02:00Z").Unix())
+	assert.Equal(t, "irishrail:E109", train.GetId(), "expected the entity id to be the trip of the source")
+	assert.Equal(t, "4452_1", train.GetTripUpdate().GetTrip().GetTripId())
+	assert.Equal(t, gtfs.TripDescriptor_SCHEDULED, train.GetTripUpdate().GetTrip().GetScheduleRelationship())
+	assert.Equal(t, protoJSON(&gtfs.VehicleDescriptor{Id: proto.String("E109")}), protoJSON(train.TripUpdate.Vehicle))
+	assert.Equal(t, protoJSON(
+		&gtfs.TripUpdate_StopTimeUpdate{StopSequence: proto.Uint32(1), StopId: proto.String("8220IR0025"), ScheduleRelationship: gtfs.TripUpdate_StopTimeUpdate_SKIPPED.Enum()},
+		&gtfs.TripUpdate_StopTimeUpdate{StopSequence: proto.Uint32(2), StopId: proto.String("8220IR0132"), Arrival: &gtfs.TripUpdate_StopTimeEvent{Delay: delay, Time: expected}, Departure: &gtfs.TripUpdate_StopTimeEvent{Delay: delay}},
+		&gtfs.TripUpdate_StopTimeUpdate{StopSequence: proto.Uint32(3), StopId: proto.String("CNLLY"), ScheduleRelationship: gtfs.TripUpdate_StopTimeUpdate_NO_DATA.Enum()},
+		&gtfs.TripUpdate_StopTimeUpdate{StopSequence: proto.Uint32(4), StopId: proto.String("TARA")},
+	), protoJSON(train.TripUpdate.StopTimeUpdate...), "expected stop codes to be mapped, updates ordered by stop sequence and no events without times")
+
+	arrival := proto.Int64(at("2025-01-21T09:41:00Z").Unix())
+	tram := f.Entity[4].TripUpdate
+	assert.Equal(t, protoJSON(&gtfs.TripDescriptor{RouteId: proto.String("4456_87008"), DirectionId: proto.Uint32(0)}), protoJSON(tram.Trip), "expected the line and destination to be mapped to the route and its direction")
+	assert.Equal(t, protoJSON(
+		&gtfs.TripUpdate_StopTimeUpdate{StopId: proto.String("8220GA00024"), Arrival: &gtfs.TripUpdate_StopTimeEvent{Time: arrival}, Departure: &gtfs.TripUpdate_StopTimeEvent{Time: arrival}},
+	), protoJSON(tram.StopTimeUpdate...), "expected the luas abbreviation to be mapped to its stop")
+	assert.Nil(t, f.Entity[3].TripUpdate.Trip.DirectionId, "expected no direction for a destination without a trip")
+}
+
+func TestAlertsFeed(t *testing.T) {
+	d := testDataset()
+	d.now = func() time.Time { return *at("2025-01-21T09:30:00Z") }
+	d.Set(transit.Dataset{LineStatuses: []transit.LineStatus{
+		{Operator: "luas", RouteID: "red", Name: "Luas Red Line", Normal: true, Message: "Red Line services operating normally"},
+		{Operator: "luas", RouteID: "green", Name: "Luas Green Line", Message: "No trams between Sandyford and Brides Glen", Updated: *at("2025-01-21T11:00:00Z")},
+	}})
+	routeType, direction := 2, 1
+	d.SetAlerts(append([]transit.Alert{{
+		ID:               "IR1",
+		InformedEntities: []transit.InformedEntity{{OperatorID: "irishrail", StopID: "HSTON", RouteType: &routeType, DirectionID: &direction}, {OperatorID: "irishrail", TripID: "E109"}},
+		Cause:            "STRIKE",
+		Effect:           "NO_SERVICE",
+		HeaderText:       []transit.Translation{{Text: "No trains from Heuston", Language: "en"}},
+	}}, testAlerts...))
+
+	f := d.AlertsFeed(irelandSchedule())
+	var entityIDs []string
+	for _, e := range f.Entity {
+		entityIDs = append(entityIDs, e.GetId())
+	}
+	assert.Equal(t, []string{"IR1", "A1", "A3", "A4", "luas-green"}, entityIDs, "expected expired alerts and normal lines to be left out")
+
+	rail := f.Entity[0].Alert
+	assert.Equal(t, gtfs.Alert_STRIKE, rail.GetCause())
+	assert.Equal(t, gtfs.Alert_NO_SERVICE, rail.GetEffect())
+	if assert.Len(t, rail.InformedEntity, 2) {
+		assert.Equal(t, protoJSON(&gtfs.EntitySelector{AgencyId: proto.String("7778017"), StopId: proto.String("8220IR0132"), RouteType: proto.Int32(2), DirectionId: proto.Uint32(1)}), protoJSON(rail.InformedEntity[0]))
+		assert.Equal(t, "4452_1", rail.InformedEntity[1].GetTrip().GetTripId())
+	}
+	assert.Equal(t, protoJSON(&gtfs.TranslatedString_Translation{Text: proto.String("No trains from Heuston"), Language: proto.String("en")}), protoJSON(rail.GetHeaderText().GetTranslation()...))
+
+	a1 := f.Entity[1].Alert
+	assert.Equal(t, protoJSON(&gtfs.TimeRange{Start: proto.Uint64(uint64(at("2025-01-21T09:00:00Z").Unix())), End: proto.Uint64(uint64(at("2025-01-21T18:00:00Z").Unix()))}), protoJSON(a1.ActivePeriod...))
+	assert.Equal(t, gtfs.Alert_UNKNOWN_CAUSE, a1.GetCause())
+
+	line := f.Entity[4].Alert
+	assert.Equal(t, protoJSON(&gtfs.EntitySelector{AgencyId: proto.String("7778021"), RouteId: proto.String("4456_87008")}), protoJSON(line.InformedEntity...))
+	assert.Equal(t, protoJSON(&gtfs.TimeRange{Start: proto.Uint64(uint64(at("2025-01-21T11:00:00Z").Unix()))}), protoJSON(line.ActivePeriod...))
+	assert.Equal(t, "No trams between Sandyford and Brides Glen", line.GetDescriptionText().GetTranslation()[0].GetText())
+}
@@ -0,0 +1,143 @@
+package dataset
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"google.golang.org/protobuf/proto"
+)
+
+// gtfsIDs maps the ids of sources which do not use the ids of the static
+// timetable to GTFS ids. Irish Rail refers to stations by code and trips by
+// train code, which are the stop code and trip short name in GTFS, and Luas
+// refers to stops by their code and lines by colour. Ids which the timetable
+// already has, or which cannot be mapped, are kept as they are
+type gtfsIDs struct {
+	sched *schedule.Schedule
+	now   time.Time
+}
+
+func (m gtfsIDs) operator(id string) string {
+	if m.sched == nil || id == "" {
+		return id
+	}
+	if _, ok := m.sched.Agencies[id]; ok {
+		return id
+	}
+	if a, ok := m.sched.AgencyByName(id); ok {
+		return a.ID
+	}
+
+	return id
+}
+
+func (m gtfsIDs) stop(id string) string {
+	if m.sched == nil || id == "" {
+		return id
+	}
+	if _, ok := m.sched.Stops[id]; ok {
+		return id
+	}
+	if stop, ok := m.sched.StopByCode(id); ok {
+		return stop.ID
+	}
+
+	return id
+}
+
+// route maps a route of an operator to the route of its agency with that
+// short or long name, a Luas line is also the route named after its colour
+// such as "Red Line". Names must match in full, as the route 4 is not the 40
+func (m gtfsIDs) route(operator, id string) string {
+	if m.sched == nil || id == "" {
+		return id
+	}
+	if _, ok := m.sched.Routes[id]; ok {
+		return id
+	}
+
+	agencyID := m.operator(operator)
+	var ids []string
+	for _, r := range m.sched.Routes {
+		if r.AgencyID != agencyID {
+			continue
+		}
+		for _, name := range []string{r.ShortName, r.LongName} {
+			if strings.EqualFold(name, id) || strings.EqualFold(name, id+" Line") {
+				ids = append(ids, r.ID)
+
+				break
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return id
+	}
+	sort.Strings(ids)
+
+	return ids[0]
+}
+
+// direction maps the headsign of a departure on a route to the direction of
+// the trips of the route with that headsign, as a Luas tram only has its
+// destination. It is nil when no trip of the route has the headsign
+func (m gtfsIDs) direction(routeID, headsign string) *uint32 {
+	if m.sched == nil || headsign == "" {
+		return nil
+	}
+
+	for _, trip := range m.sched.TripsForRoute(routeID) {
+		if strings.EqualFold(trip.Headsign, headsign) {
+			direction := uint32(trip.DirectionID)
+
+			return &direction
+		}
+	}
+
+	return nil
+}
+
+// trip maps a trip of an operator to a trip descriptor, a train code is the
+// trip with that short name running on the service day of now
+func (m gtfsIDs) trip(operator, id, routeID string) *gtfs.TripDescriptor {
+	if id == "" {
+		return nil
+	}
+
+	descriptor := &gtfs.TripDescriptor{TripId: proto.String(id), RouteId: optional(m.route(operator, routeID))}
+	if m.sched == nil {
+		return descriptor
+	}
+
+	trips := []*schedule.Trip{}
+	if trip, ok := m.sched.Trips[id]; ok {
+		trips = append(trips, trip)
+	} else {
+		agencyID := m.operator(operator)
+		for _, trip := range m.sched.TripsByShortName(id) {
+			if r, ok := m.sched.Routes[trip.RouteID]; ok && r.AgencyID == agencyID {
+				trips = append(trips, trip)
+			}
+		}
+	}
+
+	for _, trip := range trips {
+		day, ok := serviceDay(m.sched, trip, m.sched.StopTimesForTrip(trip.ID), m.now)
+		if !ok && trip.ID != id {
+			continue
+		}
+
+		descriptor.TripId, descriptor.RouteId = proto.String(trip.ID), proto.String(trip.RouteID)
+		descriptor.DirectionId = proto.Uint32(uint32(trip.DirectionID))
+		if ok {
+			descriptor.StartDate = proto.String(day.Format("20060102"))
+		}
+
+		break
+	}
+
+	return descriptor
+}
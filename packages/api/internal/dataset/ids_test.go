@@ -0,0 +1,102 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+// irelandSchedule is a timetable of Irish Rail and Luas, whose realtime ids
+// are not GTFS ids
+func irelandSchedule() *schedule.Schedule {
+	s := schedule.New()
+	s.Agencies["7778017"] = &schedule.Agency{ID: "7778017", Name: "Iarnród Éireann / Irish Rail"}
+	s.Agencies["7778021"] = &schedule.Agency{ID: "7778021", Name: "LUAS"}
+	s.Agencies["7778019"] = &schedule.Agency{ID: "7778019", Name: "Dublin Bus"}
+	s.Stops["8220IR0132"] = &schedule.Stop{ID: "8220IR0132", Code: "HSTON", Name: "Heuston", LocationType: 1}
+	s.Stops["8220IR0133"] = &schedule.Stop{ID: "8220IR0133", Code: "HSTON", Name: "Heuston", ParentStation: "8220IR0132"}
+	s.Stops["8220IR0025"] = &schedule.Stop{ID: "8220IR0025", Code: "PERSE", Name: "Dublin Pearse"}
+	s.Stops["8220GA00024"] = &schedule.Stop{ID: "8220GA00024", Code: "STS", Name: "St. Stephen's Green"}
+	s.Routes["4452_86289"] = &schedule.Route{ID: "4452_86289", AgencyID: "7778017", ShortName: "DART", Type: 2}
+	s.Routes["4456_87008"] = &schedule.Route{ID: "4456_87008", AgencyID: "7778021", ShortName: "Green", LongName: "Green Line", Type: 0}
+	s.Routes["4456_87009"] = &schedule.Route{ID: "4456_87009", AgencyID: "7778021", ShortName: "Red", LongName: "Red Line", Type: 0}
+	s.Routes["3249_46341"] = &schedule.Route{ID: "3249_46341", AgencyID: "7778019", ShortName: "40", Type: 3}
+	s.Routes["3249_46342"] = &schedule.Route{ID: "3249_46342", AgencyID: "7778019", ShortName: "46A", Type: 3}
+	s.Routes["3249_46360"] = &schedule.Route{ID: "3249_46360", AgencyID: "7778019", ShortName: "4", Type: 3}
+	s.Trips["4452_1"] = &schedule.Trip{ID: "4452_1", RouteID: "4452_86289", ServiceID: "weekdays", ShortName: "E109", DirectionID: 1}
+	s.Trips["4452_2"] = &schedule.Trip{ID: "4452_2", RouteID: "4452_86289", ServiceID: "weekends", ShortName: "E109", DirectionID: 1}
+	s.Trips["4456_1"] = &schedule.Trip{ID: "4456_1", RouteID: "4456_87008", ServiceID: "weekdays", Headsign: "Brides Glen", DirectionID: 0}
+	s.Trips["4456_2"] = &schedule.Trip{ID: "4456_2", RouteID: "4456_87008", ServiceID: "weekdays", Headsign: "Broombridge", DirectionID: 1}
+	s.AddCalendar(schedule.Calendar{ServiceID: "weekdays", Days: [7]bool{false, true, true, true, true, true, false}, StartDate: "20250101", EndDate: "20251231"})
+	s.AddCalendar(schedule.Calendar{ServiceID: "weekends", Days: [7]bool{true, false, false, false, false, false, true}, StartDate: "20250101", EndDate: "20251231"})
+	for _, trip := range []string{"4452_1", "4452_2"} {
+		s.AddStopTime(schedule.StopTime{TripID: trip, StopID: "8220IR0025", StopSequence: 1, Arrival: 9 * 3600, Departure: 9 * 3600})
+		s.AddStopTime(schedule.StopTime{TripID: trip, StopID: "8220IR0132", StopSequence: 2, Arrival: 10 * 3600, Departure: 10 * 3600})
+	}
+	s.Index()
+
+	return s
+}
+
+func TestGTFSIDs(t *testing.T) {
+	ids := gtfsIDs{sched: irelandSchedule(), now: *at("2025-01-21T09:30:00Z")}
+
+	t.Run("maps operators by name", func(t *testing.T) {
+		assert.Equal(t, "7778017", ids.operator("irishrail"))
+		assert.Equal(t, "7778021", ids.operator("luas"))
+		assert.Equal(t, "7778021", ids.operator("7778021"), "expected an agency id to be kept")
+		assert.Equal(t, "buseireann", ids.operator("buseireann"), "expected an unknown operator to be kept")
+	})
+
+	t.Run("maps stops by code", func(t *testing.T) {
+		assert.Equal(t, "8220IR0132", ids.stop("HSTON"), "expected the station of an Irish Rail station code")
+		assert.Equal(t, "8220GA00024", ids.stop("STS"), "expected the stop of a Luas abbreviation")
+		assert.Equal(t, "8220IR0133", ids.stop("8220IR0133"), "expected a stop id to be kept")
+		assert.Equal(t, "XXXXX", ids.stop("XXXXX"), "expected an unknown stop to be kept")
+	})
+
+	t.Run("maps luas lines to routes", func(t *testing.T) {
+		assert.Equal(t, "4456_87009", ids.route("luas", "red"))
+		assert.Equal(t, "4456_87008", ids.route("luas", "green"))
+		assert.Equal(t, "blue", ids.route("luas", "blue"), "expected an unknown line to be kept")
+		assert.Equal(t, "red", ids.route("irishrail", "red"), "expected the routes of other operators to be left alone")
+	})
+
+	t.Run("maps lines to the route with that name in full", func(t *testing.T) {
+		assert.Equal(t, "3249_46360", ids.route("7778019", "4"), "expected route 4 rather than the 40 or 46A")
+		assert.Equal(t, "3249_46342", ids.route("7778019", "46a"))
+		assert.Equal(t, "46", ids.route("7778019", "46"), "expected a line without a route of that name to be kept")
+		assert.Equal(t, "gre", ids.route("luas", "gre"), "expected part of a colour to be kept")
+	})
+
+	t.Run("maps headsigns to the direction of the trips of a route", func(t *testing.T) {
+		zero, one := uint32(0), uint32(1)
+		assert.Equal(t, &zero, ids.direction("4456_87008", "Brides Glen"))
+		assert.Equal(t, &one, ids.direction("4456_87008", "broombridge"))
+		assert.Nil(t, ids.direction("4456_87008", "Sandyford"), "expected no direction without a trip of the headsign")
+		assert.Nil(t, ids.direction("4456_87009", "Brides Glen"), "expected the trips of other routes to be left alone")
+		assert.Nil(t, gtfsIDs{}.direction("4456_87008", "Brides Glen"), "expected no direction without a timetable")
+	})
+
+	t.Run("maps train codes to the trip running today", func(t *testing.T) {
+		assert.Equal(t, protoJSON(&gtfs.TripDescriptor{TripId: proto.String("4452_1"), RouteId: proto.String("4452_86289"), DirectionId: proto.Uint32(1), StartDate: proto.String("20250121")}), protoJSON(ids.trip("irishrail", "E109", "")))
+
+		weekend := gtfsIDs{sched: ids.sched, now: *at("2025-01-25T09:30:00Z")}
+		assert.Equal(t, "4452_2", weekend.trip("irishrail", "E109", "").GetTripId())
+
+		assert.Equal(t, protoJSON(&gtfs.TripDescriptor{TripId: proto.String("E999")}), protoJSON(ids.trip("irishrail", "E999", "")), "expected an unknown train to be kept")
+		assert.Equal(t, "E109", ids.trip("luas", "E109", "").GetTripId(), "expected the trips of other operators to be left alone")
+		assert.Nil(t, ids.trip("irishrail", "", ""))
+	})
+
+	t.Run("keeps every id without a timetable", func(t *testing.T) {
+		none := gtfsIDs{now: ids.now}
+		assert.Equal(t, "irishrail", none.operator("irishrail"))
+		assert.Equal(t, "HSTON", none.stop("HSTON"))
+		assert.Equal(t, "red", none.route("luas", "red"))
+		assert.Equal(t, protoJSON(&gtfs.TripDescriptor{TripId: proto.String("E109")}), protoJSON(none.trip("irishrail", "E109", "")))
+	})
+}
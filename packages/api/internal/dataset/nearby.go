@@ -0,0 +1,56 @@
+package dataset
+
+import (
+	"math"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+// nearbyDepartures is how many departures are inlined with each nearby stop
+const nearbyDepartures = 3
+
+// NearbyFilter is a point, how far from it in metres stops can be and how
+// many of the nearest stops to return
+type NearbyFilter struct {
+	Latitude  float64
+	Longitude float64
+	Radius    float64
+	Limit     int
+}
+
+// NearbyStop is a stop with its distance from a point, the routes calling at
+// it and its next few departures
+type NearbyStop struct {
+	Stop       transit.Stop        `json:"stop"`
+	Distance   float64             `json:"distance" example:"120"` // metres
+	Routes     []transit.Route     `json:"routes"`
+	Departures []transit.Departure `json:"departures"`
+}
+
+// NearbyStops returns the stops of the static timetable nearest to a point
+// ordered by great-circle distance. Platforms are left out when their station
+// is known as the station has their routes and departures
+func (d *Dataset) NearbyStops(sched *schedule.Schedule, filter NearbyFilter) []NearbyStop {
+	stops := []NearbyStop{}
+	for _, near := range sched.StopsNear(filter.Latitude, filter.Longitude, filter.Radius) {
+		if _, ok := sched.Stops[near.Stop.ParentStation]; ok {
+			continue
+		}
+		if filter.Limit > 0 && len(stops) == filter.Limit {
+			break
+		}
+
+		detail, _ := Stop(sched, near.Stop.ID)
+		departures, _ := d.StopDepartures(near.Stop.ID, sched, DepartureFilter{TimeRange: time.Hour, Limit: nearbyDepartures})
+		stops = append(stops, NearbyStop{
+			Stop:       detail.Stop,
+			Distance:   math.Round(near.Distance),
+			Routes:     detail.Routes,
+			Departures: departures,
+		})
+	}
+
+	return stops
+}
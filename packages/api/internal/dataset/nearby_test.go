@@ -0,0 +1,54 @@
+package dataset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNearbyStops(t *testing.T) {
+	sched := testSchedule(t)
+	d := New()
+	d.now = func() time.Time { return *at("2025-01-21T10:30:00Z") }
+
+	t.Run("returns the stops within the radius ordered by distance", func(t *testing.T) {
+		stops := d.NearbyStops(sched, NearbyFilter{Latitude: 53.3511, Longitude: -6.2608, Radius: 700})
+		if assert.Len(t, stops, 3) {
+			assert.Equal(t, "8220DB000335", stops[0].Stop.ID)
+			assert.Equal(t, float64(0), stops[0].Distance)
+			assert.Equal(t, "8220DB000334", stops[1].Stop.ID)
+			assert.Equal(t, float64(331), stops[1].Distance)
+			assert.Equal(t, "8220DB000336", stops[2].Stop.ID)
+		}
+	})
+
+	t.Run("inlines the routes and next departures of each stop", func(t *testing.T) {
+		stops := d.NearbyStops(sched, NearbyFilter{Latitude: 53.3531, Longitude: -6.2645, Radius: 100})
+		if assert.Len(t, stops, 1) {
+			assert.Equal(t, []string{"3249_46342", "3249_46350"}, routeIDs(stops[0].Routes))
+			assert.Equal(t, []string{
+				"3249_10466 8220DB000334 2025-01-21T10:35:30Z scheduled false",
+				"3249_10511 8220DB000334 2025-01-21T10:40:00Z scheduled false",
+			}, board(stops[0].Departures))
+		}
+	})
+
+	t.Run("leaves out the platforms of a station", func(t *testing.T) {
+		stops := d.NearbyStops(sched, NearbyFilter{Latitude: 53.3464, Longitude: -6.2927, Radius: 100})
+		if assert.Len(t, stops, 1) {
+			assert.Equal(t, "8220IR0132", stops[0].Stop.ID)
+		}
+	})
+
+	t.Run("limits the number of stops", func(t *testing.T) {
+		stops := d.NearbyStops(sched, NearbyFilter{Latitude: 53.3511, Longitude: -6.2608, Radius: 700, Limit: 1})
+		if assert.Len(t, stops, 1) {
+			assert.Equal(t, "8220DB000335", stops[0].Stop.ID)
+		}
+	})
+
+	t.Run("returns an empty list when nothing is nearby", func(t *testing.T) {
+		assert.Equal(t, []NearbyStop{}, d.NearbyStops(sched, NearbyFilter{Latitude: 53.2707, Longitude: -9.0568, Radius: 1000}))
+	})
+}
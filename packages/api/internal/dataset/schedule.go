@@ -0,0 +1,90 @@
+package dataset
+
+import (
+	"time"
+	_ "time/tzdata"
+
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+// scheduleSource is the source of departures taken from the static timetable
+const scheduleSource = "schedule"
+
+// Service days are counted in Irish time
+var dublin, _ = time.LoadLocation("Europe/Dublin")
+
+// serviceDay returns the service day on which a trip is running or has yet to
+// run at now, a trip still running past midnight belongs to the day before
+func serviceDay(sched *schedule.Schedule, trip *schedule.Trip, stopTimes []schedule.StopTime, now time.Time) (time.Time, bool) {
+	if len(stopTimes) == 0 {
+		return time.Time{}, false
+	}
+
+	today := now.In(dublin)
+	for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+		last := stopTimes[len(stopTimes)-1]
+		if sched.ServiceRunsOn(trip.ServiceID, day) && !last.Arrival.On(day).Before(now) {
+			return day, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// tripServiceDay returns the service day of a trip of the timetable at now
+func tripServiceDay(sched *schedule.Schedule, tripID string, now time.Time) (time.Time, bool) {
+	trip, ok := sched.Trips[tripID]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	return serviceDay(sched, trip, sched.StopTimesForTrip(tripID), now)
+}
+
+// scheduledDeparture maps a stop time of a trip on a service day to a
+// departure which has no realtime data
+func scheduledDeparture(sched *schedule.Schedule, trip *schedule.Trip, st schedule.StopTime, day time.Time) transit.Departure {
+	d := transit.Departure{
+		StopID:             st.StopID,
+		Source:             scheduleSource,
+		RouteID:            trip.RouteID,
+		TripID:             trip.ID,
+		Headsign:           trip.Headsign,
+		StopSequence:       st.StopSequence,
+		ScheduledArrival:   st.Arrival.On(day),
+		ScheduledDeparture: st.Departure.On(day),
+		Status:             transit.DepartureScheduled,
+	}
+	if st.Headsign != "" {
+		d.Headsign = st.Headsign
+	}
+	if r, ok := sched.Routes[trip.RouteID]; ok {
+		d.Operator = r.AgencyID
+		d.Mode = transit.RouteTypeMode(r.Type)
+	}
+
+	return d
+}
+
+// scheduledTrip returns the departures of a trip on the service day it is
+// running on at now
+func scheduledTrip(sched *schedule.Schedule, tripID string, now time.Time) []transit.Departure {
+	trip, ok := sched.Trips[tripID]
+	if !ok {
+		return nil
+	}
+
+	stopTimes := sched.StopTimesForTrip(tripID)
+	day, ok := serviceDay(sched, trip, stopTimes, now)
+	if !ok {
+		return nil
+	}
+
+	departures := make([]transit.Departure, 0, len(stopTimes))
+	for _, st := range stopTimes {
+		departures = append(departures, scheduledDeparture(sched, trip, st, day))
+	}
+
+	return departures
+}
@@ -0,0 +1,94 @@
+package dataset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSchedule(t *testing.T) *schedule.Schedule {
+	t.Helper()
+	s, err := schedule.LoadGTFS("../../../../lib/schedule/testdata/gtfs")
+	require.NoError(t, err, "could not load GTFS static feed")
+
+	return s
+}
+
+func departureTimes(departures []transit.Departure) []string {
+	times := []string{}
+	for _, d := range departures {
+		times = append(times, d.StopID+" "+d.ScheduledDeparture.UTC().Format(time.RFC3339))
+	}
+
+	return times
+}
+
+func TestScheduledTrip(t *testing.T) {
+	sched := testSchedule(t)
+
+	t.Run("maps the stop times of the day", func(t *testing.T) {
+		departures := scheduledTrip(sched, "3249_10466", *at("2025-01-21T09:00:00Z"))
+		assert.Equal(t, []string{
+			"8220DB000334 2025-01-21T10:35:30Z",
+			"8220DB000335 2025-01-21T10:37:00Z",
+			"8220DB000336 2025-01-21T10:58:30Z",
+			"8250DB002002 2025-01-21T11:20:00Z",
+		}, departureTimes(departures))
+		assert.Equal(t, transit.Departure{
+			StopID:             "8220DB000334",
+			Source:             "schedule",
+			Operator:           "7778019",
+			Mode:               transit.ModeBus,
+			RouteID:            "3249_46342",
+			TripID:             "3249_10466",
+			Headsign:           "Dún Laoghaire",
+			StopSequence:       12,
+			ScheduledArrival:   *at("2025-01-21T10:35:00Z"),
+			ScheduledDeparture: *at("2025-01-21T10:35:30Z"),
+			Status:             transit.DepartureScheduled,
+		}, withUTC(departures[0]))
+	})
+
+	t.Run("places trips past midnight on the day before", func(t *testing.T) {
+		departures := scheduledTrip(sched, "3249_10467", *at("2025-01-26T00:01:00Z"))
+		assert.Equal(t, []string{
+			"8220DB000334 2025-01-25T23:50:00Z",
+			"8250DB002002 2025-01-26T00:05:00Z",
+		}, departureTimes(departures))
+	})
+
+	t.Run("is empty when the trip does not run", func(t *testing.T) {
+		assert.Empty(t, scheduledTrip(sched, "3249_10467", *at("2025-01-21T09:00:00Z")), "expected weekend trip not to run on a Tuesday")
+		assert.Empty(t, scheduledTrip(sched, "3249_10466", *at("2025-01-21T12:00:00Z")), "expected finished trip not to run")
+		assert.Empty(t, scheduledTrip(sched, "missing", *at("2025-01-21T09:00:00Z")), "expected unknown trip not to run")
+		assert.Empty(t, scheduledTrip(irelandSchedule(), "4456_2", *at("2025-01-21T09:00:00Z")), "expected trip without stop times not to run")
+	})
+
+	t.Run("takes the headsign of a stop time over that of its trip", func(t *testing.T) {
+		ireland := irelandSchedule()
+		ireland.AddStopTime(schedule.StopTime{TripID: "4456_1", StopID: "8220GA00024", StopSequence: 1, Arrival: 9 * 3600, Departure: 9 * 3600, Headsign: "Sandyford"})
+		ireland.Index()
+		departures := scheduledTrip(ireland, "4456_1", *at("2025-01-21T08:00:00Z"))
+		if assert.Len(t, departures, 1) {
+			assert.Equal(t, "Sandyford", departures[0].Headsign)
+		}
+	})
+}
+
+// withUTC converts the times of a departure to UTC so that they can be compared
+func withUTC(d transit.Departure) transit.Departure {
+	d.ScheduledArrival = d.ScheduledArrival.UTC()
+	d.ScheduledDeparture = d.ScheduledDeparture.UTC()
+	if !d.ExpectedArrival.IsZero() {
+		d.ExpectedArrival = d.ExpectedArrival.UTC()
+	}
+	if !d.ExpectedDeparture.IsZero() {
+		d.ExpectedDeparture = d.ExpectedDeparture.UTC()
+	}
+
+	return d
+}
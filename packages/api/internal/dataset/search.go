@@ -0,0 +1,39 @@
+package dataset
+
+import (
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+const (
+	SearchStop  = "stop"
+	SearchRoute = "route"
+)
+
+// SearchResult is a stop or a route matching a search, the higher its score
+// the better it matches
+type SearchResult struct {
+	Type  string         `json:"type" example:"stop" enums:"stop,route"`
+	Score int            `json:"score" example:"8"`
+	Stop  *transit.Stop  `json:"stop,omitempty"`
+	Route *transit.Route `json:"route,omitempty"`
+}
+
+// Search returns the stops and routes of the static timetable best matching a
+// query, best first
+func Search(sched *schedule.Schedule, query string, limit int) []SearchResult {
+	results := []SearchResult{}
+	for _, m := range sched.Search(query, limit) {
+		result := SearchResult{Score: m.Score}
+		if m.Stop != nil {
+			stop, _ := sched.Stop(m.Stop.ID)
+			result.Type, result.Stop = SearchStop, &stop
+		} else {
+			route, _ := sched.Route(m.Route.ID)
+			result.Type, result.Route = SearchRoute, &route
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
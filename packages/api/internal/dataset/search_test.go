@@ -0,0 +1,37 @@
+package dataset
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearch(t *testing.T) {
+	sched := testSchedule(t)
+
+	t.Run("maps matching stops", func(t *testing.T) {
+		results := Search(sched, "Stáisiún Heuston", 10)
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, SearchStop, results[0].Type)
+			assert.Equal(t, "Heuston", results[0].Stop.Name)
+			assert.Nil(t, results[0].Route)
+		}
+	})
+
+	t.Run("maps matching routes", func(t *testing.T) {
+		results := Search(sched, "46a", 10)
+		if assert.Len(t, results, 1) {
+			assert.Equal(t, SearchRoute, results[0].Type)
+			assert.Equal(t, "46A", results[0].Route.ShortName)
+			assert.Nil(t, results[0].Stop)
+		}
+	})
+
+	t.Run("limits the number of results", func(t *testing.T) {
+		assert.Len(t, Search(sched, "heuston", 1), 1)
+	})
+
+	t.Run("returns an empty list without matches", func(t *testing.T) {
+		assert.Equal(t, []SearchResult{}, Search(sched, "belfast", 10))
+	})
+}
@@ -0,0 +1,291 @@
+package dataset
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+var ErrInvalidBBox = errors.New("bbox must be minLon,minLat,maxLon,maxLat")
+
+// BBox is an area bounded by two longitudes and two latitudes, its edges are
+// inside it
+type BBox struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+// ParseBBox parses a bounding box in the form minLon,minLat,maxLon,maxLat
+func ParseBBox(s string) (BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return BBox{}, ErrInvalidBBox
+	}
+
+	var values [4]float64
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return BBox{}, ErrInvalidBBox
+		}
+		values[i] = v
+	}
+
+	b := BBox{MinLon: values[0], MinLat: values[1], MaxLon: values[2], MaxLat: values[3]}
+	if b.MinLon > b.MaxLon || b.MinLat > b.MaxLat ||
+		b.MinLon < -180 || b.MaxLon > 180 || b.MinLat < -90 || b.MaxLat > 90 {
+		return BBox{}, ErrInvalidBBox
+	}
+
+	return b, nil
+}
+
+func (b BBox) Contains(lat, lon float64) bool {
+	return lon >= b.MinLon && lon <= b.MaxLon && lat >= b.MinLat && lat <= b.MaxLat
+}
+
+// VehicleFilter leaves out vehicles outside BBox, or not of the given id,
+// operator, route or mode. Empty fields are not filtered on
+type VehicleFilter struct {
+	BBox       *BBox
+	VehicleID  string
+	OperatorID string
+	RouteID    string
+	Mode       transit.Mode
+}
+
+func (f VehicleFilter) matches(v transit.Vehicle) bool {
+	if f.VehicleID != "" && v.ID != f.VehicleID {
+		return false
+	}
+
+	if f.BBox != nil && !f.BBox.Contains(v.Latitude, v.Longitude) {
+		return false
+	}
+
+	if f.OperatorID != "" && v.Operator != f.OperatorID {
+		return false
+	}
+
+	if f.RouteID != "" && v.RouteID != f.RouteID {
+		return false
+	}
+
+	return f.Mode == "" || v.Mode == f.Mode
+}
+
+// FillVehicles fills in the operator and mode of the vehicles which only carry
+// a route id, such as those of GTFS-Realtime, from the route in the static
+// timetable so they can be filtered on
+func FillVehicles(sched *schedule.Schedule, vehicles []transit.Vehicle) {
+	if sched == nil {
+		return
+	}
+
+	for i := range vehicles {
+		v := &vehicles[i]
+		if v.Operator != "" && v.Mode != "" {
+			continue
+		}
+		r, ok := sched.Routes[v.RouteID]
+		if !ok {
+			continue
+		}
+		if v.Operator == "" {
+			v.Operator = r.AgencyID
+		}
+		if v.Mode == "" {
+			v.Mode = transit.RouteTypeMode(r.Type)
+		}
+	}
+}
+
+// Vehicles returns the latest position of every vehicle matching the filter
+func (d *Dataset) Vehicles(filter VehicleFilter) []transit.Vehicle {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	vehicles := []transit.Vehicle{}
+	for _, v := range d.vehicles {
+		if filter.matches(v) {
+			vehicles = append(vehicles, v)
+		}
+	}
+
+	return vehicles
+}
+
+// NextStop is a stop a vehicle has yet to call at, Stop is only known when a
+// static timetable is loaded
+type NextStop struct {
+	Stop      *transit.Stop     `json:"stop,omitempty"`
+	Departure transit.Departure `json:"departure"`
+}
+
+// VehicleDetail is a vehicle along with the trip it is running, its route and
+// the stops it has yet to call at. The service day is the day of the timetable
+// the trip runs on, the day before for a trip running past midnight
+type VehicleDetail struct {
+	Vehicle    transit.Vehicle `json:"vehicle"`
+	Trip       *transit.Trip   `json:"trip,omitempty"`
+	Route      *transit.Route  `json:"route,omitempty"`
+	ServiceDay string          `json:"service_day,omitempty" example:"2025-01-21"`
+	NextStops  []NextStop      `json:"next_stops"`
+}
+
+// tripDepartures returns the realtime departures of a trip ordered by stop
+// sequence, or by time when the source has no stop sequences. The lock must be
+// held
+func (d *Dataset) tripDepartures(tripID string) []transit.Departure {
+	return append([]transit.Departure{}, d.byTrip[tripID]...)
+}
+
+// upcoming drops the departures a vehicle has already made. When the stop the
+// vehicle is at or heading to is known everything before it is dropped,
+// otherwise anything which departed before now is
+func upcoming(v transit.Vehicle, departures []transit.Departure, now time.Time) []transit.Departure {
+	if v.StopID != "" {
+		for i, dep := range departures {
+			if dep.StopID == v.StopID {
+				return departures[i:]
+			}
+		}
+	}
+
+	next := []transit.Departure{}
+	for _, dep := range departures {
+		if t := dep.Time(); t.IsZero() || !t.Before(now) {
+			next = append(next, dep)
+		}
+	}
+
+	return next
+}
+
+// delayed shifts scheduled departures by the delay of the vehicle running
+// them, which is the best prediction there is without realtime departures
+func delayed(departures []transit.Departure, delay *time.Duration) []transit.Departure {
+	if delay == nil {
+		return departures
+	}
+
+	for i := range departures {
+		dep := &departures[i]
+		dep.ExpectedArrival = dep.ScheduledArrival.Add(*delay)
+		dep.ExpectedDeparture = dep.ScheduledDeparture.Add(*delay)
+		dep.Delay = delay
+	}
+
+	return departures
+}
+
+// VehicleDetails returns the vehicles with the id of a ref, of its source or of
+// any source when it has none, with their trip, route and next stops. The
+// trip and route are taken from the static timetable when it is given, and
+// from the vehicle otherwise. The next stops are the realtime departures of
+// its trip, or its scheduled stops shifted by its delay when there are none
+func (d *Dataset) VehicleDetails(ref VehicleRef, sched *schedule.Schedule) []VehicleDetail {
+	now := d.now()
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	details := []VehicleDetail{}
+	for _, v := range d.vehicles {
+		if v.ID == ref.ID && (ref.Source == "" || v.Source == ref.Source) {
+			details = append(details, d.vehicleDetail(v, sched, now))
+		}
+	}
+
+	return details
+}
+
+// vehicleDetail returns the detail of a vehicle, the lock must be held
+func (d *Dataset) vehicleDetail(v transit.Vehicle, sched *schedule.Schedule, now time.Time) VehicleDetail {
+	detail := VehicleDetail{Vehicle: v, NextStops: []NextStop{}}
+	if v.TripID != "" {
+		trip := transit.Trip{ID: v.TripID, Operator: v.Operator, RouteID: v.RouteID, Headsign: v.Headsign}
+		if sched != nil {
+			if t, ok := sched.Trip(v.TripID); ok {
+				trip = t
+			}
+		}
+		detail.Trip = &trip
+	}
+
+	routeID := v.RouteID
+	if routeID == "" && detail.Trip != nil {
+		routeID = detail.Trip.RouteID
+	}
+	if routeID != "" {
+		route := transit.Route{ID: routeID, Operator: v.Operator, Mode: v.Mode}
+		if sched != nil {
+			if r, ok := sched.Route(routeID); ok {
+				route = r
+			}
+		}
+		detail.Route = &route
+	}
+
+	if v.TripID == "" {
+		return detail
+	}
+	if sched != nil {
+		if day, ok := tripServiceDay(sched, v.TripID, now); ok {
+			detail.ServiceDay = day.Format(time.DateOnly)
+		}
+	}
+
+	departures := d.tripDepartures(v.TripID)
+	if len(departures) == 0 && sched != nil {
+		departures = delayed(scheduledTrip(sched, v.TripID, now), v.Delay)
+	}
+
+	for _, dep := range upcoming(v, departures, now) {
+		next := NextStop{Departure: dep}
+		if sched != nil {
+			if stop, ok := sched.Stop(dep.StopID); ok {
+				next.Stop = &stop
+			}
+		}
+		detail.NextStops = append(detail.NextStops, next)
+	}
+
+	return detail
+}
+
+// VehicleJourneys returns the detail of every vehicle matching the filter with
+// only the next stops it is expected at within preview of now, a preview of
+// zero leaves out every next stop
+func (d *Dataset) VehicleJourneys(filter VehicleFilter, sched *schedule.Schedule, preview time.Duration) []VehicleDetail {
+	now := d.now()
+	until := now.Add(preview)
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	journeys := []VehicleDetail{}
+	for _, v := range d.vehicles {
+		if !filter.matches(v) {
+			continue
+		}
+
+		detail := d.vehicleDetail(v, sched, now)
+		next := []NextStop{}
+		for _, stop := range detail.NextStops {
+			if t := stop.Departure.Time(); preview > 0 && !t.IsZero() && !t.After(until) {
+				next = append(next, stop)
+			}
+		}
+		detail.NextStops = next
+		journeys = append(journeys, detail)
+	}
+
+	return journeys
+}
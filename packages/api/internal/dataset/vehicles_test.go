@@ -0,0 +1,264 @@
+package dataset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+var testVehicles = []transit.Vehicle{
+	{ID: "V1", Operator: "7778019", Mode: transit.ModeBus, RouteID: "3249_46342", Latitude: 53.3498, Longitude: -6.2603},
+	{ID: "V2", Operator: "7778019", Mode: transit.ModeBus, RouteID: "3249_46350", Latitude: 53.2707, Longitude: -9.0568},
+	{ID: "E109", Operator: "irishrail", Mode: transit.ModeRail, Latitude: 53.3531, Longitude: -6.2461},
+}
+
+func vehicleIDs(vehicles []transit.Vehicle) []string {
+	ids := []string{}
+	for _, v := range vehicles {
+		ids = append(ids, v.ID)
+	}
+
+	return ids
+}
+
+func TestParseBBox(t *testing.T) {
+	t.Run("parses the corners", func(t *testing.T) {
+		b, err := ParseBBox("-6.4, 53.2,-6.1,53.5")
+		assert.NoError(t, err)
+		assert.Equal(t, BBox{MinLon: -6.4, MinLat: 53.2, MaxLon: -6.1, MaxLat: 53.5}, b)
+	})
+
+	for _, s := range []string{"", "-6.4,53.2,-6.1", "a,53.2,-6.1,53.5", "-6.1,53.2,-6.4,53.5", "-6.4,53.5,-6.1,53.2", "-190,53.2,-6.1,53.5", "-6.4,53.2,-6.1,95"} {
+		t.Run("rejects "+s, func(t *testing.T) {
+			_, err := ParseBBox(s)
+			assert.ErrorIs(t, err, ErrInvalidBBox)
+		})
+	}
+}
+
+func TestVehicles(t *testing.T) {
+	d := New()
+	d.Set(transit.Dataset{Vehicles: testVehicles})
+
+	t.Run("returns every vehicle", func(t *testing.T) {
+		assert.Equal(t, []string{"V1", "V2", "E109"}, vehicleIDs(d.Vehicles(VehicleFilter{})))
+	})
+
+	t.Run("returns an empty list without vehicles", func(t *testing.T) {
+		assert.Equal(t, []transit.Vehicle{}, New().Vehicles(VehicleFilter{}))
+	})
+
+	t.Run("filters on bounding box", func(t *testing.T) {
+		dublin := BBox{MinLon: -6.4, MinLat: 53.2, MaxLon: -6.1, MaxLat: 53.5}
+		assert.Equal(t, []string{"V1", "E109"}, vehicleIDs(d.Vehicles(VehicleFilter{BBox: &dublin})))
+	})
+
+	t.Run("filters on vehicle", func(t *testing.T) {
+		assert.Equal(t, []string{"V2"}, vehicleIDs(d.Vehicles(VehicleFilter{VehicleID: "V2"})))
+	})
+
+	t.Run("filters on operator", func(t *testing.T) {
+		assert.Equal(t, []string{"E109"}, vehicleIDs(d.Vehicles(VehicleFilter{OperatorID: "irishrail"})))
+	})
+
+	t.Run("filters on route", func(t *testing.T) {
+		assert.Equal(t, []string{"V2"}, vehicleIDs(d.Vehicles(VehicleFilter{RouteID: "3249_46350"})))
+	})
+
+	t.Run("filters on mode", func(t *testing.T) {
+		assert.Equal(t, []string{"E109"}, vehicleIDs(d.Vehicles(VehicleFilter{Mode: transit.ModeRail})))
+	})
+
+	t.Run("combines filters", func(t *testing.T) {
+		dublin := BBox{MinLon: -6.4, MinLat: 53.2, MaxLon: -6.1, MaxLat: 53.5}
+		filter := VehicleFilter{BBox: &dublin, Mode: transit.ModeBus}
+		assert.Equal(t, []string{"V1"}, vehicleIDs(d.Vehicles(filter)))
+	})
+}
+
+func TestFillVehicles(t *testing.T) {
+	t.Run("fills the operator and mode of a vehicle from its route", func(t *testing.T) {
+		vehicles := []transit.Vehicle{
+			{ID: "1", Source: "gtfsr", RouteID: "3249_46342"},
+			{ID: "E109", Operator: "irishrail", Mode: transit.ModeRail},
+			{ID: "2", Source: "gtfsr", RouteID: "unknown"},
+		}
+		FillVehicles(testSchedule(t), vehicles)
+		assert.Equal(t, "7778019", vehicles[0].Operator)
+		assert.Equal(t, transit.ModeBus, vehicles[0].Mode)
+		assert.Equal(t, "irishrail", vehicles[1].Operator, "expected vehicle with an operator to be left alone")
+		assert.Empty(t, vehicles[2].Operator, "expected vehicle of an unknown route to be left alone")
+
+		d := New()
+		d.Set(transit.Dataset{Vehicles: vehicles})
+		assert.Equal(t, []string{"1"}, vehicleIDs(d.Vehicles(VehicleFilter{OperatorID: "7778019", Mode: transit.ModeBus})))
+	})
+
+	t.Run("leaves the vehicles without a static timetable", func(t *testing.T) {
+		vehicles := []transit.Vehicle{{ID: "1", RouteID: "3249_46342"}}
+		FillVehicles(nil, vehicles)
+		assert.Equal(t, []transit.Vehicle{{ID: "1", RouteID: "3249_46342"}}, vehicles)
+	})
+}
+
+func TestVehicleDetails(t *testing.T) {
+	delay := 2 * time.Minute
+	bus := transit.Vehicle{
+		ID:        "V1",
+		Operator:  "7778019",
+		Mode:      transit.ModeBus,
+		RouteID:   "3249_46342",
+		TripID:    "3249_10466",
+		Latitude:  53.3498,
+		Longitude: -6.2603,
+		Delay:     &delay,
+	}
+	departures := []transit.Departure{
+		{StopID: "8220DB000335", TripID: "3249_10466", StopSequence: 13, ExpectedDeparture: *at("2025-01-21T10:39:00Z")},
+		{StopID: "8220DB000334", TripID: "3249_10466", StopSequence: 12, ExpectedDeparture: *at("2025-01-21T10:37:30Z")},
+		{StopID: "8250DB002002", TripID: "3249_10466", StopSequence: 15, ExpectedDeparture: *at("2025-01-21T11:22:00Z")},
+		{StopID: "8220DB000334", TripID: "3249_10511", StopSequence: 1, ExpectedDeparture: *at("2025-01-21T10:40:00Z")},
+	}
+
+	dataset := func(vehicles ...transit.Vehicle) *Dataset {
+		d := New()
+		d.now = func() time.Time { return *at("2025-01-21T10:38:00Z") }
+		d.Set(transit.Dataset{Vehicles: vehicles, Departures: departures})
+
+		return d
+	}
+
+	stopIDs := func(next []NextStop) []string {
+		ids := []string{}
+		for _, n := range next {
+			ids = append(ids, n.Departure.StopID)
+		}
+
+		return ids
+	}
+
+	detailOf := func(t *testing.T, d *Dataset, id string, sched *schedule.Schedule) VehicleDetail {
+		details := d.VehicleDetails(VehicleRef{ID: id}, sched)
+		assert.Len(t, details, 1, "expected one vehicle with the id")
+		if len(details) == 0 {
+			return VehicleDetail{}
+		}
+
+		return details[0]
+	}
+
+	t.Run("is not found for an unknown vehicle", func(t *testing.T) {
+		assert.Empty(t, dataset(bus).VehicleDetails(VehicleRef{ID: "V9"}, nil))
+	})
+
+	t.Run("returns every vehicle with the id without a source", func(t *testing.T) {
+		train := transit.Vehicle{ID: "V1", Source: "irishrail", Operator: "irishrail", Mode: transit.ModeRail}
+		details := dataset(bus, train).VehicleDetails(VehicleRef{ID: "V1"}, nil)
+		if assert.Len(t, details, 2) {
+			assert.Equal(t, bus, details[0].Vehicle)
+			assert.Equal(t, train, details[1].Vehicle)
+		}
+
+		details = dataset(bus, train).VehicleDetails(VehicleRef{Source: "irishrail", ID: "V1"}, nil)
+		if assert.Len(t, details, 1) {
+			assert.Equal(t, train, details[0].Vehicle, "expected only the vehicle of the source")
+		}
+	})
+
+	t.Run("takes the trip and route from the vehicle without a timetable", func(t *testing.T) {
+		detail := detailOf(t, dataset(bus), "V1", nil)
+		assert.Equal(t, bus, detail.Vehicle)
+		assert.Equal(t, &transit.Trip{ID: "3249_10466", Operator: "7778019", RouteID: "3249_46342"}, detail.Trip)
+		assert.Equal(t, &transit.Route{ID: "3249_46342", Operator: "7778019", Mode: transit.ModeBus}, detail.Route)
+	})
+
+	t.Run("lists the realtime departures yet to be made in order", func(t *testing.T) {
+		detail := detailOf(t, dataset(bus), "V1", nil)
+		assert.Equal(t, []string{"8220DB000335", "8250DB002002"}, stopIDs(detail.NextStops))
+		assert.Nil(t, detail.NextStops[0].Stop, "expected no stop without a timetable")
+	})
+
+	t.Run("lists from the stop the vehicle is heading to", func(t *testing.T) {
+		heading := bus
+		heading.StopID = "8220DB000334"
+		detail := detailOf(t, dataset(heading), "V1", nil)
+		assert.Equal(t, []string{"8220DB000334", "8220DB000335", "8250DB002002"}, stopIDs(detail.NextStops))
+	})
+
+	t.Run("takes the trip, route and stops from the timetable", func(t *testing.T) {
+		detail := detailOf(t, dataset(bus), "V1", testSchedule(t))
+		assert.Equal(t, "Dún Laoghaire", detail.Trip.Headsign)
+		assert.Equal(t, "46A", detail.Route.ShortName)
+		if assert.NotNil(t, detail.NextStops[0].Stop) {
+			assert.Equal(t, "8220DB000335", detail.NextStops[0].Stop.ID)
+		}
+	})
+
+	t.Run("takes the service day of the trip from the timetable", func(t *testing.T) {
+		detail := detailOf(t, dataset(bus), "V1", testSchedule(t))
+		assert.Equal(t, "2025-01-21", detail.ServiceDay)
+		assert.Empty(t, detailOf(t, dataset(bus), "V1", nil).ServiceDay, "expected no service day without a timetable")
+		unknown := transit.Vehicle{ID: "V4", TripID: "3249_99999"}
+		assert.Empty(t, detailOf(t, dataset(unknown), "V4", testSchedule(t)).ServiceDay, "expected no service day for a trip the timetable does not have")
+
+		late := transit.Vehicle{ID: "V3", Operator: "7778019", Mode: transit.ModeBus, TripID: "3249_10467"}
+		d := New()
+		d.now = func() time.Time { return *at("2025-01-26T00:02:00Z") }
+		d.Set(transit.Dataset{Vehicles: []transit.Vehicle{late}})
+		detail = detailOf(t, d, "V3", testSchedule(t))
+		assert.Equal(t, "2025-01-25", detail.ServiceDay, "expected a trip running past midnight to be of the day before")
+	})
+
+	t.Run("delays the scheduled stops without realtime departures", func(t *testing.T) {
+		d := dataset(bus)
+		d.Set(transit.Dataset{Vehicles: []transit.Vehicle{bus}})
+		detail := detailOf(t, d, "V1", testSchedule(t))
+
+		assert.Equal(t, []string{"8220DB000335", "8220DB000336", "8250DB002002"}, stopIDs(detail.NextStops))
+		next := withUTC(detail.NextStops[0].Departure)
+		assert.Equal(t, *at("2025-01-21T10:37:00Z"), next.ScheduledDeparture)
+		assert.Equal(t, *at("2025-01-21T10:39:00Z"), next.ExpectedDeparture)
+		assert.Equal(t, &delay, next.Delay)
+	})
+
+	t.Run("previews the next stops within an interval of every vehicle", func(t *testing.T) {
+		train := transit.Vehicle{ID: "E109", Operator: "irishrail", Mode: transit.ModeRail}
+		d := dataset(bus, train)
+
+		journeys := d.VehicleJourneys(VehicleFilter{}, nil, 5*time.Minute)
+		if assert.Len(t, journeys, 2) {
+			assert.Equal(t, "V1", journeys[0].Vehicle.ID)
+			assert.Equal(t, []string{"8220DB000335"}, stopIDs(journeys[0].NextStops), "expected stops after the interval to be left out")
+			assert.Equal(t, "3249_46342", journeys[0].Route.ID)
+			assert.Equal(t, []NextStop{}, journeys[1].NextStops)
+		}
+
+		journeys = d.VehicleJourneys(VehicleFilter{VehicleID: "V1"}, nil, 0)
+		if assert.Len(t, journeys, 1) {
+			assert.Equal(t, []NextStop{}, journeys[0].NextStops, "expected no next stops without an interval")
+		}
+	})
+
+	t.Run("previews each vehicle sharing an id with one of another source", func(t *testing.T) {
+		train := transit.Vehicle{ID: "V1", Source: "irishrail", Operator: "irishrail", Mode: transit.ModeRail}
+		journeys := dataset(bus, train).VehicleJourneys(VehicleFilter{}, nil, 5*time.Minute)
+		if assert.Len(t, journeys, 2) {
+			assert.Equal(t, bus, journeys[0].Vehicle)
+			assert.Equal(t, []string{"8220DB000335"}, stopIDs(journeys[0].NextStops))
+			assert.Equal(t, train, journeys[1].Vehicle, "expected the train rather than the bus with its id")
+			assert.Nil(t, journeys[1].Trip)
+			assert.Equal(t, []NextStop{}, journeys[1].NextStops)
+		}
+	})
+
+	t.Run("has no next stops without a trip", func(t *testing.T) {
+		train := transit.Vehicle{ID: "E109", Operator: "irishrail", Mode: transit.ModeRail}
+		detail := detailOf(t, dataset(train), "E109", nil)
+		assert.Nil(t, detail.Trip)
+		assert.Nil(t, detail.Route)
+		assert.Equal(t, []NextStop{}, detail.NextStops)
+	})
+}
@@ -0,0 +1,124 @@
+// Package geojson turns the responses of the API into GeoJSON feature
+// collections (RFC 7946) whose properties are the fields of the JSON responses
+package geojson
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
+)
+
+const MIME = "application/geo+json"
+
+// Geometry is a Point of a longitude and latitude or a LineString of them
+type Geometry struct {
+	Type        string `json:"type" example:"Point"`
+	Coordinates any    `json:"coordinates" swaggertype:"array,number" example:"-6.2603,53.3498"`
+}
+
+type Feature struct {
+	Type       string         `json:"type" example:"Feature"`
+	ID         string         `json:"id,omitempty" example:"V1"`
+	Geometry   *Geometry      `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type FeatureCollection struct {
+	Type     string    `json:"type" example:"FeatureCollection"`
+	Features []Feature `json:"features"`
+}
+
+func point(lat, lon float64) *Geometry {
+	return &Geometry{Type: "Point", Coordinates: []float64{lon, lat}}
+}
+
+func lineString(coordinates [][]float64) *Geometry {
+	return &Geometry{Type: "LineString", Coordinates: coordinates}
+}
+
+// properties returns the fields v is marshalled to. The fields of the object
+// under the key flatten, if given, are moved up into the properties so that
+// the main object of a detail response is not nested
+func properties(v any, flatten string) (map[string]any, error) {
+	props := map[string]any{}
+	b, err := json.Marshal(v)
+	if err == nil {
+		err = json.Unmarshal(b, &props)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not turn %T into properties: %w", v, err)
+	}
+
+	if nested, ok := props[flatten].(map[string]any); ok {
+		delete(props, flatten)
+		for k, v := range nested {
+			props[k] = v
+		}
+	}
+
+	return props, nil
+}
+
+// feature is what a feature is made of before its properties are marshalled
+type feature struct {
+	id       string
+	geometry *Geometry
+	v        any
+	flatten  string
+}
+
+// Encode turns vehicles, stops and the shapes of a route into a feature
+// collection. Vehicles and stops are points and a route is a line string for
+// each of its shapes with the patterns following it
+func Encode(data any) (FeatureCollection, error) {
+	var features []feature
+	switch data := data.(type) {
+	case []transit.Vehicle:
+		for _, v := range data {
+			features = append(features, feature{v.ID, point(v.Latitude, v.Longitude), v, ""})
+		}
+	case dataset.VehicleDetail:
+		features = append(features, feature{data.Vehicle.ID, point(data.Vehicle.Latitude, data.Vehicle.Longitude), data, "vehicle"})
+	case []transit.Stop:
+		for _, s := range data {
+			features = append(features, feature{s.ID, point(s.Latitude, s.Longitude), s, ""})
+		}
+	case dataset.StopDetail:
+		features = append(features, feature{data.Stop.ID, point(data.Stop.Latitude, data.Stop.Longitude), data, "stop"})
+	case []dataset.NearbyStop:
+		for _, s := range data {
+			features = append(features, feature{s.Stop.ID, point(s.Stop.Latitude, s.Stop.Longitude), s, "stop"})
+		}
+	case dataset.RouteDetail:
+		for _, shape := range data.Shapes {
+			patterns := []transit.StopPattern{}
+			for _, p := range data.Patterns {
+				if p.ShapeID == shape.ID {
+					patterns = append(patterns, p)
+				}
+			}
+
+			v := struct {
+				Route    transit.Route         `json:"route"`
+				ShapeID  string                `json:"shape_id"`
+				Patterns []transit.StopPattern `json:"patterns"`
+			}{data.Route, shape.ID, patterns}
+			features = append(features, feature{shape.ID, lineString(shape.Coordinates), v, "route"})
+		}
+	default:
+		return FeatureCollection{}, fmt.Errorf("could not encode %T as geojson", data)
+	}
+
+	fc := FeatureCollection{Type: "FeatureCollection", Features: make([]Feature, 0, len(features))}
+	for _, f := range features {
+		props, err := properties(f.v, f.flatten)
+		if err != nil {
+			return FeatureCollection{}, err
+		}
+		fc.Features = append(fc.Features, Feature{Type: "Feature", ID: f.id, Geometry: f.geometry, Properties: props})
+	}
+
+	return fc, nil
+}
@@ -0,0 +1,142 @@
+package geojson
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
+	"github.com/stretchr/testify/assert"
+)
+
+func encoded(t *testing.T, data any) string {
+	t.Helper()
+	fc, err := Encode(data)
+	assert.NoError(t, err, "could not encode")
+	b, err := json.Marshal(fc)
+	assert.NoError(t, err, "could not marshal")
+
+	return string(b)
+}
+
+func TestEncode(t *testing.T) {
+	delay := 2 * time.Minute
+	vehicle := transit.Vehicle{
+		ID:        "V1",
+		Source:    "gtfsr",
+		Operator:  "7778019",
+		Mode:      transit.ModeBus,
+		RouteID:   "3249_46342",
+		Latitude:  53.3498,
+		Longitude: -6.2603,
+		Delay:     &delay,
+		Timestamp: time.Date(2025, 1, 21, 12, 0, 0, 0, time.UTC),
+	}
+	stop := transit.Stop{ID: "8220DB000334", Code: "334", Name: "Parnell Square West, stop 2", Latitude: 53.3531, Longitude: -6.2649}
+	route := transit.Route{ID: "3249_46342", Operator: "7778019", ShortName: "46A", Mode: transit.ModeBus}
+
+	t.Run("encodes vehicles as points with their fields", func(t *testing.T) {
+		assert.JSONEq(t, `{
+			"type": "FeatureCollection",
+			"features": [{
+				"type": "Feature",
+				"id": "V1",
+				"geometry": {"type": "Point", "coordinates": [-6.2603, 53.3498]},
+				"properties": {
+					"id": "V1",
+					"source": "gtfsr",
+					"operator": "7778019",
+					"mode": "bus",
+					"route_id": "3249_46342",
+					"latitude": 53.3498,
+					"longitude": -6.2603,
+					"delay": 120,
+					"timestamp": "2025-01-21T12:00:00Z"
+				}
+			}]
+		}`, encoded(t, []transit.Vehicle{vehicle}))
+	})
+
+	t.Run("encodes nothing as an empty collection", func(t *testing.T) {
+		assert.JSONEq(t, `{"type": "FeatureCollection", "features": []}`, encoded(t, []transit.Vehicle{}))
+	})
+
+	t.Run("moves the fields of the vehicle of a detail up into the properties", func(t *testing.T) {
+		detail := dataset.VehicleDetail{Vehicle: vehicle, Route: &route, NextStops: []dataset.NextStop{}}
+		fc, err := Encode(detail)
+		assert.NoError(t, err, "could not encode")
+		if assert.Len(t, fc.Features, 1) {
+			props := fc.Features[0].Properties
+			assert.Equal(t, "V1", props["id"])
+			assert.Equal(t, "46A", props["route"].(map[string]any)["short_name"])
+			assert.Equal(t, []any{}, props["next_stops"])
+			assert.NotContains(t, props, "vehicle")
+		}
+	})
+
+	t.Run("encodes stops as points", func(t *testing.T) {
+		fc, err := Encode([]transit.Stop{stop})
+		assert.NoError(t, err, "could not encode")
+		if assert.Len(t, fc.Features, 1) {
+			assert.Equal(t, &Geometry{Type: "Point", Coordinates: []float64{-6.2649, 53.3531}}, fc.Features[0].Geometry)
+			assert.Equal(t, "Parnell Square West, stop 2", fc.Features[0].Properties["name"])
+		}
+
+		fc, err = Encode(dataset.StopDetail{Stop: stop, Platforms: []transit.Stop{}, Routes: []transit.Route{route}})
+		assert.NoError(t, err, "could not encode")
+		if assert.Len(t, fc.Features, 1) {
+			assert.Equal(t, "334", fc.Features[0].Properties["code"])
+			assert.Len(t, fc.Features[0].Properties["routes"], 1)
+		}
+	})
+
+	t.Run("encodes nearby stops with their distance", func(t *testing.T) {
+		fc, err := Encode([]dataset.NearbyStop{{Stop: stop, Distance: 120, Routes: []transit.Route{}, Departures: []transit.Departure{}}})
+		assert.NoError(t, err, "could not encode")
+		if assert.Len(t, fc.Features, 1) {
+			assert.Equal(t, "8220DB000334", fc.Features[0].ID)
+			assert.Equal(t, 120.0, fc.Features[0].Properties["distance"])
+		}
+	})
+
+	t.Run("encodes the shapes of a route as line strings with the patterns following them", func(t *testing.T) {
+		detail := dataset.RouteDetail{
+			Route:  route,
+			Shapes: []transit.Shape{{ID: "3249_46342_1", Coordinates: [][]float64{{-6.2649, 53.3531}, {-6.2603, 53.3498}}}},
+			Patterns: []transit.StopPattern{
+				{ID: "3249_46342:0:1", ShapeID: "3249_46342_1", StopIDs: []string{"8220DB000334"}, Trips: 2},
+				{ID: "3249_46342:1:1", DirectionID: 1, StopIDs: []string{"8220DB000335"}, Trips: 1},
+			},
+		}
+		assert.JSONEq(t, `{
+			"type": "FeatureCollection",
+			"features": [{
+				"type": "Feature",
+				"id": "3249_46342_1",
+				"geometry": {"type": "LineString", "coordinates": [[-6.2649, 53.3531], [-6.2603, 53.3498]]},
+				"properties": {
+					"id": "3249_46342",
+					"operator": "7778019",
+					"short_name": "46A",
+					"mode": "bus",
+					"shape_id": "3249_46342_1",
+					"patterns": [{"id": "3249_46342:0:1", "direction_id": 0, "shape_id": "3249_46342_1", "stop_ids": ["8220DB000334"], "trips": 2}]
+				}
+			}]
+		}`, encoded(t, detail))
+	})
+
+	t.Run("fails for fields which cannot be marshalled", func(t *testing.T) {
+		lost := vehicle
+		lost.Latitude = math.NaN()
+		_, err := Encode([]transit.Vehicle{vehicle, lost})
+		assert.EqualError(t, err, "could not turn transit.Vehicle into properties: json: error calling MarshalJSON for type *transit.Vehicle: json: unsupported value: NaN")
+	})
+
+	t.Run("fails for anything else", func(t *testing.T) {
+		_, err := Encode([]transit.Alert{})
+		assert.EqualError(t, err, "could not encode []transit.Alert as geojson")
+	})
+}
@@ -0,0 +1,197 @@
+// Package protobuf encodes the vehicles, departures and alerts responses of
+// the API as the messages of the published schema in v0.proto
+package protobuf
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const MIME = "application/x-protobuf"
+
+// Schema is the schema the responses are encoded in
+//
+//go:embed v0.proto
+var Schema []byte
+
+// message is an encoded message which fields are appended to. Like proto3 the
+// fields holding their zero value are left out unless they are optional
+type message []byte
+
+func (m *message) string(num protowire.Number, s string) {
+	if s == "" {
+		return
+	}
+	*m = protowire.AppendTag(*m, num, protowire.BytesType)
+	*m = protowire.AppendString(*m, s)
+}
+
+func (m *message) int64(num protowire.Number, v int64) {
+	if v == 0 {
+		return
+	}
+	*m = protowire.AppendTag(*m, num, protowire.VarintType)
+	*m = protowire.AppendVarint(*m, uint64(v))
+}
+
+func (m *message) optionalInt32(num protowire.Number, v *int) {
+	if v == nil {
+		return
+	}
+	*m = protowire.AppendTag(*m, num, protowire.VarintType)
+	*m = protowire.AppendVarint(*m, uint64(int32(*v)))
+}
+
+func (m *message) bool(num protowire.Number, v bool) {
+	if !v {
+		return
+	}
+	*m = protowire.AppendTag(*m, num, protowire.VarintType)
+	*m = protowire.AppendVarint(*m, protowire.EncodeBool(v))
+}
+
+func (m *message) double(num protowire.Number, v float64) {
+	if v == 0 {
+		return
+	}
+	m.optionalDouble(num, &v)
+}
+
+func (m *message) optionalDouble(num protowire.Number, v *float64) {
+	if v == nil {
+		return
+	}
+	*m = protowire.AppendTag(*m, num, protowire.Fixed64Type)
+	*m = protowire.AppendFixed64(*m, math.Float64bits(*v))
+}
+
+// time appends a time as seconds since the Unix epoch
+func (m *message) time(num protowire.Number, t time.Time) {
+	if t.IsZero() {
+		return
+	}
+	m.int64(num, t.Unix())
+}
+
+// delay appends an optional duration as zigzag encoded seconds
+func (m *message) delay(num protowire.Number, d *time.Duration) {
+	if d == nil {
+		return
+	}
+	*m = protowire.AppendTag(*m, num, protowire.VarintType)
+	*m = protowire.AppendVarint(*m, protowire.EncodeZigZag(int64(d.Round(time.Second)/time.Second)))
+}
+
+func (m *message) message(num protowire.Number, fields func(m *message)) {
+	var embedded message
+	fields(&embedded)
+	*m = protowire.AppendTag(*m, num, protowire.BytesType)
+	*m = protowire.AppendBytes(*m, embedded)
+}
+
+func vehicle(m *message, v transit.Vehicle) {
+	m.string(1, v.ID)
+	m.string(2, v.Source)
+	m.string(3, v.Operator)
+	m.string(4, string(v.Mode))
+	m.string(5, v.RouteID)
+	m.string(6, v.TripID)
+	m.string(7, v.Label)
+	m.string(8, v.Headsign)
+	m.double(9, v.Latitude)
+	m.double(10, v.Longitude)
+	m.optionalDouble(11, v.Bearing)
+	m.optionalDouble(12, v.Speed)
+	m.string(13, string(v.Status))
+	m.string(14, v.StopID)
+	m.delay(15, v.Delay)
+	m.time(16, v.Timestamp)
+}
+
+func departure(m *message, d transit.Departure) {
+	m.string(1, d.StopID)
+	m.string(2, d.Source)
+	m.string(3, d.Operator)
+	m.string(4, string(d.Mode))
+	m.string(5, d.RouteID)
+	m.string(6, d.TripID)
+	m.string(7, d.VehicleID)
+	m.string(8, d.Headsign)
+	m.string(9, d.Direction)
+	m.string(10, d.Platform)
+	m.int64(11, int64(d.StopSequence))
+	m.time(12, d.ScheduledArrival)
+	m.time(13, d.ScheduledDeparture)
+	m.time(14, d.ExpectedArrival)
+	m.time(15, d.ExpectedDeparture)
+	m.delay(16, d.Delay)
+	m.string(17, string(d.Status))
+	m.bool(18, d.Realtime)
+}
+
+func translations(m *message, num protowire.Number, translations []transit.Translation) {
+	for _, t := range translations {
+		m.message(num, func(m *message) {
+			m.string(1, t.Text)
+			m.string(2, t.Language)
+		})
+	}
+}
+
+func alert(m *message, a transit.Alert) {
+	m.string(1, a.ID)
+	for _, p := range a.ActivePeriods {
+		m.message(2, func(m *message) {
+			if p.Start != nil {
+				m.time(1, *p.Start)
+			}
+			if p.End != nil {
+				m.time(2, *p.End)
+			}
+		})
+	}
+	for _, e := range a.InformedEntities {
+		m.message(3, func(m *message) {
+			m.string(1, e.OperatorID)
+			m.string(2, e.RouteID)
+			m.optionalInt32(3, e.RouteType)
+			m.string(4, e.TripID)
+			m.string(5, e.StopID)
+			m.optionalInt32(6, e.DirectionID)
+		})
+	}
+	m.string(4, a.Cause)
+	m.string(5, a.Effect)
+	translations(m, 6, a.URL)
+	translations(m, 7, a.HeaderText)
+	translations(m, 8, a.DescriptionText)
+}
+
+// Encode encodes vehicles as a Vehicles message, departures as a Departures
+// message and alerts as an Alerts message
+func Encode(data any) ([]byte, error) {
+	var m message
+	switch data := data.(type) {
+	case []transit.Vehicle:
+		for _, v := range data {
+			m.message(1, func(m *message) { vehicle(m, v) })
+		}
+	case []transit.Departure:
+		for _, d := range data {
+			m.message(1, func(m *message) { departure(m, d) })
+		}
+	case []transit.Alert:
+		for _, a := range data {
+			m.message(1, func(m *message) { alert(m, a) })
+		}
+	default:
+		return nil, fmt.Errorf("could not encode %T as protobuf", data)
+	}
+
+	return m, nil
+}
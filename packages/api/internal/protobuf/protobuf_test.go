@@ -0,0 +1,133 @@
+package protobuf
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func encString(num protowire.Number, s string) []byte {
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+
+	return protowire.AppendString(b, s)
+}
+
+func encVarint(num protowire.Number, v uint64) []byte {
+	b := protowire.AppendTag(nil, num, protowire.VarintType)
+
+	return protowire.AppendVarint(b, v)
+}
+
+func encFloat64(num protowire.Number, v float64) []byte {
+	b := protowire.AppendTag(nil, num, protowire.Fixed64Type)
+
+	return protowire.AppendFixed64(b, math.Float64bits(v))
+}
+
+func encEmbedded(num protowire.Number, fields ...[]byte) []byte {
+	b := protowire.AppendTag(nil, num, protowire.BytesType)
+
+	return protowire.AppendBytes(b, bytes.Join(fields, nil))
+}
+
+func TestEncode(t *testing.T) {
+	timestamp := time.Date(2025, 1, 21, 12, 0, 0, 0, time.UTC)
+
+	t.Run("encodes vehicles", func(t *testing.T) {
+		bearing := 0.0
+		early := -90 * time.Second
+		b, err := Encode([]transit.Vehicle{{
+			ID:        "V1",
+			Source:    "gtfsr",
+			Mode:      transit.ModeBus,
+			Latitude:  53.3498,
+			Longitude: -6.2603,
+			Bearing:   &bearing,
+			Status:    transit.VehicleInTransit,
+			Delay:     &early,
+			Timestamp: timestamp,
+		}, {ID: "V2"}})
+		assert.NoError(t, err, "could not encode")
+		assert.Equal(t, bytes.Join([][]byte{
+			encEmbedded(1,
+				encString(1, "V1"),
+				encString(2, "gtfsr"),
+				encString(4, "bus"),
+				encFloat64(9, 53.3498),
+				encFloat64(10, -6.2603),
+				encFloat64(11, 0),
+				encString(13, "in_transit_to"),
+				encVarint(15, protowire.EncodeZigZag(-90)),
+				encVarint(16, uint64(timestamp.Unix())),
+			),
+			encEmbedded(1, encString(1, "V2")),
+		}, nil), b, "expected zero values to be left out unless optional")
+	})
+
+	t.Run("encodes departures", func(t *testing.T) {
+		b, err := Encode([]transit.Departure{{
+			StopID:            "8220DB000334",
+			Source:            "gtfsr",
+			TripID:            "3249_10466",
+			StopSequence:      12,
+			ScheduledArrival:  timestamp,
+			ExpectedDeparture: timestamp.Add(2 * time.Minute),
+			Status:            transit.DepartureScheduled,
+			Realtime:          true,
+		}, {StopID: "LUAS24"}})
+		assert.NoError(t, err, "could not encode")
+		assert.Equal(t, bytes.Join([][]byte{encEmbedded(1,
+			encString(1, "8220DB000334"),
+			encString(2, "gtfsr"),
+			encString(6, "3249_10466"),
+			encVarint(11, 12),
+			encVarint(12, uint64(timestamp.Unix())),
+			encVarint(15, uint64(timestamp.Unix()+120)),
+			encString(17, "scheduled"),
+			encVarint(18, 1),
+		), encEmbedded(1, encString(1, "LUAS24"))}, nil), b, "expected a departure without a stop sequence or realtime data to leave them out")
+	})
+
+	t.Run("encodes alerts", func(t *testing.T) {
+		routeType, end := 3, timestamp.Add(time.Hour)
+		b, err := Encode([]transit.Alert{{
+			ID:               "A1",
+			ActivePeriods:    []transit.ActivePeriod{{Start: &timestamp}, {End: &end}},
+			InformedEntities: []transit.InformedEntity{{RouteID: "3249_46342", RouteType: &routeType}},
+			Effect:           "DETOUR",
+			HeaderText:       []transit.Translation{{Text: "Route 46A diverted", Language: "en"}},
+		}})
+		assert.NoError(t, err, "could not encode")
+		assert.Equal(t, encEmbedded(1,
+			encString(1, "A1"),
+			encEmbedded(2, encVarint(1, uint64(timestamp.Unix()))),
+			encEmbedded(2, encVarint(2, uint64(end.Unix()))),
+			encEmbedded(3, encString(2, "3249_46342"), encVarint(3, 3)),
+			encString(5, "DETOUR"),
+			encEmbedded(7, encString(1, "Route 46A diverted"), encString(2, "en")),
+		), b)
+	})
+
+	t.Run("encodes nothing as an empty message", func(t *testing.T) {
+		b, err := Encode([]transit.Alert{})
+		assert.NoError(t, err, "could not encode")
+		assert.Empty(t, b)
+	})
+
+	t.Run("fails for anything else", func(t *testing.T) {
+		_, err := Encode([]transit.Stop{})
+		assert.EqualError(t, err, "could not encode []transit.Stop as protobuf")
+	})
+}
+
+func TestSchema(t *testing.T) {
+	assert.Contains(t, string(Schema), "package wheresmylift.v0;")
+	for _, m := range []string{"message Vehicles", "message Departures", "message Alerts"} {
+		assert.Contains(t, string(Schema), m)
+	}
+}
@@ -2,11 +2,17 @@ package server
 
 import (
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mcgovman/wheresmylift/lib/handoff"
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,4 +43,627 @@ func TestV0HealthCheckGet(t *testing.T) {
 
 		assert.Equal(t, http.StatusNoContent, w.Code, "expected status 204 from endpoint")
 	})
+
+	t.Run("unhealthy while the aggregator is not connected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ctx, engine := gin.CreateTestContext(w)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "/v0/healthcheck", new(bytes.Buffer))
+		assert.NoError(t, err, "could not create http request")
+		s := &Server{Subscriber: handoff.NewSubscriber("http://localhost/v0/handoff", nil)}
+		engine.GET("/v0/healthcheck", s.V0HealthCheckGet)
+		engine.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, "expected status 503 from endpoint")
+		assert.JSONEq(t, `{"error": "not connected to the aggregator"}`, w.Body.String())
+	})
+}
+
+func TestV0AlertsGet(t *testing.T) {
+	start, _ := time.Parse(time.RFC3339, "2020-01-21T09:00:00Z")
+	end, _ := time.Parse(time.RFC3339, "2020-01-21T18:00:00Z")
+	alerts := []transit.Alert{
+		{
+			ID:               "A1",
+			InformedEntities: []transit.InformedEntity{{OperatorID: "7778019", RouteID: "3249_46342"}},
+			Cause:            "CONSTRUCTION",
+			Effect:           "DETOUR",
+			HeaderText:       []transit.Translation{{Text: "Route 46A diverted", Language: "en"}},
+		},
+		{
+			ID:               "A2",
+			ActivePeriods:    []transit.ActivePeriod{{Start: &start, End: &end}},
+			InformedEntities: []transit.InformedEntity{{RouteID: "3249_46350"}},
+		},
+		{
+			ID:               "A3",
+			InformedEntities: []transit.InformedEntity{{StopID: "8220DB000334"}},
+		},
+	}
+
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ctx, engine := gin.CreateTestContext(w)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, new(bytes.Buffer))
+		assert.NoError(t, err, "could not create http request")
+		s := &Server{Dataset: dataset.New()}
+		s.Dataset.SetAlerts(alerts)
+		engine.GET("/v0/alerts", s.V0AlertsGet)
+		engine.ServeHTTP(w, req)
+
+		return w
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		w := get(t, "/v0/alerts")
+
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		var body []transit.Alert
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		assert.Equal(t, []transit.Alert{alerts[0], alerts[2]}, body, "expected expired alert to be left out")
+	})
+
+	t.Run("filters by route, stop and operator", func(t *testing.T) {
+		w := get(t, "/v0/alerts?route=3249_46342&operator=7778019")
+		assert.JSONEq(t, `[{
+			"id": "A1",
+			"active_periods": null,
+			"informed_entities": [{"operator_id": "7778019", "route_id": "3249_46342"}],
+			"cause": "CONSTRUCTION",
+			"effect": "DETOUR",
+			"header_text": [{"text": "Route 46A diverted", "language": "en"}]
+		}]`, w.Body.String())
+
+		w = get(t, "/v0/alerts?stop=8220DB000334")
+		assert.Contains(t, w.Body.String(), `"id":"A3"`)
+		assert.NotContains(t, w.Body.String(), `"id":"A1"`)
+	})
+
+	t.Run("filters by active at", func(t *testing.T) {
+		w := get(t, "/v0/alerts?active_at=2020-01-21T12:00:00Z&route=3249_46350")
+		assert.Equal(t, "[]", w.Body.String(), "expected alerts which have since expired to be left out")
+	})
+
+	t.Run("rejects an invalid active at", func(t *testing.T) {
+		w := get(t, "/v0/alerts?active_at=tomorrow")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+		assert.Equal(t, `{"error":"active_at must be an RFC3339 time"}`, w.Body.String())
+	})
+}
+
+func TestV0VehiclesGet(t *testing.T) {
+	bearing := 90.0
+	vehicles := []transit.Vehicle{
+		{
+			ID:        "V1",
+			Source:    "gtfsr",
+			Operator:  "7778019",
+			Mode:      transit.ModeBus,
+			RouteID:   "3249_46342",
+			Latitude:  53.3498,
+			Longitude: -6.2603,
+			Bearing:   &bearing,
+			Timestamp: time.Date(2025, 1, 21, 12, 0, 0, 0, time.UTC),
+		},
+		{ID: "V2", Source: "gtfsr", Operator: "7778019", Mode: transit.ModeBus, RouteID: "3249_46350", Latitude: 53.2707, Longitude: -9.0568},
+		{ID: "E109", Source: "irishrail", Operator: "irishrail", Mode: transit.ModeRail, Latitude: 53.3531, Longitude: -6.2461},
+	}
+
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ctx, engine := gin.CreateTestContext(w)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, new(bytes.Buffer))
+		assert.NoError(t, err, "could not create http request")
+		s := &Server{Dataset: dataset.New()}
+		s.Dataset.Set(transit.Dataset{Vehicles: vehicles})
+		engine.GET("/v0/vehicles", s.V0VehiclesGet)
+		engine.ServeHTTP(w, req)
+
+		return w
+	}
+
+	ids := func(t *testing.T, w *httptest.ResponseRecorder) []string {
+		var body []transit.Vehicle
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		ids := []string{}
+		for _, v := range body {
+			ids = append(ids, v.ID)
+		}
+
+		return ids
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		w := get(t, "/v0/vehicles")
+
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		assert.Equal(t, []string{"V1", "V2", "E109"}, ids(t, w))
+	})
+
+	t.Run("filters by bounding box", func(t *testing.T) {
+		w := get(t, "/v0/vehicles?bbox=-6.4,53.2,-6.1,53.5&mode=bus")
+		assert.JSONEq(t, `[{
+			"id": "V1",
+			"source": "gtfsr",
+			"operator": "7778019",
+			"mode": "bus",
+			"route_id": "3249_46342",
+			"latitude": 53.3498,
+			"longitude": -6.2603,
+			"bearing": 90,
+			"timestamp": "2025-01-21T12:00:00Z"
+		}]`, w.Body.String())
+	})
+
+	t.Run("filters by operator, route and mode", func(t *testing.T) {
+		assert.Equal(t, []string{"E109"}, ids(t, get(t, "/v0/vehicles?operator=irishrail")))
+		assert.Equal(t, []string{"V2"}, ids(t, get(t, "/v0/vehicles?route=3249_46350")))
+		assert.Equal(t, []string{"E109"}, ids(t, get(t, "/v0/vehicles?mode=rail")))
+	})
+
+	t.Run("rejects an invalid bounding box", func(t *testing.T) {
+		w := get(t, "/v0/vehicles?bbox=-6.4,53.2")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+		assert.Equal(t, `{"error":"bbox must be minLon,minLat,maxLon,maxLat"}`, w.Body.String())
+	})
+
+	t.Run("rejects an unknown mode", func(t *testing.T) {
+		w := get(t, "/v0/vehicles?mode=horse")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+		assert.Equal(t, `{"error":"mode must be one of bus, rail, tram or ferry"}`, w.Body.String())
+	})
+}
+
+func TestV0VehicleGet(t *testing.T) {
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ctx, engine := gin.CreateTestContext(w)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, new(bytes.Buffer))
+		assert.NoError(t, err, "could not create http request")
+		s := &Server{Dataset: dataset.New()}
+		s.Dataset.Set(transit.Dataset{
+			Vehicles: []transit.Vehicle{{
+				ID:        "V1",
+				Source:    "gtfsr",
+				Operator:  "7778019",
+				Mode:      transit.ModeBus,
+				RouteID:   "3249_46342",
+				TripID:    "3249_10466",
+				Latitude:  53.3498,
+				Longitude: -6.2603,
+				Timestamp: time.Date(2025, 1, 21, 12, 0, 0, 0, time.UTC),
+			}, {
+				ID:        "V1",
+				Source:    "siri",
+				Operator:  "GoAheadIreland",
+				Mode:      transit.ModeBus,
+				Latitude:  53.2891,
+				Longitude: -6.1394,
+			}},
+			Departures: []transit.Departure{{
+				StopID:            "8250DB002002",
+				Source:            "gtfsr",
+				TripID:            "3249_10466",
+				StopSequence:      15,
+				ExpectedDeparture: time.Now().Add(time.Hour).UTC().Truncate(time.Second),
+				Status:            transit.DepartureScheduled,
+			}},
+		})
+		engine.GET("/v0/vehicles/:id", s.V0VehicleGet)
+		engine.ServeHTTP(w, req)
+
+		return w
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		w := get(t, "/v0/vehicles/V1?source=gtfsr")
+
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		var body dataset.VehicleDetail
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		assert.Equal(t, "V1", body.Vehicle.ID)
+		assert.Equal(t, &transit.Trip{ID: "3249_10466", Operator: "7778019", RouteID: "3249_46342"}, body.Trip)
+		assert.Equal(t, &transit.Route{ID: "3249_46342", Operator: "7778019", Mode: transit.ModeBus}, body.Route)
+		if assert.Len(t, body.NextStops, 1) {
+			assert.Equal(t, "8250DB002002", body.NextStops[0].Departure.StopID)
+		}
+	})
+
+	t.Run("responds not found for an unknown vehicle", func(t *testing.T) {
+		w := get(t, "/v0/vehicles/V9")
+		assert.Equal(t, http.StatusNotFound, w.Code, "expected status 404 from endpoint")
+		assert.Equal(t, `{"error":"vehicle not found"}`, w.Body.String())
+
+		w = get(t, "/v0/vehicles/V1?source=luas")
+		assert.Equal(t, http.StatusNotFound, w.Code, "expected the vehicles of other sources not to be found")
+	})
+
+	t.Run("responds with a conflict when sources share the id", func(t *testing.T) {
+		w := get(t, "/v0/vehicles/V1")
+		assert.Equal(t, http.StatusConflict, w.Code, "expected status 409 from endpoint")
+		assert.Equal(t, `{"error":"vehicle id is shared by the sources gtfsr, siri, give one as source"}`, w.Body.String())
+	})
+}
+
+func TestV0StopDeparturesGet(t *testing.T) {
+	sched, err := schedule.LoadGTFS("../../../../lib/schedule/testdata/gtfs")
+	assert.NoError(t, err, "could not load GTFS static feed")
+
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ctx, engine := gin.CreateTestContext(w)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, new(bytes.Buffer))
+		assert.NoError(t, err, "could not create http request")
+		s := &Server{Dataset: dataset.New(), Schedule: sched}
+		s.Dataset.Set(transit.Dataset{
+			Departures: []transit.Departure{{
+				StopID:            "LUAS24",
+				Source:            "luas",
+				Headsign:          "Bride's Glen",
+				ExpectedDeparture: time.Now().Add(10 * time.Minute).UTC().Truncate(time.Second),
+				Status:            transit.DepartureScheduled,
+				Realtime:          true,
+			}, {
+				StopID:            "LUAS24",
+				Source:            "luas",
+				Headsign:          "Broombridge",
+				ExpectedDeparture: time.Now().Add(2 * time.Hour).UTC().Truncate(time.Second),
+				Status:            transit.DepartureScheduled,
+				Realtime:          true,
+			}},
+		})
+		engine.GET("/v0/stops/:id/departures", s.V0StopDeparturesGet)
+		engine.ServeHTTP(w, req)
+
+		return w
+	}
+
+	headsigns := func(t *testing.T, w *httptest.ResponseRecorder) []string {
+		var body []transit.Departure
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		headsigns := []string{}
+		for _, d := range body {
+			headsigns = append(headsigns, d.Headsign)
+		}
+
+		return headsigns
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		w := get(t, "/v0/stops/LUAS24/departures")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		assert.Equal(t, []string{"Bride's Glen"}, headsigns(t, w))
+	})
+
+	t.Run("returns departures within the time range", func(t *testing.T) {
+		w := get(t, "/v0/stops/LUAS24/departures?time_range=3h")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		assert.Equal(t, []string{"Bride's Glen", "Broombridge"}, headsigns(t, w))
+	})
+
+	t.Run("limits the number of departures", func(t *testing.T) {
+		w := get(t, "/v0/stops/LUAS24/departures?time_range=3h&limit=1")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		assert.Equal(t, []string{"Bride's Glen"}, headsigns(t, w))
+	})
+
+	for _, limit := range []string{"0", "101", "ten"} {
+		t.Run("rejects a limit of "+limit, func(t *testing.T) {
+			w := get(t, "/v0/stops/LUAS24/departures?limit="+limit)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+			assert.Equal(t, `{"error":"limit must be between 1 and 100"}`, w.Body.String())
+		})
+	}
+
+	for _, timeRange := range []string{"-1h", "25h", "soon"} {
+		t.Run("rejects a time range of "+timeRange, func(t *testing.T) {
+			w := get(t, "/v0/stops/LUAS24/departures?time_range="+timeRange)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+			assert.Equal(t, `{"error":"time_range must be a positive duration of at most 24h"}`, w.Body.String())
+		})
+	}
+
+	t.Run("responds not found for an unknown stop", func(t *testing.T) {
+		w := get(t, "/v0/stops/missing/departures")
+		assert.Equal(t, http.StatusNotFound, w.Code, "expected status 404 from endpoint")
+		assert.Equal(t, `{"error":"stop not found"}`, w.Body.String())
+	})
+}
+
+// serveSchedule serves a request to a handler of a server with only the static
+// timetable loaded
+func serveSchedule(t *testing.T, sched *schedule.Schedule, route string, handler func(s *Server, c *gin.Context), url string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	ctx, engine := gin.CreateTestContext(w)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, new(bytes.Buffer))
+	assert.NoError(t, err, "could not create http request")
+	s := &Server{Dataset: dataset.New(), Schedule: sched}
+	engine.GET(route, func(c *gin.Context) { handler(s, c) })
+	engine.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestV0StopsGet(t *testing.T) {
+	sched, err := schedule.LoadGTFS("../../../../lib/schedule/testdata/gtfs")
+	assert.NoError(t, err, "could not load GTFS static feed")
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		return serveSchedule(t, sched, "/v0/stops", (*Server).V0StopsGet, url)
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		w := get(t, "/v0/stops")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		var body []transit.Stop
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		assert.Len(t, body, 6)
+	})
+
+	t.Run("filters by bounding box", func(t *testing.T) {
+		w := get(t, "/v0/stops?bbox=-6.2,53.2,-6.1,53.3")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		var body []transit.Stop
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		if assert.Len(t, body, 1) {
+			assert.Equal(t, "8250DB002002", body[0].ID)
+		}
+	})
+
+	t.Run("rejects an invalid bounding box", func(t *testing.T) {
+		w := get(t, "/v0/stops?bbox=-6.2")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+	})
+
+	t.Run("is unavailable without a static timetable", func(t *testing.T) {
+		w := serveSchedule(t, nil, "/v0/stops", (*Server).V0StopsGet, "/v0/stops")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, "expected status 503 from endpoint")
+		assert.Equal(t, `{"error":"the static timetable is not loaded"}`, w.Body.String())
+	})
+}
+
+func TestV0StopGet(t *testing.T) {
+	sched, err := schedule.LoadGTFS("../../../../lib/schedule/testdata/gtfs")
+	assert.NoError(t, err, "could not load GTFS static feed")
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		return serveSchedule(t, sched, "/v0/stops/:id", (*Server).V0StopGet, url)
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		w := get(t, "/v0/stops/8220DB000335")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		var body dataset.StopDetail
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		assert.Equal(t, "O'Connell Street Upper", body.Stop.Name)
+		if assert.Len(t, body.Routes, 1) {
+			assert.Equal(t, "46A", body.Routes[0].ShortName)
+		}
+	})
+
+	t.Run("responds not found for an unknown stop", func(t *testing.T) {
+		w := get(t, "/v0/stops/missing")
+		assert.Equal(t, http.StatusNotFound, w.Code, "expected status 404 from endpoint")
+		assert.Equal(t, `{"error":"stop not found"}`, w.Body.String())
+	})
+
+	t.Run("is unavailable without a static timetable", func(t *testing.T) {
+		w := serveSchedule(t, nil, "/v0/stops/:id", (*Server).V0StopGet, "/v0/stops/8220DB000334")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, "expected status 503 from endpoint")
+		assert.Equal(t, `{"error":"the static timetable is not loaded"}`, w.Body.String())
+	})
+}
+
+func TestV0RoutesGet(t *testing.T) {
+	sched, err := schedule.LoadGTFS("../../../../lib/schedule/testdata/gtfs")
+	assert.NoError(t, err, "could not load GTFS static feed")
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		return serveSchedule(t, sched, "/v0/routes", (*Server).V0RoutesGet, url)
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		w := get(t, "/v0/routes")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		var body []transit.Route
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		assert.Len(t, body, 3)
+	})
+
+	t.Run("filters by operator and mode", func(t *testing.T) {
+		w := get(t, "/v0/routes?operator=7778020&mode=bus")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		var body []transit.Route
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		if assert.Len(t, body, 1) {
+			assert.Equal(t, "84", body[0].ShortName)
+		}
+	})
+
+	t.Run("rejects an unknown mode", func(t *testing.T) {
+		w := get(t, "/v0/routes?mode=hovercraft")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+		assert.Equal(t, `{"error":"mode must be one of bus, rail, tram or ferry"}`, w.Body.String())
+	})
+
+	t.Run("is unavailable without a static timetable", func(t *testing.T) {
+		w := serveSchedule(t, nil, "/v0/routes", (*Server).V0RoutesGet, "/v0/routes")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, "expected status 503 from endpoint")
+		assert.Equal(t, `{"error":"the static timetable is not loaded"}`, w.Body.String())
+	})
+}
+
+func TestV0RouteGet(t *testing.T) {
+	sched, err := schedule.LoadGTFS("../../../../lib/schedule/testdata/gtfs")
+	assert.NoError(t, err, "could not load GTFS static feed")
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		return serveSchedule(t, sched, "/v0/routes/:id", (*Server).V0RouteGet, url)
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		w := get(t, "/v0/routes/3249_46342")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		var body dataset.RouteDetail
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		assert.Equal(t, "46A", body.Route.ShortName)
+		if assert.Len(t, body.Shapes, 1) {
+			assert.Equal(t, []float64{-6.2645, 53.3531}, body.Shapes[0].Coordinates[0])
+		}
+		if assert.Len(t, body.Patterns, 2) {
+			assert.Equal(t, []string{"8220DB000334", "8220DB000335", "8220DB000336", "8250DB002002"}, body.Patterns[0].StopIDs)
+		}
+	})
+
+	t.Run("responds not found for an unknown route", func(t *testing.T) {
+		w := get(t, "/v0/routes/missing")
+		assert.Equal(t, http.StatusNotFound, w.Code, "expected status 404 from endpoint")
+		assert.Equal(t, `{"error":"route not found"}`, w.Body.String())
+	})
+
+	t.Run("is unavailable without a static timetable", func(t *testing.T) {
+		w := serveSchedule(t, nil, "/v0/routes/:id", (*Server).V0RouteGet, "/v0/routes/3249_46342")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, "expected status 503 from endpoint")
+		assert.Equal(t, `{"error":"the static timetable is not loaded"}`, w.Body.String())
+	})
+}
+
+func TestV0OperatorsGet(t *testing.T) {
+	sched, err := schedule.LoadGTFS("../../../../lib/schedule/testdata/gtfs")
+	assert.NoError(t, err, "could not load GTFS static feed")
+
+	t.Run("happy path", func(t *testing.T) {
+		w := serveSchedule(t, sched, "/v0/operators", (*Server).V0OperatorsGet, "/v0/operators")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		var body []transit.Operator
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		assert.Equal(t, []transit.Operator{{
+			ID:       "7778019",
+			Name:     "Dublin Bus",
+			URL:      "https://www.dublinbus.ie",
+			Timezone: "Europe/Dublin",
+		}, {
+			ID:       "7778020",
+			Name:     "Go-Ahead Ireland",
+			URL:      "https://www.goaheadireland.ie",
+			Timezone: "Europe/Dublin",
+		}}, body)
+	})
+
+	t.Run("is unavailable without a static timetable", func(t *testing.T) {
+		w := serveSchedule(t, nil, "/v0/operators", (*Server).V0OperatorsGet, "/v0/operators")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, "expected status 503 from endpoint")
+	})
+}
+
+func TestV0ProtoGet(t *testing.T) {
+	t.Run("happy path", func(t *testing.T) {
+		w := serveSchedule(t, nil, "/v0/proto", (*Server).V0ProtoGet, "/v0/proto")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "message Vehicles")
+	})
+}
+
+func TestV0StopsNearbyGet(t *testing.T) {
+	sched, err := schedule.LoadGTFS("../../../../lib/schedule/testdata/gtfs")
+	assert.NoError(t, err, "could not load GTFS static feed")
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		return serveSchedule(t, sched, "/v0/stops/nearby", (*Server).V0StopsNearbyGet, url)
+	}
+	stopIDs := func(t *testing.T, w *httptest.ResponseRecorder) []string {
+		var body []dataset.NearbyStop
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		ids := []string{}
+		for _, s := range body {
+			ids = append(ids, s.Stop.ID)
+		}
+
+		return ids
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		w := get(t, "/v0/stops/nearby?lat=53.3511&lon=-6.2608")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		assert.Equal(t, []string{"8220DB000335", "8220DB000334"}, stopIDs(t, w))
+	})
+
+	t.Run("searches within the radius", func(t *testing.T) {
+		w := get(t, "/v0/stops/nearby?lat=53.3511&lon=-6.2608&radius=700")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		assert.Equal(t, []string{"8220DB000335", "8220DB000334", "8220DB000336"}, stopIDs(t, w))
+	})
+
+	t.Run("limits the number of stops", func(t *testing.T) {
+		w := get(t, "/v0/stops/nearby?lat=53.3511&lon=-6.2608&limit=1")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		assert.Equal(t, []string{"8220DB000335"}, stopIDs(t, w))
+	})
+
+	for _, query := range []string{"", "lat=53.3511", "lat=north&lon=-6.2608", "lat=91&lon=-6.2608"} {
+		t.Run("rejects a point of "+query, func(t *testing.T) {
+			w := get(t, "/v0/stops/nearby?"+query)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+			assert.Equal(t, `{"error":"lat and lon must be a latitude and longitude"}`, w.Body.String())
+		})
+	}
+
+	for _, radius := range []string{"0", "2001", "far"} {
+		t.Run("rejects a radius of "+radius, func(t *testing.T) {
+			w := get(t, "/v0/stops/nearby?lat=53.3511&lon=-6.2608&radius="+radius)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+			assert.Equal(t, `{"error":"radius must be more than 0 and at most 2000 metres"}`, w.Body.String())
+		})
+	}
+
+	t.Run("rejects an invalid limit", func(t *testing.T) {
+		w := get(t, "/v0/stops/nearby?lat=53.3511&lon=-6.2608&limit=51")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+		assert.Equal(t, `{"error":"limit must be between 1 and 50"}`, w.Body.String())
+	})
+
+	t.Run("is unavailable without a static timetable", func(t *testing.T) {
+		w := serveSchedule(t, nil, "/v0/stops/nearby", (*Server).V0StopsNearbyGet, "/v0/stops/nearby?lat=53.3511&lon=-6.2608")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, "expected status 503 from endpoint")
+		assert.Equal(t, `{"error":"the static timetable is not loaded"}`, w.Body.String())
+	})
+}
+
+func TestV0SearchGet(t *testing.T) {
+	sched, err := schedule.LoadGTFS("../../../../lib/schedule/testdata/gtfs")
+	assert.NoError(t, err, "could not load GTFS static feed")
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		return serveSchedule(t, sched, "/v0/search", (*Server).V0SearchGet, url)
+	}
+
+	t.Run("happy path", func(t *testing.T) {
+		w := get(t, "/v0/search?q=St%C3%A1isi%C3%BAn+Heuston")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		var body []dataset.SearchResult
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		if assert.Len(t, body, 1) {
+			assert.Equal(t, "8220IR0132", body[0].Stop.ID)
+		}
+	})
+
+	t.Run("limits the number of results", func(t *testing.T) {
+		w := get(t, "/v0/search?q=heuston&limit=1")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		var body []dataset.SearchResult
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		assert.Len(t, body, 1)
+	})
+
+	t.Run("rejects an empty query", func(t *testing.T) {
+		w := get(t, "/v0/search?q=+")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+		assert.Equal(t, `{"error":"q must not be empty"}`, w.Body.String())
+	})
+
+	t.Run("rejects an invalid limit", func(t *testing.T) {
+		w := get(t, "/v0/search?q=heuston&limit=0")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+		assert.Equal(t, `{"error":"limit must be between 1 and 50"}`, w.Body.String())
+	})
+
+	t.Run("is unavailable without a static timetable", func(t *testing.T) {
+		w := serveSchedule(t, nil, "/v0/search", (*Server).V0SearchGet, "/v0/search?q=heuston")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code, "expected status 503 from endpoint")
+		assert.Equal(t, `{"error":"the static timetable is not loaded"}`, w.Body.String())
+	})
 }
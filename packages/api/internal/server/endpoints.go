@@ -1,9 +1,24 @@
 package server
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
+	h "github.com/mcgovman/wheresmylift/packages/api/internal/helpers"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/protobuf"
+)
+
+var (
+	errInvalidMode = errors.New("mode must be one of bus, rail, tram or ferry")
+	errNoSchedule  = errors.New("the static timetable is not loaded")
 )
 
 // RootGet					godoc
@@ -21,9 +36,431 @@ func (s *Server) RootGet(c *gin.Context) {
 //
 //	@Summary		Get health of API
 //	@Description	If accessing this endpoint via Cloudflare it will only accessible using the BetterStack user-agent https://betterstack.com/docs/uptime/frequently-asked-questions/#what-user-agent-does-uptime-use
+//	@Description	Unhealthy while an aggregator is configured but not connected
 //	@Tags			V0
 //	@Success		204
+//	@Failure		503	{object}	helpers.Error
 //	@Router			/v0/healthcheck [get]
 func (s *Server) V0HealthCheckGet(c *gin.Context) {
+	if s.Subscriber != nil && !s.Subscriber.Connected() {
+		h.RespondWithError(c, errors.New("not connected to the aggregator"), http.StatusServiceUnavailable)
+
+		return
+	}
+
 	c.Status(204)
 }
+
+// V0AlertsGet			godoc
+//
+//	@Summary		Get service alerts
+//	@Description	Alerts whose active periods have all ended are never returned
+//	@Tags			V0
+//	@Produce		json,application/x-protobuf
+//	@Param			route		query		string	false	"Only alerts informing this route id"
+//	@Param			stop		query		string	false	"Only alerts informing this stop id"
+//	@Param			operator	query		string	false	"Only alerts informing this operator id"
+//	@Param			active_at	query		string	false	"Only alerts active at this RFC3339 time"
+//	@Param			format		query		string	false	"json, or protobuf for an Alerts message, instead of the Accept header"	Enums(json, protobuf)
+//	@Success		200			{array}		transit.Alert
+//	@Failure		400			{object}	helpers.Error
+//	@Router			/v0/alerts [get]
+func (s *Server) V0AlertsGet(c *gin.Context) {
+	filter := dataset.AlertFilter{
+		RouteID:    c.Query("route"),
+		StopID:     c.Query("stop"),
+		OperatorID: c.Query("operator"),
+	}
+
+	if activeAt := c.Query("active_at"); activeAt != "" {
+		t, err := time.Parse(time.RFC3339, activeAt)
+		if err != nil {
+			h.RespondWithError(c, errors.New("active_at must be an RFC3339 time"), http.StatusBadRequest)
+
+			return
+		}
+		filter.ActiveAt = &t
+	}
+
+	respond(c, http.StatusOK, s.Dataset.Alerts(filter))
+}
+
+// V0VehiclesGet			godoc
+//
+//	@Summary		Get vehicle positions
+//	@Description	The latest known position of every vehicle, across every source
+//	@Tags			V0
+//	@Produce		json,application/geo+json,application/x-protobuf
+//	@Param			bbox		query		string	false	"Only vehicles within minLon,minLat,maxLon,maxLat"
+//	@Param			operator	query		string	false	"Only vehicles of this operator id"
+//	@Param			route		query		string	false	"Only vehicles on this route id"
+//	@Param			mode		query		string	false	"Only vehicles of this mode"	Enums(bus, rail, tram, ferry)
+//	@Param			format		query		string	false	"json, geojson for a FeatureCollection or protobuf for a Vehicles message, instead of the Accept header"	Enums(json, geojson, protobuf)
+//	@Success		200			{array}		transit.Vehicle
+//	@Failure		400			{object}	helpers.Error
+//	@Router			/v0/vehicles [get]
+func (s *Server) V0VehiclesGet(c *gin.Context) {
+	filter, err := vehicleFilter(c)
+	if err != nil {
+		h.RespondWithError(c, err, http.StatusBadRequest)
+
+		return
+	}
+
+	respond(c, http.StatusOK, s.Dataset.Vehicles(filter))
+}
+
+// vehicleFilter reads the filters of the vehicles endpoints from the query
+func vehicleFilter(c *gin.Context) (dataset.VehicleFilter, error) {
+	filter := dataset.VehicleFilter{
+		OperatorID: c.Query("operator"),
+		RouteID:    c.Query("route"),
+		Mode:       transit.Mode(c.Query("mode")),
+	}
+
+	if filter.Mode != "" && !slices.Contains(transit.Modes, filter.Mode) {
+		return filter, errInvalidMode
+	}
+
+	if bbox := c.Query("bbox"); bbox != "" {
+		b, err := dataset.ParseBBox(bbox)
+		if err != nil {
+			return filter, err
+		}
+		filter.BBox = &b
+	}
+
+	return filter, nil
+}
+
+// V0VehicleGet			godoc
+//
+//	@Summary		Get a vehicle
+//	@Description	The latest position of a vehicle with its trip, route and the stops it has yet to call at with their predicted times
+//	@Description	Without realtime departures for its trip the scheduled stops are shifted by the delay of the vehicle
+//	@Tags			V0
+//	@Produce		json,application/geo+json
+//	@Param			id		path		string	true	"Vehicle id"
+//	@Param			source	query		string	false	"Source of the vehicle, needed when sources share the id"
+//	@Param			format	query		string	false	"json, or geojson for a FeatureCollection, instead of the Accept header"	Enums(json, geojson)
+//	@Success		200		{object}	dataset.VehicleDetail
+//	@Failure		404		{object}	helpers.Error
+//	@Failure		409		{object}	helpers.Error
+//	@Router			/v0/vehicles/{id} [get]
+func (s *Server) V0VehicleGet(c *gin.Context) {
+	details := s.Dataset.VehicleDetails(dataset.VehicleRef{Source: c.Query("source"), ID: c.Param("id")}, s.Schedule)
+	switch {
+	case len(details) == 0:
+		h.RespondWithError(c, errors.New("vehicle not found"), http.StatusNotFound)
+
+		return
+	case len(details) > 1:
+		sources := make([]string, 0, len(details))
+		for _, d := range details {
+			sources = append(sources, d.Vehicle.Source)
+		}
+		h.RespondWithError(c, fmt.Errorf("vehicle id is shared by the sources %s, give one as source", strings.Join(sources, ", ")), http.StatusConflict)
+
+		return
+	}
+
+	respond(c, http.StatusOK, details[0])
+}
+
+// V0StopDeparturesGet		godoc
+//
+//	@Summary		Get the departures from a stop
+//	@Description	The departures from a stop and its platforms, the timetable is merged with the realtime departures of every source
+//	@Description	Each departure is marked realtime, or not when it is only scheduled, and has a status of scheduled, cancelled, skipped or no_data
+//	@Tags			V0
+//	@Produce		json,application/x-protobuf
+//	@Param			id			path		string	true	"Stop id"
+//	@Param			limit		query		int		false	"Most departures to return, between 1 and 100"	default(20)
+//	@Param			time_range	query		string	false	"Only departures within this duration of now, at most 24h"	default(1h)
+//	@Param			format		query		string	false	"json, or protobuf for a Departures message, instead of the Accept header"	Enums(json, protobuf)
+//	@Success		200			{array}		transit.Departure
+//	@Failure		400			{object}	helpers.Error
+//	@Failure		404			{object}	helpers.Error
+//	@Router			/v0/stops/{id}/departures [get]
+func (s *Server) V0StopDeparturesGet(c *gin.Context) {
+	filter := dataset.DepartureFilter{TimeRange: time.Hour, Limit: 20}
+
+	if limit := c.Query("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > 100 {
+			h.RespondWithError(c, errors.New("limit must be between 1 and 100"), http.StatusBadRequest)
+
+			return
+		}
+		filter.Limit = l
+	}
+
+	if timeRange := c.Query("time_range"); timeRange != "" {
+		r, err := time.ParseDuration(timeRange)
+		if err != nil || r <= 0 || r > 24*time.Hour {
+			h.RespondWithError(c, errors.New("time_range must be a positive duration of at most 24h"), http.StatusBadRequest)
+
+			return
+		}
+		filter.TimeRange = r
+	}
+
+	departures, ok := s.Dataset.StopDepartures(c.Param("id"), s.Schedule, filter)
+	if !ok {
+		h.RespondWithError(c, errors.New("stop not found"), http.StatusNotFound)
+
+		return
+	}
+
+	respond(c, http.StatusOK, departures)
+}
+
+// V0StopsGet			godoc
+//
+//	@Summary		Get stops
+//	@Description	Every stop of the static timetable ordered by id
+//	@Tags			V0
+//	@Produce		json,application/geo+json
+//	@Param			bbox	query		string	false	"Only stops within minLon,minLat,maxLon,maxLat"
+//	@Param			format	query		string	false	"json, or geojson for a FeatureCollection, instead of the Accept header"	Enums(json, geojson)
+//	@Success		200		{array}		transit.Stop
+//	@Failure		400		{object}	helpers.Error
+//	@Failure		503		{object}	helpers.Error
+//	@Router			/v0/stops [get]
+func (s *Server) V0StopsGet(c *gin.Context) {
+	if s.Schedule == nil {
+		h.RespondWithError(c, errNoSchedule, http.StatusServiceUnavailable)
+
+		return
+	}
+
+	filter := dataset.StopFilter{}
+	if bbox := c.Query("bbox"); bbox != "" {
+		b, err := dataset.ParseBBox(bbox)
+		if err != nil {
+			h.RespondWithError(c, err, http.StatusBadRequest)
+
+			return
+		}
+		filter.BBox = &b
+	}
+
+	respond(c, http.StatusOK, dataset.Stops(s.Schedule, filter))
+}
+
+// V0StopsNearbyGet		godoc
+//
+//	@Summary		Get the stops near a point
+//	@Description	The stops nearest to a point ordered by great-circle distance, each with the routes calling at it and its next few departures
+//	@Description	Platforms are left out in favour of their station
+//	@Tags			V0
+//	@Produce		json,application/geo+json
+//	@Param			lat		query		number	true	"Latitude"
+//	@Param			lon		query		number	true	"Longitude"
+//	@Param			radius	query		number	false	"Metres from the point, at most 2000"	default(500)
+//	@Param			limit	query		int		false	"Most stops to return, between 1 and 50"	default(10)
+//	@Param			format	query		string	false	"json, or geojson for a FeatureCollection, instead of the Accept header"	Enums(json, geojson)
+//	@Success		200		{array}		dataset.NearbyStop
+//	@Failure		400		{object}	helpers.Error
+//	@Failure		503		{object}	helpers.Error
+//	@Router			/v0/stops/nearby [get]
+func (s *Server) V0StopsNearbyGet(c *gin.Context) {
+	if s.Schedule == nil {
+		h.RespondWithError(c, errNoSchedule, http.StatusServiceUnavailable)
+
+		return
+	}
+
+	lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	lon, lonErr := strconv.ParseFloat(c.Query("lon"), 64)
+	if latErr != nil || lonErr != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+		h.RespondWithError(c, errors.New("lat and lon must be a latitude and longitude"), http.StatusBadRequest)
+
+		return
+	}
+	filter := dataset.NearbyFilter{Latitude: lat, Longitude: lon, Radius: 500, Limit: 10}
+
+	if radius := c.Query("radius"); radius != "" {
+		r, err := strconv.ParseFloat(radius, 64)
+		if err != nil || r <= 0 || r > 2000 {
+			h.RespondWithError(c, errors.New("radius must be more than 0 and at most 2000 metres"), http.StatusBadRequest)
+
+			return
+		}
+		filter.Radius = r
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		l, err := strconv.Atoi(limit)
+		if err != nil || l < 1 || l > 50 {
+			h.RespondWithError(c, errors.New("limit must be between 1 and 50"), http.StatusBadRequest)
+
+			return
+		}
+		filter.Limit = l
+	}
+
+	respond(c, http.StatusOK, s.Dataset.NearbyStops(s.Schedule, filter))
+}
+
+// V0StopGet			godoc
+//
+//	@Summary		Get a stop
+//	@Description	A stop of the static timetable with its platforms and the routes calling at it
+//	@Tags			V0
+//	@Produce		json,application/geo+json
+//	@Param			id		path		string	true	"Stop id"
+//	@Param			format	query		string	false	"json, or geojson for a FeatureCollection, instead of the Accept header"	Enums(json, geojson)
+//	@Success		200		{object}	dataset.StopDetail
+//	@Failure		404		{object}	helpers.Error
+//	@Failure		503		{object}	helpers.Error
+//	@Router			/v0/stops/{id} [get]
+func (s *Server) V0StopGet(c *gin.Context) {
+	if s.Schedule == nil {
+		h.RespondWithError(c, errNoSchedule, http.StatusServiceUnavailable)
+
+		return
+	}
+
+	detail, ok := dataset.Stop(s.Schedule, c.Param("id"))
+	if !ok {
+		h.RespondWithError(c, errors.New("stop not found"), http.StatusNotFound)
+
+		return
+	}
+
+	respond(c, http.StatusOK, detail)
+}
+
+// V0RoutesGet			godoc
+//
+//	@Summary		Get routes
+//	@Description	Every route of the static timetable ordered by id
+//	@Tags			V0
+//	@Produce		json
+//	@Param			operator	query		string	false	"Only routes of this operator id"
+//	@Param			mode		query		string	false	"Only routes of this mode"	Enums(bus, rail, tram, ferry)
+//	@Success		200			{array}		transit.Route
+//	@Failure		400			{object}	helpers.Error
+//	@Failure		503			{object}	helpers.Error
+//	@Router			/v0/routes [get]
+func (s *Server) V0RoutesGet(c *gin.Context) {
+	if s.Schedule == nil {
+		h.RespondWithError(c, errNoSchedule, http.StatusServiceUnavailable)
+
+		return
+	}
+
+	filter := dataset.RouteFilter{
+		OperatorID: c.Query("operator"),
+		Mode:       transit.Mode(c.Query("mode")),
+	}
+
+	if filter.Mode != "" && !slices.Contains(transit.Modes, filter.Mode) {
+		h.RespondWithError(c, errInvalidMode, http.StatusBadRequest)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, dataset.Routes(s.Schedule, filter))
+}
+
+// V0RouteGet			godoc
+//
+//	@Summary		Get a route
+//	@Description	A route of the static timetable with the shapes of its trips as longitude and latitude pairs and the orders of stops they call at
+//	@Tags			V0
+//	@Produce		json,application/geo+json
+//	@Param			id		path		string	true	"Route id"
+//	@Param			format	query		string	false	"json, or geojson for a FeatureCollection, instead of the Accept header"	Enums(json, geojson)
+//	@Success		200		{object}	dataset.RouteDetail
+//	@Failure		404		{object}	helpers.Error
+//	@Failure		503		{object}	helpers.Error
+//	@Router			/v0/routes/{id} [get]
+func (s *Server) V0RouteGet(c *gin.Context) {
+	if s.Schedule == nil {
+		h.RespondWithError(c, errNoSchedule, http.StatusServiceUnavailable)
+
+		return
+	}
+
+	detail, ok := dataset.Route(s.Schedule, c.Param("id"))
+	if !ok {
+		h.RespondWithError(c, errors.New("route not found"), http.StatusNotFound)
+
+		return
+	}
+
+	respond(c, http.StatusOK, detail)
+}
+
+// V0OperatorsGet			godoc
+//
+//	@Summary		Get operators
+//	@Description	Every operator of the static timetable ordered by id
+//	@Tags			V0
+//	@Produce		json
+//	@Success		200	{array}		transit.Operator
+//	@Failure		503	{object}	helpers.Error
+//	@Router			/v0/operators [get]
+func (s *Server) V0OperatorsGet(c *gin.Context) {
+	if s.Schedule == nil {
+		h.RespondWithError(c, errNoSchedule, http.StatusServiceUnavailable)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, dataset.Operators(s.Schedule))
+}
+
+// V0ProtoGet			godoc
+//
+//	@Summary		Get the protobuf schema
+//	@Description	The schema of the protobuf responses of /v0/vehicles, /v0/stops/{id}/departures and /v0/alerts, sent for Accept: application/x-protobuf or format=protobuf
+//	@Tags			V0
+//	@Produce		plain
+//	@Success		200	{string}	string
+//	@Router			/v0/proto [get]
+func (s *Server) V0ProtoGet(c *gin.Context) {
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", protobuf.Schema)
+}
+
+// V0SearchGet			godoc
+//
+//	@Summary		Search stops and routes
+//	@Description	Stops matched by their name, code or Irish name and routes matched by their short name, best match first
+//	@Description	Every word of the query has to match a word of a name, exactly, as its start or with a typo or two
+//	@Tags			V0
+//	@Produce		json
+//	@Param			q		query		string	true	"What to search for, such as heuston, Stáisiún Heuston or 46a"
+//	@Param			limit	query		int		false	"Most results to return, between 1 and 50"	default(10)
+//	@Success		200		{array}		dataset.SearchResult
+//	@Failure		400		{object}	helpers.Error
+//	@Failure		503		{object}	helpers.Error
+//	@Router			/v0/search [get]
+func (s *Server) V0SearchGet(c *gin.Context) {
+	if s.Schedule == nil {
+		h.RespondWithError(c, errNoSchedule, http.StatusServiceUnavailable)
+
+		return
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		h.RespondWithError(c, errors.New("q must not be empty"), http.StatusBadRequest)
+
+		return
+	}
+
+	limit := 10
+	if l := c.Query("limit"); l != "" {
+		var err error
+		if limit, err = strconv.Atoi(l); err != nil || limit < 1 || limit > 50 {
+			h.RespondWithError(c, errors.New("limit must be between 1 and 50"), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, dataset.Search(s.Schedule, query, limit))
+}
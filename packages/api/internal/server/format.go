@@ -0,0 +1,181 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/geojson"
+	h "github.com/mcgovman/wheresmylift/packages/api/internal/helpers"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/protobuf"
+	"github.com/rs/zerolog/log"
+)
+
+// The formats a response can be negotiated into
+const (
+	formatJSON     = "json"
+	formatGeoJSON  = "geojson"
+	formatProtobuf = "protobuf"
+)
+
+// formatKey is where negotiate keeps the format in the context
+const formatKey = "format"
+
+var formatMIMEs = map[string]string{
+	formatJSON:     gin.MIMEJSON,
+	formatGeoJSON:  geojson.MIME,
+	formatProtobuf: protobuf.MIME,
+}
+
+// negotiate is the middleware of the routes which respond in more than JSON.
+// It chooses the format from the format query parameter, or else the Accept
+// header, out of JSON and the given formats, for respond to write it in. The
+// response varies on Accept so caches keep a copy per format
+func negotiate(formats ...string) gin.HandlerFunc {
+	formats = append([]string{formatJSON}, formats...)
+	mimes := make([]string, 0, len(formats))
+	for _, f := range formats {
+		mimes = append(mimes, formatMIMEs[f])
+	}
+
+	return func(c *gin.Context) {
+		// The response differs by Accept, caches such as Cloudflare must not
+		// serve one format to a client which asked for another
+		c.Header("Vary", "Accept")
+
+		format := c.Query("format")
+		switch {
+		case format == "":
+			mime := acceptedMIME(c.GetHeader("Accept"), mimes)
+			if mime == "" {
+				h.RespondWithError(c, fmt.Errorf("accept must be one of %s", strings.Join(mimes, ", ")), http.StatusNotAcceptable)
+				c.Abort()
+
+				return
+			}
+			for _, f := range formats {
+				if formatMIMEs[f] == mime {
+					format = f
+				}
+			}
+		case !slices.Contains(formats, format):
+			h.RespondWithError(c, fmt.Errorf("format must be one of %s", strings.Join(formats, ", ")), http.StatusBadRequest)
+			c.Abort()
+
+			return
+		}
+
+		c.Set(formatKey, format)
+		c.Next()
+	}
+}
+
+// acceptedMIME returns the MIME type of the offers which an Accept header
+// prefers, the first offer without a header and nothing when it accepts none.
+// Each offer takes the quality of the most specific media range matching it,
+// offers of the same quality go to the more specific range and then to the
+// first offer, so that */* does not outweigh a type asked for by name
+func acceptedMIME(accept string, offers []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+
+	type mediaRange struct {
+		mime        string
+		quality     float64
+		specificity int
+	}
+	ranges := []mediaRange{}
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		r := mediaRange{mime: strings.ToLower(strings.TrimSpace(params[0])), quality: 1}
+		for _, param := range params[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				q, err := strconv.ParseFloat(value, 64)
+				if err != nil || q < 0 || q > 1 {
+					q = 0
+				}
+				r.quality = q
+			}
+		}
+		switch {
+		case r.mime == "*/*":
+		case strings.HasSuffix(r.mime, "/*"):
+			r.specificity = 1
+		default:
+			r.specificity = 2
+		}
+		ranges = append(ranges, r)
+	}
+
+	best, bestRange := "", mediaRange{specificity: -1}
+	for _, offer := range offers {
+		kind, _, _ := strings.Cut(offer, "/")
+		match := mediaRange{specificity: -1}
+		for _, r := range ranges {
+			if r.specificity <= match.specificity {
+				continue
+			}
+			if r.mime == "*/*" || r.mime == kind+"/*" || r.mime == offer {
+				match = r
+			}
+		}
+		if match.specificity < 0 || match.quality == 0 {
+			continue
+		}
+		if best == "" || match.quality > bestRange.quality ||
+			(match.quality == bestRange.quality && match.specificity > bestRange.specificity) {
+			best, bestRange = offer, match
+		}
+	}
+
+	return best
+}
+
+// respond writes data in the format negotiated for the request, JSON when
+// there was no negotiation
+func respond(c *gin.Context, status int, data any) {
+	switch c.GetString(formatKey) {
+	case formatGeoJSON:
+		fc, err := geojson.Encode(data)
+		if err != nil {
+			log.Error().Err(err).Msg("could not respond with geojson")
+			h.RespondWithError(c, errors.New("a server error was encountered"), http.StatusInternalServerError)
+
+			return
+		}
+		c.Render(status, geoJSONRender{fc})
+	case formatProtobuf:
+		b, err := protobuf.Encode(data)
+		if err != nil {
+			log.Error().Err(err).Msg("could not respond with protobuf")
+			h.RespondWithError(c, errors.New("a server error was encountered"), http.StatusInternalServerError)
+
+			return
+		}
+		c.Data(status, protobuf.MIME, b)
+	default:
+		c.JSON(status, data)
+	}
+}
+
+// geoJSONRender renders like JSON with the GeoJSON content type
+type geoJSONRender struct {
+	data any
+}
+
+func (r geoJSONRender) Render(w http.ResponseWriter) error {
+	r.WriteContentType(w)
+
+	return json.NewEncoder(w).Encode(r.data)
+}
+
+func (r geoJSONRender) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", geojson.MIME)
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/geojson"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/protobuf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	stops := []transit.Stop{{ID: "8220DB000334", Name: "Parnell Square West, stop 2", Latitude: 53.3531, Longitude: -6.2649}}
+	get := func(t *testing.T, url, accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		ctx, engine := gin.CreateTestContext(w)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, new(bytes.Buffer))
+		assert.NoError(t, err, "could not create http request")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		engine.GET("/stops", negotiate(formatGeoJSON), func(c *gin.Context) { respond(c, http.StatusOK, stops) })
+		engine.GET("/alerts", negotiate(formatGeoJSON, formatProtobuf), func(c *gin.Context) { respond(c, http.StatusOK, []transit.Alert{{ID: "A1"}}) })
+		engine.GET("/stops/protobuf", negotiate(formatProtobuf), func(c *gin.Context) { respond(c, http.StatusOK, stops) })
+		engine.ServeHTTP(w, req)
+
+		return w
+	}
+
+	t.Run("responds with json by default", func(t *testing.T) {
+		for _, accept := range []string{"", "*/*", "application/json", "text/html,application/xhtml+xml,*/*;q=0.8"} {
+			w := get(t, "/stops", accept)
+			assert.Equal(t, http.StatusOK, w.Code, "expected status 200 accepting %q", accept)
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"), "expected json accepting %q", accept)
+		}
+	})
+
+	t.Run("responds with geojson when accepted", func(t *testing.T) {
+		w := get(t, "/stops", "application/geo+json")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		assert.Equal(t, geojson.MIME, w.Header().Get("Content-Type"))
+		var body geojson.FeatureCollection
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		if assert.Len(t, body.Features, 1) {
+			assert.Equal(t, "8220DB000334", body.Features[0].ID)
+		}
+	})
+
+	t.Run("varies on the accept header", func(t *testing.T) {
+		for _, accept := range []string{"", "application/geo+json", "text/csv"} {
+			w := get(t, "/stops", accept)
+			assert.Equal(t, "Accept", w.Header().Get("Vary"), "expected the response to vary accepting %q", accept)
+		}
+	})
+
+	for _, tt := range []struct{ url, accept, mime string }{
+		{"/alerts", "application/x-protobuf;q=0.1, application/json", gin.MIMEJSON},
+		{"/alerts", "application/json;q=0.5, application/x-protobuf", protobuf.MIME},
+		{"/alerts", "application/*;q=0.2, application/x-protobuf;q=0.1", gin.MIMEJSON},
+		{"/stops", "application/geo+json, */*", geojson.MIME},
+		{"/stops", "*/*;q=0.9, application/geo+json;Q=0.8", gin.MIMEJSON},
+		{"/stops", "application/json;q=0, */*", geojson.MIME},
+		{"/stops", "application/json;q=high, application/geo+json;q=0.1", geojson.MIME},
+	} {
+		t.Run("weighs "+tt.accept+" by quality", func(t *testing.T) {
+			w := get(t, tt.url, tt.accept)
+			assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+			assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), tt.mime), "expected %s, got %s", tt.mime, w.Header().Get("Content-Type"))
+		})
+	}
+
+	t.Run("rejects an accept header whose formats all have no quality", func(t *testing.T) {
+		w := get(t, "/stops", "application/json;q=0, application/geo+json;q=0")
+		assert.Equal(t, http.StatusNotAcceptable, w.Code, "expected status 406 from endpoint")
+	})
+
+	t.Run("prefers the format query parameter to the accept header", func(t *testing.T) {
+		w := get(t, "/stops?format=geojson", "application/json")
+		assert.Equal(t, geojson.MIME, w.Header().Get("Content-Type"))
+
+		w = get(t, "/stops?format=json", "application/geo+json")
+		assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	})
+
+	t.Run("rejects an unknown format", func(t *testing.T) {
+		w := get(t, "/stops?format=kml", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+		assert.Equal(t, `{"error":"format must be one of json, geojson"}`, w.Body.String())
+	})
+
+	t.Run("rejects an accept header without a format", func(t *testing.T) {
+		w := get(t, "/stops", "text/csv")
+		assert.Equal(t, http.StatusNotAcceptable, w.Code, "expected status 406 from endpoint")
+		assert.Equal(t, `{"error":"accept must be one of application/json, application/geo+json"}`, w.Body.String())
+	})
+
+	t.Run("responds with protobuf when accepted", func(t *testing.T) {
+		w := get(t, "/alerts", "application/x-protobuf")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		assert.Equal(t, protobuf.MIME, w.Header().Get("Content-Type"))
+		b, _ := protobuf.Encode([]transit.Alert{{ID: "A1"}})
+		assert.Equal(t, b, w.Body.Bytes())
+
+		w = get(t, "/stops?format=protobuf", "")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 for a format the route does not offer")
+	})
+
+	for _, url := range []string{"/alerts?format=geojson", "/stops/protobuf?format=protobuf"} {
+		t.Run("fails for data without an encoding at "+url, func(t *testing.T) {
+			w := get(t, url, "")
+			assert.Equal(t, http.StatusInternalServerError, w.Code, "expected status 500 from endpoint")
+			assert.Equal(t, `{"error":"a server error was encountered"}`, w.Body.String())
+		})
+	}
+}
+
+func TestGeoJSONRoutes(t *testing.T) {
+	s := NewServer(config.Config{})
+	s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{{ID: "V1", Source: "gtfsr", Latitude: 53.3498, Longitude: -6.2603}}})
+
+	for _, url := range []string{"/v0/vehicles", "/v0/vehicles/V1"} {
+		t.Run("serves "+url+" as geojson", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			req.Header.Set("Accept", geojson.MIME)
+			s.HTTP.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+			assert.Equal(t, geojson.MIME, w.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", w.Header().Get("Vary"), "expected the response to vary on the accept header")
+			var body geojson.FeatureCollection
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+			if assert.Len(t, body.Features, 1) {
+				assert.Equal(t, &geojson.Geometry{Type: "Point", Coordinates: []any{-6.2603, 53.3498}}, body.Features[0].Geometry)
+			}
+		})
+	}
+}
+
+func TestProtobufRoutes(t *testing.T) {
+	s := NewServer(config.Config{})
+	s.Dataset.Set(transit.Dataset{
+		Vehicles:   []transit.Vehicle{{ID: "V1", Source: "gtfsr"}},
+		Departures: []transit.Departure{{StopID: "LUAS24", Source: "luas", ExpectedDeparture: time.Now().Add(time.Minute)}},
+	})
+	s.Dataset.SetAlerts([]transit.Alert{{ID: "A1"}})
+
+	for _, url := range []string{"/v0/vehicles", "/v0/stops/LUAS24/departures", "/v0/alerts"} {
+		t.Run("serves "+url+" as protobuf", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, url, nil)
+			req.Header.Set("Accept", protobuf.MIME)
+			s.HTTP.Handler.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+			assert.Equal(t, protobuf.MIME, w.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", w.Header().Get("Vary"), "expected the response to vary on the accept header")
+			assert.NotEmpty(t, w.Body.Bytes())
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"github.com/gin-gonic/gin"
+	h "github.com/mcgovman/wheresmylift/packages/api/internal/helpers"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/protobuf"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// respondFeed writes a GTFS-Realtime feed as protobuf, or as indented JSON
+// with the field and enum names of GTFS-Realtime when debugging
+func respondFeed(c *gin.Context, f *gtfs.FeedMessage) {
+	debug := false
+	if d := c.Query("debug"); d != "" {
+		var err error
+		if debug, err = strconv.ParseBool(d); err != nil {
+			h.RespondWithError(c, errors.New("debug must be true or false"), http.StatusBadRequest)
+
+			return
+		}
+	}
+
+	mime, marshal := protobuf.MIME, proto.Marshal
+	if debug {
+		mime, marshal = "application/json; charset=utf-8", protojson.MarshalOptions{Multiline: true, Indent: "    ", UseProtoNames: true}.Marshal
+	}
+
+	b, err := marshal(f)
+	if err != nil {
+		log.Error().Err(err).Msg("could not respond with gtfs-realtime")
+		h.RespondWithError(c, errors.New("a server error was encountered"), http.StatusInternalServerError)
+
+		return
+	}
+
+	c.Data(http.StatusOK, mime, b)
+}
+
+// V0GTFSRTVehiclePositionsGet	godoc
+//
+//	@Summary		Get vehicle positions as GTFS-Realtime
+//	@Description	A GTFS-Realtime FeedMessage with the vehicles of every operator, Irish Rail and Luas ids are mapped to the ids of the static timetable when it is loaded
+//	@Tags			V0
+//	@Produce		application/x-protobuf,json
+//	@Param			debug	query		bool	false	"Respond with the feed as JSON instead"
+//	@Success		200		{string}	string
+//	@Failure		400		{object}	helpers.Error
+//	@Router			/v0/gtfs-rt/vehicle-positions [get]
+func (s *Server) V0GTFSRTVehiclePositionsGet(c *gin.Context) {
+	respondFeed(c, s.Dataset.VehiclePositionsFeed(s.Schedule))
+}
+
+// V0GTFSRTTripUpdatesGet		godoc
+//
+//	@Summary		Get trip updates as GTFS-Realtime
+//	@Description	A GTFS-Realtime FeedMessage with a trip update for each trip with realtime departures, Irish Rail train codes and station codes are mapped to the ids of the static timetable when it is loaded
+//	@Description	Luas forecasts are not for a trip, each is a trip update of its route and the direction of its destination with the one stop, with the entity id source:line:direction:stop:n for the nth tram in order of time
+//	@Tags			V0
+//	@Produce		application/x-protobuf,json
+//	@Param			debug	query		bool	false	"Respond with the feed as JSON instead"
+//	@Success		200		{string}	string
+//	@Failure		400		{object}	helpers.Error
+//	@Router			/v0/gtfs-rt/trip-updates [get]
+func (s *Server) V0GTFSRTTripUpdatesGet(c *gin.Context) {
+	respondFeed(c, s.Dataset.TripUpdatesFeed(s.Schedule))
+}
+
+// V0GTFSRTAlertsGet			godoc
+//
+//	@Summary		Get service alerts as GTFS-Realtime
+//	@Description	A GTFS-Realtime FeedMessage with the alerts which have not expired and an alert for each Luas line which is not operating normally
+//	@Tags			V0
+//	@Produce		application/x-protobuf,json
+//	@Param			debug	query		bool	false	"Respond with the feed as JSON instead"
+//	@Success		200		{string}	string
+//	@Failure		400		{object}	helpers.Error
+//	@Router			/v0/gtfs-rt/alerts [get]
+func (s *Server) V0GTFSRTAlertsGet(c *gin.Context) {
+	respondFeed(c, s.Dataset.AlertsFeed(s.Schedule))
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MobilityData/gtfs-realtime-bindings/golang/gtfs"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/protobuf"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGTFSRTRoutes(t *testing.T) {
+	s := NewServer(config.Config{})
+	s.Dataset.Set(transit.Dataset{
+		Vehicles: []transit.Vehicle{{ID: "V1", Source: "gtfsr", TripID: "3249_10466", Latitude: 53.3498, Longitude: -6.2603}},
+		Departures: []transit.Departure{
+			{StopID: "HSTON", Source: "irishrail", Operator: "irishrail", TripID: "E109", ExpectedDeparture: time.Now().Add(time.Minute), Realtime: true},
+			{StopID: "STS", Source: "luas", Operator: "luas", RouteID: "green", Direction: "Outbound", ExpectedArrival: time.Now().Add(time.Minute), Realtime: true},
+		},
+		LineStatuses: []transit.LineStatus{{Operator: "luas", RouteID: "green", Name: "Luas Green Line", Message: "No trams between Sandyford and Brides Glen"}},
+	})
+	s.Dataset.SetAlerts([]transit.Alert{{ID: "A1"}})
+	get := func(t *testing.T, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		s.HTTP.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+
+		return w
+	}
+
+	for url, ids := range map[string][]string{
+		"/v0/gtfs-rt/vehicle-positions": {"gtfsr:V1"},
+		"/v0/gtfs-rt/trip-updates":      {"irishrail:E109", "luas:green:Outbound:STS:1"},
+		"/v0/gtfs-rt/alerts":            {"A1", "luas-green"},
+	} {
+		t.Run("serves "+url+" as protobuf", func(t *testing.T) {
+			w := get(t, url)
+			assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+			assert.Equal(t, protobuf.MIME, w.Header().Get("Content-Type"))
+
+			f := &gtfs.FeedMessage{}
+			assert.NoError(t, proto.Unmarshal(w.Body.Bytes(), f), "could not decode feed")
+			assert.Equal(t, "2.0", f.GetHeader().GetGtfsRealtimeVersion())
+			var entityIDs []string
+			for _, e := range f.GetEntity() {
+				entityIDs = append(entityIDs, e.GetId())
+			}
+			assert.Equal(t, ids, entityIDs)
+		})
+
+		t.Run("serves "+url+" as json when debugging", func(t *testing.T) {
+			w := get(t, url+"?debug=true")
+			assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+
+			var body struct {
+				Header struct {
+					Version string `json:"gtfs_realtime_version"`
+				} `json:"header"`
+				Entity []struct {
+					ID string `json:"id"`
+				} `json:"entity"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+			assert.Equal(t, "2.0", body.Header.Version)
+			assert.Len(t, body.Entity, len(ids))
+		})
+	}
+
+	t.Run("rejects an invalid debug", func(t *testing.T) {
+		w := get(t, "/v0/gtfs-rt/alerts?debug=maybe")
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+		assert.Equal(t, `{"error":"debug must be true or false"}`, w.Body.String())
+	})
+
+	t.Run("serves debug=false as protobuf", func(t *testing.T) {
+		w := get(t, "/v0/gtfs-rt/alerts?debug=false")
+		assert.Equal(t, protobuf.MIME, w.Header().Get("Content-Type"))
+	})
+
+	t.Run("responds 500 when the feed cannot be marshalled", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		s.Dataset.SetAlerts([]transit.Alert{{ID: "\xff"}})
+		w := httptest.NewRecorder()
+		s.HTTP.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v0/gtfs-rt/alerts?debug=true", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code, "expected an id which is not UTF-8 to fail as JSON")
+		assert.Equal(t, `{"error":"a server error was encountered"}`, w.Body.String())
+	})
+}
@@ -7,15 +7,32 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/mcgovman/wheresmylift/lib/handoff"
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
 	"github.com/mcgovman/wheresmylift/packages/api/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
 	"github.com/rs/cors"
+	"github.com/rs/zerolog/log"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 type Server struct {
-	Config config.Config
-	HTTP   *http.Server
+	Config  config.Config
+	HTTP    *http.Server
+	Dataset *dataset.Dataset
+	// Subscriber keeps the dataset up to date from the aggregator, it is nil
+	// when no aggregator is configured
+	Subscriber *handoff.Subscriber
+	// Schedule is the static timetable, it is nil when none is loaded
+	Schedule *schedule.Schedule
+	// Heartbeat is how often streams send a heartbeat, DefaultHeartbeat when
+	// it is zero
+	Heartbeat time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 func NewServer(config config.Config) *Server {
@@ -39,19 +56,57 @@ func NewServer(config config.Config) *Server {
 		ReadHeaderTimeout: 100 * time.Millisecond,
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	s := &Server{
-		Config: config,
-		HTTP:   httpSrv,
+		Config:  config,
+		HTTP:    httpSrv,
+		Dataset: dataset.New(),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	if config.Aggregator.URL != "" {
+		s.Subscriber = handoff.NewSubscriber(config.Aggregator.URL, s.setDataset)
 	}
 
 	r.GET("", s.RootGet)
 	r.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	r.GET("v0/healthcheck", s.V0HealthCheckGet)
+	r.GET("v0/alerts", negotiate(formatProtobuf), s.V0AlertsGet)
+	r.GET("v0/vehicles", negotiate(formatGeoJSON, formatProtobuf), s.V0VehiclesGet)
+	r.GET("v0/vehicles/:id", negotiate(formatGeoJSON), s.V0VehicleGet)
+	r.GET("v0/stops", negotiate(formatGeoJSON), s.V0StopsGet)
+	r.GET("v0/stops/nearby", negotiate(formatGeoJSON), s.V0StopsNearbyGet)
+	r.GET("v0/stops/:id", negotiate(formatGeoJSON), s.V0StopGet)
+	r.GET("v0/stops/:id/departures", negotiate(formatProtobuf), s.V0StopDeparturesGet)
+	r.GET("v0/routes", s.V0RoutesGet)
+	r.GET("v0/routes/:id", negotiate(formatGeoJSON), s.V0RouteGet)
+	r.GET("v0/operators", s.V0OperatorsGet)
+	r.GET("v0/proto", s.V0ProtoGet)
+	r.GET("v0/gtfs-rt/vehicle-positions", s.V0GTFSRTVehiclePositionsGet)
+	r.GET("v0/gtfs-rt/trip-updates", s.V0GTFSRTTripUpdatesGet)
+	r.GET("v0/gtfs-rt/alerts", s.V0GTFSRTAlertsGet)
+	r.GET("v0/siri/vehicle-monitoring", s.V0SiriVehicleMonitoringGet)
+	r.GET("v0/siri/situation-exchange", s.V0SiriSituationExchangeGet)
+	r.GET("v0/search", s.V0SearchGet)
+	r.GET("v0/stream/vehicles", s.V0StreamVehiclesGet)
+	r.GET("v0/ws", s.V0WebSocketGet)
 
 	return s
 }
 
+// setDataset fills in what the aggregator handed off from the static
+// timetable before it replaces the dataset
+func (s *Server) setDataset(data transit.Dataset) {
+	dataset.FillVehicles(s.Schedule, data.Vehicles)
+	s.Dataset.Set(data)
+}
+
 func (s *Server) Start() error {
+	if s.Subscriber != nil {
+		log.Info().Str("url", s.Subscriber.URL).Msg("subscribing to aggregator")
+		go s.Subscriber.Run(s.ctx)
+	}
+
 	if err := s.HTTP.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("failed to start HTTP server: %w", err)
 	}
@@ -60,6 +115,8 @@ func (s *Server) Start() error {
 }
 
 func (s *Server) Stop(ctx context.Context) {
+	s.cancel()
+
 	// Ignoring the errors here as it is difficult to test that the shutdown will fail
 	// To counter this we attempt to shutdown gracefully, and if we can't, we forcefully do so
 	_ = s.HTTP.Shutdown(ctx)
@@ -12,7 +12,11 @@ import (
 	"time"
 
 	"github.com/mcgovman/wheresmylift/lib/go-test-utils"
+	"github.com/mcgovman/wheresmylift/lib/handoff"
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
 	"github.com/mcgovman/wheresmylift/packages/api/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
 	"github.com/nsf/jsondiff"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -126,6 +130,58 @@ func TestStart(t *testing.T) {
 		)
 		assert.Len(t, logSink.Logs, 1, "expect only one log entry")
 	})
+
+	t.Run("the server follows the aggregator", func(t *testing.T) {
+		publisher := handoff.NewPublisher()
+		publisher.Publish(transit.Dataset{Alerts: []transit.Alert{{ID: "A1"}}})
+		aggregator := httptest.NewServer(publisher)
+		t.Cleanup(aggregator.Close)
+
+		portNum, err := rand.Int(rand.Reader, big.NewInt((65000-1024+1)+1024))
+		assert.NoError(t, err)
+		cfg := config.Config{
+			HTTP: config.HTTP{
+				ListenAddress: fmt.Sprintf(":%d", portNum),
+			},
+			Aggregator: config.Aggregator{
+				URL: aggregator.URL,
+			},
+		}
+		srv := NewServer(cfg)
+
+		logSink := test.LogSink{}
+		log.Logger = zerolog.New(&logSink)
+
+		go func() {
+			_ = srv.Start()
+		}()
+
+		assert.Eventually(t, srv.Subscriber.Connected, time.Second, 10*time.Millisecond, "expected to connect to the aggregator")
+		assert.Equal(t, []transit.Alert{{ID: "A1"}}, srv.Dataset.Alerts(dataset.AlertFilter{}))
+
+		publisher.Publish(transit.Dataset{Alerts: []transit.Alert{{ID: "A2"}}})
+		assert.Eventually(t, func() bool {
+			alerts := srv.Dataset.Alerts(dataset.AlertFilter{})
+
+			return len(alerts) == 1 && alerts[0].ID == "A2"
+		}, time.Second, 10*time.Millisecond, "expected the handed off alerts")
+
+		srv.Stop(context.Background())
+		assert.Eventually(t, func() bool { return publisher.Subscribers() == 0 }, time.Second, 10*time.Millisecond, "expected to unsubscribe once stopped")
+	})
+}
+
+func TestSetDataset(t *testing.T) {
+	t.Run("fills in the vehicles from the static timetable", func(t *testing.T) {
+		sched, err := schedule.LoadGTFS("../../../../lib/schedule/testdata/gtfs")
+		assert.NoError(t, err, "could not load GTFS static feed")
+		srv := NewServer(config.Config{})
+		srv.Schedule = sched
+
+		srv.setDataset(transit.Dataset{Vehicles: []transit.Vehicle{{ID: "1", Source: "gtfsr", RouteID: "3249_46342"}}})
+		vehicles := srv.Dataset.Vehicles(dataset.VehicleFilter{OperatorID: "7778019", Mode: transit.ModeBus})
+		assert.Len(t, vehicles, 1, "expected the GTFS-Realtime vehicle to be filtered on its route")
+	})
 }
 
 func TestStop(t *testing.T) {
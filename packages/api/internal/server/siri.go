@@ -0,0 +1,102 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	libsiri "github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
+	h "github.com/mcgovman/wheresmylift/packages/api/internal/helpers"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/siri"
+	"github.com/rs/zerolog/log"
+)
+
+var errInvalidPreviewInterval = errors.New("PreviewInterval " + libsiri.ErrInvalidDuration.Error())
+
+// previewInterval reads the PreviewInterval of a SIRI request, it is zero when
+// not given and cannot be negative
+func previewInterval(c *gin.Context) (time.Duration, error) {
+	s := c.Query("PreviewInterval")
+	if s == "" {
+		return 0, nil
+	}
+
+	d, err := libsiri.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, errInvalidPreviewInterval
+	}
+
+	return d, nil
+}
+
+func respondSiri(c *gin.Context, s libsiri.Siri) {
+	b, err := siri.Marshal(s)
+	if err != nil {
+		log.Error().Err(err).Msg("could not respond with siri")
+		h.RespondWithError(c, errors.New("a server error was encountered"), http.StatusInternalServerError)
+
+		return
+	}
+
+	c.Data(http.StatusOK, siri.MIME+"; charset=utf-8", b)
+}
+
+// V0SiriVehicleMonitoringGet	godoc
+//
+//	@Summary		Get vehicle positions as SIRI VehicleMonitoring
+//	@Description	A SIRI 2.0 VehicleMonitoringDelivery with a VehicleActivity for each vehicle, its ids are those of the JSON responses
+//	@Description	With a PreviewInterval the onward calls of each vehicle expected within it are included
+//	@Tags			V0
+//	@Produce		application/xml
+//	@Param			LineRef			query		string	false	"Only vehicles on this route id"
+//	@Param			VehicleRef		query		string	false	"Only the vehicle with this id"
+//	@Param			PreviewInterval	query		string	false	"ISO 8601 duration such as PT30M of the onward calls to include"
+//	@Success		200				{string}	string
+//	@Failure		400				{object}	helpers.Error
+//	@Router			/v0/siri/vehicle-monitoring [get]
+func (s *Server) V0SiriVehicleMonitoringGet(c *gin.Context) {
+	preview, err := previewInterval(c)
+	if err != nil {
+		h.RespondWithError(c, err, http.StatusBadRequest)
+
+		return
+	}
+
+	filter := dataset.VehicleFilter{RouteID: c.Query("LineRef"), VehicleID: c.Query("VehicleRef")}
+	respondSiri(c, siri.VehicleMonitoring(s.Dataset.VehicleJourneys(filter, s.Schedule, preview), time.Now()))
+}
+
+// V0SiriSituationExchangeGet	godoc
+//
+//	@Summary		Get service alerts as SIRI SituationExchange
+//	@Description	A SIRI 2.0 SituationExchangeDelivery with a PtSituationElement for each alert which has not expired and for each Luas line which is not operating normally
+//	@Description	With a PreviewInterval only the alerts active at some time within it are included
+//	@Tags			V0
+//	@Produce		application/xml
+//	@Param			LineRef			query		string	false	"Only situations affecting this route id"
+//	@Param			PreviewInterval	query		string	false	"ISO 8601 duration such as PT30M of the situations to include"
+//	@Success		200				{string}	string
+//	@Failure		400				{object}	helpers.Error
+//	@Router			/v0/siri/situation-exchange [get]
+func (s *Server) V0SiriSituationExchangeGet(c *gin.Context) {
+	preview, err := previewInterval(c)
+	if err != nil {
+		h.RespondWithError(c, err, http.StatusBadRequest)
+
+		return
+	}
+
+	lineRef := c.Query("LineRef")
+	alerts := s.Dataset.Alerts(dataset.AlertFilter{RouteID: lineRef, ActiveWithin: preview})
+	lines := []transit.LineStatus{}
+	for _, l := range s.Dataset.LineStatuses() {
+		if lineRef == "" || l.RouteID == lineRef {
+			lines = append(lines, l)
+		}
+	}
+
+	respondSiri(c, siri.SituationExchange(alerts, lines, time.Now()))
+}
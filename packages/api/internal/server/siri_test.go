@@ -0,0 +1,135 @@
+package server
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSiriRoutes(t *testing.T) {
+	now := time.Now()
+	soon, later := now.Add(10*time.Minute), now.Add(2*time.Hour)
+	s := NewServer(config.Config{})
+	s.Dataset.Set(transit.Dataset{
+		Vehicles: []transit.Vehicle{
+			{ID: "V1", Source: "gtfsr", RouteID: "3249_46342", TripID: "3249_10466"},
+			{ID: "V2", Source: "gtfsr", RouteID: "3249_46350"},
+		},
+		Departures: []transit.Departure{
+			{StopID: "8220DB000335", TripID: "3249_10466", StopSequence: 13, ExpectedDeparture: now.Add(5 * time.Minute), Realtime: true},
+			{StopID: "8250DB002002", TripID: "3249_10466", StopSequence: 15, ExpectedDeparture: now.Add(45 * time.Minute), Realtime: true},
+		},
+		LineStatuses: []transit.LineStatus{{Operator: "luas", RouteID: "green", Name: "Luas Green Line"}},
+	})
+	s.Dataset.SetAlerts([]transit.Alert{
+		{ID: "A1", InformedEntities: []transit.InformedEntity{{RouteID: "3249_46342"}}},
+		{ID: "A2", ActivePeriods: []transit.ActivePeriod{{Start: &soon}}},
+		{ID: "A3", ActivePeriods: []transit.ActivePeriod{{Start: &later}}},
+	})
+	get := func(t *testing.T, url string) (*httptest.ResponseRecorder, *siri.ServiceDelivery) {
+		w := httptest.NewRecorder()
+		s.HTTP.Handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+
+		var body siri.Siri
+		if w.Code == http.StatusOK {
+			assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &body), "could not unmarshal response")
+		}
+
+		return w, body.ServiceDelivery
+	}
+	vehicleRefs := func(d *siri.ServiceDelivery) []string {
+		refs := []string{}
+		for _, delivery := range d.VehicleMonitoringDeliveries {
+			for _, a := range delivery.VehicleActivities {
+				refs = append(refs, a.MonitoredVehicleJourney.VehicleRef)
+			}
+		}
+
+		return refs
+	}
+	situationNumbers := func(d *siri.ServiceDelivery) []string {
+		numbers := []string{}
+		for _, delivery := range d.SituationExchangeDeliveries {
+			for _, s := range delivery.Situations {
+				numbers = append(numbers, s.SituationNumber)
+			}
+		}
+
+		return numbers
+	}
+
+	t.Run("monitors every vehicle", func(t *testing.T) {
+		w, d := get(t, "/v0/siri/vehicle-monitoring")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		assert.Equal(t, []string{"V1", "V2"}, vehicleRefs(d))
+		assert.Nil(t, d.VehicleMonitoringDeliveries[0].VehicleActivities[0].MonitoredVehicleJourney.OnwardCalls, "expected no onward calls without a preview interval")
+	})
+
+	t.Run("monitors the vehicles of a line or a vehicle", func(t *testing.T) {
+		_, d := get(t, "/v0/siri/vehicle-monitoring?LineRef=3249_46350")
+		assert.Equal(t, []string{"V2"}, vehicleRefs(d))
+
+		_, d = get(t, "/v0/siri/vehicle-monitoring?VehicleRef=V1")
+		assert.Equal(t, []string{"V1"}, vehicleRefs(d))
+
+		_, d = get(t, "/v0/siri/vehicle-monitoring?VehicleRef=V9")
+		assert.Empty(t, vehicleRefs(d))
+	})
+
+	t.Run("previews the onward calls within the interval", func(t *testing.T) {
+		_, d := get(t, "/v0/siri/vehicle-monitoring?VehicleRef=V1&PreviewInterval=PT30M")
+		calls := d.VehicleMonitoringDeliveries[0].VehicleActivities[0].MonitoredVehicleJourney.OnwardCalls
+		if assert.NotNil(t, calls) && assert.Len(t, calls.OnwardCalls, 1) {
+			assert.Equal(t, "8220DB000335", calls.OnwardCalls[0].StopPointRef)
+		}
+
+		_, d = get(t, "/v0/siri/vehicle-monitoring?VehicleRef=V1&PreviewInterval=PT1H")
+		assert.Len(t, d.VehicleMonitoringDeliveries[0].VehicleActivities[0].MonitoredVehicleJourney.OnwardCalls.OnwardCalls, 2)
+	})
+
+	t.Run("exchanges every situation", func(t *testing.T) {
+		w, d := get(t, "/v0/siri/situation-exchange")
+		assert.Equal(t, http.StatusOK, w.Code, "expected status 200 from endpoint")
+		assert.Equal(t, []string{"A1", "A2", "A3", "luas-green"}, situationNumbers(d))
+	})
+
+	t.Run("exchanges the situations of a line", func(t *testing.T) {
+		_, d := get(t, "/v0/siri/situation-exchange?LineRef=3249_46342")
+		assert.Equal(t, []string{"A1"}, situationNumbers(d))
+
+		_, d = get(t, "/v0/siri/situation-exchange?LineRef=green")
+		assert.Equal(t, []string{"luas-green"}, situationNumbers(d))
+	})
+
+	t.Run("exchanges the situations within the preview interval", func(t *testing.T) {
+		_, d := get(t, "/v0/siri/situation-exchange?PreviewInterval=PT1H")
+		assert.Equal(t, []string{"A1", "A2", "luas-green"}, situationNumbers(d))
+	})
+
+	for _, url := range []string{"/v0/siri/vehicle-monitoring?PreviewInterval=30", "/v0/siri/vehicle-monitoring?PreviewInterval=-PT30M", "/v0/siri/situation-exchange?PreviewInterval=P1M"} {
+		t.Run("rejects an invalid preview interval for "+url, func(t *testing.T) {
+			w, _ := get(t, url)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+			assert.Equal(t, `{"error":"PreviewInterval must be an ISO 8601 duration such as PT30M"}`, w.Body.String())
+		})
+	}
+}
+
+func TestRespondSiri(t *testing.T) {
+	t.Run("fails for a document which cannot be encoded", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		respondSiri(c, siri.Siri{ServiceDelivery: &siri.ServiceDelivery{ResponseTimestamp: time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)}})
+		assert.Equal(t, http.StatusInternalServerError, w.Code, "expected status 500 for a year past 9999")
+		assert.Equal(t, `{"error":"a server error was encountered"}`, w.Body.String())
+	})
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
+	h "github.com/mcgovman/wheresmylift/packages/api/internal/helpers"
+)
+
+// DefaultHeartbeat is how often a stream with nothing to send says it is still
+// there, well within the 100 second timeout of Cloudflare
+const DefaultHeartbeat = 15 * time.Second
+
+// writeEvent writes a server-sent event with JSON data and flushes it
+func writeEvent(c *gin.Context, id uint64, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("could not marshal %s event: %w", event, err)
+	}
+
+	if _, err := fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", id, event, b); err != nil {
+		return fmt.Errorf("could not write %s event: %w", event, err)
+	}
+	c.Writer.Flush()
+
+	return nil
+}
+
+// V0StreamVehiclesGet		godoc
+//
+//	@Summary		Stream vehicle positions
+//	@Description	A stream of server-sent events, a snapshot event with every vehicle and then a delta event with the vehicles which changed or were removed every time the aggregator hands off new positions
+//	@Description	A vehicle which leaves the filters is in the removed refs of a delta, and so is any vehicle not matching them after resuming. A ref is the source and id of a vehicle, as ids are only unique within a source. The id of each event is the version it brings the vehicles to, reconnecting with it as Last-Event-ID resumes the stream from that version, or starts it again from a snapshot when the version is too old
+//	@Description	A comment is sent as a heartbeat when nothing else has been sent for 15 seconds
+//	@Tags			V0
+//	@Produce		text/event-stream
+//	@Param			Last-Event-ID	header		string	false	"Version to resume the stream from"
+//	@Param			bbox			query		string	false	"Only vehicles within minLon,minLat,maxLon,maxLat"
+//	@Param			operator		query		string	false	"Only vehicles of this operator id"
+//	@Param			route			query		string	false	"Only vehicles on this route id"
+//	@Param			mode			query		string	false	"Only vehicles of this mode"	Enums(bus, rail, tram, ferry)
+//	@Success		200				{object}	dataset.VehicleDelta
+//	@Failure		400				{object}	helpers.Error
+//	@Router			/v0/stream/vehicles [get]
+func (s *Server) V0StreamVehiclesGet(c *gin.Context) {
+	filter, err := vehicleFilter(c)
+	if err != nil {
+		h.RespondWithError(c, err, http.StatusBadRequest)
+
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := s.Heartbeat
+	if heartbeat == 0 {
+		heartbeat = DefaultHeartbeat
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	// known is the vehicles the client holds, which are not known when it
+	// resumes until the next snapshot
+	var known map[dataset.VehicleRef]bool
+	version, err := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+	resuming := err == nil
+	for {
+		changed := s.Dataset.Changed()
+
+		deltas, ok := s.Dataset.VehicleChanges(version)
+		if !resuming || !ok {
+			snapshot := s.Dataset.VehicleSnapshot(filter)
+			if writeEvent(c, snapshot.Version, "snapshot", snapshot) != nil {
+				return
+			}
+			version, resuming, deltas = snapshot.Version, true, nil
+			known = make(map[dataset.VehicleRef]bool, len(snapshot.Vehicles))
+			for _, v := range snapshot.Vehicles {
+				known[dataset.RefOf(v)] = true
+			}
+			ticker.Reset(heartbeat)
+		}
+
+		for _, delta := range deltas {
+			version = delta.Version
+			delta = delta.Filter(filter, known)
+			if len(delta.Updated) == 0 && len(delta.Removed) == 0 {
+				continue
+			}
+			if writeEvent(c, delta.Version, "delta", delta) != nil {
+				return
+			}
+			ticker.Reset(heartbeat)
+		}
+
+		select {
+		case <-changed:
+		case <-ticker.C:
+			if _, err := fmt.Fprint(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type event struct {
+	id    string
+	event string
+	data  string
+}
+
+// readEvents sends the events of a stream on a channel, heartbeats are sent as
+// events named heartbeat, and closes it when the stream ends
+func readEvents(body *bufio.Scanner) <-chan event {
+	events := make(chan event, 16)
+	go func() {
+		defer close(events)
+		var e event
+		for body.Scan() {
+			line := body.Text()
+			switch {
+			case line == "":
+				if e.event != "" {
+					events <- e
+				}
+				e = event{}
+			case strings.HasPrefix(line, ": "):
+				e.event = "heartbeat"
+			case strings.HasPrefix(line, "id: "):
+				e.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				e.event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				e.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}()
+
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan event) event {
+	t.Helper()
+	select {
+	case e, ok := <-events:
+		require.True(t, ok, "expected the stream not to have ended")
+
+		return e
+	case <-time.After(time.Second):
+		require.Fail(t, "expected an event")
+
+		return event{}
+	}
+}
+
+// brokenWriter is a response writer whose writes fail once it has written n
+// times, as when the client has gone
+type brokenWriter struct {
+	*httptest.ResponseRecorder
+	n int
+}
+
+func (w *brokenWriter) Write(b []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("broken pipe")
+	}
+	w.n--
+
+	return w.ResponseRecorder.Write(b)
+}
+
+// serveBroken serves a request with a writer which fails after n writes, and
+// waits for the handler to give up
+func serveBroken(t *testing.T, handler gin.HandlerFunc, url string, n int) *brokenWriter {
+	t.Helper()
+	w := &brokenWriter{ResponseRecorder: httptest.NewRecorder(), n: n}
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, url, nil)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler(c)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.Fail(t, "expected the handler to return")
+	}
+
+	return w
+}
+
+func TestV0StreamVehiclesGet(t *testing.T) {
+	bus := transit.Vehicle{ID: "V1", Operator: "7778019", Mode: transit.ModeBus, Latitude: 53.3498, Longitude: -6.2603}
+	train := transit.Vehicle{ID: "E109", Operator: "irishrail", Mode: transit.ModeRail, Latitude: 53.3531, Longitude: -6.2461}
+
+	stream := func(t *testing.T, s *Server, url string, lastEventID string) <-chan event {
+		srv := httptest.NewServer(s.HTTP.Handler)
+		t.Cleanup(srv.Close)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+url, nil)
+		require.NoError(t, err, "could not create http request")
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err, "could not connect to stream")
+		t.Cleanup(func() { resp.Body.Close() })
+		require.Equal(t, http.StatusOK, resp.StatusCode, "expected status 200 from endpoint")
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		return readEvents(bufio.NewScanner(resp.Body))
+	}
+
+	t.Run("sends a snapshot and then deltas", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{bus}})
+		events := stream(t, s, "/v0/stream/vehicles", "")
+
+		e := nextEvent(t, events)
+		assert.Equal(t, event{id: "1", event: "snapshot"}, event{id: e.id, event: e.event})
+		var snapshot dataset.VehicleSnapshot
+		assert.NoError(t, json.Unmarshal([]byte(e.data), &snapshot))
+		assert.Equal(t, []string{"V1"}, vehicleIDs(snapshot.Vehicles))
+
+		moved := bus
+		moved.Latitude = 53.35
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{moved, train}})
+		e = nextEvent(t, events)
+		assert.Equal(t, event{id: "2", event: "delta"}, event{id: e.id, event: e.event})
+		var delta dataset.VehicleDelta
+		assert.NoError(t, json.Unmarshal([]byte(e.data), &delta))
+		assert.Equal(t, []string{"E109", "V1"}, vehicleIDs(delta.Updated))
+
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{moved}})
+		e = nextEvent(t, events)
+		assert.Equal(t, `{"version":3,"updated":[],"removed":[{"source":"","id":"E109"}]}`, e.data)
+	})
+
+	t.Run("applies the filters of the vehicles list", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{bus, train}})
+		events := stream(t, s, "/v0/stream/vehicles?mode=rail", "")
+
+		e := nextEvent(t, events)
+		var snapshot dataset.VehicleSnapshot
+		assert.NoError(t, json.Unmarshal([]byte(e.data), &snapshot))
+		assert.Equal(t, []string{"E109"}, vehicleIDs(snapshot.Vehicles))
+
+		moved := bus
+		moved.Latitude = 53.35
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{moved, train}})
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{moved}})
+		e = nextEvent(t, events)
+		assert.Equal(t, event{id: "3", event: "delta", data: `{"version":3,"updated":[],"removed":[{"source":"","id":"E109"}]}`}, e, "expected the delta of the bus alone to be skipped")
+
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{moved, train}})
+		e = nextEvent(t, events)
+		assert.Equal(t, event{id: "4", event: "delta", data: `{"version":4,"updated":[{"id":"E109","source":"","operator":"irishrail","mode":"rail","latitude":53.3531,"longitude":-6.2461,"timestamp":"0001-01-01T00:00:00Z"}],"removed":[]}`}, e)
+	})
+
+	t.Run("resumes from Last-Event-ID", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{bus}})
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{bus, train}})
+		events := stream(t, s, "/v0/stream/vehicles", "1")
+
+		e := nextEvent(t, events)
+		assert.Equal(t, "2", e.id)
+		assert.Equal(t, "delta", e.event, "expected the missed delta rather than a snapshot")
+	})
+
+	t.Run("starts from a snapshot when Last-Event-ID is unknown", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{bus}})
+		events := stream(t, s, "/v0/stream/vehicles", "42")
+
+		e := nextEvent(t, events)
+		assert.Equal(t, event{id: "1", event: "snapshot"}, event{id: e.id, event: e.event})
+	})
+
+	t.Run("sends heartbeats", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		s.Heartbeat = 10 * time.Millisecond
+		events := stream(t, s, "/v0/stream/vehicles", "")
+
+		assert.Equal(t, "snapshot", nextEvent(t, events).event)
+		assert.Equal(t, "heartbeat", nextEvent(t, events).event)
+	})
+
+	t.Run("ends when the server stops", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		events := stream(t, s, "/v0/stream/vehicles", "")
+		assert.Equal(t, "snapshot", nextEvent(t, events).event)
+
+		s.Stop(context.Background())
+		select {
+		case _, ok := <-events:
+			assert.False(t, ok, "expected the stream to end")
+		case <-time.After(time.Second):
+			assert.Fail(t, "expected the stream to end")
+		}
+	})
+
+	t.Run("ends when the client has gone", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{bus}})
+		w := serveBroken(t, s.V0StreamVehiclesGet, "/v0/stream/vehicles", 0)
+		assert.Empty(t, w.Body.String(), "expected the snapshot not to be written")
+
+		s.Heartbeat = 10 * time.Millisecond
+		w = serveBroken(t, s.V0StreamVehiclesGet, "/v0/stream/vehicles", 1)
+		assert.True(t, strings.HasPrefix(w.Body.String(), "id: 1\nevent: snapshot\n"), "expected the snapshot before the heartbeat failed")
+	})
+
+	t.Run("ends when a vehicle cannot be marshalled", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		events := stream(t, s, "/v0/stream/vehicles", "")
+		assert.Equal(t, "snapshot", nextEvent(t, events).event)
+
+		broken := bus
+		broken.Latitude = math.NaN()
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{broken}})
+		select {
+		case _, ok := <-events:
+			assert.False(t, ok, "expected the stream to end")
+		case <-time.After(time.Second):
+			assert.Fail(t, "expected the stream to end")
+		}
+	})
+
+	t.Run("rejects an invalid bounding box", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		w := httptest.NewRecorder()
+		r, _ := http.NewRequest(http.MethodGet, "/v0/stream/vehicles?bbox=1", nil)
+		s.HTTP.Handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+	})
+}
+
+func vehicleIDs(vehicles []transit.Vehicle) []string {
+	ids := []string{}
+	for _, v := range vehicles {
+		ids = append(ids, v.ID)
+	}
+
+	return ids
+}
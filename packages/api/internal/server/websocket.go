@@ -0,0 +1,328 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// MaxSubscriptions is how many topics a connection can subscribe to
+	MaxSubscriptions = 20
+	// writeWait is how long a client has to take a message before it is
+	// disconnected as too slow
+	writeWait = 10 * time.Second
+	// maxRequestSize is the largest message a client can send
+	maxRequestSize = 4096
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	// The API is public, like the CORS headers of every other endpoint
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// wsRequest is a message from a client, subscribing to or unsubscribing from
+// a topic
+type wsRequest struct {
+	Type  string `json:"type" example:"subscribe" enums:"subscribe,unsubscribe"`
+	Topic string `json:"topic" example:"stop:8220DB000334:departures"`
+}
+
+// wsMessage is a message to a client. Vehicles topics are sent a snapshot and
+// then deltas, the other topics are sent an update with everything whenever it
+// changes
+type wsMessage struct {
+	Type  string `json:"type" example:"update" enums:"subscribed,unsubscribed,snapshot,delta,update,error"`
+	Topic string `json:"topic,omitempty" example:"stop:8220DB000334:departures"`
+	Data  any    `json:"data,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// topic is something a client can subscribe to. update returns what the
+// client has yet to be sent of it
+type topic interface {
+	update(s *Server) []wsMessage
+}
+
+type vehiclesTopic struct {
+	name    string
+	filter  dataset.VehicleFilter
+	version uint64
+	known   map[dataset.VehicleRef]bool
+}
+
+func (t *vehiclesTopic) update(s *Server) []wsMessage {
+	deltas, ok := s.Dataset.VehicleChanges(t.version)
+	if t.known == nil || !ok {
+		snapshot := s.Dataset.VehicleSnapshot(t.filter)
+		t.version = snapshot.Version
+		t.known = make(map[dataset.VehicleRef]bool, len(snapshot.Vehicles))
+		for _, v := range snapshot.Vehicles {
+			t.known[dataset.RefOf(v)] = true
+		}
+
+		return []wsMessage{{Type: "snapshot", Topic: t.name, Data: snapshot}}
+	}
+
+	var messages []wsMessage
+	for _, delta := range deltas {
+		t.version = delta.Version
+		if delta = delta.Filter(t.filter, t.known); len(delta.Updated) != 0 || len(delta.Removed) != 0 {
+			messages = append(messages, wsMessage{Type: "delta", Topic: t.name, Data: delta})
+		}
+	}
+
+	return messages
+}
+
+// latestTopic is sent all of its data again whenever any of it changes
+type latestTopic struct {
+	name string
+	get  func(s *Server) any
+	last []byte
+}
+
+func (t *latestTopic) update(s *Server) []wsMessage {
+	data := t.get(s)
+	b, err := json.Marshal(data)
+	if err != nil || bytes.Equal(b, t.last) {
+		return nil
+	}
+	t.last = b
+
+	return []wsMessage{{Type: "update", Topic: t.name, Data: json.RawMessage(b)}}
+}
+
+// parseTopic parses the name of a topic, which is one of
+//
+//	vehicles
+//	vehicles:bbox:{minLon,minLat,maxLon,maxLat}
+//	vehicles:route:{id}
+//	vehicles:operator:{id}
+//	stop:{id}:departures
+//	alerts
+//	alerts:route:{id}
+//	alerts:stop:{id}
+//	alerts:operator:{id}
+func (s *Server) parseTopic(name string) (topic, error) {
+	parts := strings.SplitN(name, ":", 3)
+	switch {
+	case parts[0] == "vehicles":
+		t := &vehiclesTopic{name: name}
+		switch {
+		case len(parts) == 1:
+		case len(parts) == 3 && parts[1] == "bbox":
+			b, err := dataset.ParseBBox(parts[2])
+			if err != nil {
+				return nil, err
+			}
+			t.filter.BBox = &b
+		case len(parts) == 3 && parts[1] == "route" && parts[2] != "":
+			t.filter.RouteID = parts[2]
+		case len(parts) == 3 && parts[1] == "operator" && parts[2] != "":
+			t.filter.OperatorID = parts[2]
+		default:
+			return nil, fmt.Errorf("unknown topic %s", name)
+		}
+
+		return t, nil
+	case parts[0] == "stop" && len(parts) == 3 && parts[1] != "" && parts[2] == "departures":
+		stopID := parts[1]
+		filter := dataset.DepartureFilter{TimeRange: time.Hour, Limit: 20}
+		if _, ok := s.Dataset.StopDepartures(stopID, s.Schedule, filter); !ok {
+			return nil, errors.New("stop not found")
+		}
+
+		return &latestTopic{name: name, get: func(s *Server) any {
+			departures, _ := s.Dataset.StopDepartures(stopID, s.Schedule, filter)
+
+			return departures
+		}}, nil
+	case parts[0] == "alerts":
+		filter := dataset.AlertFilter{}
+		switch {
+		case len(parts) == 1:
+		case len(parts) == 3 && parts[1] == "route" && parts[2] != "":
+			filter.RouteID = parts[2]
+		case len(parts) == 3 && parts[1] == "stop" && parts[2] != "":
+			filter.StopID = parts[2]
+		case len(parts) == 3 && parts[1] == "operator" && parts[2] != "":
+			filter.OperatorID = parts[2]
+		default:
+			return nil, fmt.Errorf("unknown topic %s", name)
+		}
+
+		return &latestTopic{name: name, get: func(s *Server) any { return s.Dataset.Alerts(filter) }}, nil
+	default:
+		return nil, fmt.Errorf("unknown topic %s", name)
+	}
+}
+
+// V0WebSocketGet			godoc
+//
+//	@Summary		Subscribe to vehicles, departures and alerts
+//	@Description	A WebSocket which is sent what it subscribes to. Send {"type":"subscribe","topic":"..."} or {"type":"unsubscribe","topic":"..."} with one of the topics
+//	@Description	vehicles, vehicles:bbox:{minLon,minLat,maxLon,maxLat}, vehicles:route:{id}, vehicles:operator:{id}, stop:{id}:departures, alerts, alerts:route:{id}, alerts:stop:{id} or alerts:operator:{id}
+//	@Description	Vehicles topics are sent a snapshot and then deltas, the others are sent an update with everything whenever it changes. A connection can subscribe to at most 20 topics
+//	@Description	Updates are never queued, a client which falls behind is sent the latest when it catches up and is disconnected when it does not take a message for 10 seconds
+//	@Tags			V0
+//	@Success		101		{object}	server.wsMessage
+//	@Router			/v0/ws [get]
+func (s *Server) V0WebSocketGet(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already responded with the error
+		return
+	}
+	defer conn.Close()
+
+	heartbeat := s.Heartbeat
+	if heartbeat == 0 {
+		heartbeat = DefaultHeartbeat
+	}
+
+	requests := make(chan wsRequest)
+	done, quit := make(chan struct{}), make(chan struct{})
+	defer close(quit)
+	go func() {
+		defer close(done)
+		readRequests(conn, heartbeat, requests, quit)
+	}()
+
+	err = s.serveWebSocket(conn, heartbeat, requests, done)
+	log.Debug().Err(err).Str("remote_addr", c.Request.RemoteAddr).Msg("websocket closed")
+}
+
+// readRequests passes the requests of a client on until the connection fails,
+// the client is silent for two heartbeats, as it is pinged every one, or quit
+// is closed
+func readRequests(conn *websocket.Conn, heartbeat time.Duration, requests chan<- wsRequest, quit <-chan struct{}) {
+	conn.SetReadLimit(maxRequestSize)
+	extend := func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(2*heartbeat + writeWait))
+	}
+	_ = extend("")
+	conn.SetPongHandler(extend)
+
+	for {
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = extend("")
+
+		// A request which is not valid is answered with an error rather than
+		// disconnecting the client
+		var req wsRequest
+		if err := json.Unmarshal(b, &req); err != nil {
+			req = wsRequest{}
+		}
+
+		select {
+		case requests <- req:
+		case <-quit:
+			return
+		}
+	}
+}
+
+// serveWebSocket handles the requests of a client and sends it the updates of
+// its topics every time the dataset is set, or on each heartbeat for the
+// departures which depart as time passes. Each write waits for the client so
+// nothing is queued, updates in the meantime are sent as one once it is done
+func (s *Server) serveWebSocket(conn *websocket.Conn, heartbeat time.Duration, requests <-chan wsRequest, done <-chan struct{}) error {
+	send := func(messages ...wsMessage) error {
+		for _, m := range messages {
+			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := conn.WriteJSON(m); err != nil {
+				return fmt.Errorf("could not send %s message: %w", m.Type, err)
+			}
+		}
+
+		return nil
+	}
+
+	topics := map[string]topic{}
+	update := func() error {
+		for _, t := range topics {
+			if err := send(t.update(s)...); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	changed := s.Dataset.Changed()
+	for {
+		var err error
+		select {
+		case req := <-requests:
+			err = s.handleRequest(topics, req, send)
+		case <-changed:
+			changed = s.Dataset.Changed()
+			err = update()
+		case <-ticker.C:
+			if err = update(); err == nil {
+				err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+			}
+		case <-done:
+			return errors.New("client disconnected")
+		case <-s.ctx.Done():
+			_ = conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "server stopping"), time.Now().Add(writeWait))
+
+			return s.ctx.Err()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handleRequest subscribes or unsubscribes a client, sending a subscribed
+// topic what there already is of it
+func (s *Server) handleRequest(topics map[string]topic, req wsRequest, send func(...wsMessage) error) error {
+	fail := func(err error) error {
+		return send(wsMessage{Type: "error", Topic: req.Topic, Error: err.Error()})
+	}
+
+	switch req.Type {
+	case "subscribe":
+		if _, ok := topics[req.Topic]; ok {
+			return send(wsMessage{Type: "subscribed", Topic: req.Topic})
+		}
+		if len(topics) >= MaxSubscriptions {
+			return fail(fmt.Errorf("subscription limit of %d reached", MaxSubscriptions))
+		}
+
+		t, err := s.parseTopic(req.Topic)
+		if err != nil {
+			return fail(err)
+		}
+		topics[req.Topic] = t
+
+		return send(append([]wsMessage{{Type: "subscribed", Topic: req.Topic}}, t.update(s)...)...)
+	case "unsubscribe":
+		delete(topics, req.Topic)
+
+		return send(wsMessage{Type: "unsubscribed", Topic: req.Topic})
+	default:
+		return fail(errors.New("type must be subscribe or unsubscribe"))
+	}
+}
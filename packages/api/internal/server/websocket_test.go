@@ -0,0 +1,373 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/mcgovman/wheresmylift/lib/schedule"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/config"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type received struct {
+	Type  string          `json:"type"`
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+	Error string          `json:"error"`
+}
+
+// dialWebSocket connects to the websocket of a server. The test server does not
+// wait for the handlers of hijacked connections, so the test waits for them to
+// return once the connection is closed rather than have them outlive it
+func dialWebSocket(t *testing.T, s *Server) *websocket.Conn {
+	var handlers sync.WaitGroup
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlers.Add(1)
+		defer handlers.Done()
+		s.HTTP.Handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(handlers.Wait)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/v0/ws", nil)
+	require.NoError(t, err, "could not connect to websocket")
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func request(t *testing.T, conn *websocket.Conn, kind, topic string) {
+	t.Helper()
+	require.NoError(t, conn.WriteJSON(wsRequest{Type: kind, Topic: topic}), "could not send request")
+}
+
+func receive(t *testing.T, conn *websocket.Conn) received {
+	t.Helper()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	var m received
+	require.NoError(t, conn.ReadJSON(&m), "expected a message")
+
+	return m
+}
+
+func TestV0WebSocketGet(t *testing.T) {
+	logger := log.Logger
+	log.Logger = zerolog.Nop()
+	t.Cleanup(func() { log.Logger = logger })
+
+	bus := transit.Vehicle{ID: "V1", Operator: "7778019", Mode: transit.ModeBus, RouteID: "3249_46342", Latitude: 53.3498, Longitude: -6.2603}
+	train := transit.Vehicle{ID: "E109", Operator: "irishrail", Mode: transit.ModeRail, Latitude: 53.3531, Longitude: -6.2461}
+
+	t.Run("sends a snapshot and then deltas of vehicles", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{bus, train}})
+		conn := dialWebSocket(t, s)
+
+		request(t, conn, "subscribe", "vehicles:route:3249_46342")
+		assert.Equal(t, received{Type: "subscribed", Topic: "vehicles:route:3249_46342"}, receive(t, conn))
+		m := receive(t, conn)
+		assert.Equal(t, "snapshot", m.Type)
+		var snapshot dataset.VehicleSnapshot
+		assert.NoError(t, json.Unmarshal(m.Data, &snapshot))
+		assert.Equal(t, []string{"V1"}, vehicleIDs(snapshot.Vehicles))
+
+		moved := bus
+		moved.Latitude = 53.35
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{moved, train}})
+		m = receive(t, conn)
+		assert.Equal(t, "delta", m.Type)
+		var delta dataset.VehicleDelta
+		assert.NoError(t, json.Unmarshal(m.Data, &delta))
+		assert.Equal(t, []string{"V1"}, vehicleIDs(delta.Updated))
+	})
+
+	t.Run("accepts a bounding box", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{bus, train}})
+		conn := dialWebSocket(t, s)
+
+		request(t, conn, "subscribe", "vehicles:bbox:-6.255,53.35,-6.24,53.36")
+		receive(t, conn)
+		var snapshot dataset.VehicleSnapshot
+		assert.NoError(t, json.Unmarshal(receive(t, conn).Data, &snapshot))
+		assert.Equal(t, []string{"E109"}, vehicleIDs(snapshot.Vehicles))
+	})
+
+	t.Run("accepts an operator", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{bus, train}})
+		s.Dataset.SetAlerts([]transit.Alert{{ID: "A1", InformedEntities: []transit.InformedEntity{{OperatorID: "irishrail"}}}, {ID: "A2"}})
+		conn := dialWebSocket(t, s)
+
+		request(t, conn, "subscribe", "vehicles:operator:irishrail")
+		receive(t, conn)
+		var snapshot dataset.VehicleSnapshot
+		assert.NoError(t, json.Unmarshal(receive(t, conn).Data, &snapshot))
+		assert.Equal(t, []string{"E109"}, vehicleIDs(snapshot.Vehicles))
+
+		request(t, conn, "subscribe", "alerts:operator:irishrail")
+		receive(t, conn)
+		var alerts []transit.Alert
+		assert.NoError(t, json.Unmarshal(receive(t, conn).Data, &alerts))
+		if assert.Len(t, alerts, 1) {
+			assert.Equal(t, "A1", alerts[0].ID)
+		}
+	})
+
+	t.Run("sends the departures of a stop when they change", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		conn := dialWebSocket(t, s)
+
+		request(t, conn, "subscribe", "stop:LUAS24:departures")
+		assert.Equal(t, received{Type: "subscribed", Topic: "stop:LUAS24:departures"}, receive(t, conn))
+		assert.Equal(t, received{Type: "update", Topic: "stop:LUAS24:departures", Data: json.RawMessage("[]")}, receive(t, conn))
+
+		s.Dataset.Set(transit.Dataset{Vehicles: []transit.Vehicle{bus}})
+		s.Dataset.Set(transit.Dataset{Departures: []transit.Departure{{
+			StopID:            "LUAS24",
+			Source:            "luas",
+			ExpectedDeparture: time.Now().Add(5 * time.Minute).UTC().Truncate(time.Second),
+			Status:            transit.DepartureScheduled,
+			Realtime:          true,
+		}}})
+		m := receive(t, conn)
+		assert.Equal(t, "update", m.Type, "expected no update while the departures did not change")
+		var departures []transit.Departure
+		assert.NoError(t, json.Unmarshal(m.Data, &departures))
+		assert.Len(t, departures, 1)
+	})
+
+	t.Run("sends the alerts of a route", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		conn := dialWebSocket(t, s)
+
+		request(t, conn, "subscribe", "alerts:route:3249_46342")
+		receive(t, conn)
+		assert.Equal(t, json.RawMessage("[]"), receive(t, conn).Data)
+
+		s.Dataset.SetAlerts([]transit.Alert{
+			{ID: "A1", InformedEntities: []transit.InformedEntity{{RouteID: "3249_46342"}}},
+			{ID: "A2", InformedEntities: []transit.InformedEntity{{RouteID: "3249_46350"}}},
+		})
+		var alerts []transit.Alert
+		assert.NoError(t, json.Unmarshal(receive(t, conn).Data, &alerts))
+		if assert.Len(t, alerts, 1) {
+			assert.Equal(t, "A1", alerts[0].ID)
+		}
+	})
+
+	t.Run("stops sending what is unsubscribed from", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		conn := dialWebSocket(t, s)
+
+		request(t, conn, "subscribe", "alerts")
+		receive(t, conn)
+		receive(t, conn)
+		request(t, conn, "unsubscribe", "alerts")
+		assert.Equal(t, received{Type: "unsubscribed", Topic: "alerts"}, receive(t, conn))
+
+		s.Dataset.SetAlerts([]transit.Alert{{ID: "A1"}})
+		request(t, conn, "subscribe", "vehicles")
+		assert.Equal(t, "subscribed", receive(t, conn).Type, "expected no alerts update")
+	})
+
+	for _, tt := range []struct{ topic, error string }{
+		{"trains", "unknown topic trains"},
+		{"vehicles:bbox:1,2", "bbox must be minLon,minLat,maxLon,maxLat"},
+		{"vehicles:colour:red", "unknown topic vehicles:colour:red"},
+		{"stop::departures", "unknown topic stop::departures"},
+		{"alerts:route:", "unknown topic alerts:route:"},
+	} {
+		t.Run("rejects the topic "+tt.topic, func(t *testing.T) {
+			conn := dialWebSocket(t, NewServer(config.Config{}))
+			request(t, conn, "subscribe", tt.topic)
+			assert.Equal(t, received{Type: "error", Topic: tt.topic, Error: tt.error}, receive(t, conn))
+		})
+	}
+
+	t.Run("rejects the departures of a stop the timetable does not have", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		sched, err := schedule.LoadGTFS("../../../../lib/schedule/testdata/gtfs")
+		require.NoError(t, err, "could not load GTFS static feed")
+		s.Schedule = sched
+		conn := dialWebSocket(t, s)
+
+		request(t, conn, "subscribe", "stop:missing:departures")
+		assert.Equal(t, received{Type: "error", Topic: "stop:missing:departures", Error: "stop not found"}, receive(t, conn))
+	})
+
+	t.Run("rejects requests which are not an upgrade", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/v0/ws", nil)
+		NewServer(config.Config{}).HTTP.Handler.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400 from endpoint")
+	})
+
+	t.Run("rejects invalid requests without disconnecting", func(t *testing.T) {
+		conn := dialWebSocket(t, NewServer(config.Config{}))
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("{")))
+		assert.Equal(t, received{Type: "error", Error: "type must be subscribe or unsubscribe"}, receive(t, conn))
+		request(t, conn, "publish", "vehicles")
+		assert.Equal(t, received{Type: "error", Topic: "vehicles", Error: "type must be subscribe or unsubscribe"}, receive(t, conn))
+		request(t, conn, "subscribe", "alerts")
+		assert.Equal(t, "subscribed", receive(t, conn).Type)
+	})
+
+	t.Run("limits the subscriptions of a connection", func(t *testing.T) {
+		conn := dialWebSocket(t, NewServer(config.Config{}))
+		for i := 0; i < MaxSubscriptions; i++ {
+			request(t, conn, "subscribe", fmt.Sprintf("alerts:stop:%d", i))
+			assert.Equal(t, "subscribed", receive(t, conn).Type)
+			receive(t, conn)
+		}
+
+		request(t, conn, "subscribe", "alerts")
+		assert.Equal(t, received{Type: "error", Topic: "alerts", Error: "subscription limit of 20 reached"}, receive(t, conn))
+		request(t, conn, "subscribe", "alerts:stop:0")
+		assert.Equal(t, "subscribed", receive(t, conn).Type, "expected a topic already subscribed to to be accepted")
+	})
+
+	t.Run("pings on every heartbeat", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		s.Heartbeat = 10 * time.Millisecond
+		conn := dialWebSocket(t, s)
+
+		pinged := make(chan struct{}, 1)
+		conn.SetPingHandler(func(string) error {
+			select {
+			case pinged <- struct{}{}:
+			default:
+			}
+
+			return nil
+		})
+		go func() {
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		select {
+		case <-pinged:
+		case <-time.After(time.Second):
+			assert.Fail(t, "expected a ping")
+		}
+	})
+
+	t.Run("closes when the server stops", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		conn := dialWebSocket(t, s)
+		request(t, conn, "subscribe", "alerts")
+		receive(t, conn)
+		receive(t, conn)
+
+		s.Stop(context.Background())
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		_, _, err := conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway), "expected a going away close, got %v", err)
+	})
+}
+
+func TestLatestTopic(t *testing.T) {
+	s := NewServer(config.Config{})
+
+	t.Run("sends nothing while the data is the same", func(t *testing.T) {
+		topic := &latestTopic{name: "alerts", get: func(*Server) any { return []string{"A1"} }}
+		assert.Len(t, topic.update(s), 1)
+		assert.Empty(t, topic.update(s))
+	})
+
+	t.Run("sends nothing for data which cannot be marshalled", func(t *testing.T) {
+		topic := &latestTopic{name: "alerts", get: func(*Server) any { return math.NaN() }}
+		assert.Empty(t, topic.update(s))
+	})
+}
+
+// serverConn returns both ends of a websocket connection, the end of the
+// server first
+func serverConn(t *testing.T) (*websocket.Conn, *websocket.Conn) {
+	conns := make(chan *websocket.Conn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err == nil {
+			conns <- conn
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	require.NoError(t, err, "could not connect to websocket")
+	t.Cleanup(func() { client.Close() })
+	conn := <-conns
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, client
+}
+
+func TestServeWebSocket(t *testing.T) {
+	t.Run("ends when a reply cannot be sent", func(t *testing.T) {
+		conn, _ := serverConn(t)
+		requests := make(chan wsRequest, 1)
+		requests <- wsRequest{Type: "subscribe", Topic: "alerts"}
+		conn.Close()
+
+		err := NewServer(config.Config{}).serveWebSocket(conn, time.Hour, requests, nil)
+		assert.ErrorContains(t, err, "could not send subscribed message: ")
+	})
+
+	t.Run("ends when an update cannot be sent", func(t *testing.T) {
+		s := NewServer(config.Config{})
+		conn, client := serverConn(t)
+		requests := make(chan wsRequest, 1)
+		requests <- wsRequest{Type: "subscribe", Topic: "alerts"}
+		errs := make(chan error, 1)
+		go func() { errs <- s.serveWebSocket(conn, time.Hour, requests, nil) }()
+		receive(t, client)
+		receive(t, client)
+
+		conn.Close()
+		s.Dataset.SetAlerts([]transit.Alert{{ID: "A1"}})
+		select {
+		case err := <-errs:
+			assert.ErrorContains(t, err, "could not send update message: ")
+		case <-time.After(time.Second):
+			assert.Fail(t, "expected the websocket to end")
+		}
+	})
+}
+
+func TestReadRequests(t *testing.T) {
+	t.Run("stops passing requests on once quit", func(t *testing.T) {
+		conn, client := serverConn(t)
+		quit := make(chan struct{})
+		close(quit)
+		request(t, client, "subscribe", "alerts")
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			readRequests(conn, time.Hour, make(chan wsRequest), quit)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			assert.Fail(t, "expected the requests to stop being read")
+		}
+	})
+}
@@ -0,0 +1,50 @@
+// Package siri turns the responses of the API into SIRI 2.0 XML, the
+// VehicleMonitoring and SituationExchange services of the European standard
+// for realtime public transport information
+package siri
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/mcgovman/wheresmylift/lib/siri"
+)
+
+const (
+	MIME = "application/xml"
+	// ProducerRef identifies the API as the producer of every delivery
+	ProducerRef = "WheresMyLift"
+)
+
+// Operating days are counted in Irish time
+var dublin, _ = time.LoadLocation("Europe/Dublin")
+
+func serviceDelivery(now time.Time) siri.Siri {
+	return siri.Siri{
+		Version: siri.Version,
+		ServiceDelivery: &siri.ServiceDelivery{
+			ResponseTimestamp: now,
+			ProducerRef:       ProducerRef,
+		},
+	}
+}
+
+// Marshal encodes a SIRI document with an XML declaration
+func Marshal(s siri.Siri) ([]byte, error) {
+	b, err := xml.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal siri: %w", err)
+	}
+
+	return append([]byte(xml.Header), b...), nil
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
@@ -0,0 +1,25 @@
+package siri
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshal(t *testing.T) {
+	now := time.Date(2025, 1, 21, 12, 0, 0, 0, time.UTC)
+
+	t.Run("encodes a document with an xml declaration", func(t *testing.T) {
+		b, err := Marshal(serviceDelivery(now))
+		assert.NoError(t, err, "could not marshal")
+		assert.True(t, strings.HasPrefix(string(b), `<?xml version="1.0" encoding="UTF-8"?>`), "expected an xml declaration")
+		assert.Contains(t, string(b), `<Siri xmlns="http://www.siri.org.uk/siri" version="2.0"><ServiceDelivery><ResponseTimestamp>2025-01-21T12:00:00Z</ResponseTimestamp><ProducerRef>WheresMyLift</ProducerRef></ServiceDelivery></Siri>`)
+	})
+
+	t.Run("returns an error when the document cannot be encoded", func(t *testing.T) {
+		_, err := Marshal(serviceDelivery(time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)))
+		assert.ErrorContains(t, err, "could not marshal siri: ", "expected an error for a year past 9999")
+	})
+}
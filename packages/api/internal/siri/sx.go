@@ -0,0 +1,192 @@
+package siri
+
+import (
+	"slices"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+)
+
+// alertCauses maps the causes of GTFS-Realtime alerts to SIRI, any other
+// cause is unknown
+var alertCauses = map[string]string{
+	"OTHER_CAUSE":       "undefinedProblem",
+	"TECHNICAL_PROBLEM": "technicalProblem",
+	"STRIKE":            "industrialAction",
+	"DEMONSTRATION":     "demonstration",
+	"ACCIDENT":          "accident",
+	"HOLIDAY":           "holiday",
+	"WEATHER":           "adverseWeather",
+	"MAINTENANCE":       "maintenanceWork",
+	"CONSTRUCTION":      "constructionWork",
+	"POLICE_ACTIVITY":   "policeActivity",
+	"MEDICAL_EMERGENCY": "illVehicleOccupants",
+}
+
+// conditions maps the effects of GTFS-Realtime alerts to the condition of a
+// consequence in SIRI, any other effect has no consequence
+var conditions = map[string]string{
+	"NO_SERVICE":         "noService",
+	"REDUCED_SERVICE":    "disrupted",
+	"SIGNIFICANT_DELAYS": "delayed",
+	"DETOUR":             "diverted",
+	"ADDITIONAL_SERVICE": "additionalService",
+	"MODIFIED_SERVICE":   "altered",
+	"STOP_MOVED":         "stopMoved",
+	"NO_EFFECT":          "normalService",
+}
+
+func texts(translations []transit.Translation) []siri.Text {
+	var texts []siri.Text
+	for _, t := range translations {
+		texts = append(texts, siri.Text{Lang: t.Language, Value: t.Text})
+	}
+
+	return texts
+}
+
+// appendUnique appends v to s unless it is already in it
+func appendUnique[T comparable](s []T, v T) []T {
+	if slices.Contains(s, v) {
+		return s
+	}
+
+	return append(s, v)
+}
+
+// affects maps the informed entities of an alert to what it affects, each
+// operator, line, stop and trip is listed once however many entities name it
+func affects(entities []transit.InformedEntity) *siri.Affects {
+	var (
+		operators []siri.AffectedOperator
+		lines     []siri.AffectedLine
+		stops     []siri.AffectedStopPoint
+		journeys  []siri.AffectedVehicleJourney
+	)
+	for _, e := range entities {
+		if e.OperatorID != "" {
+			operators = appendUnique(operators, siri.AffectedOperator{OperatorRef: e.OperatorID})
+		}
+		if e.RouteID != "" {
+			lines = appendUnique(lines, siri.AffectedLine{LineRef: e.RouteID})
+		}
+		if e.StopID != "" {
+			stops = appendUnique(stops, siri.AffectedStopPoint{StopPointRef: e.StopID})
+		}
+		if e.TripID != "" {
+			journeys = appendUnique(journeys, siri.AffectedVehicleJourney{VehicleJourneyRef: e.TripID})
+		}
+	}
+
+	a := &siri.Affects{}
+	if len(operators) > 0 {
+		a.Operators = &siri.AffectedOperators{AffectedOperators: operators}
+	}
+	if len(lines) > 0 {
+		a.Networks = &siri.AffectedNetworks{AffectedLines: lines}
+	}
+	if len(stops) > 0 {
+		a.StopPoints = &siri.AffectedStopPoints{AffectedStopPoints: stops}
+	}
+	if len(journeys) > 0 {
+		a.VehicleJourneys = &siri.AffectedVehicleJourneys{AffectedVehicleJourneys: journeys}
+	}
+	if *a == (siri.Affects{}) {
+		return nil
+	}
+
+	return a
+}
+
+// situation maps an alert, the start of its first active period is taken as
+// its creation time as alerts do not have one
+func situation(a transit.Alert, now time.Time) siri.PtSituationElement {
+	created := now
+	if len(a.ActivePeriods) > 0 && a.ActivePeriods[0].Start != nil {
+		created = *a.ActivePeriods[0].Start
+	}
+
+	cause, ok := alertCauses[a.Cause]
+	if !ok {
+		cause = "unknown"
+	}
+
+	s := siri.PtSituationElement{
+		CreationTime:    created,
+		ParticipantRef:  ProducerRef,
+		SituationNumber: a.ID,
+		Progress:        "open",
+		AlertCause:      cause,
+		Summaries:       texts(a.HeaderText),
+		Descriptions:    texts(a.DescriptionText),
+		Affects:         affects(a.InformedEntities),
+	}
+	if len(s.Summaries) == 0 {
+		s.Summaries = []siri.Text{{}}
+	}
+	for _, p := range a.ActivePeriods {
+		period := siri.ValidityPeriod{StartTime: created, EndTime: p.End}
+		if p.Start != nil {
+			period.StartTime = *p.Start
+		}
+		s.ValidityPeriods = append(s.ValidityPeriods, period)
+	}
+	if len(s.ValidityPeriods) == 0 {
+		s.ValidityPeriods = []siri.ValidityPeriod{{StartTime: created}}
+	}
+	if len(a.URL) > 0 {
+		s.InfoLinks = &siri.InfoLinks{}
+		for _, url := range a.URL {
+			s.InfoLinks.InfoLinks = append(s.InfoLinks.InfoLinks, siri.InfoLink{URI: url.Text})
+		}
+	}
+	if condition, ok := conditions[a.Effect]; ok {
+		s.Consequences = &siri.Consequences{Consequences: []siri.Consequence{{Condition: condition}}}
+	}
+
+	return s
+}
+
+// lineSituation maps the status of a line which is not running normally, such
+// as a Luas line, to a situation affecting the line
+func lineSituation(l transit.LineStatus, now time.Time) siri.PtSituationElement {
+	updated := l.Updated
+	if updated.IsZero() {
+		updated = now
+	}
+
+	s := siri.PtSituationElement{
+		CreationTime:    updated,
+		ParticipantRef:  ProducerRef,
+		SituationNumber: l.Operator + "-" + l.RouteID,
+		Progress:        "open",
+		ValidityPeriods: []siri.ValidityPeriod{{StartTime: updated}},
+		AlertCause:      "unknown",
+		Summaries:       []siri.Text{{Value: l.Name}},
+		Affects:         affects([]transit.InformedEntity{{OperatorID: l.Operator, RouteID: l.RouteID}}),
+	}
+	if l.Message != "" {
+		s.Descriptions = []siri.Text{{Value: l.Message}}
+	}
+
+	return s
+}
+
+// SituationExchange returns a SituationExchangeDelivery of the alerts and of
+// the lines which are not running normally
+func SituationExchange(alerts []transit.Alert, lines []transit.LineStatus, now time.Time) siri.Siri {
+	s := serviceDelivery(now)
+	delivery := siri.SituationExchangeDelivery{Version: siri.Version, ResponseTimestamp: now}
+	for _, a := range alerts {
+		delivery.Situations = append(delivery.Situations, situation(a, now))
+	}
+	for _, l := range lines {
+		if !l.Normal {
+			delivery.Situations = append(delivery.Situations, lineSituation(l, now))
+		}
+	}
+	s.ServiceDelivery.SituationExchangeDeliveries = []siri.SituationExchangeDelivery{delivery}
+
+	return s
+}
@@ -0,0 +1,104 @@
+package siri
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSituationExchange(t *testing.T) {
+	now := time.Date(2025, 1, 21, 12, 0, 0, 0, time.UTC)
+	start, end := now.Add(-3*time.Hour), now.Add(6*time.Hour)
+	alerts := []transit.Alert{
+		{
+			ID:            "A1",
+			ActivePeriods: []transit.ActivePeriod{{Start: &start, End: &end}},
+			InformedEntities: []transit.InformedEntity{
+				{OperatorID: "7778019", RouteID: "3249_46342"},
+				{OperatorID: "7778019", StopID: "8220DB000334"},
+				{TripID: "3249_10466"},
+			},
+			Cause:           "CONSTRUCTION",
+			Effect:          "DETOUR",
+			URL:             []transit.Translation{{Text: "https://www.dublinbus.ie"}},
+			HeaderText:      []transit.Translation{{Text: "Route 46A diverted", Language: "en"}, {Text: "Atreorú ar bhealach 46A", Language: "ga"}},
+			DescriptionText: []transit.Translation{{Text: "Due to roadworks on the N11"}},
+		},
+		{ID: "A2", Cause: "SOLAR_FLARE"},
+	}
+	lines := []transit.LineStatus{
+		{Operator: "luas", RouteID: "red", Name: "Luas Red Line", Normal: true, Message: "Red Line services operating normally"},
+		{Operator: "luas", RouteID: "green", Name: "Luas Green Line", Message: "No trams between Sandyford and Brides Glen", Updated: now.Add(-time.Hour)},
+	}
+
+	s := SituationExchange(alerts, lines, now)
+	if !assert.Len(t, s.ServiceDelivery.SituationExchangeDeliveries, 1) {
+		return
+	}
+	delivery := s.ServiceDelivery.SituationExchangeDeliveries[0]
+	if !assert.Len(t, delivery.Situations, 3, "expected lines running normally to be left out") {
+		return
+	}
+	assert.Empty(t, s.ServiceDelivery.VehicleMonitoringDeliveries)
+
+	t.Run("maps an alert", func(t *testing.T) {
+		b, err := xml.Marshal(delivery.Situations[0])
+		assert.NoError(t, err, "could not marshal")
+		assert.Equal(t, `<PtSituationElement>`+
+			`<CreationTime>2025-01-21T09:00:00Z</CreationTime>`+
+			`<ParticipantRef>WheresMyLift</ParticipantRef>`+
+			`<SituationNumber>A1</SituationNumber>`+
+			`<Progress>open</Progress>`+
+			`<ValidityPeriod><StartTime>2025-01-21T09:00:00Z</StartTime><EndTime>2025-01-21T18:00:00Z</EndTime></ValidityPeriod>`+
+			`<AlertCause>constructionWork</AlertCause>`+
+			`<Summary xml:lang="en">Route 46A diverted</Summary>`+
+			`<Summary xml:lang="ga">Atreorú ar bhealach 46A</Summary>`+
+			`<Description>Due to roadworks on the N11</Description>`+
+			`<InfoLinks><InfoLink><Uri>https://www.dublinbus.ie</Uri></InfoLink></InfoLinks>`+
+			`<Affects>`+
+			`<Operators><AffectedOperator><OperatorRef>7778019</OperatorRef></AffectedOperator></Operators>`+
+			`<Networks><AffectedNetwork><AffectedLine><LineRef>3249_46342</LineRef></AffectedLine></AffectedNetwork></Networks>`+
+			`<StopPoints><AffectedStopPoint><StopPointRef>8220DB000334</StopPointRef></AffectedStopPoint></StopPoints>`+
+			`<VehicleJourneys><AffectedVehicleJourney><VehicleJourneyRef>3249_10466</VehicleJourneyRef></AffectedVehicleJourney></VehicleJourneys>`+
+			`</Affects>`+
+			`<Consequences><Consequence><Condition>diverted</Condition></Consequence></Consequences>`+
+			`</PtSituationElement>`, string(b), "expected each operator to be affected once")
+	})
+
+	t.Run("fills in what an alert does not have", func(t *testing.T) {
+		b, err := xml.Marshal(delivery.Situations[1])
+		assert.NoError(t, err, "could not marshal")
+		assert.Equal(t, `<PtSituationElement>`+
+			`<CreationTime>2025-01-21T12:00:00Z</CreationTime>`+
+			`<ParticipantRef>WheresMyLift</ParticipantRef>`+
+			`<SituationNumber>A2</SituationNumber>`+
+			`<Progress>open</Progress>`+
+			`<ValidityPeriod><StartTime>2025-01-21T12:00:00Z</StartTime></ValidityPeriod>`+
+			`<AlertCause>unknown</AlertCause>`+
+			`<Summary></Summary>`+
+			`</PtSituationElement>`, string(b), "expected the time of the delivery and an unknown cause")
+	})
+
+	t.Run("maps a line which is not running normally", func(t *testing.T) {
+		line := delivery.Situations[2]
+		assert.Equal(t, "luas-green", line.SituationNumber)
+		assert.Equal(t, now.Add(-time.Hour), line.CreationTime)
+		assert.Equal(t, []siri.Text{{Value: "Luas Green Line"}}, line.Summaries)
+		assert.Equal(t, []siri.Text{{Value: "No trams between Sandyford and Brides Glen"}}, line.Descriptions)
+		assert.Equal(t, &siri.Affects{
+			Operators: &siri.AffectedOperators{AffectedOperators: []siri.AffectedOperator{{OperatorRef: "luas"}}},
+			Networks:  &siri.AffectedNetworks{AffectedLines: []siri.AffectedLine{{LineRef: "green"}}},
+		}, line.Affects)
+	})
+
+	t.Run("takes the time of the delivery for a line without an update time", func(t *testing.T) {
+		s := SituationExchange(nil, []transit.LineStatus{{Operator: "luas", RouteID: "red", Name: "Luas Red Line"}}, now)
+		line := s.ServiceDelivery.SituationExchangeDeliveries[0].Situations[0]
+		assert.Equal(t, now, line.CreationTime)
+		assert.Equal(t, []siri.ValidityPeriod{{StartTime: now}}, line.ValidityPeriods)
+	})
+}
@@ -0,0 +1,108 @@
+package siri
+
+import (
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/siri"
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
+)
+
+// callStatus maps the status of a departure to the status of a call, a call
+// which is running is left without one
+func callStatus(status transit.DepartureStatus) string {
+	switch status {
+	case transit.DepartureCancelled, transit.DepartureSkipped:
+		return "cancelled"
+	case transit.DepartureNoData:
+		return "noReport"
+	default:
+		return ""
+	}
+}
+
+func onwardCall(next dataset.NextStop) siri.OnwardCall {
+	dep := next.Departure
+	call := siri.OnwardCall{
+		StopPointRef:          dep.StopID,
+		Order:                 dep.StopSequence,
+		AimedArrivalTime:      timePtr(dep.ScheduledArrival),
+		ExpectedArrivalTime:   timePtr(dep.ExpectedArrival),
+		ArrivalPlatformName:   dep.Platform,
+		AimedDepartureTime:    timePtr(dep.ScheduledDeparture),
+		ExpectedDepartureTime: timePtr(dep.ExpectedDeparture),
+	}
+	if next.Stop != nil {
+		call.StopPointName = next.Stop.Name
+	}
+	if status := callStatus(dep.Status); status != "" {
+		call.ArrivalStatus, call.DepartureStatus = status, status
+	}
+
+	return call
+}
+
+// vehicleActivity maps the journey of a vehicle, whose operating day is the
+// service day of its trip or today in Ireland when its trip is not known
+func vehicleActivity(j dataset.VehicleDetail, now time.Time) siri.VehicleActivity {
+	v := j.Vehicle
+	mvj := siri.MonitoredVehicleJourney{
+		LineRef:         v.RouteID,
+		VehicleMode:     string(v.Mode),
+		OperatorRef:     v.Operator,
+		Monitored:       true,
+		VehicleLocation: &siri.VehicleLocation{Longitude: v.Longitude, Latitude: v.Latitude},
+		Bearing:         v.Bearing,
+		Velocity:        v.Speed,
+		VehicleRef:      v.ID,
+	}
+	if j.Route != nil {
+		mvj.LineRef = j.Route.ID
+		mvj.PublishedLineName = j.Route.ShortName
+	}
+	destination := v.Headsign
+	if j.Trip != nil && destination == "" {
+		destination = j.Trip.Headsign
+	}
+	if destination != "" {
+		mvj.DestinationNames = []string{destination}
+	}
+	if v.TripID != "" {
+		mvj.FramedVehicleJourneyRef = &siri.FramedVehicleJourneyRef{DataFrameRef: j.ServiceDay, DatedVehicleJourneyRef: v.TripID}
+		if j.ServiceDay == "" {
+			mvj.FramedVehicleJourneyRef.DataFrameRef = now.In(dublin).Format(time.DateOnly)
+		}
+	}
+	if v.Delay != nil {
+		mvj.Delay = siri.FormatDuration(*v.Delay)
+	}
+	if v.StopID != "" {
+		mvj.MonitoredCall = &siri.MonitoredCall{StopPointRef: v.StopID, VehicleAtStop: v.Status == transit.VehicleStoppedAt}
+	}
+	if len(j.NextStops) > 0 {
+		mvj.OnwardCalls = &siri.OnwardCalls{}
+		for _, next := range j.NextStops {
+			mvj.OnwardCalls.OnwardCalls = append(mvj.OnwardCalls.OnwardCalls, onwardCall(next))
+		}
+	}
+
+	recorded := v.Timestamp
+	if recorded.IsZero() {
+		recorded = now
+	}
+
+	return siri.VehicleActivity{RecordedAtTime: recorded, MonitoredVehicleJourney: mvj}
+}
+
+// VehicleMonitoring returns a VehicleMonitoringDelivery of the journeys of
+// vehicles, with the next stops of each as its onward calls
+func VehicleMonitoring(journeys []dataset.VehicleDetail, now time.Time) siri.Siri {
+	s := serviceDelivery(now)
+	delivery := siri.VehicleMonitoringDelivery{Version: siri.Version, ResponseTimestamp: now}
+	for _, j := range journeys {
+		delivery.VehicleActivities = append(delivery.VehicleActivities, vehicleActivity(j, now))
+	}
+	s.ServiceDelivery.VehicleMonitoringDeliveries = []siri.VehicleMonitoringDelivery{delivery}
+
+	return s
+}
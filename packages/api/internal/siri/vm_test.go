@@ -0,0 +1,108 @@
+package siri
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/mcgovman/wheresmylift/lib/transit"
+	"github.com/mcgovman/wheresmylift/packages/api/internal/dataset"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVehicleMonitoring(t *testing.T) {
+	now := time.Date(2025, 1, 21, 23, 30, 0, 0, time.UTC)
+	bearing, delay := 90.0, 2*time.Minute
+	bus := dataset.VehicleDetail{
+		Vehicle: transit.Vehicle{
+			ID:        "V1",
+			Source:    "gtfsr",
+			Operator:  "7778019",
+			Mode:      transit.ModeBus,
+			RouteID:   "3249_46342",
+			TripID:    "3249_10466",
+			Latitude:  53.3498,
+			Longitude: -6.2603,
+			Bearing:   &bearing,
+			Status:    transit.VehicleStoppedAt,
+			StopID:    "8220DB000334",
+			Delay:     &delay,
+			Timestamp: now.Add(-30 * time.Second),
+		},
+		Trip:  &transit.Trip{ID: "3249_10466", Headsign: "Dún Laoghaire"},
+		Route: &transit.Route{ID: "3249_46342", ShortName: "46A"},
+		NextStops: []dataset.NextStop{
+			{
+				Stop:      &transit.Stop{ID: "8220DB000335", Name: "O'Connell Street Upper"},
+				Departure: transit.Departure{StopID: "8220DB000335", StopSequence: 13, ScheduledDeparture: now, ExpectedDeparture: now.Add(delay)},
+			},
+			{Departure: transit.Departure{StopID: "8220DB000336", StopSequence: 14, Status: transit.DepartureSkipped}},
+			{Departure: transit.Departure{StopID: "8220DB000337", StopSequence: 15, Status: transit.DepartureNoData}},
+		},
+	}
+
+	s := VehicleMonitoring([]dataset.VehicleDetail{bus, {Vehicle: transit.Vehicle{ID: "E109", Latitude: 53.3464, Longitude: -6.2927}}}, now)
+	if !assert.Len(t, s.ServiceDelivery.VehicleMonitoringDeliveries, 1) {
+		return
+	}
+	delivery := s.ServiceDelivery.VehicleMonitoringDeliveries[0]
+	if !assert.Len(t, delivery.VehicleActivities, 2) {
+		return
+	}
+	assert.Empty(t, s.ServiceDelivery.SituationExchangeDeliveries)
+
+	t.Run("maps a vehicle with its journey and onward calls", func(t *testing.T) {
+		b, err := xml.Marshal(delivery.VehicleActivities[0])
+		assert.NoError(t, err, "could not marshal")
+		assert.Equal(t, `<VehicleActivity>`+
+			`<RecordedAtTime>2025-01-21T23:29:30Z</RecordedAtTime>`+
+			`<MonitoredVehicleJourney>`+
+			`<LineRef>3249_46342</LineRef>`+
+			`<FramedVehicleJourneyRef><DataFrameRef>2025-01-21</DataFrameRef><DatedVehicleJourneyRef>3249_10466</DatedVehicleJourneyRef></FramedVehicleJourneyRef>`+
+			`<VehicleMode>bus</VehicleMode>`+
+			`<PublishedLineName>46A</PublishedLineName>`+
+			`<OperatorRef>7778019</OperatorRef>`+
+			`<DestinationName>Dún Laoghaire</DestinationName>`+
+			`<Monitored>true</Monitored>`+
+			`<VehicleLocation><Longitude>-6.2603</Longitude><Latitude>53.3498</Latitude></VehicleLocation>`+
+			`<Bearing>90</Bearing>`+
+			`<Delay>PT2M</Delay>`+
+			`<VehicleRef>V1</VehicleRef>`+
+			`<MonitoredCall><StopPointRef>8220DB000334</StopPointRef><VehicleAtStop>true</VehicleAtStop></MonitoredCall>`+
+			`<OnwardCalls>`+
+			`<OnwardCall><StopPointRef>8220DB000335</StopPointRef><Order>13</Order><StopPointName>O&#39;Connell Street Upper</StopPointName><AimedDepartureTime>2025-01-21T23:30:00Z</AimedDepartureTime><ExpectedDepartureTime>2025-01-21T23:32:00Z</ExpectedDepartureTime></OnwardCall>`+
+			`<OnwardCall><StopPointRef>8220DB000336</StopPointRef><Order>14</Order><ArrivalStatus>cancelled</ArrivalStatus><DepartureStatus>cancelled</DepartureStatus></OnwardCall>`+
+			`<OnwardCall><StopPointRef>8220DB000337</StopPointRef><Order>15</Order><ArrivalStatus>noReport</ArrivalStatus><DepartureStatus>noReport</DepartureStatus></OnwardCall>`+
+			`</OnwardCalls>`+
+			`</MonitoredVehicleJourney>`+
+			`</VehicleActivity>`, string(b))
+	})
+
+	t.Run("leaves out what a vehicle does not have", func(t *testing.T) {
+		b, err := xml.Marshal(delivery.VehicleActivities[1])
+		assert.NoError(t, err, "could not marshal")
+		assert.Equal(t, `<VehicleActivity>`+
+			`<RecordedAtTime>2025-01-21T23:30:00Z</RecordedAtTime>`+
+			`<MonitoredVehicleJourney>`+
+			`<Monitored>true</Monitored>`+
+			`<VehicleLocation><Longitude>-6.2927</Longitude><Latitude>53.3464</Latitude></VehicleLocation>`+
+			`<VehicleRef>E109</VehicleRef>`+
+			`</MonitoredVehicleJourney>`+
+			`</VehicleActivity>`, string(b), "expected the time of the delivery without a timestamp")
+	})
+
+	t.Run("takes today in Ireland without the service day of the trip", func(t *testing.T) {
+		summer := time.Date(2025, 7, 21, 23, 30, 0, 0, time.UTC)
+		s := VehicleMonitoring([]dataset.VehicleDetail{bus}, summer)
+		ref := s.ServiceDelivery.VehicleMonitoringDeliveries[0].VehicleActivities[0].MonitoredVehicleJourney.FramedVehicleJourneyRef
+		assert.Equal(t, "2025-07-22", ref.DataFrameRef, "expected the day in Irish summer time")
+	})
+
+	t.Run("takes the service day of a trip running past midnight", func(t *testing.T) {
+		late := bus
+		late.ServiceDay = "2025-01-21"
+		s := VehicleMonitoring([]dataset.VehicleDetail{late}, now.Add(40*time.Minute))
+		ref := s.ServiceDelivery.VehicleMonitoringDeliveries[0].VehicleActivities[0].MonitoredVehicleJourney.FramedVehicleJourneyRef
+		assert.Equal(t, "2025-01-21", ref.DataFrameRef, "expected the day the trip started rather than today")
+	})
+}